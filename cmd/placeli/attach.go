@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/user/placeli/internal/models"
+	"github.com/user/placeli/internal/photos"
+)
+
+var (
+	attachMatchDir string
+	attachRadius   float64
+	attachDryRun   bool
+)
+
+func init() {
+	rootCmd.AddCommand(attachCmd)
+
+	attachCmd.Flags().StringVar(&attachMatchDir, "match", "", "attach geotagged photos in a folder to the nearest saved place")
+	attachCmd.Flags().Float64Var(&attachRadius, "radius", 100, "maximum distance in metres for --match")
+	attachCmd.Flags().BoolVar(&attachDryRun, "dry-run", false, "show matches without attaching anything")
+	attachCmd.Flags().StringVar(&photosDir, "photo-dir", "", "photo store directory (default: ~/.placeli/photos)")
+}
+
+var attachCmd = &cobra.Command{
+	Use:   "attach <place-id> <file...>",
+	Short: "Attach your own photos and documents to a place",
+	Long: `Attach personal files such as photos, menus, tickets and receipts to a place.
+
+Files are copied into the photo store. Capture time and GPS position are read
+from the Exif data of JPEG photos.
+
+With --match, every geotagged photo in a folder is attached to the nearest
+saved place within --radius metres.
+
+Examples:
+  placeli attach <place-id> menu.pdf IMG_0412.jpg
+  placeli attach --match ~/Pictures/Lisbon --radius 50`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if attachMatchDir != "" {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.MinimumNArgs(2)(cmd, args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openPhotoStore()
+		if err != nil {
+			return err
+		}
+
+		if attachMatchDir != "" {
+			var places []*models.Place
+			err := db.ForEachPlace("", func(place *models.Place) error {
+				places = append(places, place)
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("failed to load places: %w", err)
+			}
+			return matchAttachments(store, places, attachMatchDir)
+		}
+
+		place, err := db.GetPlace(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to get place: %w", err)
+		}
+
+		added := 0
+		for _, path := range args[1:] {
+			attachment, err := store.Attach(path)
+			if err != nil {
+				return err
+			}
+			if place.AddAttachment(*attachment) {
+				added++
+				fmt.Printf("Attached %s%s\n", attachment.Name, describeAttachment(attachment))
+			} else {
+				fmt.Printf("%s is already attached\n", attachment.Name)
+			}
+		}
+
+		if added > 0 {
+			if err := db.SavePlace(place); err != nil {
+				return fmt.Errorf("failed to save place: %w", err)
+			}
+		}
+		fmt.Printf("%s has %d attachments\n", place.Name, len(place.Attachments))
+		return nil
+	},
+}
+
+// matchAttachments attaches each geotagged photo under dir to the nearest
+// place within attachRadius
+func matchAttachments(store *photos.Store, places []*models.Place, dir string) error {
+	changed := make(map[string]*models.Place)
+	matchedPlaces := make(map[string]bool)
+	matched, unmatched, untagged := 0, 0, 0
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !isPhotoFile(path) {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		meta, err := photos.ReadMetadata(data)
+		if err != nil || meta.Coordinates == nil {
+			untagged++
+			return nil
+		}
+
+		place, distance := models.NearestPlace(places, *meta.Coordinates, attachRadius)
+		if place == nil {
+			unmatched++
+			return nil
+		}
+
+		matched++
+		matchedPlaces[place.ID] = true
+		fmt.Printf("%s -> %s (%.0fm)\n", path, place.Name, distance)
+		if attachDryRun {
+			return nil
+		}
+
+		attachment, err := store.Attach(path)
+		if err != nil {
+			return err
+		}
+		if place.AddAttachment(*attachment) {
+			changed[place.ID] = place
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, place := range changed {
+		if err := db.SavePlace(place); err != nil {
+			return fmt.Errorf("failed to save place %s: %w", place.Name, err)
+		}
+	}
+
+	fmt.Printf("\nMatched %d photos to %d places, %d too far from any place, %d without GPS data\n",
+		matched, len(matchedPlaces), unmatched, untagged)
+	if attachDryRun {
+		fmt.Println("Dry run: nothing was attached")
+	}
+	return nil
+}
+
+func isPhotoFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jpg", ".jpeg":
+		return true
+	}
+	return false
+}
+
+func describeAttachment(a *models.Attachment) string {
+	var parts []string
+	if a.TakenAt != nil {
+		parts = append(parts, "taken "+a.TakenAt.Format("2006-01-02 15:04"))
+	}
+	if a.Coordinates != nil {
+		parts = append(parts, fmt.Sprintf("at %.5f, %.5f", a.Coordinates.Lat, a.Coordinates.Lng))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
@@ -4,11 +4,14 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"path/filepath"
+	"os/signal"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/user/placeli/internal/maps"
+	"github.com/user/placeli/internal/photos"
 )
 
 var (
@@ -19,44 +22,88 @@ var (
 	enrichReviews    bool
 	enrichPhotoWidth int
 	enrichTimeout    int
+	enrichProvider   string
+	enrichLimit      int
+	enrichWorkers    int
+	enrichRate       float64
+	enrichResume     bool
+	enrichStale      string
 )
 
 var enrichCmd = &cobra.Command{
 	Use:   "enrich",
-	Short: "Enrich places with Google Maps API data",
-	Long: `Enrich your saved places with additional data from Google Maps API including:
+	Short: "Enrich places with Google Maps or OpenStreetMap data",
+	Long: `Enrich your saved places with additional data from an external provider.
+
+The google provider (default) uses the Google Maps API and adds:
   - Updated ratings and review counts
   - Current business hours
   - Phone numbers and websites
   - Photos (with --photos flag)
   - Latest reviews (with --reviews flag)
 
-Requires a Google Maps API key with Places API enabled.
+It requires a Google Maps API key with Places API enabled.
 Set the API key using the --api-key flag or GOOGLE_MAPS_API_KEY environment variable.
 
+The osm provider needs no API key. It looks up places by coordinates and
+name through the OpenStreetMap Overpass API and adds opening hours,
+websites, phone numbers and cuisine tags.
+
 Examples:
   placeli enrich --api-key=YOUR_API_KEY
   placeli enrich --place-id=ChIJN1t_tDeuEmsRUsoyG83frY4
   placeli enrich --photos --photo-dir=./photos
-  placeli enrich --reviews --api-key=YOUR_API_KEY`,
+  placeli enrich --reviews --api-key=YOUR_API_KEY
+  placeli enrich --provider osm
+  placeli enrich --resume
+  placeli enrich --stale 30d --workers 8
+
+Progress is recorded per place, so an interrupted run (Ctrl+C) can be
+continued with --resume. --stale re-enriches only places that were last
+enriched longer ago than the given age (e.g. 12h, 30d, 8w).`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		apiKey := enrichAPIKey
-		if apiKey == "" {
-			apiKey = os.Getenv("GOOGLE_MAPS_API_KEY")
-		}
-		if apiKey == "" {
-			return fmt.Errorf("Google Maps API key required. Use --api-key flag or set GOOGLE_MAPS_API_KEY environment variable")
+		var enricher maps.Enricher
+		switch enrichProvider {
+		case "google":
+			apiKey := enrichAPIKey
+			if apiKey == "" {
+				apiKey = os.Getenv("GOOGLE_MAPS_API_KEY")
+			}
+			if apiKey == "" {
+				return fmt.Errorf("Google Maps API key required. Use --api-key flag, set GOOGLE_MAPS_API_KEY environment variable, or use --provider osm")
+			}
+
+			if enrichPhotos && enrichPhotoDir == "" {
+				enrichPhotoDir = photos.DefaultDir()
+			}
+
+			enricher = maps.NewGoogleEnricher(apiKey, enrichPhotoDir)
+		case "osm":
+			if enrichPhotos || enrichReviews {
+				fmt.Println("Note: --photos and --reviews are only supported by the google provider")
+			}
+			enricher = maps.NewOSMEnricher()
+		default:
+			return fmt.Errorf("unknown provider %q (supported: google, osm)", enrichProvider)
 		}
 
-		if enrichPhotos && enrichPhotoDir == "" {
-			homeDir, _ := os.UserHomeDir()
-			enrichPhotoDir = filepath.Join(homeDir, ".placeli", "photos")
+		var stale time.Duration
+		if enrichStale != "" {
+			var err error
+			if stale, err = parseAge(enrichStale); err != nil {
+				return fmt.Errorf("invalid --stale value: %w", err)
+			}
 		}
 
-		service := maps.NewEnrichmentService(apiKey, db, enrichPhotoDir)
+		service := maps.NewEnrichmentServiceWithEnricher(enricher, db)
 
-		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(enrichTimeout)*time.Second)
-		defer cancel()
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		if enrichTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, time.Duration(enrichTimeout)*time.Second)
+			defer cancel()
+		}
 
 		opts := maps.EnrichmentOptions{
 			FetchPhotos:   enrichPhotos,
@@ -79,15 +126,61 @@ Examples:
 			return nil
 		}
 
-		fmt.Printf("Starting enrichment of all places...\n")
-		if enrichPhotos {
+		fmt.Printf("Starting enrichment of all places using %s...\n", enricher.Name())
+		if enrichPhotos && enrichPhotoDir != "" {
 			fmt.Printf("Photos will be saved to: %s\n", enrichPhotoDir)
 		}
 
-		return service.EnrichAllPlaces(ctx, opts)
+		result, err := service.EnrichPlaces(ctx, opts, maps.BatchOptions{
+			Limit:    enrichLimit,
+			Workers:  enrichWorkers,
+			Rate:     enrichRate,
+			Resume:   enrichResume,
+			Stale:    stale,
+			Progress: printEnrichProgress,
+		})
+		if result != nil {
+			fmt.Fprintln(os.Stderr)
+			fmt.Printf("Enriched %d, skipped %d, failed %d", result.Enriched, result.Skipped, result.Failed)
+			if result.UpToDate > 0 {
+				fmt.Printf(", %d already up to date", result.UpToDate)
+			}
+			fmt.Println()
+		}
+		if err != nil {
+			return fmt.Errorf("%w (run again with --resume to continue)", err)
+		}
+		return nil
 	},
 }
 
+// printEnrichProgress redraws a one-line progress bar on stderr
+func printEnrichProgress(r maps.BatchResult) {
+	const width = 30
+	filled := width
+	if r.Total > 0 {
+		filled = r.Done() * width / r.Total
+	}
+	fmt.Fprintf(os.Stderr, "\r[%s%s] %d/%d  enriched %d  skipped %d  failed %d",
+		strings.Repeat("=", filled), strings.Repeat(" ", width-filled),
+		r.Done(), r.Total, r.Enriched, r.Skipped, r.Failed)
+}
+
+// parseAge parses a duration that may also use day ("30d") and week ("2w")
+// units
+func parseAge(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			count, err := strconv.ParseFloat(n, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid age %q", s)
+			}
+			return time.Duration(count * float64(unit)), nil
+		}
+	}
+	return time.ParseDuration(s)
+}
+
 func init() {
 	enrichCmd.Flags().StringVar(&enrichAPIKey, "api-key", "", "Google Maps API key")
 	enrichCmd.Flags().StringVar(&enrichPhotoDir, "photo-dir", "", "directory to save photos (default: ~/.placeli/photos)")
@@ -95,7 +188,13 @@ func init() {
 	enrichCmd.Flags().BoolVar(&enrichPhotos, "photos", false, "download place photos")
 	enrichCmd.Flags().BoolVar(&enrichReviews, "reviews", false, "fetch latest reviews")
 	enrichCmd.Flags().IntVar(&enrichPhotoWidth, "photo-width", 800, "maximum photo width in pixels")
-	enrichCmd.Flags().IntVar(&enrichTimeout, "timeout", 300, "timeout in seconds for enrichment process")
+	enrichCmd.Flags().StringVar(&enrichProvider, "provider", "google", "enrichment provider (google, osm)")
+	enrichCmd.Flags().IntVar(&enrichTimeout, "timeout", 300, "timeout in seconds for enrichment process (0 for none)")
+	enrichCmd.Flags().IntVar(&enrichLimit, "limit", 0, "maximum number of places to enrich (0 for all)")
+	enrichCmd.Flags().IntVar(&enrichWorkers, "workers", 4, "number of concurrent lookups")
+	enrichCmd.Flags().Float64Var(&enrichRate, "rate", 0, "maximum requests per second (default depends on provider)")
+	enrichCmd.Flags().BoolVar(&enrichResume, "resume", false, "skip places already enriched by this provider")
+	enrichCmd.Flags().StringVar(&enrichStale, "stale", "", "only re-enrich places last enriched longer ago than this age (e.g. 30d)")
 
 	rootCmd.AddCommand(enrichCmd)
 }
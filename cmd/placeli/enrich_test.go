@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Duration
+	}{
+		{"30d", 30 * 24 * time.Hour},
+		{"2w", 14 * 24 * time.Hour},
+		{"1.5d", 36 * time.Hour},
+		{"12h", 12 * time.Hour},
+	}
+	for _, tt := range tests {
+		got, err := parseAge(tt.input)
+		if err != nil {
+			t.Errorf("parseAge(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseAge(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+
+	for _, input := range []string{"", "xd", "soon"} {
+		if _, err := parseAge(input); err == nil {
+			t.Errorf("parseAge(%q) should fail", input)
+		}
+	}
+}
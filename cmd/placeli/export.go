@@ -12,36 +12,41 @@ import (
 )
 
 var (
-	exportLimit int
+	exportLimit     int
+	exportItinerary bool
 )
 
 func init() {
 	rootCmd.AddCommand(exportCmd)
 	exportCmd.Flags().IntVar(&exportLimit, "limit", 0, "maximum number of places to export (0 = all)")
+	exportCmd.Flags().BoolVar(&exportItinerary, "itinerary", false, "ics only: group dated places into one event per day")
 }
 
 var exportCmd = &cobra.Command{
 	Use:   "export <format> <output-file>",
 	Short: "Export places to various formats",
-	Long: `Export your saved places to different formats including CSV, GeoJSON, JSON, and Markdown.
+	Long: `Export your saved places to different formats including CSV, GeoJSON, JSON, Markdown and iCalendar.
 
 Supported formats:
   csv      - Comma-separated values for spreadsheet applications
   geojson  - Geographic data format for mapping applications
   json     - Raw JSON data
   markdown - Human-readable documentation format
+  ics      - iCalendar events for places with date custom fields (e.g. visit_date)
 
 Examples:
   placeli export csv places.csv
   placeli export geojson places.geojson
   placeli export markdown places.md
-  placeli export json places.json`,
+  placeli export json places.json
+  placeli export ics visits.ics
+  placeli export ics trip.ics --itinerary`,
 	Args: cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		format := args[0]
 		outputFile := args[1]
 
-		if err := export.ValidateFormat(format); err != nil {
+		format, err := export.ParseFormat(args[0])
+		if err != nil {
 			return err
 		}
 
@@ -69,12 +74,17 @@ Examples:
 		}
 		defer file.Close()
 
-		if err := export.Export(places, export.Format(format), file); err != nil {
+		if exportItinerary && format == export.FormatICS {
+			err = export.ExportICSWithOptions(places, file, export.ICSOptions{Itinerary: true})
+		} else {
+			err = export.Export(places, format, file)
+		}
+		if err != nil {
 			return fmt.Errorf("failed to export places: %w", err)
 		}
 
 		fmt.Printf("Successfully exported %d places to %s (%s format)\n",
-			len(places), outputFile, strings.ToUpper(format))
+			len(places), outputFile, strings.ToUpper(string(format)))
 
 		return nil
 	},
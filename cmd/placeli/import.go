@@ -2,21 +2,34 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/user/placeli/internal/constants"
+	"github.com/user/placeli/internal/database"
 	"github.com/user/placeli/internal/importer/sources"
 	"github.com/user/placeli/internal/logger"
 	"github.com/user/placeli/internal/models"
 )
 
 var (
-	importSource  string
-	importDryRun  bool
-	importForce   bool
-	importNoMerge bool
+	importSource     string
+	importDryRun     bool
+	importForce      bool
+	importNoMerge    bool
+	importBatchSize  int
+	importMap        string
+	importPreset     string
+	importSavePreset string
+
+	importRadius       float64
+	importSuggestMin   int
+	importAddSuggested bool
 )
 
 func init() {
@@ -25,19 +38,29 @@ func init() {
 	// Add subcommands
 	importCmd.AddCommand(importSourcesCmd)
 	importCmd.AddCommand(importFromCmd)
+	importCmd.AddCommand(importDetectCmd)
+	importCmd.AddCommand(importPresetsCmd)
+	importPresetsCmd.AddCommand(importPresetsDeleteCmd)
 
 	// Flags for import command
-	importFromCmd.Flags().StringVar(&importSource, "source", "auto", "import source: auto, apple, osm, foursquare, takeout")
+	importFromCmd.Flags().StringVar(&importSource, "source", "auto", "import source: auto, apple, mymaps, osm, foursquare, geojson, csv, takeout, timeline")
 	importFromCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "show what would be imported without making changes")
 	importFromCmd.Flags().BoolVar(&importForce, "force", false, "overwrite existing places (default: skip duplicates)")
 	importFromCmd.Flags().BoolVar(&importNoMerge, "no-merge", false, "disable merging and treat all places as new")
+	importFromCmd.Flags().StringVar(&importMap, "map", "", "column or property for each field, e.g. name=Title,lat=Y,lng=X,field.priority=Prio (csv, geojson)")
+	importFromCmd.Flags().StringVar(&importPreset, "preset", "", "use a saved mapping (see 'import presets')")
+	importFromCmd.Flags().StringVar(&importSavePreset, "save-preset", "", "save the mapping under this name for later imports")
+	importFromCmd.Flags().IntVar(&importBatchSize, "batch-size", 500, "number of places saved per database transaction")
+	importFromCmd.Flags().Float64Var(&importRadius, "radius", 75, "timeline: maximum distance in metres to match a visit to a saved place")
+	importFromCmd.Flags().IntVar(&importSuggestMin, "suggest-min", 3, "timeline: suggest unsaved places visited at least this often")
+	importFromCmd.Flags().BoolVar(&importAddSuggested, "add-suggested", false, "timeline: save suggested places and their visits")
 }
 
 var importCmd = &cobra.Command{
 	Use:   "import",
 	Short: "Import places from various sources",
-	Long: `Import places from multiple sources including Apple Maps, OpenStreetMap,
-Foursquare/Swarm, and Google Takeout.
+	Long: `Import places from multiple sources including Apple Maps, Google My Maps,
+OpenStreetMap, Foursquare/Swarm, and Google Takeout.
 
 By default, import will:
 - Detect and skip duplicate places
@@ -45,21 +68,36 @@ By default, import will:
 - Only update places when --force is used
 
 Supported formats:
-  Apple Maps     - KML, KMZ, GPX files
+  Apple Maps     - KML, GPX files
+  Google My Maps - KMZ, KML (--source=mymaps); layers become tags, lines and
+                   polygons are imported as routes and areas
   OpenStreetMap  - JSON, CSV exports
   Foursquare     - JSON export files
+  GeoJSON        - .geojson FeatureCollections (QGIS, geojson.io, open data);
+                   points, lines and polygons, properties mapped with --map
+  Spreadsheets   - CSV (--source=csv) and TSV files, columns mapped with --map
   Google Takeout - ZIP archives, JSON (Maps), CSV (Saved)
+  Google Timeline - Timeline.json, Semantic Location History (visits only)
 
 Available subcommands:
   sources - List available import sources
+  detect  - Show which source would import a file, and why
   from    - Import from a specific file or directory
+  presets - List saved column mappings
 
 Examples:
   placeli import sources
+  placeli import detect ~/Downloads/export.json
   placeli import from ~/Downloads/takeout.zip
   placeli import from ~/Downloads/places.kml
+  placeli import from ~/Downloads/Team\ offsite.kmz
   placeli import from ~/Downloads/saved-places.csv --source=takeout
-  placeli import from ~/Downloads/places.json --force`,
+  placeli import from ~/Downloads/benches.geojson --map name=NOME,category=
+  placeli import from team.csv --source=csv --map name=Title,lat=Y,lng=X,tags=Labels,field.priority=Prio --dry-run
+  placeli import from team.csv --source=csv --map name=Title,lat=Y,lng=X --save-preset team
+  placeli import from team-2025.csv --preset team
+  placeli import from ~/Downloads/places.json --force
+  placeli import from ~/Downloads/Timeline.json`,
 }
 
 var importSourcesCmd = &cobra.Command{
@@ -71,7 +109,8 @@ var importSourcesCmd = &cobra.Command{
 
 		fmt.Println("Available import sources:")
 
-		for name, source := range sm.ListSources() {
+		for _, name := range sm.Names() {
+			source := sm.GetSource(name)
 			fmt.Printf("📁 %s (%s)\n", source.Name(), name)
 			fmt.Printf("   Supported formats: %s\n", strings.Join(source.SupportedFormats(), ", "))
 			fmt.Println()
@@ -84,13 +123,61 @@ var importSourcesCmd = &cobra.Command{
 	},
 }
 
+var importDetectCmd = &cobra.Command{
+	Use:   "detect <file-path>",
+	Short: "Show which source would import a file",
+	Long: `Sniff a file, zip archive or directory and list the sources that recognise
+it, most likely first, with the reason for each. The first one is what
+'import from' uses unless --source is given.
+
+Content decides: each source looks at the start of the file (or of each file
+in an archive or directory) for markers of its format. The file extension
+only breaks ties, and sources listed earlier by 'import sources' win when
+both are equal.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		detection, err := sources.NewSourceManager().Detect(args[0])
+		if err != nil {
+			return err
+		}
+
+		what := detection.Kind
+		if detection.Kind != "file" {
+			what = fmt.Sprintf("%s, %d files sniffed", detection.Kind, detection.Sniffed)
+		}
+		fmt.Printf("%s (%s)\n\n", detection.Path, what)
+
+		best := detection.Best()
+		if best == nil {
+			fmt.Println("No source recognises this file. Use --source to pick one.")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  SOURCE\tCONFIDENCE\tWHY")
+		for _, c := range detection.Candidates {
+			marker := " "
+			if c == best {
+				marker = "*"
+			}
+			fmt.Fprintf(w, "%s %s\t%s\t%s\n", marker, c.Name, c.Confidence, c.Reason())
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		fmt.Printf("\n'placeli import from' would use %s (%s)\n", best.Source.Name(), best.Name)
+		return nil
+	},
+}
+
 var importFromCmd = &cobra.Command{
 	Use:   "from <file-path>",
 	Short: "Import places from a file",
 	Long: `Import places from a file or directory using automatic source detection or a specified source.
 
 The import process will:
-- Detect the appropriate source based on file format
+- Detect the appropriate source from the file's content (see 'import detect')
 - Parse places from the file
 - Check for duplicates using source hashes (unless --no-merge is used)
 - Skip existing places (unless --force is used)
@@ -99,7 +186,26 @@ The import process will:
 Use --dry-run to preview what would be imported.
 Use --source to force a specific import source.
 Use --force to update existing places with new data.
-Use --no-merge to disable duplicate detection and import all as new.`,
+Use --no-merge to disable duplicate detection and import all as new.
+
+Large exports are parsed as a stream and saved in batches of --batch-size
+places per transaction, with progress shown by bytes read.
+
+Timeline imports log visits instead of adding places. Each visit is matched
+to a saved place by Google place ID, or to the nearest saved place within
+--radius metres. Places you often visit but haven't saved are listed as
+suggestions; --add-suggested saves them.
+
+Spreadsheet (--source=csv, or any .tsv file) and GeoJSON imports guess which
+columns or properties hold the name, address, category, notes, tags, phone,
+website and, for spreadsheets, lat, lng or a WKT geometry, from common names
+like "Title" or "Latitude". Use --map field=column to pick them yourself,
+field= to leave a field empty and field.<name>=column to fill a custom field.
+Other columns become custom fields. The delimiter and text encoding of
+spreadsheets are detected, and --dry-run shows how the first rows are read.
+
+--save-preset stores the mapping and source under a name, so the next
+spreadsheet with the same layout only needs --preset <name>.`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		filePath := args[0]
@@ -122,70 +228,129 @@ Use --no-merge to disable duplicate detection and import all as new.`,
 
 		sm := sources.NewSourceManager()
 
-		var places []*models.Place
-		var err error
-		var sourceName string
+		// A preset supplies a saved mapping and source, which --map and
+		// --source can then adjust
+		sourceFlag, spec := importSource, importMap
+		if importPreset != "" {
+			preset, err := db.GetImportPreset(importPreset)
+			if err != nil {
+				return err
+			}
+			spec = preset.Mapping + "," + importMap
+			if sourceFlag == "auto" && preset.Source != "" {
+				sourceFlag = preset.Source
+			}
+		}
 
-		if importSource == "auto" {
-			// Auto-detect source
-			source := sm.DetectSource(filePath)
-			if source == nil {
-				// Try each source to see if any can handle the file
-				for name, src := range sm.ListSources() {
-					places, err = src.ImportFromFile(filePath)
-					if err == nil && len(places) > 0 {
-						source = src
-						sourceName = name
-						break
-					}
-				}
-				if source == nil {
-					return fmt.Errorf("could not detect source for file: %s", filePath)
+		var mapping sources.FieldMapping
+		if spec != "" {
+			var err error
+			if mapping, err = sources.ParseFieldMapping(spec); err != nil {
+				return err
+			}
+			for _, src := range sm.ListSources() {
+				if mapped, ok := src.(sources.MappedSource); ok {
+					mapped.SetFieldMapping(mapping)
 				}
-			} else {
-				sourceName = getSourceName(sm, source)
-				places, err = source.ImportFromFile(filePath)
 			}
+		}
+
+		var source sources.ImportSource
+		var sourceName string
+
+		if sourceFlag == "auto" {
+			// Detect the source from the file's content
+			detection, err := sm.Detect(filePath)
+			if err != nil {
+				return fmt.Errorf("failed to detect source: %w", err)
+			}
+			best := detection.Best()
+			if best == nil {
+				return fmt.Errorf("could not detect source for file: %s (see 'placeli import detect' or use --source)", filePath)
+			}
+			source, sourceName = best.Source, best.Name
+			logger.Info("Detected source", "source", sourceName, "reason", best.Reason())
 		} else {
 			// Use specified source
-			source := sm.GetSource(importSource)
+			source = sm.GetSource(sourceFlag)
 			if source == nil {
-				return fmt.Errorf("unknown source: %s", importSource)
+				return fmt.Errorf("unknown source: %s", sourceFlag)
 			}
-			sourceName = importSource
-			places, err = source.ImportFromFile(filePath)
+			sourceName = sourceFlag
 		}
 
-		if err != nil {
-			return fmt.Errorf("failed to import from %s: %w", sourceName, err)
+		if _, ok := source.(sources.MappedSource); mapping != nil && !ok {
+			return fmt.Errorf("--map is not supported by the %s source", sourceName)
+		}
+		if importSavePreset != "" && mapping == nil {
+			return fmt.Errorf("--save-preset needs a mapping from --map or --preset")
 		}
 
-		if len(places) == 0 {
-			fmt.Printf("No places found in %s\n", filePath)
-			return nil
+		if spreadsheet, ok := source.(*sources.CSVImporter); ok && importDryRun {
+			if err := printCSVPreview(spreadsheet, filePath); err != nil {
+				return err
+			}
+		}
+
+		if vs, ok := source.(sources.VisitSource); ok && vs.VisitsOnly() {
+			places, err := source.ImportFromFile(filePath)
+			if err != nil {
+				return fmt.Errorf("failed to import from %s: %w", sourceName, err)
+			}
+			if len(places) == 0 {
+				fmt.Printf("No places found in %s\n", filePath)
+				return nil
+			}
+			logger.Info("Parsed places", "count", len(places), "source", sourceName)
+			return importVisits(places, importDryRun)
 		}
 
-		logger.Info("Parsed places", "count", len(places), "source", sourceName)
+		// Process the places (check for duplicates, save to database). Places
+		// are saved in batches, so parsing and saving overlap and a large
+		// export never has to be held in memory at once.
+		importer := newPlaceImporter(importDryRun, importForce, !importNoMerge, importBatchSize)
+		progress := &importProgress{quiet: importDryRun}
 
-		// Process the places (check for duplicates, save to database)
-		var added, updated, skipped int
-		if importNoMerge {
-			// Simple import without duplicate checking
-			added, updated, skipped, err = simpleImportPlaces(places, importDryRun)
+		var err error
+		if streaming, ok := source.(sources.StreamingSource); ok {
+			err = streaming.StreamFromFile(filePath, importer.add, progress.bytes)
 		} else {
-			// Smart import with duplicate detection and merging
-			added, updated, skipped, err = smartImportPlaces(places, importDryRun, importForce)
+			var places []*models.Place
+			places, err = source.ImportFromFile(filePath)
+			for i := 0; err == nil && i < len(places); i++ {
+				err = importer.add(places[i])
+				progress.places(i+1, len(places))
+			}
 		}
-
+		if err == nil {
+			err = importer.flush()
+		}
+		progress.done()
 		if err != nil {
-			return fmt.Errorf("failed to process places: %w", err)
+			return fmt.Errorf("failed to import from %s: %w", sourceName, err)
+		}
+
+		if importSavePreset != "" {
+			if err := saveImportPreset(importSavePreset, sourceName, mapping); err != nil {
+				return err
+			}
 		}
 
+		if importer.processed() == 0 {
+			fmt.Printf("No places found in %s\n", filePath)
+			return nil
+		}
+
+		logger.Info("Parsed places", "count", importer.processed(), "source", sourceName)
+
 		// Report results
 		fmt.Printf("\nImport complete from %s:\n", sourceName)
-		fmt.Printf("  Added:   %d places\n", added)
-		fmt.Printf("  Updated: %d places\n", updated)
-		fmt.Printf("  Skipped: %d places\n", skipped)
+		fmt.Printf("  Added:   %d places\n", importer.added)
+		fmt.Printf("  Updated: %d places\n", importer.updated)
+		fmt.Printf("  Skipped: %d places\n", importer.skipped)
+		if importer.visits > 0 {
+			fmt.Printf("  Visits:  %d logged\n", importer.visits)
+		}
 
 		if importDryRun {
 			fmt.Println("\nRun without --dry-run to apply changes")
@@ -193,97 +358,441 @@ Use --no-merge to disable duplicate detection and import all as new.`,
 
 		logger.Info("Import complete",
 			"source", sourceName,
-			"added", added,
-			"updated", updated,
-			"skipped", skipped)
+			"added", importer.added,
+			"updated", importer.updated,
+			"skipped", importer.skipped,
+			"visits", importer.visits)
 
 		return nil
 	},
 }
 
-func getSourceName(sm *sources.SourceManager, source sources.ImportSource) string {
-	for name, src := range sm.ListSources() {
-		if src == source {
-			return name
+var importPresetsCmd = &cobra.Command{
+	Use:   "presets",
+	Short: "List saved import mappings",
+	Long: `Show the mappings saved with 'import from --save-preset'. Use one with
+'import from <file> --preset <name>'.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		presets, err := db.ListImportPresets()
+		if err != nil {
+			return err
+		}
+		if len(presets) == 0 {
+			fmt.Println("No import presets saved. Use 'placeli import from <file> --map ... --save-preset <name>'.")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tSOURCE\tMAPPING\tSAVED")
+		for _, preset := range presets {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", preset.Name, preset.Source, preset.Mapping,
+				preset.UpdatedAt.Format("2006-01-02"))
 		}
+		return w.Flush()
+	},
+}
+
+var importPresetsDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "Delete a saved import mapping",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := db.DeleteImportPreset(args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("Deleted import preset %q\n", args[0])
+		return nil
+	},
+}
+
+// saveImportPreset stores the mapping of this import, or says it would on a
+// dry run
+func saveImportPreset(name, sourceName string, mapping sources.FieldMapping) error {
+	if importDryRun {
+		fmt.Printf("Would save import preset %q: %s\n", name, mapping)
+		return nil
+	}
+	preset := &database.ImportPreset{Name: name, Source: sourceName, Mapping: mapping.String()}
+	if err := db.SaveImportPreset(preset); err != nil {
+		return err
 	}
-	return "unknown"
+	fmt.Printf("Saved import preset %q\n", name)
+	return nil
 }
 
-func simpleImportPlaces(places []*models.Place, dryRun bool) (added, updated, skipped int, err error) {
-	for i, place := range places {
-		fmt.Printf("[%d/%d] Processing: %s\n", i+1, len(places), place.Name)
+// csvPreviewRows is how many spreadsheet rows a dry run shows as read
+const csvPreviewRows = 5
 
-		if !dryRun {
-			if err := db.SavePlace(place); err != nil {
-				fmt.Printf("  Error saving place: %v\n", err)
-				skipped++
-				continue
-			}
+// printCSVPreview shows how a spreadsheet's columns are read and the first
+// rows as places, so a mapping can be checked before importing
+func printCSVPreview(spreadsheet *sources.CSVImporter, filePath string) error {
+	preview, err := spreadsheet.Preview(filePath, csvPreviewRows)
+	if err != nil {
+		return err
+	}
+
+	delimiters := map[rune]string{',': "comma", ';': "semicolon", '\t': "tab", '|': "pipe"}
+	fmt.Printf("Spreadsheet: %s-separated, %s, %d columns\n",
+		delimiters[preview.Delimiter], preview.Encoding, len(preview.Header))
+
+	fmt.Println("\nColumn mapping:")
+	used := make(map[string]bool)
+	fields := make([]string, 0, len(preview.Fields))
+	for field, column := range preview.Fields {
+		fields = append(fields, field)
+		used[column] = true
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		return fieldOrder(fields[i]) < fieldOrder(fields[j]) ||
+			fieldOrder(fields[i]) == fieldOrder(fields[j]) && fields[i] < fields[j]
+	})
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, field := range fields {
+		fmt.Fprintf(w, "  %s\t<- %s\n", field, preview.Fields[field])
+	}
+	var rest []string
+	for _, column := range preview.Header {
+		if !used[column] {
+			rest = append(rest, column)
 		}
+	}
+	if len(rest) > 0 {
+		fmt.Fprintf(w, "  custom fields\t<- %s\n", strings.Join(rest, ", "))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
 
-		added++
-		if dryRun {
-			fmt.Printf("  ✓ Would add new place\n")
-		} else {
-			fmt.Printf("  ✓ Added new place\n")
+	fmt.Printf("\nFirst rows:\n")
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  NAME\tLOCATION\tCATEGORIES\tTAGS")
+	for _, place := range preview.Places {
+		location := "-"
+		if place.Geometry != nil {
+			location = place.Geometry.Type
+		} else if place.Coordinates != (models.Coordinates{}) {
+			location = fmt.Sprintf("%.5f, %.5f", place.Coordinates.Lat, place.Coordinates.Lng)
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", place.Name, location,
+			strings.Join(place.Categories, ", "), strings.Join(place.UserTags, ", "))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if preview.Skipped > 0 {
+		fmt.Printf("  (%d of the first rows skipped: no name or unreadable coordinates)\n", preview.Skipped)
+	}
+	fmt.Println()
+	return nil
+}
+
+// fieldOrder sorts place fields in the order of sources.MappableFields,
+// followed by custom fields
+func fieldOrder(field string) int {
+	for i, f := range sources.MappableFields {
+		if f == field {
+			return i
 		}
 	}
+	return len(sources.MappableFields)
+}
 
-	return added, updated, skipped, nil
+// placeImporter checks imported places for duplicates and saves them in
+// batches, one transaction per batch
+type placeImporter struct {
+	dryRun    bool
+	force     bool
+	merge     bool
+	batchSize int
+
+	pending []*models.Place
+	// check-ins to log once the batch holding their place is saved
+	pendingVisits []importedVisits
+	// place IDs already queued in this import, by source hash and Google
+	// place ID, so duplicates within one export are caught before they
+	// reach the database
+	queued map[string]string
+
+	added, updated, skipped, visits int
 }
 
-func smartImportPlaces(places []*models.Place, dryRun, force bool) (added, updated, skipped int, err error) {
-	for i, place := range places {
-		fmt.Printf("[%d/%d] Processing: %s\n", i+1, len(places), place.Name)
+type importedVisits struct {
+	placeID string
+	place   *models.Place
+}
 
-		// Check for existing place by source hash
-		existing, err := findExistingPlace(place)
-		if err != nil {
-			fmt.Printf("  Error checking for duplicates: %v\n", err)
+func newPlaceImporter(dryRun, force, merge bool, batchSize int) *placeImporter {
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	return &placeImporter{
+		dryRun:    dryRun,
+		force:     force,
+		merge:     merge,
+		batchSize: batchSize,
+		queued:    make(map[string]string),
+	}
+}
+
+func (pi *placeImporter) processed() int {
+	return pi.added + pi.updated + pi.skipped
+}
+
+// add queues a place for saving, flushing the batch once it is full
+func (pi *placeImporter) add(place *models.Place) error {
+	if !pi.merge {
+		// Simple import without duplicate checking
+		pi.queue(place, place.ID, place)
+		pi.added++
+		pi.report(place, "Would add")
+		return pi.flushIfFull()
+	}
+
+	if id, ok := pi.queuedID(place); ok {
+		// Already in this import, only log check-ins it didn't have
+		pi.pendingVisits = append(pi.pendingVisits, importedVisits{placeID: id, place: place})
+		pi.skipped++
+		pi.report(place, "Skip (duplicate within this import)")
+		return nil
+	}
+
+	// Check for existing place by source hash
+	existing, err := findExistingPlace(place)
+	if err != nil {
+		fmt.Printf("Error checking %s for duplicates: %v\n", place.Name, err)
+		return nil
+	}
+
+	switch {
+	case existing == nil:
+		pi.queue(place, place.ID, place)
+		pi.added++
+		pi.report(place, "Would add")
+	case pi.force:
+		// Update existing place
+		pi.queue(mergeImportedPlace(existing, place), existing.ID, place)
+		pi.updated++
+		pi.report(place, "Would update")
+	default:
+		// Skip existing place, but still log check-ins it didn't have
+		pi.pendingVisits = append(pi.pendingVisits, importedVisits{placeID: existing.ID, place: place})
+		pi.skipped++
+		pi.report(place, "Skip (already exists, use --force to update)")
+	}
+
+	return pi.flushIfFull()
+}
+
+func (pi *placeImporter) queue(save *models.Place, placeID string, imported *models.Place) {
+	pi.pending = append(pi.pending, save)
+	pi.pendingVisits = append(pi.pendingVisits, importedVisits{placeID: placeID, place: imported})
+	if imported.SourceHash != "" {
+		pi.queued["hash:"+imported.SourceHash] = placeID
+	}
+	if imported.PlaceID != "" {
+		pi.queued["place:"+imported.PlaceID] = placeID
+	}
+}
+
+func (pi *placeImporter) queuedID(place *models.Place) (string, bool) {
+	if id, ok := pi.queued["hash:"+place.SourceHash]; ok && place.SourceHash != "" {
+		return id, true
+	}
+	if id, ok := pi.queued["place:"+place.PlaceID]; ok && place.PlaceID != "" {
+		return id, true
+	}
+	return "", false
+}
+
+// report prints what a dry run would do with a place; real imports show
+// progress instead
+func (pi *placeImporter) report(place *models.Place, action string) {
+	if pi.dryRun {
+		fmt.Printf("  %s: %s\n", action, place.Name)
+	}
+}
+
+func (pi *placeImporter) flushIfFull() error {
+	if len(pi.pending) < pi.batchSize {
+		return nil
+	}
+	return pi.flush()
+}
+
+// flush saves the pending batch in one transaction and logs its check-ins
+func (pi *placeImporter) flush() error {
+	if !pi.dryRun {
+		if len(pi.pending) > 0 {
+			if err := db.SavePlaces(pi.pending); err != nil {
+				return fmt.Errorf("failed to save places: %w", err)
+			}
+		}
+		for _, v := range pi.pendingVisits {
+			pi.visits += saveImportedVisits(v.placeID, v.place)
+		}
+	}
+
+	pi.pending = pi.pending[:0]
+	pi.pendingVisits = pi.pendingVisits[:0]
+	return nil
+}
+
+// importProgress prints a single updating progress line on stderr
+type importProgress struct {
+	quiet   bool
+	last    time.Time
+	printed bool
+}
+
+func (p *importProgress) bytes(read, total int64) {
+	if !p.due(read == total) {
+		return
+	}
+	if total > 0 {
+		fmt.Fprintf(os.Stderr, "\rImporting: %s of %s (%d%%)   ",
+			formatBytes(read), formatBytes(total), read*100/total)
+	} else {
+		fmt.Fprintf(os.Stderr, "\rImporting: %s   ", formatBytes(read))
+	}
+}
+
+func (p *importProgress) places(done, total int) {
+	if !p.due(done == total) {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "\rImporting: %d of %d places   ", done, total)
+}
+
+// due throttles updates so printing doesn't slow the import down
+func (p *importProgress) due(final bool) bool {
+	if p.quiet {
+		return false
+	}
+	if !final && time.Since(p.last) < 100*time.Millisecond {
+		return false
+	}
+	p.last = time.Now()
+	p.printed = true
+	return true
+}
+
+func (p *importProgress) done() {
+	if p.printed {
+		fmt.Fprintln(os.Stderr)
+	}
+}
+
+// saveImportedVisits logs the check-ins an importer found for a place under
+// placeID, ignoring ones already logged
+func saveImportedVisits(placeID string, place *models.Place) int {
+	if len(place.Visits) == 0 {
+		return 0
+	}
+	added, err := db.AddVisits(placeID, place.Visits)
+	if err != nil {
+		fmt.Printf("  Error logging visits: %v\n", err)
+	}
+	return added
+}
+
+// importVisits logs the visits of a visit-only source against saved places
+// and suggests frequently visited places that aren't saved
+func importVisits(visited []*models.Place, dryRun bool) error {
+	saved, err := db.ListPlaces(constants.DefaultExportLimit, 0)
+	if err != nil {
+		return fmt.Errorf("failed to load places: %w", err)
+	}
+
+	matches, unsaved := matchVisitedPlaces(visited, saved, importRadius)
+
+	total, logged := 0, 0
+	for _, match := range matches {
+		total += len(match.visited.Visits)
+		if dryRun {
 			continue
 		}
+		added, err := db.AddVisits(match.saved.ID, match.visited.Visits)
+		if err != nil {
+			return err
+		}
+		logged += added
+	}
+
+	var suggestions []*models.Place
+	for _, place := range unsaved {
+		if len(place.Visits) >= importSuggestMin {
+			suggestions = append(suggestions, place)
+		}
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return len(suggestions[i].Visits) > len(suggestions[j].Visits)
+	})
 
-		if existing == nil {
-			// New place
-			if !dryRun {
-				if err := db.SavePlace(place); err != nil {
-					fmt.Printf("  Error saving place: %v\n", err)
-					continue
-				}
-			}
+	fmt.Printf("\nMatched %d visits to %d saved places", total, len(matches))
+	if !dryRun {
+		fmt.Printf(" (%d new)", logged)
+	}
+	fmt.Printf("\n%d visited places are not saved\n", len(unsaved))
 
-			added++
-			if dryRun {
-				fmt.Printf("  ✓ Would add new place\n")
-			} else {
-				fmt.Printf("  ✓ Added new place\n")
+	if len(suggestions) > 0 {
+		fmt.Printf("\nFrequently visited places you haven't saved:\n")
+		for _, place := range suggestions {
+			fmt.Printf("  %3d visits  %s\n", len(place.Visits), place.Name)
+		}
+	}
+
+	if importAddSuggested && !dryRun {
+		for _, place := range suggestions {
+			place.AddTag("timeline")
+			if err := db.SavePlace(place); err != nil {
+				return fmt.Errorf("failed to save place %s: %w", place.Name, err)
 			}
-		} else if force {
-			// Update existing place
-			mergedPlace := mergeImportedPlace(existing, place)
-
-			if !dryRun {
-				if err := db.SavePlace(mergedPlace); err != nil {
-					fmt.Printf("  Error updating place: %v\n", err)
-					continue
-				}
+			if _, err := db.AddVisits(place.ID, place.Visits); err != nil {
+				return err
 			}
+		}
+		fmt.Printf("\nSaved %d suggested places (tagged 'timeline')\n", len(suggestions))
+	} else if len(suggestions) > 0 {
+		fmt.Println("\nRun with --add-suggested to save them")
+	}
 
-			updated++
-			if dryRun {
-				fmt.Printf("  ✓ Would update existing place\n")
-			} else {
-				fmt.Printf("  ✓ Updated existing place\n")
-			}
-		} else {
-			// Skip existing place
-			skipped++
-			fmt.Printf("  - Skipped: already exists (use --force to update)\n")
+	if dryRun {
+		fmt.Println("\nRun without --dry-run to apply changes")
+	}
+	return nil
+}
+
+type visitMatch struct {
+	visited *models.Place
+	saved   *models.Place
+}
+
+// matchVisitedPlaces pairs each visited place with a saved place, by Google
+// place ID first and then by distance. Visited places without a match are
+// returned separately.
+func matchVisitedPlaces(visited, saved []*models.Place, radius float64) ([]visitMatch, []*models.Place) {
+	byPlaceID := make(map[string]*models.Place)
+	for _, place := range saved {
+		if place.PlaceID != "" {
+			byPlaceID[place.PlaceID] = place
 		}
 	}
 
-	return added, updated, skipped, nil
+	var matches []visitMatch
+	var unsaved []*models.Place
+	for _, place := range visited {
+		match := byPlaceID[place.PlaceID]
+		if match == nil {
+			match, _ = models.NearestPlace(saved, place.Coordinates, radius)
+		}
+		if match == nil {
+			unsaved = append(unsaved, place)
+			continue
+		}
+		matches = append(matches, visitMatch{visited: place, saved: match})
+	}
+	return matches, unsaved
 }
 
 func findExistingPlace(place *models.Place) (*models.Place, error) {
@@ -324,6 +833,8 @@ func mergeImportedPlace(existing, imported *models.Place) *models.Place {
 	// Preserve user data
 	merged.UserNotes = existing.UserNotes
 	merged.UserTags = existing.UserTags
+	merged.SharedTags = existing.SharedTags
+	merged.Attachments = existing.Attachments
 
 	// Merge custom fields, preserving user-added fields
 	if existing.CustomFields != nil {
@@ -332,7 +843,7 @@ func mergeImportedPlace(existing, imported *models.Place) *models.Place {
 		}
 
 		// Preserve user-added custom fields (not from import systems)
-		systemPrefixes := []string{"google_", "osm_", "apple_", "foursquare_", "gpx_", "imported_from", "import_date"}
+		systemPrefixes := []string{"google_", "osm_", "apple_", "mymaps_", "geojson_", "csv_", "foursquare_", "gpx_", "imported_from", "import_date"}
 		for key, value := range existing.CustomFields {
 			isSystemField := false
 			for _, prefix := range systemPrefixes {
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/user/placeli/internal/models"
+)
+
+func TestMatchVisitedPlaces(t *testing.T) {
+	saved := []*models.Place{
+		{ID: "ramiro", PlaceID: "ChIJramiro", Coordinates: models.Coordinates{Lat: 38.7205, Lng: -9.1357}},
+		{ID: "market", Coordinates: models.Coordinates{Lat: 38.7070, Lng: -9.1460}},
+	}
+	visited := []*models.Place{
+		// Matched by place ID even though the coordinates are off
+		{PlaceID: "ChIJramiro", Coordinates: models.Coordinates{Lat: 38.80, Lng: -9.20}},
+		// Matched by distance
+		{PlaceID: "ChIJother", Coordinates: models.Coordinates{Lat: 38.7072, Lng: -9.1461}},
+		// Too far from anything saved
+		{PlaceID: "ChIJhome", Coordinates: models.Coordinates{Lat: 38.75, Lng: -9.15}},
+	}
+
+	matches, unsaved := matchVisitedPlaces(visited, saved, 75)
+	if len(matches) != 2 {
+		t.Fatalf("Expected 2 matches, got %d", len(matches))
+	}
+	if matches[0].saved.ID != "ramiro" || matches[1].saved.ID != "market" {
+		t.Errorf("Unexpected matches: %s, %s", matches[0].saved.ID, matches[1].saved.ID)
+	}
+	if len(unsaved) != 1 || unsaved[0].PlaceID != "ChIJhome" {
+		t.Errorf("Expected home to be unsaved, got %+v", unsaved)
+	}
+}
@@ -4,10 +4,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/user/placeli/internal/constants"
+	"github.com/user/placeli/internal/database"
 	"github.com/user/placeli/internal/logger"
 	"github.com/user/placeli/internal/models"
 	"github.com/user/placeli/internal/tui/mapview"
@@ -20,6 +24,15 @@ var (
 	listSearch  string
 	listMap     bool
 	listMapSize int
+	listOpenNow bool
+	listOpenAt  string
+
+	listVisited   bool
+	listUnvisited bool
+	listVisitedIn string
+	listSort      string
+
+	listInside string
 )
 
 func init() {
@@ -31,6 +44,13 @@ func init() {
 	listCmd.Flags().StringVar(&listSearch, "search", "", "search query to filter places")
 	listCmd.Flags().BoolVar(&listMap, "map", false, "show mini-map of places")
 	listCmd.Flags().IntVar(&listMapSize, "map-size", 20, "size of mini-map (width)")
+	listCmd.Flags().BoolVar(&listOpenNow, "open-now", false, "only show places that are open right now")
+	listCmd.Flags().StringVar(&listOpenAt, "open-at", "", "only show places open at a time, e.g. \"sat 20:00\"")
+	listCmd.Flags().BoolVar(&listVisited, "visited", false, "only show places you have logged visits to")
+	listCmd.Flags().BoolVar(&listUnvisited, "unvisited", false, "only show places without logged visits")
+	listCmd.Flags().StringVar(&listVisitedIn, "visited-in", "", "only show places visited in a period: YYYY, YYYY-MM, YYYY-MM-DD or FROM..TO")
+	listCmd.Flags().StringVar(&listSort, "sort", "", "sort by: name, visits, last-visited, first-visited")
+	listCmd.Flags().StringVar(&listInside, "inside", "", "only show places inside an area, by the area's place ID")
 }
 
 var listCmd = &cobra.Command{
@@ -46,6 +66,12 @@ Output formats:
 
 The --map flag adds a mini-map to table and simple formats.
 Use --search to filter places by name, address, or notes.
+Use --open-now or --open-at to filter by opening hours; places without
+known opening hours are left out.
+Use --visited, --unvisited or --visited-in to filter by your visit log, and
+--sort to order by name, visit count or first/last visit.
+Use --inside with the ID of an area, such as a park or neighbourhood
+imported from KML, GeoJSON or OpenStreetMap, to list the places within it.
 
 Examples:
   placeli list                           # List first 20 places
@@ -53,26 +79,64 @@ Examples:
   placeli list --search="coffee"        # Search for coffee places
   placeli list --format=json            # Output as JSON
   placeli list --map                    # Include mini-map
-  placeli list --format=map             # Show only map`,
+  placeli list --format=map             # Show only map
+  placeli list --open-now               # Places open right now
+  placeli list --open-at "sat 20:00"    # Places open on Saturday evening
+  placeli list --search=Lisbon --visited-in=2024 --sort=last-visited
+  placeli list --inside <area-id>       # Places inside a park or district`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		logger.Info("Listing places",
 			"limit", listLimit,
 			"offset", listOffset,
 			"format", listFormat,
 			"search", listSearch,
-			"map", listMap)
+			"map", listMap,
+			"open_now", listOpenNow,
+			"open_at", listOpenAt)
+
+		openMatch, err := models.OpenMatcher(listOpenNow, listOpenAt, time.Now())
+		if err != nil {
+			return fmt.Errorf("invalid --open-at: %w", err)
+		}
+
+		visitMatch, summaries, err := visitFilter()
+		if err != nil {
+			return err
+		}
+		var insideMatch func(*models.Place) bool
+		if listInside != "" {
+			area, err := db.GetPlace(listInside)
+			if err != nil {
+				return fmt.Errorf("failed to get area: %w", err)
+			}
+			if insideMatch, err = models.InsideMatcher(area); err != nil {
+				return err
+			}
+		}
+		match := models.MatchAll(openMatch, visitMatch, insideMatch)
 
 		// Get places
 		var places []*models.Place
-		var err error
 
-		if listSearch != "" {
-			places, err = db.SearchPlaces(listSearch)
-			if err != nil {
-				return fmt.Errorf("failed to search places: %w", err)
+		if listSearch != "" || match != nil || listSort != "" {
+			if listSearch != "" {
+				places, err = db.SearchPlaces(listSearch)
+				if err != nil {
+					return fmt.Errorf("failed to search places: %w", err)
+				}
+			} else {
+				places, err = db.ListPlaces(constants.DefaultPlaceLimit, 0)
+				if err != nil {
+					return fmt.Errorf("failed to list places: %w", err)
+				}
 			}
 
-			// Apply offset and limit to search results
+			places = models.FilterPlaces(places, match)
+			if err := sortPlaces(places, listSort, summaries); err != nil {
+				return err
+			}
+
+			// Apply offset and limit to filtered results
 			if listOffset >= len(places) {
 				places = []*models.Place{}
 			} else {
@@ -105,6 +169,73 @@ Examples:
 	},
 }
 
+// visitFilter builds a place filter from the visit flags. Visit summaries
+// are loaded when filtering or sorting needs them.
+func visitFilter() (func(*models.Place) bool, map[string]models.VisitSummary, error) {
+	if !listVisited && !listUnvisited && listVisitedIn == "" && listSort == "" {
+		return nil, nil, nil
+	}
+
+	summaries, err := db.GetVisitSummaries()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var matchers []func(*models.Place) bool
+	if listVisited {
+		matchers = append(matchers, func(p *models.Place) bool {
+			return summaries[p.ID].Count > 0
+		})
+	}
+	if listUnvisited {
+		matchers = append(matchers, func(p *models.Place) bool {
+			return summaries[p.ID].Count == 0
+		})
+	}
+	if listVisitedIn != "" {
+		period, err := models.ParseVisitPeriod(listVisitedIn, time.Local)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid --visited-in: %w", err)
+		}
+		visits, err := db.ListVisits(database.VisitFilter{From: period.From, To: period.To})
+		if err != nil {
+			return nil, nil, err
+		}
+		visitedIn := make(map[string]bool)
+		for _, visit := range visits {
+			visitedIn[visit.PlaceID] = true
+		}
+		matchers = append(matchers, func(p *models.Place) bool {
+			return visitedIn[p.ID]
+		})
+	}
+
+	return models.MatchAll(matchers...), summaries, nil
+}
+
+// sortPlaces orders places by name or by visit-derived fields, most visited
+// or most recent first
+func sortPlaces(places []*models.Place, by string, summaries map[string]models.VisitSummary) error {
+	var less func(a, b *models.Place) bool
+	switch by {
+	case "":
+		return nil
+	case "name":
+		less = func(a, b *models.Place) bool { return strings.ToLower(a.Name) < strings.ToLower(b.Name) }
+	case "visits":
+		less = func(a, b *models.Place) bool { return summaries[a.ID].Count > summaries[b.ID].Count }
+	case "last-visited":
+		less = func(a, b *models.Place) bool { return summaries[a.ID].Last.After(summaries[b.ID].Last) }
+	case "first-visited":
+		less = func(a, b *models.Place) bool { return summaries[a.ID].First.After(summaries[b.ID].First) }
+	default:
+		return fmt.Errorf("unknown sort: %s (use name, visits, last-visited or first-visited)", by)
+	}
+
+	sort.SliceStable(places, func(i, j int) bool { return less(places[i], places[j]) })
+	return nil
+}
+
 func displayTable(places []*models.Place) error {
 	if len(places) == 0 {
 		fmt.Println("No places found")
@@ -197,6 +328,10 @@ func displaySimple(places []*models.Place) error {
 			fmt.Printf("   🔖 %s\n", strings.Join(place.UserTags, ", "))
 		}
 
+		if len(place.SharedTags) > 0 {
+			fmt.Printf("   👥 %s\n", strings.Join(place.SharedTags, ", "))
+		}
+
 		if place.UserNotes != "" {
 			notes := place.UserNotes
 			if len(notes) > 100 {
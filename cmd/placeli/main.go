@@ -10,8 +10,9 @@ import (
 )
 
 var (
-	dbPath string
-	db     *database.DB
+	dbPath   string
+	userName string
+	db       *database.DB
 )
 
 var rootCmd = &cobra.Command{
@@ -49,6 +50,23 @@ var rootCmd = &cobra.Command{
 			fmt.Fprintf(os.Stderr, "Database will be created at: %s\n", dbPath)
 			os.Exit(1)
 		}
+
+		// Notes, tags, custom fields and visits are per user
+		if userName == "" {
+			userName = os.Getenv("PLACELI_USER")
+		}
+		if userName != "" {
+			exists, err := db.UserExists(userName)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if !exists {
+				fmt.Fprintf(os.Stderr, "Error: unknown user %q (add it with 'placeli users add %s')\n", userName, userName)
+				os.Exit(1)
+			}
+			db = db.ForUser(userName)
+		}
 	},
 	PersistentPostRun: func(cmd *cobra.Command, args []string) {
 		if db != nil {
@@ -67,6 +85,7 @@ var versionCmd = &cobra.Command{
 
 func init() {
 	rootCmd.PersistentFlags().StringVar(&dbPath, "db", "", "path to SQLite database file")
+	rootCmd.PersistentFlags().StringVar(&userName, "user", "", "user whose notes, tags and visits to use (default: env PLACELI_USER, or the default user)")
 	rootCmd.AddCommand(versionCmd)
 }
 
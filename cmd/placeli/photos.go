@@ -0,0 +1,246 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/user/placeli/internal/models"
+	"github.com/user/placeli/internal/photos"
+)
+
+var (
+	photosDir    string
+	photosDryRun bool
+)
+
+func init() {
+	rootCmd.AddCommand(photosCmd)
+
+	photosCmd.AddCommand(photosStatsCmd)
+	photosCmd.AddCommand(photosGCCmd)
+	photosCmd.AddCommand(photosVerifyCmd)
+	photosCmd.AddCommand(photosMigrateCmd)
+
+	photosCmd.PersistentFlags().StringVar(&photosDir, "photo-dir", "", "photo store directory (default: ~/.placeli/photos)")
+	photosGCCmd.Flags().BoolVar(&photosDryRun, "dry-run", false, "show what would be removed without deleting anything")
+}
+
+var photosCmd = &cobra.Command{
+	Use:   "photos",
+	Short: "Manage the local photo store",
+	Long: `Manage downloaded place photos.
+
+Photos are stored once per unique image, keyed by content hash, with
+thumbnails generated at fixed sizes. Places keep references to the hashes.
+
+Available subcommands:
+  stats   - Show store size and how many photos are shared
+  gc      - Remove photos no place references any more
+  verify  - Check stored photos against their hashes
+  migrate - Move photos downloaded by older versions into the store`,
+}
+
+var photosStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show photo store statistics",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openPhotoStore()
+		if err != nil {
+			return err
+		}
+
+		stats, err := store.Stats()
+		if err != nil {
+			return err
+		}
+
+		var refs, legacy, withPhotos int
+		referenced := make(map[string]bool)
+		err = db.ForEachPlace("", func(place *models.Place) error {
+			if len(place.Photos) > 0 {
+				withPhotos++
+			}
+			for _, photo := range place.Photos {
+				if photo.Hash != "" {
+					refs++
+				} else if photo.LocalPath != "" {
+					legacy++
+				}
+			}
+			for hash := range photos.References([]*models.Place{place}) {
+				referenced[hash] = true
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to load places: %w", err)
+		}
+		unique := len(referenced)
+
+		fmt.Printf("Photo store: %s\n", store.Root())
+		fmt.Printf("Images:      %d (%s)\n", stats.Objects, formatBytes(stats.ObjectBytes))
+		fmt.Printf("Thumbnails:  %d (%s)\n", stats.Thumbnails, formatBytes(stats.ThumbnailBytes))
+		fmt.Printf("References:  %d from %d places (%d unique, %d shared)\n", refs, withPhotos, unique, refs-unique)
+		orphans, err := store.GC(referenced, true)
+		if err != nil {
+			return err
+		}
+		if orphans.Objects > 0 {
+			fmt.Printf("Unreferenced: %d (run 'placeli photos gc' to remove)\n", orphans.Objects)
+		}
+		if legacy > 0 {
+			fmt.Printf("Legacy photos outside the store: %d (run 'placeli photos migrate')\n", legacy)
+		}
+		return nil
+	},
+}
+
+var photosGCCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Remove photos that no place references",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openPhotoStore()
+		if err != nil {
+			return err
+		}
+		referenced, err := photoReferences()
+		if err != nil {
+			return err
+		}
+
+		result, err := store.GC(referenced, photosDryRun)
+		if err != nil {
+			return err
+		}
+
+		verb := "Removed"
+		if photosDryRun {
+			verb = "Would remove"
+		}
+		fmt.Printf("%s %d images and %d thumbnails (%s)\n",
+			verb, result.Objects, result.Thumbnails, formatBytes(result.Bytes))
+		return nil
+	},
+}
+
+var photosVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check stored photos against their content hashes",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openPhotoStore()
+		if err != nil {
+			return err
+		}
+		referenced, err := photoReferences()
+		if err != nil {
+			return err
+		}
+
+		result, err := store.Verify(referenced)
+		if err != nil {
+			return err
+		}
+
+		for _, hash := range result.Corrupt {
+			fmt.Printf("corrupt: %s\n", hash)
+		}
+		for _, hash := range result.Missing {
+			fmt.Printf("missing: %s\n", hash)
+		}
+
+		if !result.OK() {
+			return fmt.Errorf("%d corrupt and %d missing photos (re-run 'placeli enrich --photos' to fetch them again)",
+				len(result.Corrupt), len(result.Missing))
+		}
+		fmt.Printf("Verified %d photos\n", result.Checked)
+		return nil
+	},
+}
+
+var photosMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Move photos from the old flat layout into the store",
+	Long: `Older versions saved photos as <place_name>_<n>.jpg in a flat directory.
+This copies every such photo into the content-addressed store and updates the
+place's reference. The old files are left in place for you to delete.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openPhotoStore()
+		if err != nil {
+			return err
+		}
+
+		migrated, missing := 0, 0
+		err = db.ForEachPlace("", func(place *models.Place) error {
+			changed := false
+			for i, photo := range place.Photos {
+				if photo.Hash != "" || photo.LocalPath == "" {
+					continue
+				}
+				hash, err := store.PutFile(photo.LocalPath)
+				if err != nil {
+					missing++
+					fmt.Fprintf(os.Stderr, "Skipping %s: %v\n", photo.LocalPath, err)
+					continue
+				}
+				place.Photos[i].Hash = hash
+				place.Photos[i].LocalPath = store.Path(hash)
+				changed = true
+				migrated++
+			}
+			if changed {
+				if err := db.SavePlace(place); err != nil {
+					return fmt.Errorf("failed to save place %s: %w", place.Name, err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Migrated %d photos", migrated)
+		if missing > 0 {
+			fmt.Printf(", %d could not be read", missing)
+		}
+		fmt.Println()
+		return nil
+	},
+}
+
+func openPhotoStore() (*photos.Store, error) {
+	dir := photosDir
+	if dir == "" {
+		dir = photos.DefaultDir()
+	}
+	return photos.NewStore(dir)
+}
+
+// photoReferences collects the hashes of the photos and attachments every
+// place refers to
+func photoReferences() (map[string]bool, error) {
+	referenced := make(map[string]bool)
+	err := db.ForEachPlace("", func(place *models.Place) error {
+		for hash := range photos.References([]*models.Place{place}) {
+			referenced[hash] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load places: %w", err)
+	}
+	return referenced, nil
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
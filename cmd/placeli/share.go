@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/user/placeli/internal/database"
+)
+
+var (
+	shareTag     string
+	shareExpires string
+	shareNotes   bool
+	shareFields  []string
+	shareAllTags bool
+	shareURL     string
+)
+
+var shareCmd = &cobra.Command{
+	Use:   "share",
+	Short: "Share tagged places with a read-only link",
+	Long: `Manage read-only links to the places with a tag.
+
+'placeli web' serves each link at /s/<secret> as a map and list of the
+tagged places, without a login. The page always shows the places as they
+are now, so a trip shared this way never goes stale. Anyone with the link
+can open it until it expires or is revoked.
+
+Available subcommands:
+  create - Create a link to the places with a tag
+  list   - List links
+  revoke - Turn a link off`,
+}
+
+var shareCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a share link",
+	Long: `Create a read-only link to the places the user selected with --user has
+tagged with --tag, or that have it as a shared tag.
+
+Notes, custom fields and the places' other tags are private unless
+--notes, --field and --all-tags let them through. Photos, attachments and
+visits are never shown.
+
+Examples:
+  placeli share create --tag lisbon-trip --expires 30d
+  placeli share create --tag lisbon-trip --notes --field dish --field price
+  placeli share create --tag team-lunch --expires never --url https://places.example.com`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if shareTag == "" {
+			return fmt.Errorf("--tag is required")
+		}
+
+		var expiresAt *time.Time
+		if shareExpires != "never" {
+			age, err := parseAge(shareExpires)
+			if err != nil || age <= 0 {
+				return fmt.Errorf("invalid --expires %q: use a duration such as 30d, 2w or 12h, or never", shareExpires)
+			}
+			t := time.Now().Add(age)
+			expiresAt = &t
+		}
+
+		places, err := db.PlacesWithTag(shareTag)
+		if err != nil {
+			return err
+		}
+
+		share, secret, err := db.CreateShare(shareTag, database.SharePolicy{
+			Notes:  shareNotes,
+			Fields: shareFields,
+			Tags:   shareAllTags,
+		}, expiresAt)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Created share %s for %d places tagged %s", share.ID, len(places), share.Tag)
+		if share.ExpiresAt != nil {
+			fmt.Printf(", expiring %s", share.ExpiresAt.Local().Format("2006-01-02 15:04"))
+		}
+		fmt.Println()
+		if len(places) == 0 {
+			fmt.Println("No places have this tag yet; places you tag later will show up.")
+		}
+		fmt.Printf("\n  %s/s/%s\n\n", strings.TrimSuffix(shareURL, "/"), secret)
+		fmt.Println("This is the only time the link is shown; revoke it with 'placeli share revoke " + share.ID + "'.")
+		return nil
+	},
+}
+
+var shareListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List share links",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		shares, err := db.ListShares()
+		if err != nil {
+			return err
+		}
+		if len(shares) == 0 {
+			fmt.Println("No share links (create one with 'placeli share create --tag <tag>')")
+			return nil
+		}
+
+		now := time.Now()
+		fmt.Printf("%-16s  %-20s  %-12s  %-16s  %-16s  %s\n", "ID", "TAG", "USER", "CREATED", "EXPIRES", "SHOWS")
+		for _, share := range shares {
+			user := share.User
+			if user == "" {
+				user = "(default)"
+			}
+			expires := "never"
+			if share.ExpiresAt != nil {
+				expires = share.ExpiresAt.Local().Format("2006-01-02 15:04")
+			}
+			shows := describePolicy(share.Policy)
+			switch {
+			case share.RevokedAt != nil:
+				shows += " (revoked)"
+			case !share.Active(now):
+				shows += " (expired)"
+			}
+			fmt.Printf("%-16s  %-20s  %-12s  %-16s  %-16s  %s\n", share.ID, share.Tag, user,
+				share.CreatedAt.Local().Format("2006-01-02 15:04"), expires, shows)
+		}
+		return nil
+	},
+}
+
+var shareRevokeCmd = &cobra.Command{
+	Use:   "revoke <id>",
+	Short: "Revoke a share link",
+	Long:  "Revoke a share link. Anyone opening it from then on is told it has expired.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		share, err := db.RevokeShare(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Revoked share %s (%s) at %s\n", share.ID, share.Tag, share.RevokedAt.Format(time.RFC3339))
+		return nil
+	},
+}
+
+// describePolicy lists what a share shows beyond the places' public details
+func describePolicy(p database.SharePolicy) string {
+	var parts []string
+	if p.Notes {
+		parts = append(parts, "notes")
+	}
+	if len(p.Fields) > 0 {
+		parts = append(parts, "fields "+strings.Join(p.Fields, ","))
+	}
+	if p.Tags {
+		parts = append(parts, "all tags")
+	}
+	if len(parts) == 0 {
+		return "public details"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func init() {
+	shareCreateCmd.Flags().StringVar(&shareTag, "tag", "", "share the places with this tag")
+	shareCreateCmd.Flags().StringVar(&shareExpires, "expires", "30d", "how long the link works, such as 30d, 2w or 12h, or never")
+	shareCreateCmd.Flags().BoolVar(&shareNotes, "notes", false, "show your notes")
+	shareCreateCmd.Flags().StringSliceVar(&shareFields, "field", nil, "show a custom field (repeatable, or * for all)")
+	shareCreateCmd.Flags().BoolVar(&shareAllTags, "all-tags", false, "show the places' other tags too")
+	shareCreateCmd.Flags().StringVar(&shareURL, "url", "http://localhost:8080", "address 'placeli web' is reached at, for the printed link")
+
+	shareCmd.AddCommand(shareCreateCmd)
+	shareCmd.AddCommand(shareListCmd)
+	shareCmd.AddCommand(shareRevokeCmd)
+	rootCmd.AddCommand(shareCmd)
+}
@@ -13,6 +13,7 @@ import (
 var (
 	tagsFilter string
 	tagsForce  bool
+	tagsShared bool
 )
 
 func init() {
@@ -24,6 +25,8 @@ func init() {
 	tagsCmd.AddCommand(tagsDeleteCmd)
 	tagsCmd.AddCommand(tagsApplyCmd)
 
+	tagsCmd.PersistentFlags().BoolVar(&tagsShared, "shared", false, "manage the team tags every user sees instead of your own")
+
 	// Flags for apply command
 	tagsApplyCmd.Flags().StringVar(&tagsFilter, "filter", "", "search query to filter places")
 	tagsDeleteCmd.Flags().BoolVar(&tagsForce, "force", false, "delete tag without confirmation")
@@ -34,6 +37,9 @@ var tagsCmd = &cobra.Command{
 	Short: "Manage tags for organizing places",
 	Long: `Manage tags for organizing and categorizing your saved places.
 
+Tags are personal to the user selected with --user. With --shared, the
+commands manage team tags instead, which every user sees.
+
 Available subcommands:
   list    - Show all tags with usage counts
   rename  - Rename a tag across all places
@@ -44,7 +50,8 @@ Examples:
   placeli tags list
   placeli tags rename "old-name" "new-name"
   placeli tags delete "unwanted-tag"
-  placeli tags apply "favorite" --filter="coffee"`,
+  placeli tags apply "favorite" --filter="coffee"
+  placeli tags apply "team-lunch" --shared --filter="lisbon"`,
 }
 
 var tagsListCmd = &cobra.Command{
@@ -209,13 +216,44 @@ the tag will be applied to all places.`,
 	},
 }
 
-// Database operations for tag management
+// Database operations for tag management. They work on the user's own
+// tags, or on the shared tags with --shared.
+
+func placeTags(place *models.Place) []string {
+	if tagsShared {
+		return place.SharedTags
+	}
+	return place.UserTags
+}
+
+func hasTag(place *models.Place, tag string) bool {
+	if tagsShared {
+		return place.HasSharedTag(tag)
+	}
+	return place.HasTag(tag)
+}
+
+func addTag(place *models.Place, tag string) {
+	if tagsShared {
+		place.AddSharedTag(tag)
+	} else {
+		place.AddTag(tag)
+	}
+}
+
+func removeTag(place *models.Place, tag string) {
+	if tagsShared {
+		place.RemoveSharedTag(tag)
+	} else {
+		place.RemoveTag(tag)
+	}
+}
 
 func getTagCounts() (map[string]int, error) {
 	tagCounts := make(map[string]int)
 
 	err := db.ForEachPlace("", func(place *models.Place) error {
-		for _, tag := range place.UserTags {
+		for _, tag := range placeTags(place) {
 			tagCounts[tag]++
 		}
 		return nil
@@ -232,7 +270,7 @@ func getTagUsageCount(tag string) (int, error) {
 	count := 0
 
 	err := db.ForEachPlace("", func(place *models.Place) error {
-		if place.HasTag(tag) {
+		if hasTag(place, tag) {
 			count++
 		}
 		return nil
@@ -249,9 +287,9 @@ func renameTag(oldTag, newTag string) (int, error) {
 	count := 0
 
 	err := db.ForEachPlace("", func(place *models.Place) error {
-		if place.HasTag(oldTag) {
-			place.RemoveTag(oldTag)
-			place.AddTag(newTag)
+		if hasTag(place, oldTag) {
+			removeTag(place, oldTag)
+			addTag(place, newTag)
 
 			if err := db.SavePlace(place); err != nil {
 				return fmt.Errorf("failed to update place %s: %w", place.ID, err)
@@ -272,8 +310,8 @@ func deleteTag(tag string) (int, error) {
 	count := 0
 
 	err := db.ForEachPlace("", func(place *models.Place) error {
-		if place.HasTag(tag) {
-			place.RemoveTag(tag)
+		if hasTag(place, tag) {
+			removeTag(place, tag)
 
 			if err := db.SavePlace(place); err != nil {
 				return fmt.Errorf("failed to update place %s: %w", place.ID, err)
@@ -294,8 +332,8 @@ func applyTag(tag, filter string) (int, error) {
 	count := 0
 
 	err := db.ForEachPlace(filter, func(place *models.Place) error {
-		if !place.HasTag(tag) {
-			place.AddTag(tag)
+		if !hasTag(place, tag) {
+			addTag(place, tag)
 
 			if err := db.SavePlace(place); err != nil {
 				return fmt.Errorf("failed to update place %s: %w", place.ID, err)
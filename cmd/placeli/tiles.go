@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/user/placeli/internal/tiles"
+)
+
+var (
+	tilesCache    string
+	tilesURL      string
+	tilesBBox     string
+	tilesZoom     string
+	tilesWorkers  int
+	tilesRefresh  bool
+	tilesMaxTiles int
+)
+
+var tilesCmd = &cobra.Command{
+	Use:   "tiles",
+	Short: "Manage the offline map tile cache",
+	Long: `Manage the map tiles the web interface shows.
+
+'placeli web' serves map tiles from an MBTiles file, fetching and caching
+tiles it doesn't have while online. Fetch an area ahead of a trip to have
+its map offline.
+
+Available subcommands:
+  fetch - Download the tiles covering an area
+  stats - Show what the cache holds`,
+}
+
+var tilesFetchCmd = &cobra.Command{
+	Use:   "fetch",
+	Short: "Download map tiles for an area",
+	Long: `Download the tiles covering a bounding box at a range of zoom levels into
+the tile cache. Tiles already cached are skipped unless --refresh is given.
+
+The number of tiles grows fourfold with each zoom level, so the download is
+refused above --max-tiles. Public tile servers such as OpenStreetMap's ask
+for light use; keep areas small and --workers low, or use your own server
+with --url.
+
+Examples:
+  placeli tiles fetch --bbox -9.23,38.69,-9.09,38.80 --zoom 0-16
+  placeli tiles fetch --bbox 2.25,48.81,2.42,48.90 --zoom 12-15 --url 'http://localhost:8081/{z}/{x}/{y}.png'`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if tilesBBox == "" {
+			return fmt.Errorf("--bbox is required")
+		}
+		bbox, err := tiles.ParseBBox(tilesBBox)
+		if err != nil {
+			return err
+		}
+		minZoom, maxZoom, err := tiles.ParseZoomRange(tilesZoom)
+		if err != nil {
+			return err
+		}
+
+		count := tiles.CountCovering(bbox, minZoom, maxZoom)
+		if count > tilesMaxTiles {
+			return fmt.Errorf("%d tiles is more than --max-tiles %d; shrink the area or zoom range", count, tilesMaxTiles)
+		}
+
+		cache, err := tiles.Open(tilesCache)
+		if err != nil {
+			return err
+		}
+		defer cache.Close()
+		if tilesURL == tiles.DefaultURL {
+			if err := cache.SetMetadata("attribution", "© OpenStreetMap contributors"); err != nil {
+				return err
+			}
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		fmt.Printf("Fetching %d tiles (zoom %d-%d) into %s...\n", count, minZoom, maxZoom, cache.Path())
+		fetcher := tiles.NewFetcher(tilesURL)
+		result, err := fetcher.FetchAll(ctx, cache, tiles.Covering(bbox, minZoom, maxZoom), tiles.FetchOptions{
+			Workers:  tilesWorkers,
+			Refresh:  tilesRefresh,
+			Progress: printTilesProgress,
+		})
+		if result != nil {
+			fmt.Fprintln(os.Stderr)
+			fmt.Printf("Fetched %d (%s), already cached %d, failed %d\n",
+				result.Fetched, formatBytes(result.Bytes), result.Cached, result.Failed)
+		}
+		if err != nil {
+			return fmt.Errorf("%w (run again to continue; cached tiles are skipped)", err)
+		}
+		return nil
+	},
+}
+
+var tilesStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show tile cache statistics",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cache, err := tiles.Open(tilesCache)
+		if err != nil {
+			return err
+		}
+		defer cache.Close()
+
+		stats, err := cache.Stats()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Tile cache: %s\n", cache.Path())
+		if stats.Tiles == 0 {
+			fmt.Println("Tiles:      none (fetch some with 'placeli tiles fetch')")
+			return nil
+		}
+		fmt.Printf("Tiles:      %d (%s)\n", stats.Tiles, formatBytes(stats.Bytes))
+		fmt.Printf("Zoom:       %d-%d\n", stats.MinZoom, stats.MaxZoom)
+		return nil
+	},
+}
+
+// printTilesProgress redraws a one-line progress bar on stderr
+func printTilesProgress(r tiles.FetchResult) {
+	const width = 30
+	filled := width
+	if r.Total > 0 {
+		filled = r.Done() * width / r.Total
+	}
+	fmt.Fprintf(os.Stderr, "\r[%s%s] %d/%d  fetched %d  cached %d  failed %d",
+		strings.Repeat("=", filled), strings.Repeat(" ", width-filled),
+		r.Done(), r.Total, r.Fetched, r.Cached, r.Failed)
+}
+
+func init() {
+	tilesCmd.PersistentFlags().StringVar(&tilesCache, "cache", tiles.DefaultPath(), "MBTiles file to store tiles in")
+
+	tilesFetchCmd.Flags().StringVar(&tilesBBox, "bbox", "", "area to fetch: minLng,minLat,maxLng,maxLat")
+	tilesFetchCmd.Flags().StringVar(&tilesZoom, "zoom", "0-15", "zoom level or range, such as 12 or 0-15")
+	tilesFetchCmd.Flags().StringVar(&tilesURL, "url", tiles.DefaultURL, "tile server URL template with {z}, {x} and {y}")
+	tilesFetchCmd.Flags().IntVar(&tilesWorkers, "workers", tiles.DefaultWorkers, "parallel downloads")
+	tilesFetchCmd.Flags().BoolVar(&tilesRefresh, "refresh", false, "download tiles again even if cached")
+	tilesFetchCmd.Flags().IntVar(&tilesMaxTiles, "max-tiles", 20000, "refuse downloads of more tiles than this")
+
+	tilesCmd.AddCommand(tilesFetchCmd)
+	tilesCmd.AddCommand(tilesStatsCmd)
+	rootCmd.AddCommand(tilesCmd)
+}
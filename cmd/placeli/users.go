@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var usersForce bool
+
+func init() {
+	rootCmd.AddCommand(usersCmd)
+
+	usersCmd.AddCommand(usersListCmd)
+	usersCmd.AddCommand(usersAddCmd)
+	usersCmd.AddCommand(usersRemoveCmd)
+
+	usersRemoveCmd.Flags().BoolVar(&usersForce, "force", false, "remove without confirmation")
+}
+
+var usersCmd = &cobra.Command{
+	Use:   "users",
+	Short: "Manage users sharing this database",
+	Long: `Manage the users of a shared database.
+
+Places themselves are shared. Each user has their own notes, tags, custom
+fields and visits; pick the user with --user or PLACELI_USER. Shared team
+tags are managed with 'placeli tags --shared'. Without --user, commands use
+the default user, who owns everything from before users were added.
+
+API tokens belong to the user they were created for:
+  placeli --user alice web tokens create alice-laptop --scope write
+
+Available subcommands:
+  list   - List users
+  add    - Add a user
+  remove - Remove a user and their personal data`,
+}
+
+var usersListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List users",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		users, err := db.ListUsers()
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("(default)")
+		for _, user := range users {
+			fmt.Printf("%-20s added %s\n", user.Name, user.CreatedAt.Local().Format("2006-01-02"))
+		}
+		return nil
+	},
+}
+
+var usersAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Add a user",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		user, err := db.AddUser(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Added user %s (use --user %s)\n", user.Name, user.Name)
+		return nil
+	},
+}
+
+var usersRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove a user with their notes, tags, fields and visits",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if !usersForce {
+			fmt.Printf("This will delete all notes, tags, custom fields and visits of %s and revoke their tokens. Continue? (y/N): ", name)
+			var response string
+			if _, err := fmt.Scanln(&response); err != nil {
+				fmt.Println("Cancelled")
+				return nil
+			}
+			if strings.ToLower(response) != "y" && strings.ToLower(response) != "yes" {
+				fmt.Println("Cancelled")
+				return nil
+			}
+		}
+
+		if err := db.RemoveUser(name); err != nil {
+			return err
+		}
+		fmt.Printf("Removed user %s\n", name)
+		return nil
+	},
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/user/placeli/internal/logger"
+	"github.com/user/placeli/internal/vault"
+)
+
+var (
+	vaultPrefer string
+	vaultDryRun bool
+)
+
+func init() {
+	rootCmd.AddCommand(vaultCmd)
+
+	vaultCmd.AddCommand(vaultSyncCmd)
+
+	vaultSyncCmd.Flags().StringVar(&vaultPrefer, "prefer", "skip", "conflict resolution: skip, db, file")
+	vaultSyncCmd.Flags().BoolVar(&vaultDryRun, "dry-run", false, "show what would be synced without making changes")
+}
+
+var vaultCmd = &cobra.Command{
+	Use:   "vault",
+	Short: "Sync places with a Markdown vault",
+	Long: `Keep one Markdown note per place in a directory, such as an Obsidian vault.
+
+Each note has YAML front-matter (id, coordinates, tags, custom fields)
+followed by your free-text notes. Edits made to the notes are read back
+into placeli on the next sync.
+
+Available subcommands:
+  sync - Two-way sync between the database and a vault directory`,
+}
+
+var vaultSyncCmd = &cobra.Command{
+	Use:   "sync <dir>",
+	Short: "Two-way sync between places and Markdown notes",
+	Long: `Write a note for every place and read back edits to notes, tags and custom fields.
+
+A place that changed both in placeli and in its note since the last sync is
+a conflict. Conflicts are skipped by default; use --prefer=db or --prefer=file
+to resolve them in favour of one side.
+
+Examples:
+  placeli vault sync ~/Obsidian/Places
+  placeli vault sync ~/Obsidian/Places --dry-run
+  placeli vault sync ~/Obsidian/Places --prefer=file`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := args[0]
+
+		policy := vault.ConflictPolicy(vaultPrefer)
+		switch policy {
+		case vault.ConflictSkip, vault.ConflictPreferDB, vault.ConflictPreferFile:
+		default:
+			return fmt.Errorf("unknown conflict policy: %s (use skip, db or file)", vaultPrefer)
+		}
+
+		logger.Info("Syncing vault", "dir", dir, "prefer", vaultPrefer, "dry_run", vaultDryRun)
+
+		result, err := vault.Sync(db, dir, vault.Options{
+			OnConflict: policy,
+			DryRun:     vaultDryRun,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to sync vault: %w", err)
+		}
+
+		fmt.Printf("Vault sync complete for %s:\n", dir)
+		fmt.Printf("  Written:   %d notes\n", result.Written)
+		fmt.Printf("  Imported:  %d notes\n", result.Imported)
+		fmt.Printf("  Unchanged: %d notes\n", result.Unchanged)
+		fmt.Printf("  Conflicts: %d notes\n", len(result.Conflicts))
+
+		for _, name := range result.SortedConflicts() {
+			fmt.Printf("  ! %s changed in both placeli and the vault\n", name)
+		}
+
+		if vaultDryRun {
+			fmt.Println("\nRun without --dry-run to apply changes")
+		}
+
+		return nil
+	},
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/user/placeli/internal/constants"
+	"github.com/user/placeli/internal/database"
+	"github.com/user/placeli/internal/models"
+)
+
+var (
+	visitAt     string
+	visitWith   string
+	visitRating int
+	visitNote   string
+	visitIn     string
+	visitSearch string
+)
+
+func init() {
+	rootCmd.AddCommand(visitCmd)
+
+	visitCmd.AddCommand(visitAddCmd)
+	visitCmd.AddCommand(visitListCmd)
+	visitCmd.AddCommand(visitRemoveCmd)
+
+	visitAddCmd.Flags().StringVar(&visitAt, "at", "", "when the visit was, e.g. 2024-05-17 or \"2024-05-17 20:30\" (default: now)")
+	visitAddCmd.Flags().StringVar(&visitWith, "with", "", "comma-separated list of companions")
+	visitAddCmd.Flags().IntVar(&visitRating, "rating", 0, "your rating for this visit, 1-5")
+	visitAddCmd.Flags().StringVar(&visitNote, "note", "", "note about the visit")
+
+	visitListCmd.Flags().StringVar(&visitIn, "in", "", "only visits in a period: YYYY, YYYY-MM, YYYY-MM-DD or FROM..TO")
+	visitListCmd.Flags().StringVar(&visitSearch, "search", "", "only visits to places matching a search query")
+}
+
+var visitCmd = &cobra.Command{
+	Use:   "visit",
+	Short: "Log and list visits to places",
+	Long: `Keep a log of when you went where.
+
+Visits are also imported from Foursquare/Swarm check-ins. Visit counts and
+first/last visit dates can be used with 'placeli list --visited-in' and
+'placeli list --sort'.
+
+Available subcommands:
+  add    - Log a visit to a place
+  list   - List logged visits
+  remove - Remove a logged visit
+
+Examples:
+  placeli visit add <place-id> --with "Ana, João" --rating 5 --note "grilled sardines"
+  placeli visit add <place-id> --at 2024-05-17
+  placeli visit list --in 2024 --search Lisbon`,
+}
+
+var visitAddCmd = &cobra.Command{
+	Use:   "add <place-id>",
+	Short: "Log a visit to a place",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		place, err := db.GetPlace(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to get place: %w", err)
+		}
+
+		visitedAt := time.Now()
+		if visitAt != "" {
+			visitedAt, err = parseVisitTime(visitAt)
+			if err != nil {
+				return err
+			}
+		}
+		if visitRating < 0 || visitRating > 5 {
+			return fmt.Errorf("rating must be between 1 and 5")
+		}
+
+		visit := &models.Visit{
+			PlaceID:    place.ID,
+			VisitedAt:  visitedAt,
+			Companions: splitList(visitWith),
+			Rating:     visitRating,
+			Note:       visitNote,
+			Source:     "manual",
+		}
+		if _, err := db.AddVisit(visit); err != nil {
+			return err
+		}
+
+		fmt.Printf("Logged visit %d to %s on %s\n", visit.ID, place.Name, visitedAt.Format("2006-01-02 15:04"))
+		return nil
+	},
+}
+
+var visitListCmd = &cobra.Command{
+	Use:   "list [place-id]",
+	Short: "List logged visits, most recent first",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var filter database.VisitFilter
+		if len(args) == 1 {
+			filter.PlaceID = args[0]
+		}
+		if visitIn != "" {
+			period, err := models.ParseVisitPeriod(visitIn, time.Local)
+			if err != nil {
+				return err
+			}
+			filter.From, filter.To = period.From, period.To
+		}
+
+		visits, err := db.ListVisits(filter)
+		if err != nil {
+			return err
+		}
+
+		places, err := placesByID(visitSearch)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tDATE\tPLACE\tWITH\tRATING\tNOTE")
+		shown := 0
+		for _, visit := range visits {
+			place, ok := places[visit.PlaceID]
+			if !ok {
+				continue
+			}
+			rating := ""
+			if visit.Rating > 0 {
+				rating = strings.Repeat("⭐", visit.Rating)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n",
+				visit.ID, visit.VisitedAt.Local().Format("2006-01-02 15:04"), place.Name,
+				strings.Join(visit.Companions, ", "), rating, visit.Note)
+			shown++
+		}
+		if shown == 0 {
+			fmt.Println("No visits found")
+			return nil
+		}
+		return w.Flush()
+	},
+}
+
+var visitRemoveCmd = &cobra.Command{
+	Use:   "remove <visit-id>",
+	Short: "Remove a logged visit",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid visit id: %s", args[0])
+		}
+		if err := db.DeleteVisit(id); err != nil {
+			return err
+		}
+		fmt.Printf("Removed visit %d\n", id)
+		return nil
+	},
+}
+
+// placesByID loads the places matching query (all places when empty),
+// keyed by ID
+func placesByID(query string) (map[string]*models.Place, error) {
+	var places []*models.Place
+	var err error
+	if query != "" {
+		places, err = db.SearchPlaces(query)
+	} else {
+		places, err = db.ListPlaces(constants.DefaultExportLimit, 0)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load places: %w", err)
+	}
+
+	byID := make(map[string]*models.Place, len(places))
+	for _, place := range places {
+		byID[place.ID] = place
+	}
+	return byID, nil
+}
+
+// parseVisitTime accepts a date or a date and time in local time
+func parseVisitTime(value string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: use YYYY-MM-DD or \"YYYY-MM-DD HH:MM\"", value)
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/user/placeli/internal/models"
+)
+
+func TestParseVisitTime(t *testing.T) {
+	got, err := parseVisitTime("2024-05-17 20:30")
+	if err != nil {
+		t.Fatalf("parseVisitTime failed: %v", err)
+	}
+	if want := time.Date(2024, 5, 17, 20, 30, 0, 0, time.Local); !got.Equal(want) {
+		t.Errorf("parseVisitTime = %v, want %v", got, want)
+	}
+
+	if _, err := parseVisitTime("yesterday"); err == nil {
+		t.Error("Expected error for unparseable time")
+	}
+}
+
+func TestSortPlaces(t *testing.T) {
+	places := []*models.Place{{ID: "a", Name: "Zé"}, {ID: "b", Name: "alfama"}, {ID: "c", Name: "Belém"}}
+	summaries := map[string]models.VisitSummary{
+		"a": {Count: 1, Last: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		"c": {Count: 3, Last: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	order := func() string {
+		ids := ""
+		for _, p := range places {
+			ids += p.ID
+		}
+		return ids
+	}
+
+	for _, tt := range []struct{ by, want string }{
+		{"name", "bca"},
+		{"visits", "cab"},
+		{"last-visited", "acb"},
+	} {
+		if err := sortPlaces(places, tt.by, summaries); err != nil {
+			t.Fatalf("sortPlaces(%s) failed: %v", tt.by, err)
+		}
+		if got := order(); got != tt.want {
+			t.Errorf("sortPlaces(%s) = %s, want %s", tt.by, got, tt.want)
+		}
+	}
+
+	if err := sortPlaces(places, "rating", summaries); err == nil {
+		t.Error("Expected error for unknown sort")
+	}
+}
@@ -3,14 +3,26 @@ package main
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/user/placeli/internal/database"
+	"github.com/user/placeli/internal/photos"
+	"github.com/user/placeli/internal/tiles"
 	"github.com/user/placeli/internal/web"
 )
 
 var (
-	webPort   int
-	webAPIKey string
+	webPort     int
+	webHost     string
+	webAuth     bool
+	webAPIKey   string
+	webPhotoDir string
+	webTiles    string
+	webTileURL  string
+	webOffline  bool
+
+	tokenScope string
 )
 
 var webCmd = &cobra.Command{
@@ -19,10 +31,19 @@ var webCmd = &cobra.Command{
 	Long: `Start a web server that provides an interactive map interface for browsing your saved places.
 
 The web interface includes:
-- Interactive map showing all your places
+- List, map, place and stats views, each with its own URL
 - Search and filter functionality
-- Detailed place information
-- Mobile-friendly responsive design
+- Keyboard shortcuts like the TUI's (press ? to list them)
+- Dark mode, and a mobile-friendly responsive design
+- A JSON REST API under /api/v1, described at /api/v1/openapi.json
+
+The server only listens on localhost unless --host says otherwise. With
+--auth, every request needs an API token (see 'placeli web tokens'): API
+clients send it as a bearer token and browsers log in with it once. Share
+links (see 'placeli share') open without a login.
+
+Map tiles come from a local cache (see 'placeli tiles'). Tiles missing from
+it are downloaded from --tile-url and kept, unless --offline is given.
 
 You can optionally provide a Google Maps API key for enhanced map features.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -34,14 +55,157 @@ You can optionally provide a Google Maps API key for enhanced map features.`,
 		if err != nil {
 			return fmt.Errorf("failed to create server: %w", err)
 		}
+		server.SetHost(webHost)
+		server.RequireAuth(webAuth)
+
+		if webAuth {
+			tokens, err := db.ListAPITokens()
+			if err != nil {
+				return err
+			}
+			if countActiveTokens(tokens) == 0 {
+				return fmt.Errorf("--auth needs an API token; create one with 'placeli web tokens create <name>'")
+			}
+		} else if !web.IsLoopback(webHost) {
+			fmt.Fprintf(os.Stderr, "Warning: listening on %s without --auth; anyone who can reach it can change your places\n", webHost)
+		}
+
+		if webPhotoDir == "" {
+			webPhotoDir = photos.DefaultDir()
+		}
+		store, err := photos.NewStore(webPhotoDir)
+		if err != nil {
+			return err
+		}
+		server.SetPhotoStore(store)
+
+		tileCache, err := tiles.Open(webTiles)
+		if err != nil {
+			return err
+		}
+		defer tileCache.Close()
+		upstream := webTileURL
+		if webOffline {
+			upstream = ""
+		}
+		server.SetTileCache(tileCache, upstream)
 
 		return server.Start()
 	},
 }
 
+var webTokensCmd = &cobra.Command{
+	Use:   "tokens",
+	Short: "Manage API tokens for the web server",
+	Long: `Manage the API tokens 'placeli web --auth' accepts.
+
+Read tokens can browse and query; write tokens can also change places.
+Send a token as 'Authorization: Bearer <token>', or paste it into the
+login page of the web interface.
+
+Available subcommands:
+  create - Create a token and print its secret
+  list   - List tokens
+  revoke - Revoke a token by ID or name`,
+}
+
+var webTokensCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create an API token",
+	Long: `Create an API token. The token acts as the user selected with --user,
+so requests made with it see and change that user's notes, tags and visits.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		token, secret, err := db.CreateAPIToken(args[0], tokenScope)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Created %s token %s (%s)", token.Scope, token.Name, token.ID)
+		if token.User != "" {
+			fmt.Printf(" for user %s", token.User)
+		}
+		fmt.Println()
+		fmt.Printf("\n  %s\n\n", secret)
+		fmt.Println("This is the only time the token is shown; store it somewhere safe.")
+		return nil
+	},
+}
+
+var webTokensListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List API tokens",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tokens, err := db.ListAPITokens()
+		if err != nil {
+			return err
+		}
+		if len(tokens) == 0 {
+			fmt.Println("No tokens (create one with 'placeli web tokens create <name>')")
+			return nil
+		}
+
+		fmt.Printf("%-16s  %-20s  %-5s  %-12s  %-16s  %s\n", "ID", "NAME", "SCOPE", "USER", "CREATED", "LAST USED")
+		for _, token := range tokens {
+			user := token.User
+			if user == "" {
+				user = "(default)"
+			}
+			lastUsed := "never"
+			if token.LastUsedAt != nil {
+				lastUsed = token.LastUsedAt.Local().Format("2006-01-02 15:04")
+			}
+			if token.Revoked() {
+				lastUsed += " (revoked)"
+			}
+			fmt.Printf("%-16s  %-20s  %-5s  %-12s  %-16s  %s\n", token.ID, token.Name, token.Scope, user,
+				token.CreatedAt.Local().Format("2006-01-02 15:04"), lastUsed)
+		}
+		return nil
+	},
+}
+
+var webTokensRevokeCmd = &cobra.Command{
+	Use:   "revoke <id|name>",
+	Short: "Revoke an API token",
+	Long:  "Revoke an API token. Requests and browser sessions using it are refused from then on.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		token, err := db.RevokeAPIToken(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Revoked token %s (%s) at %s\n", token.Name, token.ID, token.RevokedAt.Format(time.RFC3339))
+		return nil
+	},
+}
+
+func countActiveTokens(tokens []*database.APIToken) int {
+	n := 0
+	for _, token := range tokens {
+		if !token.Revoked() {
+			n++
+		}
+	}
+	return n
+}
+
 func init() {
 	webCmd.Flags().IntVarP(&webPort, "port", "p", 8080, "port to run web server on")
+	webCmd.Flags().StringVar(&webHost, "host", "127.0.0.1", "address to listen on (0.0.0.0 for all interfaces)")
+	webCmd.Flags().BoolVar(&webAuth, "auth", false, "require an API token or login for every request")
+	webCmd.Flags().StringVar(&webPhotoDir, "photo-dir", "", "photo store directory (default: ~/.placeli/photos)")
+	webCmd.Flags().StringVar(&webTiles, "tile-cache", tiles.DefaultPath(), "MBTiles file map tiles are served from")
+	webCmd.Flags().StringVar(&webTileURL, "tile-url", tiles.DefaultURL, "tile server to fetch tiles missing from the cache")
+	webCmd.Flags().BoolVar(&webOffline, "offline", false, "only serve cached map tiles")
 	webCmd.Flags().StringVar(&webAPIKey, "api-key", "", "Google Maps API key (optional, uses env GOOGLE_MAPS_API_KEY if not set)")
 
+	webTokensCreateCmd.Flags().StringVar(&tokenScope, "scope", database.ScopeRead, "token scope: read or write")
+
+	webTokensCmd.AddCommand(webTokensCreateCmd)
+	webTokensCmd.AddCommand(webTokensListCmd)
+	webTokensCmd.AddCommand(webTokensRevokeCmd)
+	webCmd.AddCommand(webTokensCmd)
+
 	rootCmd.AddCommand(webCmd)
 }
@@ -3,15 +3,26 @@ package database
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/user/placeli/internal/models"
 	_ "modernc.org/sqlite"
 )
 
+// DB is a handle on the places database. Place data and shared tags are
+// shared by all users; notes, tags, custom fields and visits belong to the
+// handle's user, see ForUser.
 type DB struct {
-	conn *sql.DB
+	conn    *sql.DB
+	user    string
+	changes *changeHub
+	// saveMu serializes this process's place saves and deletes. A save
+	// reads the place before writing it, and two such transactions on
+	// separate connections would each keep the other from writing.
+	saveMu *sync.Mutex
 }
 
 func New(dbPath string) (*DB, error) {
@@ -20,7 +31,7 @@ func New(dbPath string) (*DB, error) {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	db := &DB{conn: conn}
+	db := &DB{conn: conn, changes: newChangeHub(), saveMu: &sync.Mutex{}}
 	if err := db.migrate(); err != nil {
 		return nil, fmt.Errorf("failed to migrate database: %w", err)
 	}
@@ -32,6 +43,19 @@ func (db *DB) Close() error {
 	return db.conn.Close()
 }
 
+// ForUser returns a handle that reads and writes the personal data of the
+// named user. It shares the connection, so only one of them needs closing.
+// The empty name is the default user, who owns data from before users
+// existed.
+func (db *DB) ForUser(user string) *DB {
+	return &DB{conn: db.conn, user: user, changes: db.changes, saveMu: db.saveMu}
+}
+
+// User returns the name of the user whose personal data the handle uses
+func (db *DB) User() string {
+	return db.user
+}
+
 func (db *DB) migrate() error {
 	schema := `
 	CREATE TABLE IF NOT EXISTS places (
@@ -46,21 +70,85 @@ func (db *DB) migrate() error {
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		imported_at DATETIME,
-		source_hash TEXT
+		source_hash TEXT,
+		geohash TEXT
 	);
 
 	CREATE TABLE IF NOT EXISTS user_data (
-		place_id TEXT PRIMARY KEY,
+		place_id TEXT NOT NULL,
+		user_id TEXT NOT NULL DEFAULT '',
 		notes TEXT,
 		tags TEXT,
 		custom_fields TEXT,
+		PRIMARY KEY (place_id, user_id),
 		FOREIGN KEY (place_id) REFERENCES places(id) ON DELETE CASCADE
 	);
 
+	CREATE TABLE IF NOT EXISTS shared_tags (
+		place_id TEXT NOT NULL,
+		tag TEXT NOT NULL,
+		PRIMARY KEY (place_id, tag)
+	);
+
+	CREATE TABLE IF NOT EXISTS users (
+		name TEXT PRIMARY KEY,
+		created_at DATETIME
+	);
+
+	CREATE TABLE IF NOT EXISTS enrichment_state (
+		place_id TEXT NOT NULL,
+		provider TEXT NOT NULL,
+		enriched_at DATETIME,
+		attempted_at DATETIME,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT,
+		PRIMARY KEY (place_id, provider)
+	);
+
+	CREATE TABLE IF NOT EXISTS visits (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		place_id TEXT NOT NULL,
+		visited_at TEXT NOT NULL,
+		companions TEXT,
+		rating INTEGER NOT NULL DEFAULT 0,
+		note TEXT,
+		source TEXT NOT NULL DEFAULT '',
+		source_id TEXT NOT NULL DEFAULT '',
+		user_id TEXT NOT NULL DEFAULT ''
+	);
+
+	CREATE TABLE IF NOT EXISTS import_presets (
+		name TEXT PRIMARY KEY,
+		source TEXT NOT NULL DEFAULT '',
+		mapping TEXT NOT NULL,
+		updated_at DATETIME
+	);
+
+	CREATE TABLE IF NOT EXISTS api_tokens (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		scope TEXT NOT NULL,
+		token_hash TEXT NOT NULL UNIQUE,
+		user_id TEXT NOT NULL DEFAULT '',
+		created_at DATETIME,
+		last_used_at DATETIME,
+		revoked_at DATETIME
+	);
+
+	CREATE TABLE IF NOT EXISTS shares (
+		id TEXT PRIMARY KEY,
+		tag TEXT NOT NULL,
+		policy TEXT NOT NULL,
+		token_hash TEXT NOT NULL UNIQUE,
+		user_id TEXT NOT NULL DEFAULT '',
+		created_at DATETIME,
+		expires_at DATETIME,
+		revoked_at DATETIME
+	);
+
 	CREATE INDEX IF NOT EXISTS idx_places_name ON places(name);
 	CREATE INDEX IF NOT EXISTS idx_places_coordinates ON places(lat, lng);
 	CREATE INDEX IF NOT EXISTS idx_places_place_id ON places(place_id);
-	CREATE INDEX IF NOT EXISTS idx_user_data_tags ON user_data(tags);
 	CREATE INDEX IF NOT EXISTS idx_places_source_hash ON places(source_hash);
 	`
 
@@ -73,6 +161,9 @@ func (db *DB) migrate() error {
 	migrations := []string{
 		"ALTER TABLE places ADD COLUMN imported_at DATETIME",
 		"ALTER TABLE places ADD COLUMN source_hash TEXT",
+		"ALTER TABLE visits ADD COLUMN user_id TEXT NOT NULL DEFAULT ''",
+		"ALTER TABLE api_tokens ADD COLUMN user_id TEXT NOT NULL DEFAULT ''",
+		"ALTER TABLE places ADD COLUMN geohash TEXT",
 	}
 
 	for _, migration := range migrations {
@@ -80,16 +171,182 @@ func (db *DB) migrate() error {
 		_, _ = db.conn.Exec(migration)
 	}
 
-	return nil
+	if err := db.migrateUserData(); err != nil {
+		return fmt.Errorf("failed to migrate user data: %w", err)
+	}
+	if err := db.migrateSharedTags(); err != nil {
+		return fmt.Errorf("failed to migrate shared tags: %w", err)
+	}
+	if err := db.backfillGeohashes(); err != nil {
+		return fmt.Errorf("failed to index place locations: %w", err)
+	}
+
+	// Indexes on migrated columns can only be created once they exist
+	_, err = db.conn.Exec(`
+	DROP INDEX IF EXISTS idx_visits_source;
+	CREATE INDEX IF NOT EXISTS idx_user_data_tags ON user_data(tags);
+	CREATE INDEX IF NOT EXISTS idx_visits_place ON visits(place_id, visited_at);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_visits_user_source ON visits(user_id, source, source_id) WHERE source_id != '';
+	CREATE INDEX IF NOT EXISTS idx_places_geohash ON places(geohash);
+	`)
+	return err
 }
 
+// migrateUserData rebuilds a user_data table keyed by place alone into one
+// keyed by place and user. Existing rows go to the default user.
+func (db *DB) migrateUserData() error {
+	var hasUser int
+	err := db.conn.QueryRow(
+		"SELECT COUNT(*) FROM pragma_table_info('user_data') WHERE name = 'user_id'",
+	).Scan(&hasUser)
+	if err != nil || hasUser > 0 {
+		return err
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	_, err = tx.Exec(`
+	ALTER TABLE user_data RENAME TO user_data_old;
+	CREATE TABLE user_data (
+		place_id TEXT NOT NULL,
+		user_id TEXT NOT NULL DEFAULT '',
+		notes TEXT,
+		tags TEXT,
+		custom_fields TEXT,
+		PRIMARY KEY (place_id, user_id),
+		FOREIGN KEY (place_id) REFERENCES places(id) ON DELETE CASCADE
+	);
+	INSERT INTO user_data (place_id, user_id, notes, tags, custom_fields)
+		SELECT place_id, '', notes, tags, custom_fields FROM user_data_old;
+	DROP TABLE user_data_old;
+	`)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// migrateSharedTags moves shared tags kept in the places data column into
+// the shared_tags table
+func (db *DB) migrateSharedTags() error {
+	_, err := db.conn.Exec(`
+	INSERT OR IGNORE INTO shared_tags (place_id, tag)
+		SELECT p.id, t.value
+		FROM places p, json_each(CASE WHEN json_valid(p.data) THEN p.data ELSE '{}' END, '$.shared_tags') t;
+	UPDATE places SET data = json_remove(data, '$.shared_tags')
+		WHERE json_valid(data) AND json_type(data, '$.shared_tags') IS NOT NULL;
+	`)
+	return err
+}
+
+// ErrConflict is returned by SavePlaceIfUnchanged for a place that was
+// saved again since it was loaded
+var ErrConflict = errors.New("place was changed since it was loaded")
+
 func (db *DB) SavePlace(place *models.Place) error {
+	return db.SavePlaces([]*models.Place{place})
+}
+
+// SavePlaceIfUnchanged saves a place only if it is still stored with the
+// update time it was loaded with, and returns ErrConflict otherwise. The
+// check reads the place in the transaction that writes it, so two edits of
+// the same copy can't both be saved.
+func (db *DB) SavePlaceIfUnchanged(place *models.Place, updatedAt time.Time) error {
+	return db.savePlaces([]*models.Place{place}, &updatedAt)
+}
+
+// SavePlaces saves a batch of places in a single transaction, so bulk
+// imports don't pay for a commit per place. Either all places are saved or
+// none are. Shared fields are merged with the stored ones, see
+// models.Place.Snapshot, and each place is left as saved.
+func (db *DB) SavePlaces(places []*models.Place) error {
+	return db.savePlaces(places, nil)
+}
+
+// savePlaces saves places in one transaction. With ifUpdatedAt, each
+// place must still be stored with that update time.
+func (db *DB) savePlaces(places []*models.Place, ifUpdatedAt *time.Time) error {
+	db.saveMu.Lock()
+	defer db.saveMu.Unlock()
+	db.changes.writeMu.RLock()
+	defer db.changes.writeMu.RUnlock()
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	changes := make([]Change, 0, len(places))
+	updatedAt := make(map[string]time.Time, len(places))
+	for _, place := range places {
+		created, err := savePlace(tx, db.user, place, ifUpdatedAt)
+		if err != nil {
+			return err
+		}
+		kind := PlaceUpdated
+		if created {
+			kind = PlaceCreated
+		}
+		changes = append(changes, Change{Kind: kind, PlaceID: place.ID})
+		updatedAt[place.ID] = place.UpdatedAt
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for _, place := range places {
+		snapshot(place)
+	}
+	db.changes.publish(changes, updatedAt)
+	return nil
+}
+
+// savePlace writes a place and the user's data for it, and reports whether
+// the place is new. With ifUpdatedAt, it fails with ErrConflict unless the
+// place is stored with that update time.
+func savePlace(tx *sql.Tx, user string, place *models.Place, ifUpdatedAt *time.Time) (bool, error) {
+	current, err := scanPlace(tx.QueryRow(selectPlaces+`
+		WHERE p.id = ?`, user, place.ID))
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return false, err
+	}
+	created := current == nil
+	if ifUpdatedAt != nil && (created || !current.UpdatedAt.Equal(*ifUpdatedAt)) {
+		return false, ErrConflict
+	}
+
 	now := time.Now()
-	if place.CreatedAt.IsZero() {
-		place.CreatedAt = now
+	added, removed := place.SharedTags, []string(nil)
+	if created {
+		if place.CreatedAt.IsZero() {
+			place.CreatedAt = now
+		}
+	} else {
+		place.CreatedAt = current.CreatedAt
+		added, removed = mergeShared(place, current)
 	}
 	place.UpdatedAt = now
 
+	for _, tag := range added {
+		if _, err := tx.Exec("INSERT OR IGNORE INTO shared_tags (place_id, tag) VALUES (?, ?)", place.ID, tag); err != nil {
+			return false, err
+		}
+	}
+	for _, tag := range removed {
+		if _, err := tx.Exec("DELETE FROM shared_tags WHERE place_id = ? AND tag = ?", place.ID, tag); err != nil {
+			return false, err
+		}
+	}
+
 	categoriesJSON, _ := json.Marshal(place.Categories)
 
 	data := placeData{
@@ -101,41 +358,47 @@ func (db *DB) SavePlace(place *models.Place) error {
 		Hours:       place.Hours,
 		Phone:       place.Phone,
 		Website:     place.Website,
+
+		OpeningHours: place.OpeningHours,
+		Attachments:  place.Attachments,
+		Geometry:     place.Geometry,
 	}
 	dataJSON, _ := json.Marshal(data)
 
-	tx, err := db.conn.Begin()
-	if err != nil {
-		return err
+	if created {
+		_, err = tx.Exec(`
+			INSERT INTO places
+			(id, place_id, name, address, lat, lng, categories, data, created_at, updated_at, imported_at, source_hash, geohash)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			place.ID, place.PlaceID, place.Name, place.Address,
+			place.Coordinates.Lat, place.Coordinates.Lng,
+			string(categoriesJSON), string(dataJSON),
+			place.CreatedAt, place.UpdatedAt, place.ImportedAt, place.SourceHash,
+			placeGeohash(place.Coordinates.Lat, place.Coordinates.Lng))
+	} else {
+		_, err = tx.Exec(`
+			UPDATE places SET
+				place_id = ?, name = ?, address = ?, lat = ?, lng = ?, categories = ?, data = ?,
+				updated_at = ?, imported_at = ?, source_hash = ?, geohash = ?
+			WHERE id = ?`,
+			place.PlaceID, place.Name, place.Address,
+			place.Coordinates.Lat, place.Coordinates.Lng,
+			string(categoriesJSON), string(dataJSON),
+			place.UpdatedAt, place.ImportedAt, place.SourceHash,
+			placeGeohash(place.Coordinates.Lat, place.Coordinates.Lng), place.ID)
 	}
-	defer func() {
-		_ = tx.Rollback()
-	}()
-
-	_, err = tx.Exec(`
-		INSERT OR REPLACE INTO places
-		(id, place_id, name, address, lat, lng, categories, data, created_at, updated_at, imported_at, source_hash)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-		place.ID, place.PlaceID, place.Name, place.Address,
-		place.Coordinates.Lat, place.Coordinates.Lng,
-		string(categoriesJSON), string(dataJSON),
-		place.CreatedAt, place.UpdatedAt, place.ImportedAt, place.SourceHash)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	tagsJSON, _ := json.Marshal(place.UserTags)
 	customFieldsJSON, _ := json.Marshal(place.CustomFields)
 
 	_, err = tx.Exec(`
-		INSERT OR REPLACE INTO user_data (place_id, notes, tags, custom_fields)
-		VALUES (?, ?, ?, ?)`,
-		place.ID, place.UserNotes, string(tagsJSON), string(customFieldsJSON))
-	if err != nil {
-		return err
-	}
-
-	return tx.Commit()
+		INSERT OR REPLACE INTO user_data (place_id, user_id, notes, tags, custom_fields)
+		VALUES (?, ?, ?, ?, ?)`,
+		place.ID, user, place.UserNotes, string(tagsJSON), string(customFieldsJSON))
+	return created, err
 }
 
 type placeData struct {
@@ -147,6 +410,45 @@ type placeData struct {
 	Hours       string          `json:"hours"`
 	Phone       string          `json:"phone"`
 	Website     string          `json:"website"`
+
+	OpeningHours *models.OpeningHours `json:"opening_hours,omitempty"`
+	Attachments  []models.Attachment  `json:"attachments,omitempty"`
+	Geometry     *models.Geometry     `json:"geometry,omitempty"`
+}
+
+// sharedTagsColumn selects a place's shared tags as a JSON array. They are
+// read through the table's key, so sorted by tag.
+const sharedTagsColumn = `(SELECT json_group_array(st.tag) FROM shared_tags st WHERE st.place_id = p.id)`
+
+// selectPlaces selects places with the personal data of one user, who is
+// the first query argument
+const selectPlaces = `
+		SELECT
+			p.id, p.place_id, p.name, p.address, p.lat, p.lng,
+			p.categories, p.data, p.created_at, p.updated_at,
+			p.imported_at, p.source_hash,
+			ud.notes, ud.tags, ud.custom_fields, ` + sharedTagsColumn + `
+		FROM places p
+		LEFT JOIN user_data ud ON p.id = ud.place_id AND ud.user_id = ?`
+
+// queryPlaces runs a selectPlaces query for the handle's user
+func (db *DB) queryPlaces(where string, args ...interface{}) ([]*models.Place, error) {
+	rows, err := db.conn.Query(selectPlaces+where, append([]interface{}{db.user}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var places []*models.Place
+	for rows.Next() {
+		place, err := scanPlace(rows)
+		if err != nil {
+			return nil, err
+		}
+		places = append(places, place)
+	}
+
+	return places, rows.Err()
 }
 
 func scanPlace(scanner interface {
@@ -154,7 +456,7 @@ func scanPlace(scanner interface {
 }) (*models.Place, error) {
 	var place models.Place
 	var categoriesJSON, dataJSON string
-	var tagsJSON, customFieldsJSON sql.NullString
+	var notes, tagsJSON, customFieldsJSON, sharedTagsJSON sql.NullString
 	var importedAt sql.NullTime
 	var sourceHash sql.NullString
 
@@ -163,10 +465,11 @@ func scanPlace(scanner interface {
 		&place.Coordinates.Lat, &place.Coordinates.Lng,
 		&categoriesJSON, &dataJSON, &place.CreatedAt, &place.UpdatedAt,
 		&importedAt, &sourceHash,
-		&place.UserNotes, &tagsJSON, &customFieldsJSON)
+		&notes, &tagsJSON, &customFieldsJSON, &sharedTagsJSON)
 	if err != nil {
 		return nil, err
 	}
+	place.UserNotes = notes.String
 
 	if importedAt.Valid {
 		place.ImportedAt = &importedAt.Time
@@ -175,10 +478,19 @@ func scanPlace(scanner interface {
 		place.SourceHash = sourceHash.String
 	}
 
-	return unmarshalPlace(&place, categoriesJSON, dataJSON, tagsJSON, customFieldsJSON)
+	if sharedTagsJSON.Valid {
+		_ = json.Unmarshal([]byte(sharedTagsJSON.String), &place.SharedTags)
+		if len(place.SharedTags) == 0 {
+			place.SharedTags = nil
+		}
+	}
+
+	unmarshalPlace(&place, categoriesJSON, dataJSON, tagsJSON, customFieldsJSON)
+	snapshot(&place)
+	return &place, nil
 }
 
-func unmarshalPlace(place *models.Place, categoriesJSON, dataJSON string, tagsJSON, customFieldsJSON sql.NullString) (*models.Place, error) {
+func unmarshalPlace(place *models.Place, categoriesJSON, dataJSON string, tagsJSON, customFieldsJSON sql.NullString) {
 	if err := json.Unmarshal([]byte(categoriesJSON), &place.Categories); err != nil {
 		place.Categories = []string{}
 	}
@@ -193,6 +505,9 @@ func unmarshalPlace(place *models.Place, categoriesJSON, dataJSON string, tagsJS
 		place.Hours = data.Hours
 		place.Phone = data.Phone
 		place.Website = data.Website
+		place.OpeningHours = data.OpeningHours
+		place.Attachments = data.Attachments
+		place.Geometry = data.Geometry
 	}
 
 	if tagsJSON.Valid {
@@ -205,81 +520,67 @@ func unmarshalPlace(place *models.Place, categoriesJSON, dataJSON string, tagsJS
 			place.CustomFields = make(map[string]interface{})
 		}
 	}
-
-	return place, nil
 }
 
 func (db *DB) GetPlace(id string) (*models.Place, error) {
-	row := db.conn.QueryRow(`
-		SELECT
-			p.id, p.place_id, p.name, p.address, p.lat, p.lng,
-			p.categories, p.data, p.created_at, p.updated_at,
-			p.imported_at, p.source_hash,
-			ud.notes, ud.tags, ud.custom_fields
-		FROM places p
-		LEFT JOIN user_data ud ON p.id = ud.place_id
-		WHERE p.id = ?`, id)
+	row := db.conn.QueryRow(selectPlaces+`
+		WHERE p.id = ?`, db.user, id)
 
 	return scanPlace(row)
 }
 
 func (db *DB) ListPlaces(limit, offset int) ([]*models.Place, error) {
-	rows, err := db.conn.Query(`
-		SELECT
-			p.id, p.place_id, p.name, p.address, p.lat, p.lng,
-			p.categories, p.data, p.created_at, p.updated_at,
-			p.imported_at, p.source_hash,
-			ud.notes, ud.tags, ud.custom_fields
-		FROM places p
-		LEFT JOIN user_data ud ON p.id = ud.place_id
+	return db.queryPlaces(`
 		ORDER BY p.updated_at DESC
 		LIMIT ? OFFSET ?`, limit, offset)
+}
+
+// DeletePlace removes a place with every user's notes and visits and its
+// shared tags, all or nothing
+func (db *DB) DeletePlace(id string) error {
+	db.saveMu.Lock()
+	defer db.saveMu.Unlock()
+	db.changes.writeMu.RLock()
+	defer db.changes.writeMu.RUnlock()
+
+	tx, err := db.conn.Begin()
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer rows.Close()
+	defer func() {
+		_ = tx.Rollback()
+	}()
 
-	var places []*models.Place
-	for rows.Next() {
-		place, err := scanPlace(rows)
-		if err != nil {
-			return nil, err
+	for _, table := range []string{"visits", "user_data", "shared_tags", "enrichment_state"} {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE place_id = ?", id); err != nil {
+			return err
 		}
-		places = append(places, place)
 	}
-
-	return places, nil
+	result, err := tx.Exec("DELETE FROM places WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n > 0 {
+		db.changes.publish([]Change{{Kind: PlaceDeleted, PlaceID: id}}, nil)
+	}
+	return nil
 }
 
-func (db *DB) DeletePlace(id string) error {
-	_, err := db.conn.Exec("DELETE FROM places WHERE id = ?", id)
-	return err
+// PlacesWithTag returns the places the handle's user has tagged with tag,
+// or that have it as a shared tag, sorted by name
+func (db *DB) PlacesWithTag(tag string) ([]*models.Place, error) {
+	return db.queryPlaces(`
+		WHERE EXISTS (SELECT 1 FROM json_each(ud.tags) WHERE value = ?)
+			OR EXISTS (SELECT 1 FROM shared_tags st WHERE st.place_id = p.id AND st.tag = ?)
+		ORDER BY p.name, p.id`, tag, tag)
 }
 
 func (db *DB) SearchPlaces(query string) ([]*models.Place, error) {
-	rows, err := db.conn.Query(`
-		SELECT
-			p.id, p.place_id, p.name, p.address, p.lat, p.lng,
-			p.categories, p.data, p.created_at, p.updated_at,
-			p.imported_at, p.source_hash,
-			ud.notes, ud.tags, ud.custom_fields
-		FROM places p
-		LEFT JOIN user_data ud ON p.id = ud.place_id
+	pattern := "%" + query + "%"
+	return db.queryPlaces(`
 		WHERE p.name LIKE ? OR p.address LIKE ? OR ud.notes LIKE ?
-		ORDER BY p.updated_at DESC`, "%"+query+"%", "%"+query+"%", "%"+query+"%")
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var places []*models.Place
-	for rows.Next() {
-		place, err := scanPlace(rows)
-		if err != nil {
-			return nil, err
-		}
-		places = append(places, place)
-	}
-
-	return places, nil
+		ORDER BY p.updated_at DESC`, pattern, pattern, pattern)
 }
@@ -1,7 +1,13 @@
 package database
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -167,13 +173,17 @@ func TestDB_DeletePlace(t *testing.T) {
 	defer db.Close()
 
 	place := &models.Place{
-		ID:   "test-delete",
-		Name: "To Be Deleted",
+		ID:         "test-delete",
+		Name:       "To Be Deleted",
+		SharedTags: []string{"team"},
 	}
 
 	if err := db.SavePlace(place); err != nil {
 		t.Fatal(err)
 	}
+	if err := db.RecordEnrichment("test-delete", "google", nil); err != nil {
+		t.Fatal(err)
+	}
 
 	_, err = db.GetPlace("test-delete")
 	if err != nil {
@@ -189,4 +199,932 @@ func TestDB_DeletePlace(t *testing.T) {
 	if err == nil {
 		t.Error("Place should not exist after deletion")
 	}
+
+	var tags int
+	if err := db.conn.QueryRow("SELECT COUNT(*) FROM shared_tags WHERE place_id = ?", "test-delete").Scan(&tags); err != nil {
+		t.Fatal(err)
+	}
+	if tags != 0 {
+		t.Errorf("Expected shared tags to be deleted with the place, got %d", tags)
+	}
+	states, err := db.GetEnrichmentStates("google")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := states["test-delete"]; ok {
+		t.Error("Expected the enrichment state to be deleted with the place")
+	}
+}
+
+func TestDB_EnrichmentState(t *testing.T) {
+	db, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	quotaErr := errors.New("API returned status: OVER_QUERY_LIMIT")
+	for _, record := range []struct {
+		placeID, provider string
+		err               error
+	}{
+		{"p1", "google", quotaErr},
+		{"p1", "google", quotaErr},
+		{"p2", "google", nil},
+		{"p1", "osm", nil},
+	} {
+		if err := db.RecordEnrichment(record.placeID, record.provider, record.err); err != nil {
+			t.Fatalf("RecordEnrichment failed: %v", err)
+		}
+	}
+
+	states, err := db.GetEnrichmentStates("google")
+	if err != nil {
+		t.Fatalf("GetEnrichmentStates failed: %v", err)
+	}
+	if len(states) != 2 {
+		t.Fatalf("Expected 2 states, got %d", len(states))
+	}
+	if p1 := states["p1"]; p1.EnrichedAt != nil || p1.Attempts != 2 || p1.LastError != quotaErr.Error() {
+		t.Errorf("Unexpected state for failed place: %+v", p1)
+	}
+	if p2 := states["p2"]; p2.EnrichedAt == nil || time.Since(*p2.EnrichedAt) > time.Minute || p2.LastError != "" {
+		t.Errorf("Unexpected state for enriched place: %+v", p2)
+	}
+
+	// Success clears the error and the failure count
+	if err := db.RecordEnrichment("p1", "google", nil); err != nil {
+		t.Fatalf("RecordEnrichment failed: %v", err)
+	}
+	states, err = db.GetEnrichmentStates("google")
+	if err != nil {
+		t.Fatalf("GetEnrichmentStates failed: %v", err)
+	}
+	if p1 := states["p1"]; p1.EnrichedAt == nil || p1.Attempts != 0 || p1.LastError != "" {
+		t.Errorf("Expected success to reset state, got %+v", p1)
+	}
+}
+
+func TestDB_Attachments(t *testing.T) {
+	db, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	taken := time.Date(2024, 5, 17, 13, 45, 0, 0, time.UTC)
+	place := &models.Place{ID: "p1", Name: "Time Out Market"}
+	place.AddAttachment(models.Attachment{
+		Hash:        "abc123",
+		Name:        "receipt.jpg",
+		ContentType: "image/jpeg",
+		TakenAt:     &taken,
+		Coordinates: &models.Coordinates{Lat: 38.707, Lng: -9.146},
+	})
+	if err := db.SavePlace(place); err != nil {
+		t.Fatalf("SavePlace failed: %v", err)
+	}
+
+	got, err := db.GetPlace("p1")
+	if err != nil {
+		t.Fatalf("GetPlace failed: %v", err)
+	}
+	if len(got.Attachments) != 1 {
+		t.Fatalf("Expected 1 attachment, got %d", len(got.Attachments))
+	}
+	a := got.Attachments[0]
+	if a.Name != "receipt.jpg" || a.TakenAt == nil || !a.TakenAt.Equal(taken) || a.Coordinates == nil {
+		t.Errorf("Attachment not preserved: %+v", a)
+	}
+}
+
+func TestDB_Visits(t *testing.T) {
+	db, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	lisbon := time.FixedZone("WEST", 3600)
+	visits := []models.Visit{
+		{VisitedAt: time.Date(2023, 8, 2, 13, 0, 0, 0, lisbon), Source: "foursquare", SourceID: "c1"},
+		{VisitedAt: time.Date(2024, 5, 17, 20, 30, 0, 0, lisbon), Companions: []string{"Ana"}, Rating: 5, Note: "sardines"},
+	}
+	added, err := db.AddVisits("p1", visits)
+	if err != nil {
+		t.Fatalf("AddVisits failed: %v", err)
+	}
+	if added != 2 {
+		t.Fatalf("Expected 2 visits added, got %d", added)
+	}
+
+	// Re-importing the same check-in is ignored
+	added, err = db.AddVisits("p1", []models.Visit{{VisitedAt: time.Now(), Source: "foursquare", SourceID: "c1"}})
+	if err != nil {
+		t.Fatalf("AddVisits failed: %v", err)
+	}
+	if added != 0 {
+		t.Errorf("Expected duplicate check-in to be ignored, got %d added", added)
+	}
+	if _, err := db.AddVisit(&models.Visit{PlaceID: "p2", VisitedAt: time.Date(2024, 1, 3, 9, 0, 0, 0, time.UTC)}); err != nil {
+		t.Fatalf("AddVisit failed: %v", err)
+	}
+
+	all, err := db.ListVisits(VisitFilter{})
+	if err != nil {
+		t.Fatalf("ListVisits failed: %v", err)
+	}
+	if len(all) != 3 || all[0].Note != "sardines" || all[0].Companions[0] != "Ana" {
+		t.Fatalf("Unexpected visits: %+v", all)
+	}
+	if !all[0].VisitedAt.Equal(visits[1].VisitedAt) {
+		t.Errorf("Expected visit time %v, got %v", visits[1].VisitedAt, all[0].VisitedAt)
+	}
+
+	in2024, err := db.ListVisits(VisitFilter{
+		PlaceID: "p1",
+		From:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:      time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("ListVisits failed: %v", err)
+	}
+	if len(in2024) != 1 || in2024[0].Rating != 5 {
+		t.Errorf("Expected one 2024 visit to p1, got %+v", in2024)
+	}
+
+	summaries, err := db.GetVisitSummaries()
+	if err != nil {
+		t.Fatalf("GetVisitSummaries failed: %v", err)
+	}
+	p1 := summaries["p1"]
+	if p1.Count != 2 || !p1.First.Equal(visits[0].VisitedAt) || !p1.Last.Equal(visits[1].VisitedAt) {
+		t.Errorf("Unexpected summary for p1: %+v", p1)
+	}
+
+	if err := db.DeleteVisit(all[0].ID); err != nil {
+		t.Fatalf("DeleteVisit failed: %v", err)
+	}
+	if err := db.DeleteVisit(all[0].ID); err == nil {
+		t.Error("Expected error deleting a missing visit")
+	}
+
+	if err := db.DeletePlace("p2"); err != nil {
+		t.Fatalf("DeletePlace failed: %v", err)
+	}
+	remaining, _ := db.ListVisits(VisitFilter{})
+	if len(remaining) != 1 || remaining[0].PlaceID != "p1" {
+		t.Errorf("Expected deleting a place to remove its visits, got %+v", remaining)
+	}
+}
+
+func TestDB_SavePlaces(t *testing.T) {
+	db, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var places []*models.Place
+	for i := 0; i < 250; i++ {
+		places = append(places, &models.Place{
+			ID:       fmt.Sprintf("p%d", i),
+			Name:     fmt.Sprintf("Place %d", i),
+			UserTags: []string{"batch"},
+		})
+	}
+	if err := db.SavePlaces(places); err != nil {
+		t.Fatalf("SavePlaces failed: %v", err)
+	}
+
+	count, err := db.CountPlaces()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 250 {
+		t.Errorf("Expected 250 places, got %d", count)
+	}
+
+	got, err := db.GetPlace("p42")
+	if err != nil {
+		t.Fatalf("GetPlace failed: %v", err)
+	}
+	if got.Name != "Place 42" || len(got.UserTags) != 1 || got.CreatedAt.IsZero() {
+		t.Errorf("Place not saved correctly: %+v", got)
+	}
+}
+
+func TestDB_ForEachPlace(t *testing.T) {
+	db, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	total := forEachBatch*2 + 5
+	var places []*models.Place
+	for i := 0; i < total; i++ {
+		places = append(places, &models.Place{ID: fmt.Sprintf("p%05d", i), Name: fmt.Sprintf("Place %d", i)})
+	}
+	if err := db.SavePlaces(places); err != nil {
+		t.Fatal(err)
+	}
+
+	// Saving during the walk must not skip or repeat places
+	seen := make(map[string]bool)
+	err = db.ForEachPlace("", func(place *models.Place) error {
+		if seen[place.ID] {
+			t.Errorf("visited %s twice", place.ID)
+		}
+		seen[place.ID] = true
+		place.AddTag("walked")
+		return db.SavePlace(place)
+	})
+	if err != nil {
+		t.Fatalf("ForEachPlace failed: %v", err)
+	}
+	if len(seen) != total {
+		t.Errorf("Expected %d places, visited %d", total, len(seen))
+	}
+
+	stop := errors.New("stop")
+	visited := 0
+	err = db.ForEachPlace("", func(place *models.Place) error {
+		visited++
+		return stop
+	})
+	if !errors.Is(err, stop) || visited != 1 {
+		t.Errorf("Expected the walk to stop at the first error, got %v after %d places", err, visited)
+	}
+}
+
+func TestDB_ImportPresets(t *testing.T) {
+	db, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveImportPreset(&ImportPreset{Name: "team", Source: "csv", Mapping: "name=Title"}); err != nil {
+		t.Fatalf("Failed to save preset: %v", err)
+	}
+	if err := db.SaveImportPreset(&ImportPreset{Name: "team", Source: "csv", Mapping: "lat=Y,name=Title"}); err != nil {
+		t.Fatalf("Failed to replace preset: %v", err)
+	}
+
+	preset, err := db.GetImportPreset("team")
+	if err != nil {
+		t.Fatalf("Failed to get preset: %v", err)
+	}
+	if preset.Mapping != "lat=Y,name=Title" || preset.Source != "csv" {
+		t.Errorf("Unexpected preset: %+v", preset)
+	}
+
+	presets, err := db.ListImportPresets()
+	if err != nil || len(presets) != 1 {
+		t.Fatalf("Expected 1 preset, got %d (%v)", len(presets), err)
+	}
+
+	if err := db.DeleteImportPreset("team"); err != nil {
+		t.Fatalf("Failed to delete preset: %v", err)
+	}
+	if _, err := db.GetImportPreset("team"); err == nil {
+		t.Error("Expected error for deleted preset")
+	}
+	if err := db.DeleteImportPreset("team"); err == nil {
+		t.Error("Expected error deleting a missing preset")
+	}
+}
+
+func TestDB_APITokens(t *testing.T) {
+	db, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	if _, _, err := db.CreateAPIToken("laptop", "admin"); err == nil {
+		t.Error("Expected error for unknown scope")
+	}
+
+	token, secret, err := db.CreateAPIToken("laptop", ScopeWrite)
+	if err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+	if !token.CanWrite() || secret == "" {
+		t.Errorf("Unexpected token: %+v", token)
+	}
+	if _, _, err := db.CreateAPIToken("laptop", ScopeRead); err == nil {
+		t.Error("Expected error for duplicate name")
+	}
+
+	found, err := db.AuthenticateAPIToken(secret)
+	if err != nil {
+		t.Fatalf("Failed to authenticate: %v", err)
+	}
+	if found.ID != token.ID || found.LastUsedAt == nil {
+		t.Errorf("Unexpected token: %+v", found)
+	}
+	if _, err := db.AuthenticateAPIToken(secret + "x"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected ErrInvalidToken, got %v", err)
+	}
+
+	if _, err := db.RevokeAPIToken("laptop"); err != nil {
+		t.Fatalf("Failed to revoke token: %v", err)
+	}
+	if _, err := db.AuthenticateAPIToken(secret); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected revoked token to be rejected, got %v", err)
+	}
+	if _, err := db.RevokeAPIToken(token.ID); err == nil {
+		t.Error("Expected error revoking a revoked token")
+	}
+
+	// The name is free again once revoked
+	if _, _, err := db.CreateAPIToken("laptop", ScopeRead); err != nil {
+		t.Errorf("Failed to reuse name: %v", err)
+	}
+	tokens, err := db.ListAPITokens()
+	if err != nil || len(tokens) != 2 {
+		t.Fatalf("Expected 2 tokens, got %d (%v)", len(tokens), err)
+	}
+	if !tokens[0].Revoked() || tokens[1].Revoked() {
+		t.Errorf("Unexpected revocation state: %+v %+v", tokens[0], tokens[1])
+	}
+}
+
+func TestDB_Shares(t *testing.T) {
+	db, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	places := []*models.Place{
+		{ID: "a", Name: "Tasca", UserNotes: "ask for Rui", UserTags: []string{"lisbon-trip", "cheap"},
+			CustomFields: map[string]interface{}{"dish": "bacalhau", "budget": 20}},
+		{ID: "b", Name: "Miradouro", SharedTags: []string{"lisbon-trip"}},
+		{ID: "c", Name: "Elsewhere", UserTags: []string{"porto"}},
+	}
+	if err := db.SavePlaces(places); err != nil {
+		t.Fatalf("Failed to save places: %v", err)
+	}
+
+	tagged, err := db.PlacesWithTag("lisbon-trip")
+	if err != nil {
+		t.Fatalf("Failed to list tagged places: %v", err)
+	}
+	if len(tagged) != 2 || tagged[0].ID != "b" || tagged[1].ID != "a" {
+		t.Fatalf("Expected places b and a by name, got %d", len(tagged))
+	}
+
+	if _, _, err := db.CreateShare("", SharePolicy{}, nil); err == nil {
+		t.Error("Expected error for missing tag")
+	}
+	expires := time.Now().Add(time.Hour)
+	share, secret, err := db.CreateShare("lisbon-trip", SharePolicy{Fields: []string{"dish"}}, &expires)
+	if err != nil {
+		t.Fatalf("Failed to create share: %v", err)
+	}
+
+	found, err := db.GetShareBySecret(secret)
+	if err != nil {
+		t.Fatalf("Failed to look up share: %v", err)
+	}
+	if found.ID != share.ID || found.Tag != "lisbon-trip" || found.ExpiresAt == nil {
+		t.Errorf("Unexpected share: %+v", found)
+	}
+	if _, err := db.GetShareBySecret(secret + "x"); !errors.Is(err, ErrInvalidShare) {
+		t.Errorf("Expected ErrInvalidShare, got %v", err)
+	}
+
+	shared := found.Redact(tagged[1])
+	if shared.UserNotes != "" || len(shared.UserTags) != 1 || shared.UserTags[0] != "lisbon-trip" {
+		t.Errorf("Expected notes and other tags to be redacted, got %+v", shared)
+	}
+	if len(shared.CustomFields) != 1 || shared.CustomFields["dish"] != "bacalhau" {
+		t.Errorf("Expected only the dish field, got %v", shared.CustomFields)
+	}
+	if tagged[1].UserNotes == "" {
+		t.Error("Redact changed the original place")
+	}
+	if shared := found.Redact(tagged[0]); len(shared.SharedTags) != 1 || len(shared.UserTags) != 0 {
+		t.Errorf("Expected the shared tag to be kept, got %v %v", shared.UserTags, shared.SharedTags)
+	}
+
+	open := Share{Tag: "lisbon-trip", Policy: SharePolicy{Notes: true, Tags: true, Fields: []string{"*"}}}
+	if shared := open.Redact(tagged[1]); shared.UserNotes == "" || len(shared.UserTags) != 2 || len(shared.CustomFields) != 2 {
+		t.Errorf("Expected the policy to let everything through, got %+v", shared)
+	}
+
+	// Expired and revoked links stop working
+	past := time.Now().Add(-time.Minute)
+	_, expiredSecret, err := db.CreateShare("lisbon-trip", SharePolicy{}, &past)
+	if err != nil {
+		t.Fatalf("Failed to create share: %v", err)
+	}
+	if _, err := db.GetShareBySecret(expiredSecret); !errors.Is(err, ErrShareExpired) {
+		t.Errorf("Expected ErrShareExpired, got %v", err)
+	}
+	if _, err := db.RevokeShare(share.ID); err != nil {
+		t.Fatalf("Failed to revoke share: %v", err)
+	}
+	if _, err := db.GetShareBySecret(secret); !errors.Is(err, ErrShareExpired) {
+		t.Errorf("Expected revoked share to be rejected, got %v", err)
+	}
+	if _, err := db.RevokeShare(share.ID); err == nil {
+		t.Error("Expected error revoking a revoked share")
+	}
+
+	shares, err := db.ListShares()
+	if err != nil || len(shares) != 2 {
+		t.Fatalf("Expected 2 shares, got %d (%v)", len(shares), err)
+	}
+	if shares[0].Policy.Fields[0] != "dish" {
+		t.Errorf("Expected the policy to round-trip, got %+v", shares[0].Policy)
+	}
+}
+
+func TestDB_PerUserData(t *testing.T) {
+	db, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.AddUser("alice"); err != nil {
+		t.Fatalf("Failed to add user: %v", err)
+	}
+	if _, err := db.AddUser("alice"); err == nil {
+		t.Error("Expected error adding a user twice")
+	}
+	if _, err := db.AddUser("bad name"); err == nil {
+		t.Error("Expected error for invalid user name")
+	}
+	alice := db.ForUser("alice")
+
+	place := &models.Place{ID: "shared", Name: "Cafe", UserNotes: "mine", UserTags: []string{"coffee"}}
+	if err := db.SavePlace(place); err != nil {
+		t.Fatalf("Failed to save place: %v", err)
+	}
+
+	// Alice sees the place but none of the default user's data
+	got, err := alice.GetPlace("shared")
+	if err != nil {
+		t.Fatalf("Failed to get place: %v", err)
+	}
+	if got.Name != "Cafe" || got.UserNotes != "" || len(got.UserTags) != 0 {
+		t.Errorf("Unexpected place for alice: %+v", got)
+	}
+
+	got.UserNotes = "hers"
+	got.UserTags = []string{"lunch"}
+	got.AddSharedTag("team")
+	if err := alice.SavePlace(got); err != nil {
+		t.Fatalf("Failed to save place: %v", err)
+	}
+
+	mine, err := db.GetPlace("shared")
+	if err != nil {
+		t.Fatalf("Failed to get place: %v", err)
+	}
+	if mine.UserNotes != "mine" || !mine.HasTag("coffee") || mine.HasTag("lunch") {
+		t.Errorf("Alice's edits leaked into the default user's data: %+v", mine)
+	}
+	if !mine.HasSharedTag("team") {
+		t.Errorf("Expected shared tag to be visible to everyone, got %v", mine.SharedTags)
+	}
+
+	if results, _ := alice.SearchPlaces("hers"); len(results) != 1 {
+		t.Errorf("Expected alice to find her notes, got %d results", len(results))
+	}
+	if results, _ := db.SearchPlaces("hers"); len(results) != 0 {
+		t.Errorf("Expected default user not to find alice's notes, got %d results", len(results))
+	}
+
+	// Visits with the same source ID are kept apart per user
+	visit := models.Visit{VisitedAt: time.Now(), Source: "swarm", SourceID: "c1"}
+	for _, handle := range []*DB{db, alice} {
+		v := visit
+		v.PlaceID = "shared"
+		if added, err := handle.AddVisit(&v); err != nil || !added {
+			t.Fatalf("Failed to add visit for %q: %v", handle.User(), err)
+		}
+	}
+	visits, err := alice.ListVisits(VisitFilter{})
+	if err != nil || len(visits) != 1 {
+		t.Fatalf("Expected 1 visit for alice, got %d (%v)", len(visits), err)
+	}
+
+	token, _, err := alice.CreateAPIToken("alice-laptop", ScopeWrite)
+	if err != nil || token.User != "alice" {
+		t.Fatalf("Expected token for alice, got %+v (%v)", token, err)
+	}
+	_, shareSecret, err := alice.CreateShare("lunch", SharePolicy{}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create share: %v", err)
+	}
+
+	if err := db.RemoveUser("alice"); err != nil {
+		t.Fatalf("Failed to remove user: %v", err)
+	}
+	if exists, _ := db.UserExists("alice"); exists {
+		t.Error("Expected alice to be gone")
+	}
+	if visits, _ := db.ListVisits(VisitFilter{}); len(visits) != 1 {
+		t.Errorf("Expected the default user's visit to remain, got %d", len(visits))
+	}
+	if token, _ := db.GetAPIToken(token.ID); !token.Revoked() {
+		t.Error("Expected alice's token to be revoked")
+	}
+	if _, err := db.GetShareBySecret(shareSecret); !errors.Is(err, ErrShareExpired) {
+		t.Errorf("Expected alice's share to be revoked, got %v", err)
+	}
+}
+
+func TestDB_ConcurrentEdits(t *testing.T) {
+	db, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	alice, bob := db.ForUser("alice"), db.ForUser("bob")
+
+	place := &models.Place{ID: "p1", Name: "Cafe", Phone: "555-0100", SharedTags: []string{"lunch"}}
+	if err := db.SavePlace(place); err != nil {
+		t.Fatal(err)
+	}
+
+	// Both load the place, then edit it without seeing each other's saves
+	hers, err := alice.GetPlace("p1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	his, err := bob.GetPlace("p1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hers.Phone = "555-0199"
+	hers.AddSharedTag("team")
+	hers.UserNotes = "ask for the terrace"
+	if err := alice.SavePlace(hers); err != nil {
+		t.Fatal(err)
+	}
+
+	his.Website = "https://cafe.example"
+	his.RemoveSharedTag("lunch")
+	his.UserNotes = "cash only"
+	if err := bob.SavePlace(his); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := alice.GetPlace("p1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Phone != "555-0199" || got.Website != "https://cafe.example" {
+		t.Errorf("Expected both edits to the place to be kept, got phone %q and website %q", got.Phone, got.Website)
+	}
+	if len(got.SharedTags) != 1 || got.SharedTags[0] != "team" {
+		t.Errorf("Expected shared tags [team], got %v", got.SharedTags)
+	}
+	if got.UserNotes != "ask for the terrace" {
+		t.Errorf("Expected alice's notes, got %q", got.UserNotes)
+	}
+
+	// The saved place is left as stored, with the other user's edits
+	if his.Phone != "555-0199" || len(his.SharedTags) != 1 {
+		t.Errorf("Expected bob's copy to be updated, got %+v", his)
+	}
+
+	// Places without a snapshot are saved whole
+	whole := &models.Place{ID: "p1", Name: "Cafe Renamed"}
+	if err := db.SavePlace(whole); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = db.GetPlace("p1")
+	if got.Name != "Cafe Renamed" || got.Phone != "" || len(got.SharedTags) != 0 {
+		t.Errorf("Expected the place to be replaced, got %+v", got)
+	}
+}
+
+func TestDB_SavePlaceIfUnchanged(t *testing.T) {
+	db, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if err := db.SavePlace(&models.Place{ID: "p1", Name: "Cafe"}); err != nil {
+		t.Fatal(err)
+	}
+	first, _ := db.GetPlace("p1")
+	second, _ := db.GetPlace("p1")
+	loaded := first.UpdatedAt
+
+	first.UserNotes = "first"
+	if err := db.SavePlaceIfUnchanged(first, loaded); err != nil {
+		t.Fatalf("SavePlaceIfUnchanged failed: %v", err)
+	}
+	second.UserNotes = "second"
+	if err := db.SavePlaceIfUnchanged(second, loaded); !errors.Is(err, ErrConflict) {
+		t.Fatalf("Expected ErrConflict for an outdated copy, got %v", err)
+	}
+	if err := db.SavePlaceIfUnchanged(&models.Place{ID: "missing", Name: "Missing"}, loaded); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict for a missing place, got %v", err)
+	}
+
+	got, _ := db.GetPlace("p1")
+	if got.UserNotes != "first" || !got.UpdatedAt.Equal(first.UpdatedAt) {
+		t.Errorf("Expected the first save to be kept, got %q updated at %v", got.UserNotes, got.UpdatedAt)
+	}
+}
+
+func TestDB_MigrateUserData(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.db")
+	conn, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The user_data table from before users existed
+	_, err = conn.Exec(`
+		CREATE TABLE places (id TEXT PRIMARY KEY, place_id TEXT, name TEXT NOT NULL, address TEXT,
+			lat REAL, lng REAL, categories TEXT, data TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP, updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			imported_at DATETIME, source_hash TEXT);
+		CREATE TABLE user_data (place_id TEXT PRIMARY KEY, notes TEXT, tags TEXT, custom_fields TEXT);
+		CREATE INDEX idx_user_data_tags ON user_data(tags);
+		CREATE TABLE visits (id INTEGER PRIMARY KEY AUTOINCREMENT, place_id TEXT NOT NULL,
+			visited_at TEXT NOT NULL, companions TEXT, rating INTEGER NOT NULL DEFAULT 0, note TEXT,
+			source TEXT NOT NULL DEFAULT '', source_id TEXT NOT NULL DEFAULT '');
+		CREATE UNIQUE INDEX idx_visits_source ON visits(source, source_id) WHERE source_id != '';
+		INSERT INTO places (id, place_id, name, address, lat, lng, categories, data) VALUES ('p1', 'g1', 'Old Place', '', 0, 0, '[]', '{"phone": "555", "shared_tags": ["team"]}');
+		INSERT INTO places (id, place_id, name, address, lat, lng, categories, data) VALUES ('p2', 'g2', 'Broken', '', 0, 0, '[]', 'not json');
+		INSERT INTO user_data VALUES ('p1', 'old notes', '["old"]', '{}');
+		INSERT INTO visits (place_id, visited_at, source, source_id) VALUES ('p1', '2024-01-01T00:00:00Z', 'swarm', 'c1');
+	`)
+	conn.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := New(path)
+	if err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	defer db.Close()
+
+	place, err := db.GetPlace("p1")
+	if err != nil {
+		t.Fatalf("Failed to get place: %v", err)
+	}
+	if place.UserNotes != "old notes" || !place.HasTag("old") {
+		t.Errorf("Expected old data to belong to the default user, got %+v", place)
+	}
+	if !place.HasSharedTag("team") || place.Phone != "555" {
+		t.Errorf("Expected shared tags to move to their table, got %v (phone %q)", place.SharedTags, place.Phone)
+	}
+	var data string
+	if err := db.conn.QueryRow("SELECT data FROM places WHERE id = 'p1'").Scan(&data); err != nil || strings.Contains(data, "shared_tags") {
+		t.Errorf("Expected shared tags to be removed from the data column, got %s (%v)", data, err)
+	}
+	if visits, _ := db.ListVisits(VisitFilter{}); len(visits) != 1 {
+		t.Errorf("Expected old visit to belong to the default user, got %d", len(visits))
+	}
+}
+
+func nextChange(t *testing.T, changes <-chan Change) Change {
+	t.Helper()
+	select {
+	case c := <-changes:
+		return c
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for a change")
+		return Change{}
+	}
+}
+
+func TestDB_Subscribe(t *testing.T) {
+	db, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	changes, unsubscribe := db.Subscribe()
+	defer unsubscribe()
+
+	place := &models.Place{ID: "p1", Name: "Cafe"}
+	if err := db.SavePlace(place); err != nil {
+		t.Fatal(err)
+	}
+	if c := nextChange(t, changes); c.Kind != PlaceCreated || c.PlaceID != "p1" || c.External {
+		t.Errorf("Unexpected change: %+v", c)
+	}
+
+	// Other users' handles publish to the same subscribers
+	if err := db.ForUser("alice").SavePlace(place); err != nil {
+		t.Fatal(err)
+	}
+	if c := nextChange(t, changes); c.Kind != PlaceUpdated {
+		t.Errorf("Unexpected change: %+v", c)
+	}
+
+	if err := db.DeletePlace("p1"); err != nil {
+		t.Fatal(err)
+	}
+	if c := nextChange(t, changes); c.Kind != PlaceDeleted {
+		t.Errorf("Unexpected change: %+v", c)
+	}
+
+	unsubscribe()
+	if _, ok := <-changes; ok {
+		t.Error("Expected channel to be closed after unsubscribing")
+	}
+}
+
+func TestDB_WatchExternalChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := New(path)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+	if err := db.SavePlace(&models.Place{ID: "old", Name: "Old"}); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, unsubscribe := db.Subscribe()
+	defer unsubscribe()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = db.WatchExternalChanges(ctx, 10*time.Millisecond)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// Let the watcher take its snapshot before writing
+	time.Sleep(50 * time.Millisecond)
+
+	// A second handle stands in for another process, such as the TUI
+	other, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+
+	if err := other.SavePlace(&models.Place{ID: "new", Name: "New"}); err != nil {
+		t.Fatal(err)
+	}
+	if c := nextChange(t, changes); c.Kind != PlaceCreated || c.PlaceID != "new" || !c.External {
+		t.Errorf("Unexpected change: %+v", c)
+	}
+
+	if err := other.DeletePlace("old"); err != nil {
+		t.Fatal(err)
+	}
+	if c := nextChange(t, changes); c.Kind != PlaceDeleted || c.PlaceID != "old" {
+		t.Errorf("Unexpected change: %+v", c)
+	}
+
+	// Writes through this handle are reported once, not again by the watcher
+	if err := db.SavePlace(&models.Place{ID: "new", Name: "Renamed"}); err != nil {
+		t.Fatal(err)
+	}
+	if c := nextChange(t, changes); c.Kind != PlaceUpdated || c.External {
+		t.Errorf("Unexpected change: %+v", c)
+	}
+	select {
+	case c := <-changes:
+		t.Errorf("Unexpected duplicate change: %+v", c)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestGeohash(t *testing.T) {
+	if got := geohash(57.64911, 10.40744, 11); got != "u4pruydqqvj" {
+		t.Errorf("Expected u4pruydqqvj, got %s", got)
+	}
+	if got := placeGeohash(0, 0); got != "" {
+		t.Errorf("Expected no geohash without coordinates, got %s", got)
+	}
+
+	// Cells shrink as the map zooms in
+	previous := 0
+	for zoom := 0; zoom <= 20; zoom++ {
+		precision := clusterPrecision(zoom)
+		if precision < previous {
+			t.Errorf("Precision went down from %d to %d at zoom %d", previous, precision, zoom)
+		}
+		previous = precision
+	}
+}
+
+func TestDB_PlacesInView(t *testing.T) {
+	db, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// A crowd of places in Lisbon, one in Porto and one without coordinates
+	var places []*models.Place
+	for i := 0; i < 300; i++ {
+		places = append(places, &models.Place{
+			ID:          fmt.Sprintf("lisbon-%d", i),
+			Name:        fmt.Sprintf("Lisbon %03d", i),
+			Coordinates: models.Coordinates{Lat: 38.70 + float64(i%20)*0.002, Lng: -9.15 + float64(i/20)*0.002},
+			Categories:  []string{"cafe"},
+		})
+	}
+	places = append(places,
+		&models.Place{ID: "porto", Name: "Porto", Coordinates: models.Coordinates{Lat: 41.15, Lng: -8.61},
+			Rating: 4.5, UserTags: []string{"wine"}, SharedTags: []string{"team"}},
+		&models.Place{ID: "nowhere", Name: "Nowhere"},
+	)
+	if err := db.SavePlaces(places); err != nil {
+		t.Fatal(err)
+	}
+	portugal := []models.Coordinates{{Lat: 36.9, Lng: -9.6}, {Lat: 42.2, Lng: -6.1}}
+
+	view, err := db.PlacesInView(portugal[0], portugal[1], 6)
+	if err != nil {
+		t.Fatalf("PlacesInView failed: %v", err)
+	}
+	if view.Total != 301 {
+		t.Errorf("Expected 301 places in view, got %d", view.Total)
+	}
+	if len(view.Clusters) != 1 || view.Clusters[0].Count != 300 {
+		t.Fatalf("Expected Lisbon as one cluster, got %+v", view.Clusters)
+	}
+	if c := view.Clusters[0]; c.Min.Lat != 38.70 || c.Max.Lng < -9.123 || c.Max.Lng > -9.121 || c.Coordinates.Lat < 38.70 || c.Coordinates.Lat > 38.74 {
+		t.Errorf("Unexpected cluster bounds: %+v", c)
+	}
+	if len(view.Places) != 1 || view.Places[0].ID != "porto" {
+		t.Fatalf("Expected Porto on its own, got %+v", view.Places)
+	}
+	porto := view.Places[0]
+	if porto.Rating != 4.5 || len(porto.Tags) != 2 {
+		t.Errorf("Expected Porto's rating and both tags, got %+v", porto)
+	}
+
+	// Zoomed in, places are listed one by one
+	view, err = db.PlacesInView(models.Coordinates{Lat: 38.70, Lng: -9.15}, models.Coordinates{Lat: 38.71, Lng: -9.14}, ClusterMaxZoom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(view.Clusters) != 0 || len(view.Places) != view.Total || view.Total != 36 {
+		t.Errorf("Expected 36 unclustered places, got %d clusters, %d of %d places", len(view.Clusters), len(view.Places), view.Total)
+	}
+	if len(view.Places) > 0 && view.Places[0].Categories[0] != "cafe" {
+		t.Errorf("Expected categories in summaries, got %+v", view.Places[0])
+	}
+
+	// Places saved before the geohash column existed are indexed on open
+	if _, err := db.conn.Exec("UPDATE places SET geohash = NULL"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.backfillGeohashes(); err != nil {
+		t.Fatal(err)
+	}
+	view, err = db.PlacesInView(portugal[0], portugal[1], 6)
+	if err != nil || view.Total != 301 {
+		t.Errorf("Expected 301 places after backfill, got %+v, %v", view, err)
+	}
+}
+
+func TestDB_PlacesInView_TruncatesSingles(t *testing.T) {
+	db, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// A grid of places far enough apart that each has a cell to itself
+	var places []*models.Place
+	for i := 0; i < 45*45; i++ {
+		places = append(places, &models.Place{
+			ID:          fmt.Sprintf("grid-%d", i),
+			Name:        fmt.Sprintf("Grid %04d", i),
+			Coordinates: models.Coordinates{Lat: 38 + float64(i%45)*0.02, Lng: -9 + float64(i/45)*0.02},
+		})
+	}
+	if err := db.SavePlaces(places); err != nil {
+		t.Fatal(err)
+	}
+
+	view, err := db.PlacesInView(models.Coordinates{Lat: 37, Lng: -10}, models.Coordinates{Lat: 40, Lng: -7}, ClusterMaxZoom-1)
+	if err != nil {
+		t.Fatalf("PlacesInView failed: %v", err)
+	}
+	if view.Total != len(places) || len(view.Clusters) != 0 {
+		t.Fatalf("Expected %d places and no clusters, got %d places and %d clusters", len(places), view.Total, len(view.Clusters))
+	}
+	if len(view.Places) != maxViewPlaces || !view.Truncated {
+		t.Errorf("Expected %d places and a truncated view, got %d (truncated %v)", maxViewPlaces, len(view.Places), view.Truncated)
+	}
 }
@@ -0,0 +1,80 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// EnrichmentState tracks the last enrichment of a place by one provider
+type EnrichmentState struct {
+	PlaceID     string
+	Provider    string
+	EnrichedAt  *time.Time // last successful enrichment
+	AttemptedAt time.Time
+	Attempts    int // consecutive failed attempts
+	LastError   string
+}
+
+// RecordEnrichment stores the outcome of enriching a place. A nil enrichErr
+// marks the place as enriched now and clears any previous error.
+func (db *DB) RecordEnrichment(placeID, provider string, enrichErr error) error {
+	now := time.Now()
+
+	var err error
+	if enrichErr == nil {
+		_, err = db.conn.Exec(`
+			INSERT INTO enrichment_state (place_id, provider, enriched_at, attempted_at, attempts, last_error)
+			VALUES (?, ?, ?, ?, 0, '')
+			ON CONFLICT (place_id, provider) DO UPDATE SET
+				enriched_at = excluded.enriched_at,
+				attempted_at = excluded.attempted_at,
+				attempts = 0,
+				last_error = ''`,
+			placeID, provider, now, now)
+	} else {
+		_, err = db.conn.Exec(`
+			INSERT INTO enrichment_state (place_id, provider, attempted_at, attempts, last_error)
+			VALUES (?, ?, ?, 1, ?)
+			ON CONFLICT (place_id, provider) DO UPDATE SET
+				attempted_at = excluded.attempted_at,
+				attempts = attempts + 1,
+				last_error = excluded.last_error`,
+			placeID, provider, now, enrichErr.Error())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record enrichment state: %w", err)
+	}
+	return nil
+}
+
+// GetEnrichmentStates returns the enrichment state of every place that has
+// been attempted with the provider, keyed by place ID
+func (db *DB) GetEnrichmentStates(provider string) (map[string]*EnrichmentState, error) {
+	rows, err := db.conn.Query(`
+		SELECT place_id, provider, enriched_at, attempted_at, attempts, last_error
+		FROM enrichment_state
+		WHERE provider = ?`, provider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query enrichment state: %w", err)
+	}
+	defer rows.Close()
+
+	states := make(map[string]*EnrichmentState)
+	for rows.Next() {
+		var state EnrichmentState
+		var enrichedAt, attemptedAt sql.NullTime
+		var lastError sql.NullString
+		if err := rows.Scan(&state.PlaceID, &state.Provider, &enrichedAt, &attemptedAt, &state.Attempts, &lastError); err != nil {
+			return nil, fmt.Errorf("failed to scan enrichment state: %w", err)
+		}
+		if enrichedAt.Valid {
+			state.EnrichedAt = &enrichedAt.Time
+		}
+		state.AttemptedAt = attemptedAt.Time
+		state.LastError = lastError.String
+		states[state.PlaceID] = &state
+	}
+
+	return states, rows.Err()
+}
@@ -0,0 +1,218 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/user/placeli/internal/logger"
+)
+
+// ChangeKind says what happened to a place
+type ChangeKind string
+
+const (
+	PlaceCreated ChangeKind = "created"
+	PlaceUpdated ChangeKind = "updated"
+	PlaceDeleted ChangeKind = "deleted"
+)
+
+// Change is a notification that a place was written, by this process or,
+// once WatchExternalChanges runs, by another one such as the TUI
+type Change struct {
+	Kind    ChangeKind `json:"kind"`
+	PlaceID string     `json:"id"`
+	// External is set for changes made by another process
+	External bool `json:"external,omitempty"`
+}
+
+// subscriberBuffer is how many changes a subscriber may fall behind by
+// before further changes to it are dropped
+const subscriberBuffer = 256
+
+// changeHub fans changes out to subscribers. It also remembers when each
+// place was last updated, so the external change watcher can tell which
+// places another process wrote and skip the ones this process did.
+type changeHub struct {
+	// writeMu keeps the watcher from diffing while this process is
+	// between committing a write and publishing it
+	writeMu sync.RWMutex
+
+	mu          sync.Mutex
+	subscribers map[chan Change]struct{}
+	snapshot    map[string]time.Time // nil until a watcher runs
+}
+
+func newChangeHub() *changeHub {
+	return &changeHub{subscribers: make(map[chan Change]struct{})}
+}
+
+// Subscribe returns a channel of changes and a function that ends the
+// subscription and closes the channel. A subscriber that falls too far
+// behind misses changes rather than blocking writers.
+func (db *DB) Subscribe() (<-chan Change, func()) {
+	h := db.changes
+	ch := make(chan Change, subscriberBuffer)
+
+	h.mu.Lock()
+	h.subscribers[ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subscribers, ch)
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// publish sends changes to all subscribers. Places are recorded in the
+// snapshot first, so the watcher doesn't report them again.
+func (h *changeHub) publish(changes []Change, updatedAt map[string]time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.snapshot != nil {
+		for _, c := range changes {
+			if c.Kind == PlaceDeleted {
+				delete(h.snapshot, c.PlaceID)
+			} else {
+				h.snapshot[c.PlaceID] = updatedAt[c.PlaceID]
+			}
+		}
+	}
+
+	for _, c := range changes {
+		for ch := range h.subscribers {
+			select {
+			case ch <- c:
+			default:
+			}
+		}
+	}
+}
+
+// WatchExternalChanges polls SQLite's data_version until ctx is done and
+// publishes changes other processes make to places. data_version only
+// changes when another connection commits, so idle polls are cheap.
+func (db *DB) WatchExternalChanges(ctx context.Context, interval time.Duration) error {
+	conn, err := db.conn.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to open watch connection: %w", err)
+	}
+	defer conn.Close()
+
+	db.changes.writeMu.Lock()
+	version, err := dataVersion(ctx, conn)
+	if err != nil {
+		db.changes.writeMu.Unlock()
+		return err
+	}
+	snapshot, err := placeVersions(ctx, conn)
+	if err != nil {
+		db.changes.writeMu.Unlock()
+		return err
+	}
+	db.changes.mu.Lock()
+	db.changes.snapshot = snapshot
+	db.changes.mu.Unlock()
+	db.changes.writeMu.Unlock()
+	defer func() {
+		db.changes.mu.Lock()
+		db.changes.snapshot = nil
+		db.changes.mu.Unlock()
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		current, err := dataVersion(ctx, conn)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			logger.Error("Failed to poll database version", "error", err)
+			continue
+		}
+		if current == version {
+			continue
+		}
+		version = current
+
+		if err := db.diffPlaces(ctx, conn); err != nil && ctx.Err() == nil {
+			logger.Error("Failed to detect database changes", "error", err)
+		}
+	}
+}
+
+// diffPlaces compares the places table with the snapshot and publishes
+// what changed since
+func (db *DB) diffPlaces(ctx context.Context, conn *sql.Conn) error {
+	h := db.changes
+	h.writeMu.Lock()
+	defer h.writeMu.Unlock()
+
+	versions, err := placeVersions(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	var changes []Change
+	for id, updatedAt := range versions {
+		previous, known := h.snapshot[id]
+		switch {
+		case !known:
+			changes = append(changes, Change{Kind: PlaceCreated, PlaceID: id, External: true})
+		case !previous.Equal(updatedAt):
+			changes = append(changes, Change{Kind: PlaceUpdated, PlaceID: id, External: true})
+		}
+	}
+	for id := range h.snapshot {
+		if _, ok := versions[id]; !ok {
+			changes = append(changes, Change{Kind: PlaceDeleted, PlaceID: id, External: true})
+		}
+	}
+	h.mu.Unlock()
+
+	h.publish(changes, versions)
+	return nil
+}
+
+func dataVersion(ctx context.Context, conn *sql.Conn) (int64, error) {
+	var version int64
+	if err := conn.QueryRowContext(ctx, "PRAGMA data_version").Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read data_version: %w", err)
+	}
+	return version, nil
+}
+
+func placeVersions(ctx context.Context, conn *sql.Conn) (map[string]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT id, updated_at FROM places")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read places: %w", err)
+	}
+	defer rows.Close()
+
+	versions := make(map[string]time.Time)
+	for rows.Next() {
+		var id string
+		var updatedAt sql.NullTime
+		if err := rows.Scan(&id, &updatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan place: %w", err)
+		}
+		versions[id] = updatedAt.Time
+	}
+	return versions, rows.Err()
+}
@@ -0,0 +1,82 @@
+package database
+
+import "math"
+
+const (
+	geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+	// geohashPrecision is how many characters are stored per place, about
+	// 4cm; shorter prefixes name larger cells
+	geohashPrecision = 12
+)
+
+// geohash encodes a point as a geohash: each character narrows the cell
+// down by 5 bits, alternating between longitude and latitude, so places
+// in the same cell share a prefix
+func geohash(lat, lng float64, precision int) string {
+	latLo, latHi := -90.0, 90.0
+	lngLo, lngHi := -180.0, 180.0
+
+	hash := make([]byte, 0, precision)
+	bit, ch := 0, 0
+	even := true
+	for len(hash) < precision {
+		if even {
+			mid := (lngLo + lngHi) / 2
+			if lng >= mid {
+				ch = ch<<1 | 1
+				lngLo = mid
+			} else {
+				ch <<= 1
+				lngHi = mid
+			}
+		} else {
+			mid := (latLo + latHi) / 2
+			if lat >= mid {
+				ch = ch<<1 | 1
+				latLo = mid
+			} else {
+				ch <<= 1
+				latHi = mid
+			}
+		}
+		even = !even
+
+		if bit++; bit == 5 {
+			hash = append(hash, geohashAlphabet[ch])
+			bit, ch = 0, 0
+		}
+	}
+	return string(hash)
+}
+
+// placeGeohash is the geohash stored for a place, empty for places
+// without coordinates
+func placeGeohash(lat, lng float64) string {
+	if lat == 0 && lng == 0 {
+		return ""
+	}
+	return geohash(lat, lng, geohashPrecision)
+}
+
+// geohashCellWidth is the width in degrees of longitude of the cells named
+// by geohashes of a length
+func geohashCellWidth(length int) float64 {
+	lngBits := (5*length + 1) / 2
+	return 360 / math.Exp2(float64(lngBits))
+}
+
+// clusterPrecision picks the geohash length whose cells are closest to
+// clusterCellPixels wide on a web map at a zoom level
+func clusterPrecision(zoom int) int {
+	// A 256 pixel tile spans 360/2^zoom degrees
+	target := 360 / math.Exp2(float64(zoom)) * clusterCellPixels / 256
+
+	best := 1
+	for length := 2; length <= geohashPrecision; length++ {
+		if math.Abs(math.Log(geohashCellWidth(length)/target)) < math.Abs(math.Log(geohashCellWidth(best)/target)) {
+			best = length
+		}
+	}
+	return best
+}
@@ -1,32 +1,48 @@
 package database
 
 import (
-	"github.com/user/placeli/internal/constants"
 	"github.com/user/placeli/internal/models"
 )
 
-// ForEachPlace applies a function to all places, with optional filtering
-func (db *DB) ForEachPlace(filter string, fn func(*models.Place) error) error {
-	var places []*models.Place
-	var err error
+// forEachBatch is how many places ForEachPlace loads at a time
+const forEachBatch = 1000
 
+// ForEachPlace applies a function to all places, with optional filtering.
+// Without a filter, places are loaded a batch at a time in id order, so
+// every place is visited however many there are, and fn may save them.
+func (db *DB) ForEachPlace(filter string, fn func(*models.Place) error) error {
 	if filter != "" {
-		places, err = db.SearchPlaces(filter)
-	} else {
-		places, err = db.ListPlaces(constants.DefaultPlaceLimit, 0)
-	}
-
-	if err != nil {
-		return err
+		places, err := db.SearchPlaces(filter)
+		if err != nil {
+			return err
+		}
+		for _, place := range places {
+			if err := fn(place); err != nil {
+				return err
+			}
+		}
+		return nil
 	}
 
-	for _, place := range places {
-		if err := fn(place); err != nil {
+	after := ""
+	for {
+		places, err := db.queryPlaces(`
+			WHERE p.id > ?
+			ORDER BY p.id
+			LIMIT ?`, after, forEachBatch)
+		if err != nil {
 			return err
 		}
+		for _, place := range places {
+			if err := fn(place); err != nil {
+				return err
+			}
+		}
+		if len(places) < forEachBatch {
+			return nil
+		}
+		after = places[len(places)-1].ID
 	}
-
-	return nil
 }
 
 // CountPlaces returns the total number of places in the database
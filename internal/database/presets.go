@@ -0,0 +1,88 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ImportPreset is a saved column mapping for importing spreadsheets or
+// GeoJSON files that share a layout
+type ImportPreset struct {
+	Name      string
+	Source    string // import source the mapping is for
+	Mapping   string // mapping spec, as given to import --map
+	UpdatedAt time.Time
+}
+
+// SaveImportPreset stores a preset, replacing any with the same name
+func (db *DB) SaveImportPreset(preset *ImportPreset) error {
+	preset.UpdatedAt = time.Now()
+	_, err := db.conn.Exec(`
+		INSERT INTO import_presets (name, source, mapping, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET
+			source = excluded.source,
+			mapping = excluded.mapping,
+			updated_at = excluded.updated_at`,
+		preset.Name, preset.Source, preset.Mapping, preset.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save import preset: %w", err)
+	}
+	return nil
+}
+
+// GetImportPreset returns the preset with the given name
+func (db *DB) GetImportPreset(name string) (*ImportPreset, error) {
+	var preset ImportPreset
+	var updatedAt sql.NullTime
+	err := db.conn.QueryRow(`
+		SELECT name, source, mapping, updated_at
+		FROM import_presets
+		WHERE name = ?`, name).Scan(&preset.Name, &preset.Source, &preset.Mapping, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("import preset %q not found", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get import preset: %w", err)
+	}
+	preset.UpdatedAt = updatedAt.Time
+	return &preset, nil
+}
+
+// ListImportPresets returns all presets sorted by name
+func (db *DB) ListImportPresets() ([]*ImportPreset, error) {
+	rows, err := db.conn.Query(`
+		SELECT name, source, mapping, updated_at
+		FROM import_presets
+		ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list import presets: %w", err)
+	}
+	defer rows.Close()
+
+	var presets []*ImportPreset
+	for rows.Next() {
+		var preset ImportPreset
+		var updatedAt sql.NullTime
+		if err := rows.Scan(&preset.Name, &preset.Source, &preset.Mapping, &updatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan import preset: %w", err)
+		}
+		preset.UpdatedAt = updatedAt.Time
+		presets = append(presets, &preset)
+	}
+	return presets, rows.Err()
+}
+
+// DeleteImportPreset removes a preset
+func (db *DB) DeleteImportPreset(name string) error {
+	result, err := db.conn.Exec("DELETE FROM import_presets WHERE name = ?", name)
+	if err != nil {
+		return fmt.Errorf("failed to delete import preset: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("import preset %q not found", name)
+	}
+	return nil
+}
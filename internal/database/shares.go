@@ -0,0 +1,200 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/user/placeli/internal/models"
+)
+
+var (
+	// ErrInvalidShare is returned for unknown share links
+	ErrInvalidShare = errors.New("invalid share link")
+
+	// ErrShareExpired is returned for share links that have expired or
+	// been revoked
+	ErrShareExpired = errors.New("share link has expired or was revoked")
+)
+
+// SharePolicy says what a share link shows besides a place's public
+// details. Notes, custom fields and other tags are private unless the
+// policy lets them through; attachments, photos and visits never are.
+type SharePolicy struct {
+	Notes bool `json:"notes,omitempty"`
+	// Fields are the custom fields shown, or "*" for all of them
+	Fields []string `json:"fields,omitempty"`
+	// Tags shows the places' other tags as well as the shared one
+	Tags bool `json:"tags,omitempty"`
+}
+
+// Share is a read-only link to the places with a tag. Like API tokens,
+// only a hash of the link's secret is stored.
+type Share struct {
+	ID        string
+	Tag       string
+	Policy    SharePolicy
+	User      string // whose tags and notes the link shows
+	CreatedAt time.Time
+	ExpiresAt *time.Time
+	RevokedAt *time.Time
+}
+
+// Active reports whether the link still works
+func (s *Share) Active(now time.Time) bool {
+	return s.RevokedAt == nil && (s.ExpiresAt == nil || now.Before(*s.ExpiresAt))
+}
+
+// Redact returns a copy of a place with what the policy keeps private
+// removed
+func (s *Share) Redact(place *models.Place) *models.Place {
+	shared := *place
+	shared.Photos = nil
+	shared.Attachments = nil
+	shared.Visits = nil
+	shared.SourceHash = ""
+	shared.ImportedAt = nil
+
+	if !s.Policy.Notes {
+		shared.UserNotes = ""
+	}
+
+	if !s.Policy.Tags {
+		shared.UserTags = nil
+		shared.SharedTags = nil
+		if place.HasTag(s.Tag) {
+			shared.UserTags = []string{s.Tag}
+		} else {
+			shared.SharedTags = []string{s.Tag}
+		}
+	}
+
+	shared.CustomFields = map[string]interface{}{}
+	for name, value := range place.CustomFields {
+		if containsString(s.Policy.Fields, "*") || containsString(s.Policy.Fields, name) {
+			shared.CustomFields[name] = value
+		}
+	}
+	return &shared
+}
+
+// CreateShare creates a link to the places the handle's user has tagged
+// with tag, and returns it with its secret. A nil expiresAt never expires.
+func (db *DB) CreateShare(tag string, policy SharePolicy, expiresAt *time.Time) (*Share, string, error) {
+	if tag == "" {
+		return nil, "", fmt.Errorf("tag is required")
+	}
+	policyJSON, err := json.Marshal(policy)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode share policy: %w", err)
+	}
+
+	id, err := randomHex(idBytes)
+	if err != nil {
+		return nil, "", err
+	}
+	// Links go in URLs, so the secret is left without the token prefix
+	secret, err := randomHex(24)
+	if err != nil {
+		return nil, "", err
+	}
+
+	share := &Share{ID: id, Tag: tag, Policy: policy, User: db.user, CreatedAt: time.Now(), ExpiresAt: expiresAt}
+	_, err = db.conn.Exec(`
+		INSERT INTO shares (id, tag, policy, token_hash, user_id, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		share.ID, share.Tag, string(policyJSON), hashToken(secret), share.User, share.CreatedAt, share.ExpiresAt)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create share: %w", err)
+	}
+	return share, secret, nil
+}
+
+// GetShareBySecret returns the share a link's secret belongs to. It
+// returns ErrInvalidShare for unknown secrets and ErrShareExpired for
+// links that no longer work.
+func (db *DB) GetShareBySecret(secret string) (*Share, error) {
+	row := db.conn.QueryRow(`
+		SELECT id, tag, policy, user_id, created_at, expires_at, revoked_at
+		FROM shares
+		WHERE token_hash = ?`, hashToken(secret))
+	share, err := scanShare(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidShare
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up share: %w", err)
+	}
+	if !share.Active(time.Now()) {
+		return nil, ErrShareExpired
+	}
+	return share, nil
+}
+
+// ListShares returns all share links, oldest first
+func (db *DB) ListShares() ([]*Share, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, tag, policy, user_id, created_at, expires_at, revoked_at
+		FROM shares
+		ORDER BY created_at, id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list shares: %w", err)
+	}
+	defer rows.Close()
+
+	var shares []*Share
+	for rows.Next() {
+		share, err := scanShare(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan share: %w", err)
+		}
+		shares = append(shares, share)
+	}
+	return shares, rows.Err()
+}
+
+// RevokeShare revokes the share link with the given ID
+func (db *DB) RevokeShare(id string) (*Share, error) {
+	row := db.conn.QueryRow(`
+		SELECT id, tag, policy, user_id, created_at, expires_at, revoked_at
+		FROM shares
+		WHERE id = ? AND revoked_at IS NULL`, id)
+	share, err := scanShare(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("no active share %q", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get share: %w", err)
+	}
+
+	now := time.Now()
+	if _, err := db.conn.Exec("UPDATE shares SET revoked_at = ? WHERE id = ?", now, share.ID); err != nil {
+		return nil, fmt.Errorf("failed to revoke share: %w", err)
+	}
+	share.RevokedAt = &now
+	return share, nil
+}
+
+func scanShare(scanner interface {
+	Scan(dest ...interface{}) error
+}) (*Share, error) {
+	var share Share
+	var policy string
+	var createdAt, expiresAt, revokedAt sql.NullTime
+	if err := scanner.Scan(&share.ID, &share.Tag, &policy, &share.User, &createdAt, &expiresAt, &revokedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(policy), &share.Policy); err != nil {
+		return nil, fmt.Errorf("invalid policy for share %s: %w", share.ID, err)
+	}
+	share.CreatedAt = createdAt.Time
+	if expiresAt.Valid {
+		share.ExpiresAt = &expiresAt.Time
+	}
+	if revokedAt.Valid {
+		share.RevokedAt = &revokedAt.Time
+	}
+	return &share, nil
+}
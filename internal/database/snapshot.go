@@ -0,0 +1,163 @@
+package database
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/user/placeli/internal/models"
+)
+
+// sharedPlace holds the fields of a place that all users share. Empty
+// values are left out, so a nil list and an empty one compare equal.
+type sharedPlace struct {
+	PlaceID     string             `json:"place_id,omitempty"`
+	Name        string             `json:"name,omitempty"`
+	Address     string             `json:"address,omitempty"`
+	Coordinates models.Coordinates `json:"coordinates"`
+	Categories  []string           `json:"categories,omitempty"`
+
+	Photos       []models.Photo       `json:"photos,omitempty"`
+	Reviews      []models.Review      `json:"reviews,omitempty"`
+	Rating       float32              `json:"rating,omitempty"`
+	UserRatings  int                  `json:"user_ratings,omitempty"`
+	PriceLevel   int                  `json:"price_level,omitempty"`
+	Hours        string               `json:"hours,omitempty"`
+	Phone        string               `json:"phone,omitempty"`
+	Website      string               `json:"website,omitempty"`
+	OpeningHours *models.OpeningHours `json:"opening_hours,omitempty"`
+	Attachments  []models.Attachment  `json:"attachments,omitempty"`
+	Geometry     *models.Geometry     `json:"geometry,omitempty"`
+
+	ImportedAt *time.Time `json:"imported_at,omitempty"`
+	SourceHash string     `json:"source_hash,omitempty"`
+	SharedTags []string   `json:"shared_tags,omitempty"`
+}
+
+func sharedOf(p *models.Place) sharedPlace {
+	return sharedPlace{
+		PlaceID:      p.PlaceID,
+		Name:         p.Name,
+		Address:      p.Address,
+		Coordinates:  p.Coordinates,
+		Categories:   p.Categories,
+		Photos:       p.Photos,
+		Reviews:      p.Reviews,
+		Rating:       p.Rating,
+		UserRatings:  p.UserRatings,
+		PriceLevel:   p.PriceLevel,
+		Hours:        p.Hours,
+		Phone:        p.Phone,
+		Website:      p.Website,
+		OpeningHours: p.OpeningHours,
+		Attachments:  p.Attachments,
+		Geometry:     p.Geometry,
+		ImportedAt:   p.ImportedAt,
+		SourceHash:   p.SourceHash,
+		SharedTags:   p.SharedTags,
+	}
+}
+
+func (s sharedPlace) applyTo(p *models.Place) {
+	p.PlaceID = s.PlaceID
+	p.Name = s.Name
+	p.Address = s.Address
+	p.Coordinates = s.Coordinates
+	p.Categories = s.Categories
+	p.Photos = s.Photos
+	p.Reviews = s.Reviews
+	p.Rating = s.Rating
+	p.UserRatings = s.UserRatings
+	p.PriceLevel = s.PriceLevel
+	p.Hours = s.Hours
+	p.Phone = s.Phone
+	p.Website = s.Website
+	p.OpeningHours = s.OpeningHours
+	p.Attachments = s.Attachments
+	p.Geometry = s.Geometry
+	p.ImportedAt = s.ImportedAt
+	p.SourceHash = s.SourceHash
+	p.SharedTags = s.SharedTags
+}
+
+// snapshot records the place's shared fields, see models.Place.Snapshot
+func snapshot(p *models.Place) {
+	p.Snapshot, _ = json.Marshal(sharedOf(p))
+}
+
+// sharedFields splits shared fields into their JSON members
+func sharedFields(s sharedPlace) map[string]json.RawMessage {
+	data, _ := json.Marshal(s)
+	fields := make(map[string]json.RawMessage)
+	_ = json.Unmarshal(data, &fields)
+	return fields
+}
+
+// mergeShared makes place's shared fields those of current, the place as
+// stored, with the fields place changed since its snapshot on top. Shared
+// tags merge tag by tag. It returns the tags to add and remove.
+func mergeShared(place, current *models.Place) (added, removed []string) {
+	var base *sharedPlace
+	if place.Snapshot != nil {
+		base = &sharedPlace{}
+		if err := json.Unmarshal(place.Snapshot, base); err != nil {
+			base = nil
+		}
+	}
+
+	mine := sharedFields(sharedOf(place))
+	merged := mine
+	baseTags := current.SharedTags
+	if base != nil {
+		baseTags = base.SharedTags
+		baseFields := sharedFields(*base)
+		merged = sharedFields(sharedOf(current))
+		for _, fields := range []map[string]json.RawMessage{mine, baseFields} {
+			for key := range fields {
+				if bytes.Equal(mine[key], baseFields[key]) {
+					continue
+				}
+				if value, ok := mine[key]; ok {
+					merged[key] = value
+				} else {
+					delete(merged, key)
+				}
+			}
+		}
+	}
+
+	data, _ := json.Marshal(merged)
+	var result sharedPlace
+	_ = json.Unmarshal(data, &result)
+
+	added = missingFrom(baseTags, place.SharedTags)
+	removed = missingFrom(place.SharedTags, baseTags)
+	result.SharedTags = nil
+	for _, tag := range current.SharedTags {
+		if !containsString(removed, tag) {
+			result.SharedTags = append(result.SharedTags, tag)
+		}
+	}
+	for _, tag := range added {
+		if !containsString(result.SharedTags, tag) {
+			result.SharedTags = append(result.SharedTags, tag)
+		}
+	}
+	// In the order they load in
+	sort.Strings(result.SharedTags)
+
+	result.applyTo(place)
+	return added, removed
+}
+
+// missingFrom returns the items of list that aren't in other
+func missingFrom(other, list []string) []string {
+	var missing []string
+	for _, item := range list {
+		if !containsString(other, item) {
+			missing = append(missing, item)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,234 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/user/placeli/internal/models"
+)
+
+const (
+	// ClusterMaxZoom is the map zoom level from which places are shown one
+	// by one however many there are
+	ClusterMaxZoom = 15
+
+	// clusterCellPixels is about how wide the area a cluster gathers
+	// places from is on screen
+	clusterCellPixels = 80
+
+	// unclusteredLimit is how many places a view may hold and still show
+	// them one by one at any zoom level
+	unclusteredLimit = 200
+
+	// maxViewPlaces caps the places returned for one view
+	maxViewPlaces = 2000
+)
+
+// PlaceSummary is the part of a place a map needs to draw it
+type PlaceSummary struct {
+	ID          string             `json:"id"`
+	Name        string             `json:"name"`
+	Coordinates models.Coordinates `json:"coordinates"`
+	Rating      float32            `json:"rating,omitempty"`
+	Categories  []string           `json:"categories,omitempty"`
+	Tags        []string           `json:"tags,omitempty"`
+	Geometry    *models.Geometry   `json:"geometry,omitempty"`
+}
+
+// Cluster stands for several places close together on the map
+type Cluster struct {
+	// Coordinates is the places' mean position
+	Coordinates models.Coordinates `json:"coordinates"`
+	Count       int                `json:"count"`
+	// Min and Max bound the places, so a map can zoom in on them
+	Min models.Coordinates `json:"min"`
+	Max models.Coordinates `json:"max"`
+}
+
+// MapView is what a map needs to draw the places in its view
+type MapView struct {
+	Total    int            `json:"total"`
+	Clusters []Cluster      `json:"clusters"`
+	Places   []PlaceSummary `json:"places"`
+	// Truncated is set when the view holds more places than were returned
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// viewWhere limits a query to places with coordinates between two
+// corners. The lat range uses idx_places_coordinates.
+const viewWhere = `
+		WHERE p.lat BETWEEN ? AND ? AND p.lng BETWEEN ? AND ? AND p.geohash != ''`
+
+// PlacesInView returns the places between the south-west corner min and
+// the north-east corner max for a map at a zoom level. Below
+// ClusterMaxZoom, places that would crowd each other are grouped into
+// clusters by geohash cell, unless the view holds only a few places.
+func (db *DB) PlacesInView(min, max models.Coordinates, zoom int) (*MapView, error) {
+	view := &MapView{Clusters: []Cluster{}, Places: []PlaceSummary{}}
+	bounds := []interface{}{min.Lat, max.Lat, min.Lng, max.Lng}
+
+	err := db.conn.QueryRow("SELECT COUNT(*) FROM places p"+viewWhere, bounds...).Scan(&view.Total)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count places in view: %w", err)
+	}
+
+	if zoom >= ClusterMaxZoom || view.Total <= unclusteredLimit {
+		view.Places, err = db.PlaceSummariesIn(min, max, maxViewPlaces+1)
+		if err != nil {
+			return nil, err
+		}
+		if len(view.Places) > maxViewPlaces {
+			view.Places = view.Places[:maxViewPlaces]
+			view.Truncated = true
+		}
+		return view, nil
+	}
+
+	precision := clusterPrecision(zoom)
+	rows, err := db.conn.Query(`
+		SELECT COUNT(*), AVG(p.lat), AVG(p.lng),
+			MIN(p.lat), MIN(p.lng), MAX(p.lat), MAX(p.lng)
+		FROM places p`+viewWhere+`
+		GROUP BY substr(p.geohash, 1, ?)
+		HAVING COUNT(*) > 1`,
+		append(bounds, precision)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cluster places: %w", err)
+	}
+	clustered := 0
+	for rows.Next() {
+		var c Cluster
+		if err := rows.Scan(&c.Count, &c.Coordinates.Lat, &c.Coordinates.Lng,
+			&c.Min.Lat, &c.Min.Lng, &c.Max.Lat, &c.Max.Lng); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan cluster: %w", err)
+		}
+		view.Clusters = append(view.Clusters, c)
+		clustered += c.Count
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Cells with one place show that place rather than a cluster of one.
+	// The cells are found again in SQL rather than passing their ids back.
+	if clustered < view.Total {
+		view.Places, err = db.placeSummaries(`
+		WHERE p.id IN (
+			SELECT MIN(p.id) FROM places p`+viewWhere+`
+			GROUP BY substr(p.geohash, 1, ?)
+			HAVING COUNT(*) = 1)
+		ORDER BY p.name
+		LIMIT ?`, append(bounds, precision, maxViewPlaces+1)...)
+		if err != nil {
+			return nil, err
+		}
+		if len(view.Places) > maxViewPlaces {
+			view.Places = view.Places[:maxViewPlaces]
+			view.Truncated = true
+		}
+	}
+	return view, nil
+}
+
+// PlaceSummariesIn returns up to limit places between the south-west
+// corner min and the north-east corner max, ordered by name
+func (db *DB) PlaceSummariesIn(min, max models.Coordinates, limit int) ([]PlaceSummary, error) {
+	return db.placeSummaries(viewWhere+`
+		ORDER BY p.name
+		LIMIT ?`, min.Lat, max.Lat, min.Lng, max.Lng, limit)
+}
+
+// placeSummaries selects place summaries with the handle's user's tags,
+// reading only the fields they need from the data column
+func (db *DB) placeSummaries(where string, args ...interface{}) ([]PlaceSummary, error) {
+	rows, err := db.conn.Query(`
+		SELECT p.id, p.name, p.lat, p.lng,
+			COALESCE(json_extract(p.data, '$.rating'), 0), p.categories,
+			ud.tags, `+sharedTagsColumn+`, json_extract(p.data, '$.geometry')
+		FROM places p
+		LEFT JOIN user_data ud ON p.id = ud.place_id AND ud.user_id = ?`+where,
+		append([]interface{}{db.user}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query places: %w", err)
+	}
+	defer rows.Close()
+
+	summaries := []PlaceSummary{}
+	for rows.Next() {
+		var s PlaceSummary
+		var categories string
+		var tags, sharedTags, geometry sql.NullString
+		if err := rows.Scan(&s.ID, &s.Name, &s.Coordinates.Lat, &s.Coordinates.Lng,
+			&s.Rating, &categories, &tags, &sharedTags, &geometry); err != nil {
+			return nil, fmt.Errorf("failed to scan place: %w", err)
+		}
+
+		_ = json.Unmarshal([]byte(categories), &s.Categories)
+		if tags.Valid {
+			_ = json.Unmarshal([]byte(tags.String), &s.Tags)
+		}
+		if sharedTags.Valid {
+			var shared []string
+			_ = json.Unmarshal([]byte(sharedTags.String), &shared)
+			for _, tag := range shared {
+				if !containsString(s.Tags, tag) {
+					s.Tags = append(s.Tags, tag)
+				}
+			}
+		}
+		if geometry.Valid {
+			var g models.Geometry
+			if err := json.Unmarshal([]byte(geometry.String), &g); err == nil {
+				s.Geometry = &g
+			}
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries, rows.Err()
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// backfillGeohashes indexes places saved before the geohash column existed
+func (db *DB) backfillGeohashes() error {
+	rows, err := db.conn.Query("SELECT id, lat, lng FROM places WHERE geohash IS NULL")
+	if err != nil {
+		return err
+	}
+	hashes := make(map[string]string)
+	for rows.Next() {
+		var id string
+		var lat, lng sql.NullFloat64
+		if err := rows.Scan(&id, &lat, &lng); err != nil {
+			rows.Close()
+			return err
+		}
+		hashes[id] = placeGeohash(lat.Float64, lng.Float64)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || len(hashes) == 0 {
+		return err
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for id, hash := range hashes {
+		if _, err := tx.Exec("UPDATE places SET geohash = ? WHERE id = ?", hash, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
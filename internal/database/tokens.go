@@ -0,0 +1,206 @@
+package database
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Token scopes. Read tokens may only use safe methods; write tokens may
+// also change data.
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
+// tokenPrefix marks placeli secrets so they are easy to spot in configs
+const tokenPrefix = "plc_"
+
+// idBytes is how many random bytes token and share IDs have. IDs are
+// shown to users and used to revoke, so they must not collide.
+const idBytes = 8
+
+// ErrInvalidToken is returned for unknown and revoked tokens
+var ErrInvalidToken = errors.New("invalid or revoked token")
+
+// APIToken is a bearer token for the web server. Only a hash of the secret
+// is stored; the secret itself is shown once, when the token is created.
+type APIToken struct {
+	ID         string
+	Name       string
+	Scope      string
+	User       string // whose notes, tags and visits the token uses
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
+
+// CanWrite reports whether the token may change data
+func (t *APIToken) CanWrite() bool {
+	return t.Scope == ScopeWrite
+}
+
+// Revoked reports whether the token has been revoked
+func (t *APIToken) Revoked() bool {
+	return t.RevokedAt != nil
+}
+
+// CreateAPIToken creates a token for the handle's user and returns it with
+// its secret. Names must be unique among tokens that aren't revoked.
+func (db *DB) CreateAPIToken(name, scope string) (*APIToken, string, error) {
+	if name == "" {
+		return nil, "", fmt.Errorf("token name is required")
+	}
+	if scope != ScopeRead && scope != ScopeWrite {
+		return nil, "", fmt.Errorf("invalid scope %q (use %s or %s)", scope, ScopeRead, ScopeWrite)
+	}
+
+	var existing int
+	if err := db.conn.QueryRow(
+		"SELECT COUNT(*) FROM api_tokens WHERE name = ? AND revoked_at IS NULL", name,
+	).Scan(&existing); err != nil {
+		return nil, "", fmt.Errorf("failed to check token name: %w", err)
+	}
+	if existing > 0 {
+		return nil, "", fmt.Errorf("a token named %q already exists", name)
+	}
+
+	id, err := randomHex(idBytes)
+	if err != nil {
+		return nil, "", err
+	}
+	secretHex, err := randomHex(24)
+	if err != nil {
+		return nil, "", err
+	}
+	secret := tokenPrefix + secretHex
+
+	token := &APIToken{ID: id, Name: name, Scope: scope, User: db.user, CreatedAt: time.Now()}
+	_, err = db.conn.Exec(`
+		INSERT INTO api_tokens (id, name, scope, token_hash, user_id, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		token.ID, token.Name, token.Scope, hashToken(secret), token.User, token.CreatedAt)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create token: %w", err)
+	}
+	return token, secret, nil
+}
+
+// AuthenticateAPIToken returns the token a secret belongs to and records
+// its use. It returns ErrInvalidToken for unknown or revoked secrets.
+func (db *DB) AuthenticateAPIToken(secret string) (*APIToken, error) {
+	row := db.conn.QueryRow(`
+		SELECT id, name, scope, user_id, created_at, last_used_at, revoked_at
+		FROM api_tokens
+		WHERE token_hash = ?`, hashToken(secret))
+	token, err := scanAPIToken(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up token: %w", err)
+	}
+	if token.Revoked() {
+		return nil, ErrInvalidToken
+	}
+
+	now := time.Now()
+	token.LastUsedAt = &now
+	_, _ = db.conn.Exec("UPDATE api_tokens SET last_used_at = ? WHERE id = ?", now, token.ID)
+	return token, nil
+}
+
+// GetAPIToken returns the token with the given ID, revoked or not
+func (db *DB) GetAPIToken(id string) (*APIToken, error) {
+	row := db.conn.QueryRow(`
+		SELECT id, name, scope, user_id, created_at, last_used_at, revoked_at
+		FROM api_tokens
+		WHERE id = ?`, id)
+	token, err := scanAPIToken(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("token %q not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token: %w", err)
+	}
+	return token, nil
+}
+
+// ListAPITokens returns all tokens, oldest first
+func (db *DB) ListAPITokens() ([]*APIToken, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, name, scope, user_id, created_at, last_used_at, revoked_at
+		FROM api_tokens
+		ORDER BY created_at, id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []*APIToken
+	for rows.Next() {
+		token, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan token: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+// RevokeAPIToken revokes the active token with the given ID or name
+func (db *DB) RevokeAPIToken(idOrName string) (*APIToken, error) {
+	row := db.conn.QueryRow(`
+		SELECT id, name, scope, user_id, created_at, last_used_at, revoked_at
+		FROM api_tokens
+		WHERE (id = ? OR name = ?) AND revoked_at IS NULL`, idOrName, idOrName)
+	token, err := scanAPIToken(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("no active token %q", idOrName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token: %w", err)
+	}
+
+	now := time.Now()
+	if _, err := db.conn.Exec("UPDATE api_tokens SET revoked_at = ? WHERE id = ?", now, token.ID); err != nil {
+		return nil, fmt.Errorf("failed to revoke token: %w", err)
+	}
+	token.RevokedAt = &now
+	return token, nil
+}
+
+func scanAPIToken(scanner interface {
+	Scan(dest ...interface{}) error
+}) (*APIToken, error) {
+	var token APIToken
+	var createdAt, lastUsedAt, revokedAt sql.NullTime
+	if err := scanner.Scan(&token.ID, &token.Name, &token.Scope, &token.User, &createdAt, &lastUsedAt, &revokedAt); err != nil {
+		return nil, err
+	}
+	token.CreatedAt = createdAt.Time
+	if lastUsedAt.Valid {
+		token.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+	return &token, nil
+}
+
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
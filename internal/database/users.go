@@ -0,0 +1,108 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// User is someone with their own notes, tags, custom fields and visits
+// on the shared places. The default user, with the empty name, always
+// exists and is not stored.
+type User struct {
+	Name      string
+	CreatedAt time.Time
+}
+
+var userNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// AddUser creates a user
+func (db *DB) AddUser(name string) (*User, error) {
+	if !userNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid user name %q (use letters, digits, '.', '_' and '-')", name)
+	}
+	if exists, err := db.UserExists(name); err != nil {
+		return nil, err
+	} else if exists {
+		return nil, fmt.Errorf("user %q already exists", name)
+	}
+
+	user := &User{Name: name, CreatedAt: time.Now()}
+	if _, err := db.conn.Exec("INSERT INTO users (name, created_at) VALUES (?, ?)", user.Name, user.CreatedAt); err != nil {
+		return nil, fmt.Errorf("failed to add user: %w", err)
+	}
+	return user, nil
+}
+
+// UserExists reports whether a user exists. The default user always does.
+func (db *DB) UserExists(name string) (bool, error) {
+	if name == "" {
+		return true, nil
+	}
+	var count int
+	if err := db.conn.QueryRow("SELECT COUNT(*) FROM users WHERE name = ?", name).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to look up user: %w", err)
+	}
+	return count > 0, nil
+}
+
+// ListUsers returns the stored users sorted by name
+func (db *DB) ListUsers() ([]*User, error) {
+	rows, err := db.conn.Query("SELECT name, created_at FROM users ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*User
+	for rows.Next() {
+		var user User
+		if err := rows.Scan(&user.Name, &user.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, &user)
+	}
+	return users, rows.Err()
+}
+
+// RemoveUser deletes a user with their notes, tags, custom fields and
+// visits, and revokes their API tokens and share links. Shared places are
+// kept.
+func (db *DB) RemoveUser(name string) error {
+	if name == "" {
+		return errors.New("the default user can't be removed")
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	result, err := tx.Exec("DELETE FROM users WHERE name = ?", name)
+	if err != nil {
+		return fmt.Errorf("failed to remove user: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("user %q not found", name)
+	}
+	for _, query := range []string{
+		"DELETE FROM user_data WHERE user_id = ?",
+		"DELETE FROM visits WHERE user_id = ?",
+	} {
+		if _, err := tx.Exec(query, name); err != nil {
+			return fmt.Errorf("failed to remove user data: %w", err)
+		}
+	}
+	for _, table := range []string{"api_tokens", "shares"} {
+		if _, err := tx.Exec(
+			"UPDATE "+table+" SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL", time.Now(), name,
+		); err != nil {
+			return fmt.Errorf("failed to revoke %s: %w", table, err)
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,151 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/user/placeli/internal/models"
+)
+
+// Visit times are stored as UTC RFC 3339 text so they sort and compare
+// correctly in SQL
+const visitTimeFormat = time.RFC3339
+
+// VisitFilter narrows ListVisits. Zero values match everything.
+type VisitFilter struct {
+	PlaceID string
+	From    time.Time // inclusive
+	To      time.Time // exclusive
+}
+
+// AddVisit logs a visit for the handle's user and sets its ID. Visits with a Source and SourceID
+// that are already logged are ignored; the returned bool reports whether
+// the visit was added.
+func (db *DB) AddVisit(visit *models.Visit) (bool, error) {
+	companions, _ := json.Marshal(visit.Companions)
+
+	result, err := db.conn.Exec(`
+		INSERT OR IGNORE INTO visits (place_id, visited_at, companions, rating, note, source, source_id, user_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		visit.PlaceID, visit.VisitedAt.UTC().Format(visitTimeFormat), string(companions),
+		visit.Rating, visit.Note, visit.Source, visit.SourceID, db.user)
+	if err != nil {
+		return false, fmt.Errorf("failed to add visit: %w", err)
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return false, nil
+	}
+	visit.ID, _ = result.LastInsertId()
+	return true, nil
+}
+
+// AddVisits logs visits to one place and returns how many were new
+func (db *DB) AddVisits(placeID string, visits []models.Visit) (int, error) {
+	added := 0
+	for i := range visits {
+		visits[i].PlaceID = placeID
+		ok, err := db.AddVisit(&visits[i])
+		if err != nil {
+			return added, err
+		}
+		if ok {
+			added++
+		}
+	}
+	return added, nil
+}
+
+// ListVisits returns the user's visits matching the filter, most recent
+// first
+func (db *DB) ListVisits(filter VisitFilter) ([]*models.Visit, error) {
+	where := []string{"user_id = ?"}
+	args := []interface{}{db.user}
+	if filter.PlaceID != "" {
+		where = append(where, "place_id = ?")
+		args = append(args, filter.PlaceID)
+	}
+	if !filter.From.IsZero() {
+		where = append(where, "visited_at >= ?")
+		args = append(args, filter.From.UTC().Format(visitTimeFormat))
+	}
+	if !filter.To.IsZero() {
+		where = append(where, "visited_at < ?")
+		args = append(args, filter.To.UTC().Format(visitTimeFormat))
+	}
+
+	query := `SELECT id, place_id, visited_at, companions, rating, note, source, source_id FROM visits`
+	query += " WHERE " + strings.Join(where, " AND ")
+	query += " ORDER BY visited_at DESC, id DESC"
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query visits: %w", err)
+	}
+	defer rows.Close()
+
+	var visits []*models.Visit
+	for rows.Next() {
+		var visit models.Visit
+		var visitedAt string
+		var companions, note sql.NullString
+		if err := rows.Scan(&visit.ID, &visit.PlaceID, &visitedAt, &companions,
+			&visit.Rating, &note, &visit.Source, &visit.SourceID); err != nil {
+			return nil, fmt.Errorf("failed to scan visit: %w", err)
+		}
+		visit.VisitedAt, err = time.Parse(visitTimeFormat, visitedAt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid visit time %q: %w", visitedAt, err)
+		}
+		if companions.Valid {
+			_ = json.Unmarshal([]byte(companions.String), &visit.Companions)
+		}
+		visit.Note = note.String
+		visits = append(visits, &visit)
+	}
+
+	return visits, rows.Err()
+}
+
+// DeleteVisit removes one of the user's logged visits
+func (db *DB) DeleteVisit(id int64) error {
+	result, err := db.conn.Exec("DELETE FROM visits WHERE id = ? AND user_id = ?", id, db.user)
+	if err != nil {
+		return fmt.Errorf("failed to delete visit: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("visit %d not found", id)
+	}
+	return nil
+}
+
+// GetVisitSummaries returns the user's visit count and first/last visit
+// per place, keyed by place ID. Places without visits are absent.
+func (db *DB) GetVisitSummaries() (map[string]models.VisitSummary, error) {
+	rows, err := db.conn.Query(`
+		SELECT place_id, COUNT(*), MIN(visited_at), MAX(visited_at)
+		FROM visits
+		WHERE user_id = ?
+		GROUP BY place_id`, db.user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query visit summaries: %w", err)
+	}
+	defer rows.Close()
+
+	summaries := make(map[string]models.VisitSummary)
+	for rows.Next() {
+		var placeID, first, last string
+		var summary models.VisitSummary
+		if err := rows.Scan(&placeID, &summary.Count, &first, &last); err != nil {
+			return nil, fmt.Errorf("failed to scan visit summary: %w", err)
+		}
+		summary.First, _ = time.Parse(visitTimeFormat, first)
+		summary.Last, _ = time.Parse(visitTimeFormat, last)
+		summaries[placeID] = summary
+	}
+
+	return summaries, rows.Err()
+}
@@ -15,6 +15,7 @@ const (
 	FormatGeoJSON  Format = "geojson"
 	FormatJSON     Format = "json"
 	FormatMarkdown Format = "markdown"
+	FormatICS      Format = "ics"
 )
 
 func Export(places []*models.Place, format Format, writer io.Writer) error {
@@ -27,17 +28,30 @@ func Export(places []*models.Place, format Format, writer io.Writer) error {
 		return ExportJSON(places, writer)
 	case string(FormatMarkdown), "md":
 		return ExportMarkdown(places, writer)
+	case string(FormatICS), "ical":
+		return ExportICS(places, writer)
 	default:
 		return fmt.Errorf("unsupported export format: %s", format)
 	}
 }
 
 func ValidateFormat(format string) error {
-	switch strings.ToLower(format) {
-	case string(FormatCSV), string(FormatGeoJSON), string(FormatJSON), string(FormatMarkdown), "md":
-		return nil
+	_, err := ParseFormat(format)
+	return err
+}
+
+// ParseFormat returns the format a name stands for, ignoring case and
+// resolving the "md" and "ical" aliases
+func ParseFormat(format string) (Format, error) {
+	switch name := strings.ToLower(format); name {
+	case string(FormatCSV), string(FormatGeoJSON), string(FormatJSON), string(FormatMarkdown), string(FormatICS):
+		return Format(name), nil
+	case "md":
+		return FormatMarkdown, nil
+	case "ical":
+		return FormatICS, nil
 	default:
-		return fmt.Errorf("unsupported format '%s'. Supported formats: csv, geojson, json, markdown", format)
+		return "", fmt.Errorf("unsupported format '%s'. Supported formats: csv, geojson, json, markdown, ics", format)
 	}
 }
 
@@ -47,5 +61,6 @@ func GetSupportedFormats() []string {
 		string(FormatGeoJSON),
 		string(FormatJSON),
 		string(FormatMarkdown),
+		string(FormatICS),
 	}
 }
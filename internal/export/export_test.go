@@ -135,6 +135,59 @@ func TestExportGeoJSON(t *testing.T) {
 	assert.Equal(t, []interface{}{"favorite", "pizza"}, feature.Properties["user_tags"])
 }
 
+func TestExportGeoJSON_Geometry(t *testing.T) {
+	places := createTestPlaces()
+	places[1].Geometry = models.NewPolygonGeometry([][]models.Position{
+		{{-73.981, 40.768}, {-73.958, 40.800}, {-73.949, 40.797}, {-73.973, 40.764}},
+	})
+
+	var buf bytes.Buffer
+	require.NoError(t, ExportGeoJSON(places, &buf))
+
+	var result struct {
+		Features []struct {
+			Geometry struct {
+				Type        string          `json:"type"`
+				Coordinates json.RawMessage `json:"coordinates"`
+			} `json:"geometry"`
+		} `json:"features"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+
+	assert.Equal(t, "Point", result.Features[0].Geometry.Type)
+	assert.Equal(t, "Polygon", result.Features[1].Geometry.Type)
+
+	var rings [][][]float64
+	require.NoError(t, json.Unmarshal(result.Features[1].Geometry.Coordinates, &rings))
+	require.Len(t, rings, 1)
+	assert.Len(t, rings[0], 5)
+	assert.Equal(t, rings[0][0], rings[0][4])
+}
+
+func TestExportAttachments(t *testing.T) {
+	places := createTestPlaces()
+	places[0].AddAttachment(models.Attachment{
+		Hash:        "0123456789abcdef",
+		Name:        "menu.pdf",
+		ContentType: "application/pdf",
+		Size:        2048,
+	})
+
+	var geo bytes.Buffer
+	require.NoError(t, ExportGeoJSON(places, &geo))
+	var result GeoJSONFeatureCollection
+	require.NoError(t, json.Unmarshal(geo.Bytes(), &result))
+	attachments := result.Features[0].Properties["attachments"].([]interface{})
+	require.Len(t, attachments, 1)
+	assert.Equal(t, "menu.pdf", attachments[0].(map[string]interface{})["name"])
+	assert.NotContains(t, result.Features[1].Properties, "attachments")
+
+	var md bytes.Buffer
+	require.NoError(t, ExportMarkdown(places, &md))
+	assert.Contains(t, md.String(), "### Attachments")
+	assert.Contains(t, md.String(), "- menu.pdf (`0123456789ab`)")
+}
+
 func TestExportMarkdown(t *testing.T) {
 	places := createTestPlaces()
 	var buf bytes.Buffer
@@ -170,6 +223,7 @@ func TestExportWithFormat(t *testing.T) {
 		{FormatJSON, `"name": "Joe's Pizza"`},
 		{FormatGeoJSON, `"FeatureCollection"`},
 		{FormatMarkdown, "# Places Export"},
+		{FormatICS, "BEGIN:VCALENDAR"},
 	}
 
 	for _, tc := range testCases {
@@ -183,7 +237,7 @@ func TestExportWithFormat(t *testing.T) {
 }
 
 func TestValidateFormat(t *testing.T) {
-	validFormats := []string{"csv", "json", "geojson", "markdown", "md"}
+	validFormats := []string{"csv", "json", "geojson", "markdown", "md", "ics"}
 	for _, format := range validFormats {
 		assert.NoError(t, ValidateFormat(format))
 		assert.NoError(t, ValidateFormat(strings.ToUpper(format)))
@@ -195,9 +249,20 @@ func TestValidateFormat(t *testing.T) {
 	}
 }
 
+func TestParseFormat(t *testing.T) {
+	for name, want := range map[string]Format{"ICS": FormatICS, "ical": FormatICS, "md": FormatMarkdown, "csv": FormatCSV} {
+		format, err := ParseFormat(name)
+		assert.NoError(t, err)
+		assert.Equal(t, want, format, name)
+	}
+
+	_, err := ParseFormat("xml")
+	assert.Error(t, err)
+}
+
 func TestGetSupportedFormats(t *testing.T) {
 	formats := GetSupportedFormats()
-	expected := []string{"csv", "geojson", "json", "markdown"}
+	expected := []string{"csv", "geojson", "json", "markdown", "ics"}
 	assert.Equal(t, expected, formats)
 }
 
@@ -244,3 +309,78 @@ func TestExportMarkdownWithEmptyFields(t *testing.T) {
 	assert.NotContains(t, output, "**Rating:**")
 	assert.NotContains(t, output, "### Notes")
 }
+
+func TestExportICS(t *testing.T) {
+	places := createTestPlaces()
+	var buf bytes.Buffer
+
+	err := ExportICS(places, &buf)
+	require.NoError(t, err)
+
+	output := buf.String()
+	assert.True(t, strings.HasPrefix(output, "BEGIN:VCALENDAR\r\n"))
+	assert.True(t, strings.HasSuffix(output, "END:VCALENDAR\r\n"))
+	assert.Equal(t, 1, strings.Count(output, "BEGIN:VEVENT"))
+	assert.Contains(t, output, "UID:place1-visited_date@placeli")
+	assert.Contains(t, output, "DTSTART;VALUE=DATE:20231201")
+	assert.Contains(t, output, "DTEND;VALUE=DATE:20231202")
+	assert.Contains(t, output, "SUMMARY:Joe's Pizza (visited date)")
+	assert.Contains(t, output, "LOCATION:123 Main St\\, Brooklyn\\, NY 11201")
+	assert.Contains(t, output, "GEO:40.689200;-74.044500")
+	assert.Contains(t, output, "CATEGORIES:favorite,pizza")
+}
+
+func TestExportICSTimes(t *testing.T) {
+	places := createTestPlaces()
+	places[0].CustomFields = map[string]interface{}{
+		"dinner":   "2024-05-04 19:00",
+		"tour":     "2024-05-05T09:30",
+		"check_in": "2024-05-06T15:00:00+02:00",
+	}
+	places[1].CustomFields = nil
+
+	var buf bytes.Buffer
+	require.NoError(t, ExportICS(places, &buf))
+
+	// Times without a zone stay at the same wall-clock time
+	output := buf.String()
+	assert.Contains(t, output, "DTSTART:20240504T190000\r\n")
+	assert.Contains(t, output, "DTEND:20240504T200000\r\n")
+	assert.Contains(t, output, "DTSTART:20240505T093000\r\n")
+	assert.Contains(t, output, "DTSTART:20240506T130000Z\r\n")
+}
+
+func TestExportICSItinerary(t *testing.T) {
+	places := createTestPlaces()
+	places[0].CustomFields["visit_date"] = "2024-05-04"
+	places[1].CustomFields = map[string]interface{}{
+		"visit_date":  "2024-05-04",
+		"import_date": "2024-01-01",
+	}
+
+	var buf bytes.Buffer
+	err := ExportICSWithOptions(places, &buf, ICSOptions{Itinerary: true})
+	require.NoError(t, err)
+
+	output := buf.String()
+	assert.Equal(t, 2, strings.Count(output, "BEGIN:VEVENT"))
+	assert.Contains(t, output, "UID:itinerary-20240504@placeli")
+	assert.Contains(t, output, "SUMMARY:Itinerary: 2 places")
+	assert.NotContains(t, output, "20240101")
+}
+
+func TestEscapeICSText(t *testing.T) {
+	assert.Equal(t, `a\,b\;c\\d\ne`, escapeICSText("a,b;c\\d\ne"))
+}
+
+func TestICSLineFolding(t *testing.T) {
+	var buf bytes.Buffer
+	w := &icsWriter{w: &buf}
+	w.line("DESCRIPTION:" + strings.Repeat("x", 100))
+	require.NoError(t, w.err)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n")
+	require.Len(t, lines, 2)
+	assert.Len(t, lines[0], 75)
+	assert.True(t, strings.HasPrefix(lines[1], " "))
+}
@@ -22,6 +22,17 @@ type GeoJSONFeature struct {
 type GeoJSONGeometry struct {
 	Type        string    `json:"type"`
 	Coordinates []float64 `json:"coordinates"`
+	// Shape is the line or polygon of an area or route. When set it is
+	// written instead of the point in Coordinates.
+	Shape *models.Geometry `json:"-"`
+}
+
+func (g GeoJSONGeometry) MarshalJSON() ([]byte, error) {
+	if g.Shape != nil {
+		return json.Marshal(g.Shape)
+	}
+	type point GeoJSONGeometry
+	return json.Marshal(point(g))
 }
 
 func ExportGeoJSON(places []*models.Place, writer io.Writer) error {
@@ -53,11 +64,17 @@ func ExportGeoJSON(places []*models.Place, writer io.Writer) error {
 			},
 		}
 
+		if place.Geometry != nil {
+			feature.Geometry.Type = place.Geometry.Type
+			feature.Geometry.Shape = place.Geometry
+		}
+
 		if len(place.Photos) > 0 {
 			photos := make([]map[string]interface{}, len(place.Photos))
 			for i, photo := range place.Photos {
 				photos[i] = map[string]interface{}{
 					"reference":  photo.Reference,
+					"hash":       photo.Hash,
 					"local_path": photo.LocalPath,
 					"width":      photo.Width,
 					"height":     photo.Height,
@@ -66,6 +83,26 @@ func ExportGeoJSON(places []*models.Place, writer io.Writer) error {
 			feature.Properties["photos"] = photos
 		}
 
+		if len(place.Attachments) > 0 {
+			attachments := make([]map[string]interface{}, len(place.Attachments))
+			for i, a := range place.Attachments {
+				attachment := map[string]interface{}{
+					"hash":         a.Hash,
+					"name":         a.Name,
+					"content_type": a.ContentType,
+					"size":         a.Size,
+				}
+				if a.TakenAt != nil {
+					attachment["taken_at"] = a.TakenAt.Format("2006-01-02T15:04:05Z07:00")
+				}
+				if a.Coordinates != nil {
+					attachment["coordinates"] = []float64{a.Coordinates.Lng, a.Coordinates.Lat}
+				}
+				attachments[i] = attachment
+			}
+			feature.Properties["attachments"] = attachments
+		}
+
 		if len(place.Reviews) > 0 {
 			reviews := make([]map[string]interface{}, len(place.Reviews))
 			for i, review := range place.Reviews {
@@ -0,0 +1,273 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/user/placeli/internal/models"
+)
+
+// ICSOptions controls iCalendar output
+type ICSOptions struct {
+	// Itinerary groups all dated places into one all-day event per day
+	// instead of emitting one event per place and field
+	Itinerary bool
+}
+
+// icsEvent is a dated occurrence of a place taken from a custom field
+type icsEvent struct {
+	place *models.Place
+	field string
+	start time.Time
+	// allDay is true when the field holds a date without a time of day
+	allDay bool
+	// floating is true when the field holds a time of day without a zone,
+	// which calendars show at that time wherever the user is
+	floating bool
+}
+
+// Fields that carry import bookkeeping rather than user plans
+var icsSkippedFields = map[string]bool{
+	"import_date": true,
+	"last_import": true,
+	"last_sync":   true,
+	"saved_date":  true,
+	"added_at":    true,
+}
+
+func ExportICS(places []*models.Place, writer io.Writer) error {
+	return ExportICSWithOptions(places, writer, ICSOptions{})
+}
+
+func ExportICSWithOptions(places []*models.Place, writer io.Writer, opts ICSOptions) error {
+	events := collectICSEvents(places)
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+
+	w := &icsWriter{w: writer}
+	w.line("BEGIN:VCALENDAR")
+	w.line("VERSION:2.0")
+	w.line("PRODID:-//placeli//placeli export//EN")
+	w.line("CALSCALE:GREGORIAN")
+	if opts.Itinerary {
+		w.line("X-WR-CALNAME:placeli itinerary")
+		writeItinerary(w, events, stamp)
+	} else {
+		w.line("X-WR-CALNAME:placeli")
+		for _, ev := range events {
+			writePlaceEvent(w, ev, stamp)
+		}
+	}
+	w.line("END:VCALENDAR")
+
+	if w.err != nil {
+		return fmt.Errorf("failed to write iCalendar: %w", w.err)
+	}
+	return nil
+}
+
+// collectICSEvents finds every date-valued custom field, sorted by start
+func collectICSEvents(places []*models.Place) []icsEvent {
+	var events []icsEvent
+
+	for _, place := range places {
+		for field, value := range place.CustomFields {
+			if icsSkippedFields[field] {
+				continue
+			}
+			str, ok := value.(string)
+			if !ok {
+				continue
+			}
+			start, allDay, floating, ok := parseICSDate(str)
+			if !ok {
+				continue
+			}
+			events = append(events, icsEvent{place: place, field: field, start: start, allDay: allDay, floating: floating})
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].start.Equal(events[j].start) {
+			return events[i].start.Before(events[j].start)
+		}
+		if events[i].place.Name != events[j].place.Name {
+			return events[i].place.Name < events[j].place.Name
+		}
+		return events[i].field < events[j].field
+	})
+
+	return events
+}
+
+// parseICSDate reads a date, a time with a zone (RFC 3339), or a local time
+// without one. Local times are read in the local zone, so they sort
+// alongside zoned ones, and are written as floating times.
+func parseICSDate(value string) (start time.Time, allDay, floating, ok bool) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, true, false, true
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, false, true
+	}
+	for _, layout := range []string{"2006-01-02T15:04", "2006-01-02 15:04"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, false, true, true
+		}
+	}
+	return time.Time{}, false, false, false
+}
+
+func writePlaceEvent(w *icsWriter, ev icsEvent, stamp string) {
+	place := ev.place
+
+	w.line("BEGIN:VEVENT")
+	w.line("UID:" + place.ID + "-" + ev.field + "@placeli")
+	w.line("DTSTAMP:" + stamp)
+	if ev.allDay {
+		w.line("DTSTART;VALUE=DATE:" + ev.start.Format("20060102"))
+		w.line("DTEND;VALUE=DATE:" + ev.start.AddDate(0, 0, 1).Format("20060102"))
+	} else if ev.floating {
+		w.line("DTSTART:" + ev.start.Format("20060102T150405"))
+		w.line("DTEND:" + ev.start.Add(time.Hour).Format("20060102T150405"))
+	} else {
+		w.line("DTSTART:" + ev.start.UTC().Format("20060102T150405Z"))
+		w.line("DTEND:" + ev.start.Add(time.Hour).UTC().Format("20060102T150405Z"))
+	}
+	w.line("SUMMARY:" + escapeICSText(fmt.Sprintf("%s (%s)", place.Name, humanizeField(ev.field))))
+	writeLocation(w, place)
+
+	var desc []string
+	if place.Hours != "" {
+		desc = append(desc, "Hours: "+place.Hours)
+	}
+	if place.Phone != "" {
+		desc = append(desc, "Phone: "+place.Phone)
+	}
+	if place.UserNotes != "" {
+		desc = append(desc, place.UserNotes)
+	}
+	if len(desc) > 0 {
+		w.line("DESCRIPTION:" + escapeICSText(strings.Join(desc, "\n")))
+	}
+	if place.Website != "" {
+		w.line("URL:" + place.Website)
+	}
+	if len(place.UserTags) > 0 {
+		tags := make([]string, len(place.UserTags))
+		for i, tag := range place.UserTags {
+			tags[i] = escapeICSText(tag)
+		}
+		w.line("CATEGORIES:" + strings.Join(tags, ","))
+	}
+	w.line("END:VEVENT")
+}
+
+func writeItinerary(w *icsWriter, events []icsEvent, stamp string) {
+	var days []string
+	byDay := make(map[string][]icsEvent)
+	for _, ev := range events {
+		day := ev.start.Format("2006-01-02")
+		if _, ok := byDay[day]; !ok {
+			days = append(days, day)
+		}
+		byDay[day] = append(byDay[day], ev)
+	}
+
+	for _, day := range days {
+		dayEvents := byDay[day]
+		start, _ := time.Parse("2006-01-02", day)
+
+		var lines []string
+		seen := make(map[string]bool)
+		for _, ev := range dayEvents {
+			if seen[ev.place.ID] {
+				continue
+			}
+			seen[ev.place.ID] = true
+
+			line := "- " + ev.place.Name
+			if !ev.allDay {
+				line = "- " + ev.start.Format("15:04") + " " + ev.place.Name
+			}
+			if ev.place.Address != "" {
+				line += ", " + ev.place.Address
+			}
+			if ev.place.Hours != "" {
+				line += " (hours: " + ev.place.Hours + ")"
+			}
+			lines = append(lines, line)
+		}
+
+		w.line("BEGIN:VEVENT")
+		w.line("UID:itinerary-" + start.Format("20060102") + "@placeli")
+		w.line("DTSTAMP:" + stamp)
+		w.line("DTSTART;VALUE=DATE:" + start.Format("20060102"))
+		w.line("DTEND;VALUE=DATE:" + start.AddDate(0, 0, 1).Format("20060102"))
+		if len(lines) == 1 {
+			w.line("SUMMARY:" + escapeICSText(dayEvents[0].place.Name))
+			writeLocation(w, dayEvents[0].place)
+		} else {
+			w.line("SUMMARY:" + escapeICSText(fmt.Sprintf("Itinerary: %d places", len(lines))))
+		}
+		w.line("DESCRIPTION:" + escapeICSText(strings.Join(lines, "\n")))
+		w.line("END:VEVENT")
+	}
+}
+
+func writeLocation(w *icsWriter, place *models.Place) {
+	location := place.Address
+	if location == "" {
+		location = place.Name
+	}
+	w.line("LOCATION:" + escapeICSText(location))
+	if place.Coordinates.Lat != 0 || place.Coordinates.Lng != 0 {
+		w.line(fmt.Sprintf("GEO:%.6f;%.6f", place.Coordinates.Lat, place.Coordinates.Lng))
+	}
+}
+
+func humanizeField(field string) string {
+	return strings.ReplaceAll(field, "_", " ")
+}
+
+// escapeICSText escapes a TEXT value as described in RFC 5545 section 3.3.11
+func escapeICSText(text string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	)
+	return replacer.Replace(text)
+}
+
+// icsWriter writes CRLF-terminated content lines folded at 75 octets
+type icsWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (iw *icsWriter) line(content string) {
+	if iw.err != nil {
+		return
+	}
+
+	var b strings.Builder
+	width := 0
+	for _, r := range content {
+		size := len(string(r))
+		if width+size > 75 {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	b.WriteString("\r\n")
+
+	_, iw.err = io.WriteString(iw.w, b.String())
+}
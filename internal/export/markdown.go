@@ -89,6 +89,18 @@ func ExportMarkdown(places []*models.Place, writer io.Writer) error {
 			fmt.Fprintf(writer, "\n")
 		}
 
+		if len(place.Attachments) > 0 {
+			fmt.Fprintf(writer, "### Attachments\n\n")
+			for _, a := range place.Attachments {
+				fmt.Fprintf(writer, "- %s (`%s`)", a.Name, a.Hash[:12])
+				if a.TakenAt != nil {
+					fmt.Fprintf(writer, " taken %s", a.TakenAt.Format("2006-01-02"))
+				}
+				fmt.Fprintf(writer, "\n")
+			}
+			fmt.Fprintf(writer, "\n")
+		}
+
 		if len(place.Reviews) > 0 {
 			fmt.Fprintf(writer, "### Reviews\n\n")
 			for _, review := range place.Reviews {
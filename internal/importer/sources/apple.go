@@ -71,7 +71,18 @@ func (ai *AppleImporter) Name() string {
 }
 
 func (ai *AppleImporter) SupportedFormats() []string {
-	return []string{"kml", "kmz", "gpx"}
+	return []string{"kml", "gpx"}
+}
+
+// Sniff recognises GPX and KML documents
+func (ai *AppleImporter) Sniff(header []byte) Confidence {
+	switch sniffXMLRoot(header) {
+	case "gpx":
+		return ConfidenceHigh
+	case "kml":
+		return ConfidenceMedium
+	}
+	return ConfidenceNone
 }
 
 func (ai *AppleImporter) ImportFromFile(filePath string) ([]*models.Place, error) {
@@ -91,7 +102,7 @@ func (ai *AppleImporter) ImportFromFile(filePath string) ([]*models.Place, error
 	if strings.HasSuffix(strings.ToLower(filePath), ".gpx") {
 		format = "gpx"
 	} else if strings.HasSuffix(strings.ToLower(filePath), ".kmz") {
-		return nil, fmt.Errorf("KMZ files not yet supported, please extract to KML first")
+		return nil, fmt.Errorf("KMZ files are imported by the mymaps source, use --source=mymaps")
 	}
 
 	return ai.ImportFromData(data, format)
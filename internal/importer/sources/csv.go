@@ -0,0 +1,386 @@
+package sources
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/user/placeli/internal/models"
+	"github.com/user/placeli/internal/utils"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// CSVImporter handles spreadsheets exported as CSV or TSV. The delimiter
+// and text encoding are detected, and which columns hold the name,
+// location and other fields is guessed from their headers or set with a
+// FieldMapping. The location comes from lat/lng columns or a WKT geometry
+// column; other columns become custom fields.
+type CSVImporter struct {
+	Mapping FieldMapping
+}
+
+// CSVTable describes a spreadsheet as the importer reads it
+type CSVTable struct {
+	Delimiter rune
+	Encoding  string
+	Header    []string
+	// Fields maps each resolved field to the column that holds it
+	Fields map[string]string
+}
+
+// CSVPreview is the table description and the first rows of a
+// spreadsheet, as places
+type CSVPreview struct {
+	CSVTable
+	Places []*models.Place
+	// Skipped counts rows among the first ones that could not be imported
+	Skipped int
+}
+
+// sniffSize is how much of the file is looked at to detect its encoding
+// and delimiter
+const sniffSize = 64 * 1024
+
+func (ci *CSVImporter) Name() string {
+	return "CSV/TSV spreadsheet"
+}
+
+// SupportedFormats only claims .tsv files, as .csv files are more often
+// Takeout or OSM exports; use --source=csv for other spreadsheets
+func (ci *CSVImporter) SupportedFormats() []string {
+	return []string{"tsv"}
+}
+
+// Sniff recognises delimited text with a name column, and with more
+// confidence when it also has coordinate or WKT columns
+func (ci *CSVImporter) Sniff(header []byte) Confidence {
+	columns := sniffCSVHeader(header)
+	if columns == nil {
+		return ConfidenceNone
+	}
+	has := func(field string) bool {
+		return findKey(columns, ci.Mapping[field]) != "" ||
+			hasColumn(columns, defaultFieldNames[field]...)
+	}
+	switch {
+	case !has("name"):
+		return ConfidenceNone
+	case has("geometry") || (has("lat") && has("lng")):
+		return ConfidenceMedium
+	}
+	return ConfidenceLow
+}
+
+func (ci *CSVImporter) SetFieldMapping(mapping FieldMapping) {
+	ci.Mapping = mapping
+}
+
+func (ci *CSVImporter) ImportFromFile(filePath string) ([]*models.Place, error) {
+	return collect(func(emit func(*models.Place) error) error {
+		return ci.StreamFromFile(filePath, emit, nil)
+	})
+}
+
+// StreamFromFile streams the rows of a spreadsheet
+func (ci *CSVImporter) StreamFromFile(filePath string, emit func(*models.Place) error, progress ProgressFunc) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	var size int64
+	if info, err := file.Stat(); err == nil {
+		size = info.Size()
+	}
+	return ci.stream(newProgressTracker(size, progress).wrap(file), emit)
+}
+
+func (ci *CSVImporter) ImportFromData(data []byte, format string) ([]*models.Place, error) {
+	if format != "csv" && format != "tsv" {
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
+	return collect(func(emit func(*models.Place) error) error {
+		return ci.stream(bytes.NewReader(data), emit)
+	})
+}
+
+// Preview reads the table description and up to rows rows of a
+// spreadsheet, to check a mapping before importing
+func (ci *CSVImporter) Preview(filePath string, rows int) (*CSVPreview, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	reader, table, err := ci.open(file)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &CSVPreview{CSVTable: *table}
+	for line := 2; line < rows+2; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read row %d: %w", line, err)
+		}
+		if place := ci.convertRow(table, record); place != nil {
+			preview.Places = append(preview.Places, place)
+		} else {
+			preview.Skipped++
+		}
+	}
+	return preview, nil
+}
+
+func (ci *CSVImporter) stream(r io.Reader, emit func(*models.Place) error) error {
+	reader, table, err := ci.open(r)
+	if err != nil {
+		return err
+	}
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read row %d: %w", line, err)
+		}
+		if place := ci.convertRow(table, record); place != nil {
+			if err := emit(place); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// open detects the encoding and delimiter, reads the header and resolves
+// the mapping, returning a reader positioned at the first row
+func (ci *CSVImporter) open(r io.Reader) (*csv.Reader, *CSVTable, error) {
+	decoded, encoding := decodeText(r)
+	br := bufio.NewReaderSize(decoded, sniffSize)
+	sample, _ := br.Peek(sniffSize)
+
+	reader := csv.NewReader(br)
+	reader.Comma = detectDelimiter(sample)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, fmt.Errorf("spreadsheet is empty")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	for i, column := range header {
+		if header[i] = strings.TrimSpace(column); header[i] == "" {
+			header[i] = fmt.Sprintf("column %d", i+1)
+		}
+	}
+
+	table := &CSVTable{
+		Delimiter: reader.Comma,
+		Encoding:  encoding,
+		Header:    header,
+		Fields:    ci.Mapping.resolve(header, MappableFields),
+	}
+	if table.Fields["name"] == "" {
+		return nil, nil, fmt.Errorf("no name column in %s, use --map name=<column>", strings.Join(header, ", "))
+	}
+	for field, column := range ci.Mapping {
+		if column != "" && table.Fields[field] == "" {
+			return nil, nil, fmt.Errorf("column %q mapped to %s not found in %s", column, field, strings.Join(header, ", "))
+		}
+	}
+	return reader, table, nil
+}
+
+func (ci *CSVImporter) convertRow(table *CSVTable, record []string) *models.Place {
+	columns := make(map[string]string, len(record))
+	for i, value := range record {
+		if i < len(table.Header) {
+			columns[table.Header[i]] = strings.TrimSpace(value)
+		}
+	}
+	get := func(field string) string {
+		if column := table.Fields[field]; column != "" {
+			return columns[column]
+		}
+		return ""
+	}
+
+	name := get("name")
+	if name == "" {
+		return nil // Skip rows without names
+	}
+
+	coords, geometry, ok := rowLocation(get("lat"), get("lng"), get("geometry"))
+	if !ok {
+		return nil // Skip rows with unreadable coordinates
+	}
+
+	categories := propertyStrings(get("category"))
+	if geometry != nil {
+		if geometry.IsArea() {
+			categories = append(categories, "Area")
+		} else {
+			categories = append(categories, "Route")
+		}
+	}
+	tags := propertyStrings(get("tags"))
+	if tags == nil {
+		tags = []string{}
+	}
+
+	address := get("address")
+	sourceData := fmt.Sprintf("csv|%s|%s|%f,%f", name, address, coords.Lat, coords.Lng)
+	sourceHash := fmt.Sprintf("%x", sha256.Sum256([]byte(sourceData)))
+	placeID := fmt.Sprintf("csv_%s", sourceHash[:16])
+
+	now := time.Now()
+	place := &models.Place{
+		ID:          utils.GenerateID(placeID),
+		PlaceID:     placeID,
+		Name:        name,
+		Address:     address,
+		Coordinates: coords,
+		Geometry:    geometry,
+		Categories:  categories,
+		Phone:       get("phone"),
+		Website:     get("website"),
+		UserNotes:   get("notes"),
+		UserTags:    tags,
+		Photos:      []models.Photo{},
+		Reviews:     []models.Review{},
+		CustomFields: map[string]interface{}{
+			"imported_from": "csv",
+			"import_date":   now.Format(time.RFC3339),
+		},
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		ImportedAt: &now,
+		SourceHash: sourceHash,
+	}
+
+	mapped := make(map[string]bool)
+	for field, column := range table.Fields {
+		mapped[column] = true
+		if custom, ok := strings.CutPrefix(field, customFieldPrefix); ok && columns[column] != "" {
+			place.CustomFields[custom] = columns[column]
+		}
+	}
+
+	// Unmapped columns become custom fields
+	for _, column := range table.Header {
+		if value := columns[column]; value != "" && !mapped[column] {
+			place.CustomFields["csv_"+column] = value
+		}
+	}
+
+	return place
+}
+
+// rowLocation reads a row's location from its WKT column, or else its
+// latitude and longitude. Rows without any location are imported without
+// coordinates, like Takeout's saved lists; unreadable values are not ok.
+func rowLocation(lat, lng, wkt string) (models.Coordinates, *models.Geometry, bool) {
+	if wkt != "" {
+		coords, geometry, err := models.ParseWKT(wkt)
+		return coords, geometry, err == nil
+	}
+	if lat == "" && lng == "" {
+		return models.Coordinates{}, nil, true
+	}
+
+	latValue, latErr := parseDecimal(lat)
+	lngValue, lngErr := parseDecimal(lng)
+	if latErr != nil || lngErr != nil ||
+		latValue < -90 || latValue > 90 || lngValue < -180 || lngValue > 180 {
+		return models.Coordinates{}, nil, false
+	}
+	return models.Coordinates{Lat: latValue, Lng: lngValue}, nil, true
+}
+
+// parseDecimal parses a number written with a decimal point or, as
+// spreadsheets in many locales do, a decimal comma
+func parseDecimal(value string) (float64, error) {
+	if !strings.Contains(value, ".") {
+		value = strings.Replace(value, ",", ".", 1)
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// decodeText returns r decoded to UTF-8 and the name of its encoding.
+// UTF-8 and UTF-16 are recognised by their byte order mark; text that is
+// not valid UTF-8 is read as Windows-1252, which is what Excel writes.
+func decodeText(r io.Reader) (io.Reader, string) {
+	br := bufio.NewReaderSize(r, sniffSize)
+	sample, _ := br.Peek(sniffSize)
+
+	switch {
+	case bytes.HasPrefix(sample, []byte{0xEF, 0xBB, 0xBF}):
+		_, _ = br.Discard(3)
+		return br, "UTF-8"
+	case bytes.HasPrefix(sample, []byte{0xFF, 0xFE}):
+		return unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM).NewDecoder().Reader(br), "UTF-16LE"
+	case bytes.HasPrefix(sample, []byte{0xFE, 0xFF}):
+		return unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM).NewDecoder().Reader(br), "UTF-16BE"
+	}
+
+	if len(sample) == sniffSize {
+		// Don't count a character cut off at the end of the sample
+		for i := len(sample) - 1; i >= 0 && i >= len(sample)-utf8.UTFMax; i-- {
+			if utf8.RuneStart(sample[i]) {
+				sample = sample[:i]
+				break
+			}
+		}
+	}
+	if !utf8.Valid(sample) {
+		return charmap.Windows1252.NewDecoder().Reader(br), "Windows-1252"
+	}
+	return br, "UTF-8"
+}
+
+// detectDelimiter picks whichever of comma, semicolon, tab and pipe occurs
+// most often outside quotes in the header line, preferring comma
+func detectDelimiter(sample []byte) rune {
+	counts := make(map[rune]int)
+	quoted := false
+	for _, c := range string(sample) {
+		if c == '"' {
+			quoted = !quoted
+			continue
+		}
+		if quoted {
+			continue
+		}
+		if c == '\n' {
+			break
+		}
+		counts[c]++
+	}
+
+	best := ','
+	for _, candidate := range []rune{';', '\t', '|'} {
+		if counts[candidate] > counts[best] {
+			best = candidate
+		}
+	}
+	return best
+}
@@ -0,0 +1,114 @@
+package sources
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func TestCSVImporter_Mapping(t *testing.T) {
+	// Semicolons, decimal commas and Windows-1252, as Excel writes them in
+	// much of Europe
+	text := "Title;Y;X;Labels;Prio;Owner\r\n" +
+		"Café Nicola;38,7139;-9,1394;coffee, lisbon;high;Ana\r\n" +
+		"No location;;;;low;Rui\r\n" +
+		"Bad coordinates;north;1;;;\r\n" +
+		";38.7;-9.1;;;\r\n"
+	data, err := charmap.Windows1252.NewEncoder().Bytes([]byte(text))
+	require.NoError(t, err)
+
+	mapping, err := ParseFieldMapping("name=Title,lat=Y,lng=X,tags=Labels,field.priority=Prio")
+	require.NoError(t, err)
+	places, err := (&CSVImporter{Mapping: mapping}).ImportFromData(data, "csv")
+	require.NoError(t, err)
+	require.Len(t, places, 2)
+
+	cafe := places[0]
+	assert.Equal(t, "Café Nicola", cafe.Name)
+	assert.InDelta(t, 38.7139, cafe.Coordinates.Lat, 1e-9)
+	assert.InDelta(t, -9.1394, cafe.Coordinates.Lng, 1e-9)
+	assert.Equal(t, []string{"coffee", "lisbon"}, cafe.UserTags)
+	assert.Equal(t, "high", cafe.CustomFields["priority"])
+	assert.Equal(t, "Ana", cafe.CustomFields["csv_Owner"])
+	assert.NotContains(t, cafe.CustomFields, "csv_Prio")
+
+	assert.Equal(t, "No location", places[1].Name)
+	assert.Zero(t, places[1].Coordinates.Lat)
+}
+
+func TestCSVImporter_TSVWithWKT(t *testing.T) {
+	text := "name\tcategory\twkt\tnotes\n" +
+		"Jardim\tPark\tPOLYGON ((0 0, 2 0, 2 2, 0 2, 0 0))\tShady\n" +
+		"Miradouro\tViewpoint\tPOINT (-9.13 38.71)\t\n"
+	data, err := unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewEncoder().Bytes([]byte(text))
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "places.tsv")
+	require.NoError(t, os.WriteFile(path, data, 0644))
+
+	places, err := (&CSVImporter{}).ImportFromFile(path)
+	require.NoError(t, err)
+	require.Len(t, places, 2)
+
+	park := places[0]
+	require.NotNil(t, park.Geometry)
+	assert.True(t, park.Geometry.IsArea())
+	assert.Equal(t, []string{"Park", "Area"}, park.Categories)
+	assert.Equal(t, "Shady", park.UserNotes)
+	assert.InDelta(t, 1, park.Coordinates.Lat, 1e-9)
+
+	assert.Nil(t, places[1].Geometry)
+	assert.InDelta(t, 38.71, places[1].Coordinates.Lat, 1e-9)
+}
+
+func TestCSVImporter_Preview(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "list.csv")
+	require.NoError(t, os.WriteFile(path, []byte("\xEF\xBB\xBFName,Latitude,Longitude,\"Notes, long\"\n"+
+		"A,1,2,x\nB,3,4,y\nC,5,6,z\n"), 0644))
+
+	preview, err := (&CSVImporter{}).Preview(path, 2)
+	require.NoError(t, err)
+	assert.Equal(t, ',', preview.Delimiter)
+	assert.Equal(t, "UTF-8", preview.Encoding)
+	assert.Equal(t, "Name", preview.Fields["name"], "BOM should be stripped from the first header")
+	assert.Equal(t, "Latitude", preview.Fields["lat"])
+	assert.Equal(t, "Longitude", preview.Fields["lng"])
+	require.Len(t, preview.Places, 2)
+	assert.Equal(t, "B", preview.Places[1].Name)
+}
+
+func TestCSVImporter_MappingErrors(t *testing.T) {
+	_, err := (&CSVImporter{}).ImportFromData([]byte("Foo,Bar\n1,2\n"), "csv")
+	assert.ErrorContains(t, err, "no name column")
+
+	mapping, err := ParseFieldMapping("name=Foo,lat=Latitude")
+	require.NoError(t, err)
+	_, err = (&CSVImporter{Mapping: mapping}).ImportFromData([]byte("Foo,Bar\n1,2\n"), "csv")
+	assert.ErrorContains(t, err, `column "Latitude" mapped to lat not found`)
+}
+
+func TestDetectDelimiter(t *testing.T) {
+	assert.Equal(t, ',', detectDelimiter([]byte("a,b,c\n1;2;3;4;5")))
+	assert.Equal(t, ';', detectDelimiter([]byte(`"a,b";c;d`)))
+	assert.Equal(t, '\t', detectDelimiter([]byte("a\tb\tc")))
+	assert.Equal(t, '|', detectDelimiter([]byte("a|b|c")))
+	assert.Equal(t, ',', detectDelimiter([]byte("name")))
+}
+
+func TestFieldMapping_String(t *testing.T) {
+	mapping, err := ParseFieldMapping("NAME=Title, field.Priority=Prio,category=")
+	require.NoError(t, err)
+	assert.Equal(t, "category=,field.Priority=Prio,name=Title", mapping.String())
+
+	again, err := ParseFieldMapping(mapping.String())
+	require.NoError(t, err)
+	assert.Equal(t, mapping, again)
+
+	_, err = ParseFieldMapping("field.=Prio")
+	assert.Error(t, err)
+}
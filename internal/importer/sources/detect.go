@@ -0,0 +1,410 @@
+package sources
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Confidence is how sure a source is that it can import some content
+type Confidence int
+
+const (
+	ConfidenceNone Confidence = iota
+	// ConfidenceLow means the content has the right shape, but other
+	// sources are likely to read it better
+	ConfidenceLow
+	// ConfidenceMedium means the content is in a format the source reads,
+	// such as any GeoJSON FeatureCollection
+	ConfidenceMedium
+	// ConfidenceHigh means the content has markers only this source's
+	// exports have, such as Takeout's google_maps_url properties
+	ConfidenceHigh
+)
+
+func (c Confidence) String() string {
+	switch c {
+	case ConfidenceLow:
+		return "low"
+	case ConfidenceMedium:
+		return "medium"
+	case ConfidenceHigh:
+		return "high"
+	default:
+		return "none"
+	}
+}
+
+// headerSize is how much of a file, or of each archive entry, is sniffed
+const headerSize = 64 * 1024
+
+// maxDetectFiles bounds how many archive entries or directory files are
+// sniffed, so detecting a huge Takeout export stays quick
+const maxDetectFiles = 1000
+
+// Candidate is a source that may be able to import a file, and why
+type Candidate struct {
+	Name       string
+	Source     ImportSource
+	Confidence Confidence
+	// Entry is the archive entry or directory file that gave the
+	// confidence, if the file is an archive or directory
+	Entry string
+	// Extension is the matching file extension, if any
+	Extension string
+}
+
+// Reason explains the candidate's confidence in words
+func (c *Candidate) Reason() string {
+	var parts []string
+	if c.Confidence > ConfidenceNone {
+		if c.Entry != "" {
+			parts = append(parts, fmt.Sprintf("content of %s matches (%s)", c.Entry, c.Confidence))
+		} else {
+			parts = append(parts, fmt.Sprintf("content matches (%s)", c.Confidence))
+		}
+	}
+	if c.Extension != "" {
+		parts = append(parts, "extension ."+c.Extension)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Detection is the outcome of detecting the source of a file
+type Detection struct {
+	Path string
+	// Kind is "file", "zip archive" or "directory"
+	Kind string
+	// Sniffed counts the files or archive entries looked at
+	Sniffed int
+	// Candidates are the sources with any confidence or a matching
+	// extension, best first
+	Candidates []*Candidate
+}
+
+// Best returns the source to import with: the most confident candidate,
+// or the first one with a matching extension when no content matched
+func (d *Detection) Best() *Candidate {
+	if len(d.Candidates) == 0 {
+		return nil
+	}
+	return d.Candidates[0]
+}
+
+// Detect sniffs a file, zip archive or directory and ranks the sources
+// that could import it. Ties go to the source registered first.
+func (sm *SourceManager) Detect(filePath string) (*Detection, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	detection := &Detection{Path: filePath, Kind: "file"}
+	candidates := make(map[string]*Candidate)
+	// consider records the confidence of an eligible source in a sample
+	consider := func(name string, confidence Confidence, entry string) {
+		c, ok := candidates[name]
+		if !ok {
+			c = &Candidate{Name: name, Source: sm.sources[name]}
+			candidates[name] = c
+		}
+		if confidence > c.Confidence {
+			c.Confidence, c.Entry = confidence, entry
+		}
+	}
+	sniffAll := func(eligible []string, header []byte, entry string) {
+		detection.Sniffed++
+		for _, name := range eligible {
+			consider(name, sm.sources[name].Sniff(header), entry)
+		}
+	}
+
+	switch {
+	case info.IsDir():
+		detection.Kind = "directory"
+		eligible := sm.supporting("directory")
+		err = filepath.Walk(filePath, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				if path != filePath && strings.HasPrefix(info.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if detection.Sniffed >= maxDetectFiles {
+				return filepath.SkipAll
+			}
+			header, err := readHeader(path)
+			if err != nil {
+				return err
+			}
+			rel, _ := filepath.Rel(filePath, path)
+			sniffAll(eligible, header, rel)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read directory: %w", err)
+		}
+
+	default:
+		header, err := readHeader(filePath)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.HasPrefix(header, []byte("PK\x03\x04")) {
+			sniffAll(sm.order, header, "")
+			break
+		}
+
+		detection.Kind = "zip archive"
+		eligible := sm.supporting("zip", "kmz")
+		reader, err := zip.OpenReader(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open zip archive: %w", err)
+		}
+		defer reader.Close()
+		for _, f := range reader.File {
+			if f.FileInfo().IsDir() {
+				continue
+			}
+			if detection.Sniffed >= maxDetectFiles {
+				break
+			}
+			header, err := readZipHeader(f)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+			}
+			sniffAll(eligible, header, f.Name)
+		}
+	}
+
+	// Extensions only count for sources eligible for this kind of input
+	for _, name := range sm.order {
+		for _, format := range sm.sources[name].SupportedFormats() {
+			if c, ok := candidates[name]; ok && matchesFormat(filePath, format) {
+				c.Extension = format
+				break
+			}
+		}
+	}
+
+	priority := make(map[string]int, len(sm.order))
+	for i, name := range sm.order {
+		priority[name] = i
+	}
+	for _, c := range candidates {
+		if c.Confidence > ConfidenceNone || c.Extension != "" {
+			detection.Candidates = append(detection.Candidates, c)
+		}
+	}
+	sort.Slice(detection.Candidates, func(i, j int) bool {
+		a, b := detection.Candidates[i], detection.Candidates[j]
+		if a.Confidence != b.Confidence {
+			return a.Confidence > b.Confidence
+		}
+		if (a.Extension != "") != (b.Extension != "") {
+			return a.Extension != ""
+		}
+		return priority[a.Name] < priority[b.Name]
+	})
+
+	return detection, nil
+}
+
+// supporting returns the names of sources handling any of formats, in
+// priority order
+func (sm *SourceManager) supporting(formats ...string) []string {
+	var names []string
+	for _, name := range sm.order {
+		for _, format := range sm.sources[name].SupportedFormats() {
+			if containsString(formats, format) {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	return names
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func readHeader(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+	return readUpTo(file, headerSize)
+}
+
+func readZipHeader(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return readUpTo(rc, headerSize)
+}
+
+func readUpTo(r io.Reader, n int) ([]byte, error) {
+	header, err := io.ReadAll(io.LimitReader(r, int64(n)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return header, nil
+}
+
+// jsonSample is what could be read of a possibly truncated JSON header
+type jsonSample struct {
+	// Object is true for a top-level object, false for an array
+	Object bool
+	// Top holds the top-level keys, with their value if it is a string
+	Top map[string]string
+	// Keys holds the object keys seen at any depth
+	Keys map[string]bool
+}
+
+// sniffJSON reads as much of a JSON document's structure as the header
+// holds. It returns nil if the header does not start like JSON.
+func sniffJSON(header []byte) *jsonSample {
+	header = bytes.TrimPrefix(header, []byte("\xEF\xBB\xBF"))
+	trimmed := bytes.TrimLeft(header, " \t\r\n")
+	if len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return nil
+	}
+
+	sample := &jsonSample{
+		Object: trimmed[0] == '{',
+		Top:    make(map[string]string),
+		Keys:   make(map[string]bool),
+	}
+
+	// Each frame is an open object or array; objects alternate between
+	// expecting a key and a value
+	type frame struct {
+		object    bool
+		expectKey bool
+		key       string
+	}
+	var stack []*frame
+
+	dec := json.NewDecoder(bytes.NewReader(trimmed))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return sample // end of header, or of the document
+		}
+
+		var top *frame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+		if top != nil && top.object && top.expectKey {
+			if key, ok := tok.(string); ok {
+				top.key, top.expectKey = key, false
+				sample.Keys[key] = true
+				if len(stack) == 1 {
+					sample.Top[key] = ""
+				}
+				continue
+			}
+		}
+
+		switch t := tok.(type) {
+		case json.Delim:
+			if t == '}' || t == ']' {
+				stack = stack[:len(stack)-1]
+				continue
+			}
+			if top != nil {
+				top.expectKey = true
+			}
+			stack = append(stack, &frame{object: t == '{', expectKey: t == '{'})
+		default:
+			if top != nil {
+				if s, ok := t.(string); ok && len(stack) == 1 && top.object {
+					sample.Top[top.key] = s
+				}
+				top.expectKey = true
+			}
+		}
+	}
+}
+
+// hasAny reports whether any of the keys were seen
+func (s *jsonSample) hasAny(keys ...string) bool {
+	for _, key := range keys {
+		if s.Keys[key] {
+			return true
+		}
+	}
+	return false
+}
+
+// sniffXMLRoot returns the name of the root element of an XML header, or
+// "" if the header is not XML
+func sniffXMLRoot(header []byte) string {
+	trimmed := bytes.TrimLeft(bytes.TrimPrefix(header, []byte("\xEF\xBB\xBF")), " \t\r\n")
+	if !bytes.HasPrefix(trimmed, []byte("<")) {
+		return ""
+	}
+	dec := xml.NewDecoder(bytes.NewReader(trimmed))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return ""
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return start.Name.Local
+		}
+	}
+}
+
+// sniffCSVHeader returns the lower-cased column names of a delimited text
+// header, or nil if the header does not look like one
+func sniffCSVHeader(header []byte) []string {
+	decoded, _ := decodeText(bytes.NewReader(header))
+	text, _ := io.ReadAll(decoded)
+	trimmed := bytes.TrimLeft(text, " \t\r\n")
+	if len(trimmed) == 0 || bytes.ContainsAny(trimmed[:1], "{[<") || bytes.IndexByte(trimmed, 0) >= 0 {
+		return nil
+	}
+
+	line, _, _ := bytes.Cut(trimmed, []byte("\n"))
+	delimiter := detectDelimiter(line)
+	if !bytes.ContainsRune(line, delimiter) {
+		return nil
+	}
+
+	var columns []string
+	for _, column := range strings.Split(string(bytes.TrimRight(line, "\r")), string(delimiter)) {
+		columns = append(columns, strings.ToLower(strings.Trim(strings.TrimSpace(column), `"`)))
+	}
+	return columns
+}
+
+// hasColumn reports whether any of names is among columns
+func hasColumn(columns []string, names ...string) bool {
+	for _, name := range names {
+		if containsString(columns, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+package sources
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceManager_Detect(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		want     string
+		wantConf Confidence
+	}{
+		{"takeout saved places", "Saved Places.json",
+			`{"type": "FeatureCollection", "features": [{"geometry": {"coordinates": [1, 2], "type": "Point"},
+			"properties": {"google_maps_url": "http://maps.google.com/?cid=1", "location": {"name": "A"}}}]}`,
+			"takeout", ConfidenceHigh},
+		{"takeout saved lists", "lists.json", `{"lists": [{"name": "Favorites", "places": []}]}`, "takeout", ConfidenceHigh},
+		{"takeout csv", "Want to go.csv", "Title,Note,URL,Tags,Comment\nCafe,,https://maps.google.com,,\n", "takeout", ConfidenceHigh},
+		{"overpass json", "export.json", `{"version": 0.6, "generator": "Overpass API", "elements": []}`, "osm", ConfidenceHigh},
+		{"foursquare", "checkins.json", `{"checkins": [{"venue": {"name": "Cafe"}}]}`, "foursquare", ConfidenceHigh},
+		{"semantic location history", "2024_JANUARY.json", `{"timelineObjects": [{"placeVisit": {}}]}`, "timeline", ConfidenceHigh},
+		{"ios location history", "location-history.json", `[{"startTime": "2024-01-01", "visit": {"topCandidate": {}}}]`, "timeline", ConfidenceHigh},
+		{"geojson with json extension", "parks.json",
+			`{"type": "FeatureCollection", "features": [{"type": "Feature", "properties": {"name": "Park"}}]}`,
+			"geojson", ConfidenceMedium},
+		{"spreadsheet", "team.csv", "Title;Y;X\nCafe;38,7;-9,1\n", "csv", ConfidenceMedium},
+		{"gpx", "track.gpx", `<?xml version="1.0"?><gpx version="1.1"><wpt lat="1" lon="2"/></gpx>`, "apple", ConfidenceHigh},
+		{"my maps kml", "offsite.kml", myMapsKML, "mymaps", ConfidenceHigh},
+		{"plain kml", "places.kml", `<kml><Document><Placemark><name>A</name></Placemark></Document></kml>`, "apple", ConfidenceMedium},
+		{"misnamed overpass export", "data.txt", `{"elements": [{"type": "node"}]}`, "osm", ConfidenceHigh},
+	}
+
+	sm := NewSourceManager()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0644))
+
+			detection, err := sm.Detect(path)
+			require.NoError(t, err)
+			best := detection.Best()
+			require.NotNil(t, best)
+			assert.Equal(t, tt.want, best.Name, "candidates: %v", candidateNames(detection))
+			assert.Equal(t, tt.wantConf, best.Confidence)
+			assert.NotEmpty(t, best.Reason())
+		})
+	}
+}
+
+func TestSourceManager_DetectIsDeterministic(t *testing.T) {
+	// Both takeout and osm claim .json; with no content match the
+	// registration order decides, the same way every time
+	path := filepath.Join(t.TempDir(), "empty.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{}`), 0644))
+
+	sm := NewSourceManager()
+	for i := 0; i < 20; i++ {
+		detection, err := sm.Detect(path)
+		require.NoError(t, err)
+		require.NotNil(t, detection.Best())
+		assert.Equal(t, "takeout", detection.Best().Name)
+		assert.Equal(t, ConfidenceNone, detection.Best().Confidence)
+		assert.Equal(t, "extension .json", detection.Best().Reason())
+	}
+}
+
+func TestSourceManager_DetectArchivesAndDirectories(t *testing.T) {
+	sm := NewSourceManager()
+
+	detection, err := sm.Detect(writeTakeoutZip(t, 3))
+	require.NoError(t, err)
+	assert.Equal(t, "zip archive", detection.Kind)
+	assert.Equal(t, 2, detection.Sniffed)
+	require.NotNil(t, detection.Best())
+	assert.Equal(t, "takeout", detection.Best().Name)
+	assert.Contains(t, detection.Best().Reason(), "Saved Places.json")
+
+	detection, err = sm.Detect(writeKMZ(t, myMapsKML))
+	require.NoError(t, err)
+	require.NotNil(t, detection.Best())
+	assert.Equal(t, "mymaps", detection.Best().Name)
+
+	dir := t.TempDir()
+	history := filepath.Join(dir, "Location History", "Semantic Location History", "2024")
+	require.NoError(t, os.MkdirAll(history, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(history, "2024_MARCH.json"),
+		[]byte(`{"timelineObjects": []}`), 0644))
+	detection, err = sm.Detect(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "directory", detection.Kind)
+	require.NotNil(t, detection.Best())
+	assert.Equal(t, "timeline", detection.Best().Name)
+	assert.Equal(t, NewSourceManager().GetSource("timeline").Name(), detection.Best().Source.Name())
+}
+
+func TestSourceManager_DetectUnknown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	require.NoError(t, os.WriteFile(path, []byte("just some notes\n"), 0644))
+
+	sm := NewSourceManager()
+	detection, err := sm.Detect(path)
+	require.NoError(t, err)
+	assert.Nil(t, detection.Best())
+	assert.Nil(t, sm.DetectSource(path))
+
+	_, err = sm.ImportFromFile(path)
+	assert.IsType(t, &UnsupportedFormatError{}, err)
+}
+
+func TestSniffJSON_Truncated(t *testing.T) {
+	full := `{"type": "FeatureCollection", "features": [{"properties": {"google_maps_url": "x"}}, {"properties": {"more": 1}}]}`
+	sample := sniffJSON([]byte(full[:strings.Index(full, `"more"`)]))
+	require.NotNil(t, sample)
+	assert.True(t, sample.Object)
+	assert.Equal(t, "FeatureCollection", sample.Top["type"])
+	assert.Contains(t, sample.Top, "features")
+	assert.NotContains(t, sample.Top, "google_maps_url", "nested keys are not top-level")
+	assert.True(t, sample.Keys["google_maps_url"])
+
+	assert.Nil(t, sniffJSON([]byte("name,lat,lng")))
+}
+
+func candidateNames(d *Detection) []string {
+	var names []string
+	for _, c := range d.Candidates {
+		names = append(names, c.Name+":"+c.Confidence.String())
+	}
+	return names
+}
@@ -1,6 +1,7 @@
 package sources
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
@@ -121,19 +122,50 @@ func (fi *FoursquareImporter) SupportedFormats() []string {
 	return []string{"json"}
 }
 
+// Sniff recognises a check-in export
+func (fi *FoursquareImporter) Sniff(header []byte) Confidence {
+	sample := sniffJSON(header)
+	switch {
+	case sample == nil:
+		return ConfidenceNone
+	case sample.Object && sample.Keys["checkins"]:
+		return ConfidenceHigh
+	case sample.Keys["venue"] && sample.Keys["createdAt"]:
+		return ConfidenceMedium
+	}
+	return ConfidenceNone
+}
+
 func (fi *FoursquareImporter) ImportFromFile(filePath string) ([]*models.Place, error) {
+	return collect(func(emit func(*models.Place) error) error {
+		return fi.StreamFromFile(filePath, emit, nil)
+	})
+}
+
+// StreamFromFile decodes check-ins one at a time. Check-ins are grouped by
+// venue, so places are emitted once the whole file has been read.
+func (fi *FoursquareImporter) StreamFromFile(filePath string, emit func(*models.Place) error, progress ProgressFunc) error {
 	file, err := os.Open(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+		return fmt.Errorf("failed to open file: %w", err)
 	}
 	defer file.Close()
 
-	data, err := io.ReadAll(file)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read file: %w", err)
+	var size int64
+	if info, err := file.Stat(); err == nil {
+		size = info.Size()
 	}
 
-	return fi.ImportFromData(data, "json")
+	places, err := fi.parse(newProgressTracker(size, progress).wrap(file))
+	if err != nil {
+		return err
+	}
+	for _, place := range places {
+		if err := emit(place); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (fi *FoursquareImporter) ImportFromData(data []byte, format string) ([]*models.Place, error) {
@@ -141,12 +173,20 @@ func (fi *FoursquareImporter) ImportFromData(data []byte, format string) ([]*mod
 		return nil, fmt.Errorf("unsupported format: %s", format)
 	}
 
-	return fi.parseJSON(data)
+	return fi.parse(bytes.NewReader(data))
 }
 
-func (fi *FoursquareImporter) parseJSON(data []byte) ([]*models.Place, error) {
-	var export FoursquareExport
-	if err := json.Unmarshal(data, &export); err != nil {
+func (fi *FoursquareImporter) parse(r io.Reader) ([]*models.Place, error) {
+	var checkins []FoursquareCheckin
+	err := streamObject(json.NewDecoder(r), map[string]func(*json.Decoder) error{
+		"checkins": func(dec *json.Decoder) error {
+			return streamArray(dec, func(checkin FoursquareCheckin) error {
+				checkins = append(checkins, checkin)
+				return nil
+			})
+		},
+	})
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse Foursquare JSON: %w", err)
 	}
 
@@ -154,13 +194,13 @@ func (fi *FoursquareImporter) parseJSON(data []byte) ([]*models.Place, error) {
 	venueMap := make(map[string]*models.Place)
 	venueCheckins := make(map[string][]FoursquareCheckin)
 
-	for _, checkin := range export.Checkins {
+	for _, checkin := range checkins {
 		venueID := checkin.Venue.ID
 		if venueID == "" {
 			continue // Skip check-ins without venue ID
 		}
 
-		// Track check-ins per venue for metadata
+		// Track check-ins per venue for the visit log
 		venueCheckins[venueID] = append(venueCheckins[venueID], checkin)
 
 		// Create or update place for this venue
@@ -172,10 +212,9 @@ func (fi *FoursquareImporter) parseJSON(data []byte) ([]*models.Place, error) {
 		}
 	}
 
-	// Add check-in statistics to places
+	// Each check-in becomes a visit in the visit log
 	for venueID, place := range venueMap {
-		checkins := venueCheckins[venueID]
-		fi.addCheckinMetadata(place, checkins)
+		fi.addCheckinVisits(place, venueCheckins[venueID])
 	}
 
 	// Convert map to slice
@@ -307,34 +346,14 @@ func (fi *FoursquareImporter) convertPhotos(photos []FoursquarePhoto) []models.P
 	return result
 }
 
-func (fi *FoursquareImporter) addCheckinMetadata(place *models.Place, checkins []FoursquareCheckin) {
-	if len(checkins) == 0 {
-		return
-	}
-
-	// Find first and last check-in
-	var firstCheckin, lastCheckin int64 = checkins[0].CreatedAt, checkins[0].CreatedAt
-	for _, checkin := range checkins {
-		if checkin.CreatedAt < firstCheckin {
-			firstCheckin = checkin.CreatedAt
-		}
-		if checkin.CreatedAt > lastCheckin {
-			lastCheckin = checkin.CreatedAt
-		}
-	}
-
-	place.CustomFields["my_checkins_count"] = len(checkins)
-	place.CustomFields["first_visit"] = time.Unix(firstCheckin, 0).Format("2006-01-02")
-	place.CustomFields["last_visit"] = time.Unix(lastCheckin, 0).Format("2006-01-02")
-
-	// Collect unique comments
-	var comments []string
+// addCheckinVisits records each check-in at the venue as a visit
+func (fi *FoursquareImporter) addCheckinVisits(place *models.Place, checkins []FoursquareCheckin) {
 	for _, checkin := range checkins {
-		if checkin.Comments != "" {
-			comments = append(comments, checkin.Comments)
-		}
-	}
-	if len(comments) > 0 {
-		place.CustomFields["checkin_comments"] = comments
+		place.Visits = append(place.Visits, models.Visit{
+			VisitedAt: time.Unix(checkin.CreatedAt, 0),
+			Note:      checkin.Comments,
+			Source:    "foursquare",
+			SourceID:  checkin.ID,
+		})
 	}
 }
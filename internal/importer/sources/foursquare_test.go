@@ -0,0 +1,37 @@
+package sources
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFoursquareImporter_CheckinsBecomeVisits(t *testing.T) {
+	data := []byte(`{"checkins": [
+		{"id": "c1", "createdAt": 1715970000, "comments": "sardines!",
+		 "venue": {"id": "v1", "name": "Cervejaria Ramiro", "location": {"lat": 38.72, "lng": -9.136, "city": "Lisboa"}}},
+		{"id": "c2", "createdAt": 1690970000,
+		 "venue": {"id": "v1", "name": "Cervejaria Ramiro", "location": {"lat": 38.72, "lng": -9.136, "city": "Lisboa"}}},
+		{"id": "c3", "createdAt": 1700000000,
+		 "venue": {"id": "v2", "name": "Time Out Market"}}
+	]}`)
+
+	places, err := (&FoursquareImporter{}).ImportFromData(data, "json")
+	require.NoError(t, err)
+	require.Len(t, places, 2)
+
+	for _, place := range places {
+		if place.Name != "Cervejaria Ramiro" {
+			assert.Len(t, place.Visits, 1)
+			continue
+		}
+		require.Len(t, place.Visits, 2)
+		assert.Equal(t, "c1", place.Visits[0].SourceID)
+		assert.Equal(t, "foursquare", place.Visits[0].Source)
+		assert.Equal(t, "sardines!", place.Visits[0].Note)
+		assert.True(t, place.Visits[1].VisitedAt.Equal(time.Unix(1690970000, 0)))
+		assert.NotContains(t, place.CustomFields, "my_checkins_count")
+	}
+}
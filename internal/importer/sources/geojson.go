@@ -0,0 +1,342 @@
+package sources
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/user/placeli/internal/models"
+	"github.com/user/placeli/internal/utils"
+)
+
+// GeoJSONImporter handles GeoJSON files from tools such as QGIS, geojson.io
+// and open-data portals. Which properties hold the name, address, category
+// and so on is guessed from common names or set with a FieldMapping; all
+// other properties become custom fields.
+type GeoJSONImporter struct {
+	Mapping FieldMapping
+}
+
+// GeoJSONFeature is a feature of a FeatureCollection
+type GeoJSONFeature struct {
+	ID         json.RawMessage        `json:"id"`
+	Geometry   json.RawMessage        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geoJSONObject struct {
+	Type        string            `json:"type"`
+	Coordinates json.RawMessage   `json:"coordinates"`
+	Geometries  []json.RawMessage `json:"geometries"`
+}
+
+func (gi *GeoJSONImporter) Name() string {
+	return "GeoJSON"
+}
+
+func (gi *GeoJSONImporter) SupportedFormats() []string {
+	return []string{"geojson"}
+}
+
+// Sniff recognises any FeatureCollection or Feature
+func (gi *GeoJSONImporter) Sniff(header []byte) Confidence {
+	sample := sniffJSON(header)
+	if sample == nil || !sample.Object {
+		return ConfidenceNone
+	}
+	switch sample.Top["type"] {
+	case "FeatureCollection", "Feature":
+		return ConfidenceMedium
+	}
+	return ConfidenceNone
+}
+
+func (gi *GeoJSONImporter) SetFieldMapping(mapping FieldMapping) {
+	gi.Mapping = mapping
+}
+
+func (gi *GeoJSONImporter) ImportFromFile(filePath string) ([]*models.Place, error) {
+	return collect(func(emit func(*models.Place) error) error {
+		return gi.StreamFromFile(filePath, emit, nil)
+	})
+}
+
+// StreamFromFile streams the features of a GeoJSON file
+func (gi *GeoJSONImporter) StreamFromFile(filePath string, emit func(*models.Place) error, progress ProgressFunc) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	var size int64
+	if info, err := file.Stat(); err == nil {
+		size = info.Size()
+	}
+	return gi.stream(newProgressTracker(size, progress).wrap(file), emit)
+}
+
+func (gi *GeoJSONImporter) ImportFromData(data []byte, format string) ([]*models.Place, error) {
+	if format != "geojson" && format != "json" {
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
+	return collect(func(emit func(*models.Place) error) error {
+		return gi.stream(bytes.NewReader(data), emit)
+	})
+}
+
+// stream decodes a FeatureCollection one feature at a time. A single
+// Feature is accepted too.
+func (gi *GeoJSONImporter) stream(r io.Reader, emit func(*models.Place) error) error {
+	emit = guardEmit(emit)
+	var docType string
+	var single GeoJSONFeature
+
+	convert := func(feature GeoJSONFeature) error {
+		if place := gi.convertFeature(feature); place != nil {
+			return emit(place)
+		}
+		return nil
+	}
+
+	dec := json.NewDecoder(r)
+	err := streamObject(dec, map[string]func(*json.Decoder) error{
+		"type": func(dec *json.Decoder) error {
+			return dec.Decode(&docType)
+		},
+		"features": func(dec *json.Decoder) error {
+			return streamArray(dec, convert)
+		},
+		"id": func(dec *json.Decoder) error {
+			return dec.Decode(&single.ID)
+		},
+		"geometry": func(dec *json.Decoder) error {
+			return dec.Decode(&single.Geometry)
+		},
+		"properties": func(dec *json.Decoder) error {
+			return dec.Decode(&single.Properties)
+		},
+	})
+	if emitErr, ok := unwrapEmitError(err); ok {
+		return emitErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse GeoJSON: %w", err)
+	}
+
+	switch docType {
+	case "FeatureCollection":
+		return nil
+	case "Feature":
+		if err := convert(single); err != nil {
+			emitErr, _ := unwrapEmitError(err)
+			return emitErr
+		}
+		return nil
+	default:
+		return fmt.Errorf("not a GeoJSON FeatureCollection or Feature (type %q)", docType)
+	}
+}
+
+func (gi *GeoJSONImporter) convertFeature(feature GeoJSONFeature) *models.Place {
+	coords, geometry, ok := featureGeometry(feature.Geometry)
+	if !ok {
+		return nil // Skip features without usable geometry
+	}
+
+	props := feature.Properties
+	keys := make([]string, 0, len(props))
+	for key := range props {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	fields := gi.Mapping.resolve(keys, detailFields)
+
+	name := propertyString(props[fields["name"]])
+	if name == "" {
+		return nil // Skip features without names
+	}
+
+	categories := propertyStrings(props[fields["category"]])
+	geometryType := "Point"
+	if geometry != nil {
+		geometryType = geometry.Type
+		if geometry.IsArea() {
+			categories = append(categories, "Area")
+		} else {
+			categories = append(categories, "Route")
+		}
+	}
+
+	sourceData := fmt.Sprintf("geojson|%s|%s|%f,%f|%s",
+		bytes.TrimSpace(feature.ID), name, coords.Lat, coords.Lng, geometryType)
+	sourceHash := fmt.Sprintf("%x", sha256.Sum256([]byte(sourceData)))
+
+	// placeli's own exports carry the original place ID
+	placeID := propertyString(props["place_id"])
+	if placeID == "" {
+		placeID = fmt.Sprintf("geojson_%s", sourceHash[:16])
+	}
+
+	tags := propertyStrings(props[fields["tags"]])
+	if tags == nil {
+		tags = []string{}
+	}
+
+	now := time.Now()
+	place := &models.Place{
+		ID:          utils.GenerateID(placeID),
+		PlaceID:     placeID,
+		Name:        name,
+		Address:     propertyString(props[fields["address"]]),
+		Coordinates: coords,
+		Geometry:    geometry,
+		Categories:  categories,
+		Phone:       propertyString(props[fields["phone"]]),
+		Website:     propertyString(props[fields["website"]]),
+		UserNotes:   propertyString(props[fields["notes"]]),
+		UserTags:    tags,
+		Photos:      []models.Photo{},
+		Reviews:     []models.Review{},
+		CustomFields: map[string]interface{}{
+			"imported_from": "geojson",
+			"import_date":   now.Format(time.RFC3339),
+		},
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		ImportedAt: &now,
+		SourceHash: sourceHash,
+	}
+
+	mapped := map[string]bool{"place_id": true, "custom_fields": true}
+	for field, key := range fields {
+		mapped[key] = true
+		if custom, ok := strings.CutPrefix(field, customFieldPrefix); ok && props[key] != nil {
+			place.CustomFields[custom] = props[key]
+		}
+	}
+
+	// Unmapped properties become custom fields
+	for _, key := range keys {
+		value := props[key]
+		if mapped[key] || value == nil || value == "" {
+			continue
+		}
+		place.CustomFields["geojson_"+key] = value
+	}
+
+	// Custom fields of a placeli export are restored as they were
+	if custom, ok := props["custom_fields"].(map[string]interface{}); ok {
+		for key, value := range custom {
+			if _, exists := place.CustomFields[key]; !exists {
+				place.CustomFields[key] = value
+			}
+		}
+	}
+
+	return place
+}
+
+// featureGeometry returns where to put a feature and, for lines and
+// polygons, its geometry. As with KML, polygons win over lines and lines
+// over points when a GeometryCollection mixes them.
+func featureGeometry(raw json.RawMessage) (models.Coordinates, *models.Geometry, bool) {
+	var points []models.Position
+	var lines [][]models.Position
+	var polygons [][][]models.Position
+
+	var add func(raw json.RawMessage)
+	add = func(raw json.RawMessage) {
+		var obj geoJSONObject
+		if err := json.Unmarshal(raw, &obj); err != nil {
+			return
+		}
+		switch obj.Type {
+		case "Point":
+			var point []float64
+			if json.Unmarshal(obj.Coordinates, &point) == nil && len(point) >= 2 {
+				points = append(points, models.Position{point[0], point[1]})
+			}
+		case "MultiPoint":
+			var multi [][]float64
+			if json.Unmarshal(obj.Coordinates, &multi) == nil {
+				for _, point := range multi {
+					if len(point) >= 2 {
+						points = append(points, models.Position{point[0], point[1]})
+					}
+				}
+			}
+		case "GeometryCollection":
+			for _, member := range obj.Geometries {
+				add(member)
+			}
+		default:
+			var geometry models.Geometry
+			if json.Unmarshal(raw, &geometry) == nil {
+				lines = append(lines, geometry.Lines...)
+				polygons = append(polygons, geometry.Polygons...)
+			}
+		}
+	}
+	add(raw)
+
+	if geometry := models.NewPolygonGeometry(polygons...); geometry != nil {
+		return geometry.Centroid(), geometry, true
+	}
+	if geometry := models.NewLineGeometry(lines...); geometry != nil {
+		return geometry.Centroid(), geometry, true
+	}
+	for _, point := range points {
+		c := point.Coordinates()
+		if c.Lat >= -90 && c.Lat <= 90 && c.Lng >= -180 && c.Lng <= 180 {
+			return c, nil, true
+		}
+	}
+	return models.Coordinates{}, nil, false
+}
+
+// propertyString formats a scalar property value as text
+func propertyString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return ""
+}
+
+// propertyStrings reads a list property: an array, or a comma or semicolon
+// separated string
+func propertyStrings(value interface{}) []string {
+	var parts []string
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			parts = append(parts, propertyString(item))
+		}
+	case string:
+		parts = strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ';' })
+	default:
+		if s := propertyString(v); s != "" {
+			parts = []string{s}
+		}
+	}
+
+	var values []string
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
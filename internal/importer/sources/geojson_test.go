@@ -0,0 +1,127 @@
+package sources
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/placeli/internal/models"
+)
+
+const openDataGeoJSON = `{
+  "type": "FeatureCollection",
+  "name": "equipamentos",
+  "features": [
+    {"type": "Feature", "id": 7,
+     "geometry": {"type": "Point", "coordinates": [-9.1393, 38.7223]},
+     "properties": {"NOME": "Biblioteca Camões", "TIPO": "Library", "Description": "Open Sundays",
+                    "MORADA": "Largo do Calhariz 17", "capacity": 120, "wifi": true, "empty": null}},
+    {"type": "Feature",
+     "geometry": {"type": "LineString", "coordinates": [[-9.20, 38.69], [-9.18, 38.69]]},
+     "properties": {"NOME": "Ciclovia", "TIPO": "Cycle path"}},
+    {"type": "Feature",
+     "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [2, 0], [2, 2], [0, 2], [0, 0]]]},
+     "properties": {"NOME": "Jardim", "tags": ["park", "green"]}},
+    {"type": "Feature", "geometry": null, "properties": {"NOME": "No geometry"}},
+    {"type": "Feature", "geometry": {"type": "Point", "coordinates": [1, 1]}, "properties": {}}
+  ]
+}`
+
+func TestGeoJSONImporter_Mapping(t *testing.T) {
+	gi := &GeoJSONImporter{}
+	mapping, err := ParseFieldMapping("name=NOME, category=TIPO, address=MORADA, field.seats=capacity")
+	require.NoError(t, err)
+	gi.SetFieldMapping(mapping)
+
+	places, err := gi.ImportFromData([]byte(openDataGeoJSON), "geojson")
+	require.NoError(t, err)
+	require.Len(t, places, 3, "features without geometry or name are skipped")
+
+	library := places[0]
+	assert.Equal(t, "Biblioteca Camões", library.Name)
+	assert.Equal(t, "Largo do Calhariz 17", library.Address)
+	assert.Equal(t, []string{"Library"}, library.Categories)
+	assert.Equal(t, "Open Sundays", library.UserNotes, "notes found by default name")
+	assert.InDelta(t, 38.7223, library.Coordinates.Lat, 1e-9)
+	assert.Nil(t, library.Geometry)
+	assert.Equal(t, float64(120), library.CustomFields["seats"])
+	assert.NotContains(t, library.CustomFields, "geojson_capacity")
+	assert.Equal(t, true, library.CustomFields["geojson_wifi"])
+	assert.NotContains(t, library.CustomFields, "geojson_NOME")
+	assert.NotContains(t, library.CustomFields, "geojson_empty")
+
+	path := places[1]
+	require.NotNil(t, path.Geometry)
+	assert.Equal(t, "LineString", path.Geometry.Type)
+	assert.Equal(t, []string{"Cycle path", "Route"}, path.Categories)
+
+	park := places[2]
+	require.NotNil(t, park.Geometry)
+	assert.True(t, park.Geometry.IsArea())
+	assert.Equal(t, []string{"park", "green"}, park.UserTags)
+	assert.InDelta(t, 1, park.Coordinates.Lat, 1e-9)
+}
+
+func TestGeoJSONImporter_DefaultsAndDisabledField(t *testing.T) {
+	data := []byte(`{"type": "Feature",
+		"geometry": {"type": "GeometryCollection", "geometries": [
+			{"type": "Point", "coordinates": [5, 5]},
+			{"type": "MultiLineString", "coordinates": [[[0, 0], [0, 1]]]}
+		]},
+		"properties": {"title": "Trailhead", "type": "trail", "website": "https://example.org"}}`)
+
+	places, err := (&GeoJSONImporter{}).ImportFromData(data, "json")
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+	assert.Equal(t, "Trailhead", places[0].Name)
+	assert.Equal(t, []string{"trail", "Route"}, places[0].Categories)
+	assert.Equal(t, "https://example.org", places[0].Website)
+	assert.Equal(t, "LineString", places[0].Geometry.Type)
+
+	mapping, err := ParseFieldMapping("category=")
+	require.NoError(t, err)
+	places, err = (&GeoJSONImporter{Mapping: mapping}).ImportFromData(data, "json")
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+	assert.Equal(t, []string{"Route"}, places[0].Categories)
+	assert.Equal(t, "trail", places[0].CustomFields["geojson_type"])
+}
+
+func TestGeoJSONImporter_RoundTripsExport(t *testing.T) {
+	data := []byte(`{"type": "FeatureCollection", "features": [{"type": "Feature",
+		"geometry": {"type": "Point", "coordinates": [-9.14, 38.71]},
+		"properties": {"id": "abc", "place_id": "ChIJ123", "name": "Café", "categories": ["cafe"],
+			"user_notes": "Good pastries", "user_tags": ["breakfast"],
+			"custom_fields": {"visited": "yes"}}}]}`)
+
+	path := filepath.Join(t.TempDir(), "export.geojson")
+	require.NoError(t, os.WriteFile(path, data, 0644))
+
+	var places []*models.Place
+	err := (&GeoJSONImporter{}).StreamFromFile(path, func(place *models.Place) error {
+		places = append(places, place)
+		return nil
+	}, nil)
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+
+	place := places[0]
+	assert.Equal(t, "ChIJ123", place.PlaceID)
+	assert.Equal(t, []string{"cafe"}, place.Categories)
+	assert.Equal(t, "Good pastries", place.UserNotes)
+	assert.Equal(t, []string{"breakfast"}, place.UserTags)
+	assert.Equal(t, "yes", place.CustomFields["visited"])
+	assert.NotContains(t, place.CustomFields, "geojson_custom_fields")
+}
+
+func TestGeoJSONImporter_Invalid(t *testing.T) {
+	_, err := (&GeoJSONImporter{}).ImportFromData([]byte(`{"elements": []}`), "geojson")
+	assert.ErrorContains(t, err, "not a GeoJSON")
+
+	_, err = ParseFieldMapping("colour=COR")
+	assert.ErrorContains(t, err, "unknown field")
+	_, err = ParseFieldMapping("name")
+	assert.Error(t, err)
+}
@@ -1,6 +1,8 @@
 package sources
 
 import (
+	"strings"
+
 	"github.com/user/placeli/internal/models"
 )
 
@@ -17,11 +19,17 @@ type ImportSource interface {
 
 	// ImportFromData imports places from raw data
 	ImportFromData(data []byte, format string) ([]*models.Place, error)
+
+	// Sniff reports how likely the source is to import content starting
+	// with header, which is at most the first 64 KiB of a file
+	Sniff(header []byte) Confidence
 }
 
 // SourceManager manages multiple import sources
 type SourceManager struct {
 	sources map[string]ImportSource
+	// order is the registration order, which breaks detection ties
+	order []string
 }
 
 // NewSourceManager creates a new source manager with all available sources
@@ -30,17 +38,26 @@ func NewSourceManager() *SourceManager {
 		sources: make(map[string]ImportSource),
 	}
 
-	// Register all available sources
+	// Register all available sources, most specific first so they win
+	// detection ties against the generic formats
+	sm.RegisterSource("takeout", &TakeoutImporter{})
+	sm.RegisterSource("timeline", &TimelineImporter{})
+	sm.RegisterSource("mymaps", &MyMapsImporter{})
 	sm.RegisterSource("apple", &AppleImporter{})
-	sm.RegisterSource("osm", &OSMImporter{})
 	sm.RegisterSource("foursquare", &FoursquareImporter{})
-	sm.RegisterSource("takeout", &TakeoutImporter{})
+	sm.RegisterSource("osm", &OSMImporter{})
+	sm.RegisterSource("geojson", &GeoJSONImporter{})
+	sm.RegisterSource("csv", &CSVImporter{})
 
 	return sm
 }
 
-// RegisterSource registers a new import source
+// RegisterSource registers a new import source. Sources registered
+// earlier take priority when detection confidence is equal.
 func (sm *SourceManager) RegisterSource(name string, source ImportSource) {
+	if _, exists := sm.sources[name]; !exists {
+		sm.order = append(sm.order, name)
+	}
 	sm.sources[name] = source
 }
 
@@ -54,14 +71,20 @@ func (sm *SourceManager) ListSources() map[string]ImportSource {
 	return sm.sources
 }
 
-// DetectSource attempts to detect the appropriate source for a file
+// Names returns the names of all sources in priority order
+func (sm *SourceManager) Names() []string {
+	return append([]string{}, sm.order...)
+}
+
+// DetectSource returns the source most likely to import a file, judged by
+// its content and then its extension, or nil if none recognises it
 func (sm *SourceManager) DetectSource(filePath string) ImportSource {
-	for _, source := range sm.sources {
-		for _, format := range source.SupportedFormats() {
-			if matchesFormat(filePath, format) {
-				return source
-			}
-		}
+	detection, err := sm.Detect(filePath)
+	if err != nil {
+		return nil
+	}
+	if best := detection.Best(); best != nil {
+		return best.Source
 	}
 	return nil
 }
@@ -70,13 +93,6 @@ func (sm *SourceManager) DetectSource(filePath string) ImportSource {
 func (sm *SourceManager) ImportFromFile(filePath string) ([]*models.Place, error) {
 	source := sm.DetectSource(filePath)
 	if source == nil {
-		// Try to detect from file content
-		for _, src := range sm.sources {
-			places, err := src.ImportFromFile(filePath)
-			if err == nil && len(places) > 0 {
-				return places, nil
-			}
-		}
 		return nil, &UnsupportedFormatError{FilePath: filePath}
 	}
 
@@ -94,23 +110,30 @@ func (e *UnsupportedFormatError) Error() string {
 
 // Helper functions
 
+// matchesFormat reports whether a file's extension fits a format. Content
+// decides first; the extension only breaks ties.
 func matchesFormat(filePath, format string) bool {
-	// Simple extension matching for now
-	// Could be enhanced with more sophisticated detection
+	filePath = strings.ToLower(filePath)
 	switch format {
-	case "kml", "kmz":
-		return hasExtension(filePath, ".kml") || hasExtension(filePath, ".kmz")
+	case "kml":
+		return hasExtension(filePath, ".kml")
+	case "kmz":
+		return hasExtension(filePath, ".kmz")
 	case "gpx":
 		return hasExtension(filePath, ".gpx")
 	case "json":
 		return hasExtension(filePath, ".json")
+	case "geojson":
+		return hasExtension(filePath, ".geojson")
 	case "csv":
 		return hasExtension(filePath, ".csv")
+	case "tsv":
+		return hasExtension(filePath, ".tsv")
 	case "zip":
 		return hasExtension(filePath, ".zip")
 	case "directory":
-		// Check if it's a directory (would need os.Stat)
-		return false // Let individual importers handle directory detection
+		// Directories have no extension; Detect handles them
+		return false
 	}
 	return false
 }
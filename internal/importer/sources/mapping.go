@@ -0,0 +1,144 @@
+package sources
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FieldMapping names the property or column that holds each place field,
+// keyed by field name (see MappableFields). Keys of the form field.<name>
+// fill the custom field <name>. An empty value means the field is never
+// filled in, even where a default would match.
+type FieldMapping map[string]string
+
+// MappableFields are the place fields a FieldMapping can set
+var MappableFields = []string{"name", "address", "category", "notes", "tags", "phone", "website", "lat", "lng", "geometry"}
+
+// detailFields are the mappable fields other than the location, which
+// GeoJSON takes from the feature geometry instead
+var detailFields = []string{"name", "address", "category", "notes", "tags", "phone", "website"}
+
+// customFieldPrefix marks a mapping to a custom field
+const customFieldPrefix = "field."
+
+// defaultFieldNames are the property or column names tried, ignoring case,
+// for fields that are not mapped explicitly
+var defaultFieldNames = map[string][]string{
+	"name":     {"name", "title", "label"},
+	"address":  {"address", "addr", "full_address", "street_address"},
+	"category": {"categories", "category", "amenity", "type", "kind", "class"},
+	"notes":    {"user_notes", "notes", "note", "description", "desc", "comment"},
+	"tags":     {"user_tags", "tags", "labels"},
+	"phone":    {"phone", "telephone", "tel", "contact:phone"},
+	"website":  {"website", "url", "contact:website"},
+	"lat":      {"lat", "latitude", "y"},
+	"lng":      {"lng", "lon", "long", "longitude", "x"},
+	"geometry": {"wkt", "geometry", "geom", "the_geom", "shape"},
+}
+
+// MappedSource is implemented by sources whose input has no fixed schema,
+// so which property or column holds each field can be configured
+type MappedSource interface {
+	ImportSource
+
+	// SetFieldMapping overrides the default names for the mapped fields
+	SetFieldMapping(mapping FieldMapping)
+}
+
+// ParseFieldMapping parses a mapping such as
+// "name=Title,lat=Y,lng=X,field.priority=Prio". Leaving out the property,
+// as in "category=", turns the field off. Later pairs override earlier
+// ones, so a saved mapping can be adjusted by appending to it.
+func ParseFieldMapping(spec string) (FieldMapping, error) {
+	mapping := make(FieldMapping)
+	for _, pair := range strings.Split(spec, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		field, property, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid mapping %q, expected field=property", pair)
+		}
+		field = strings.TrimSpace(field)
+		if name, custom := strings.CutPrefix(field, customFieldPrefix); custom {
+			if name == "" {
+				return nil, fmt.Errorf("invalid mapping %q, missing custom field name", pair)
+			}
+		} else {
+			field = strings.ToLower(field)
+			if _, known := defaultFieldNames[field]; !known {
+				return nil, fmt.Errorf("unknown field %q in mapping, expected one of: %s, or field.<name>",
+					field, strings.Join(MappableFields, ", "))
+			}
+		}
+		mapping[field] = strings.TrimSpace(property)
+	}
+	return mapping, nil
+}
+
+// String formats the mapping the way ParseFieldMapping reads it
+func (m FieldMapping) String() string {
+	pairs := make([]string, 0, len(m))
+	for field, property := range m {
+		pairs = append(pairs, field+"="+property)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// resolve returns, for each of fields and each custom field mapping, the
+// key of keys that holds it. keys are the property names or column headers
+// of the input.
+func (m FieldMapping) resolve(keys []string, fields []string) map[string]string {
+	resolved := make(map[string]string)
+	used := make(map[string]bool)
+
+	// Explicit mappings first, so defaults never take a mapped key
+	explicit := append([]string{}, fields...)
+	for field := range m {
+		if strings.HasPrefix(field, customFieldPrefix) {
+			explicit = append(explicit, field)
+		}
+	}
+	sort.Strings(explicit[len(fields):])
+	for _, field := range explicit {
+		property, ok := m[field]
+		if !ok {
+			continue
+		}
+		if key := findKey(keys, property); property != "" && key != "" {
+			resolved[field] = key
+			used[key] = true
+		}
+	}
+
+	for _, field := range fields {
+		if _, ok := m[field]; ok {
+			continue
+		}
+		for _, name := range defaultFieldNames[field] {
+			if key := findKey(keys, name); key != "" && !used[key] {
+				resolved[field] = key
+				used[key] = true
+				break
+			}
+		}
+	}
+	return resolved
+}
+
+// findKey returns the key equal to name, or else the first one equal to it
+// ignoring case
+func findKey(keys []string, name string) string {
+	match := ""
+	for _, key := range keys {
+		if key == name {
+			return key
+		}
+		if match == "" && strings.EqualFold(key, name) {
+			match = key
+		}
+	}
+	return match
+}
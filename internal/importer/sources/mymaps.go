@@ -0,0 +1,456 @@
+package sources
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/user/placeli/internal/models"
+	"github.com/user/placeli/internal/utils"
+)
+
+// MyMapsImporter handles KMZ and KML exports from Google My Maps. Layers
+// become tags, icon styles become categories and lines and polygons are
+// imported as routes and areas with their geometry.
+type MyMapsImporter struct{}
+
+// MyMapsKML is the root of a My Maps KML document
+type MyMapsKML struct {
+	XMLName  xml.Name     `xml:"kml"`
+	Document MyMapsFolder `xml:"Document"`
+}
+
+// MyMapsFolder is the document or one of its layers. Layers may be nested.
+type MyMapsFolder struct {
+	Name       string            `xml:"name"`
+	Styles     []MyMapsStyle     `xml:"Style"`
+	StyleMaps  []MyMapsStyleMap  `xml:"StyleMap"`
+	Folders    []MyMapsFolder    `xml:"Folder"`
+	Placemarks []MyMapsPlacemark `xml:"Placemark"`
+}
+
+type MyMapsStyle struct {
+	ID   string `xml:"id,attr"`
+	Icon string `xml:"IconStyle>Icon>href"`
+}
+
+type MyMapsStyleMap struct {
+	ID    string `xml:"id,attr"`
+	Pairs []struct {
+		Key      string `xml:"key"`
+		StyleURL string `xml:"styleUrl"`
+	} `xml:"Pair"`
+}
+
+type MyMapsPlacemark struct {
+	Name         string             `xml:"name"`
+	Description  string             `xml:"description"`
+	Address      string             `xml:"address"`
+	StyleURL     string             `xml:"styleUrl"`
+	ExtendedData MyMapsExtendedData `xml:"ExtendedData"`
+
+	Point         *KMLPoint            `xml:"Point"`
+	LineString    *MyMapsLineString    `xml:"LineString"`
+	Polygon       *MyMapsPolygon       `xml:"Polygon"`
+	MultiGeometry *MyMapsMultiGeometry `xml:"MultiGeometry"`
+}
+
+// MyMapsExtendedData holds the columns of a My Maps layer table, either as
+// Data elements or as typed SchemaData
+type MyMapsExtendedData struct {
+	Data       []KMLData `xml:"Data"`
+	SimpleData []struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"SchemaData>SimpleData"`
+}
+
+type MyMapsLineString struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+type MyMapsPolygon struct {
+	Outer string   `xml:"outerBoundaryIs>LinearRing>coordinates"`
+	Inner []string `xml:"innerBoundaryIs>LinearRing>coordinates"`
+}
+
+type MyMapsMultiGeometry struct {
+	Points      []KMLPoint         `xml:"Point"`
+	LineStrings []MyMapsLineString `xml:"LineString"`
+	Polygons    []MyMapsPolygon    `xml:"Polygon"`
+}
+
+// myMapsIconCategories maps the icon numbers in My Maps style IDs
+// ("icon-1577-0288D1") to categories
+var myMapsIconCategories = map[string]string{
+	"1517": "Bar",
+	"1534": "Cafe",
+	"1577": "Restaurant",
+	"1602": "Hotel",
+}
+
+// iconNameCategories maps words in icon file names, as used by Google
+// Earth and older My Maps exports, to categories
+var iconNameCategories = []struct {
+	keyword  string
+	category string
+}{
+	{"dining", "Restaurant"},
+	{"restaurant", "Restaurant"},
+	{"coffee", "Cafe"},
+	{"cafe", "Cafe"},
+	{"bars", "Bar"},
+	{"lodging", "Hotel"},
+	{"hotel", "Hotel"},
+	{"parks", "Park"},
+	{"museum", "Museum"},
+	{"shopping", "Shopping"},
+	{"airports", "Airport"},
+	{"train", "Station"},
+}
+
+var myMapsIconID = regexp.MustCompile(`^icon-(\d+)`)
+
+// myMapsStylePattern matches the style IDs My Maps gives points, lines and
+// polygons, such as icon-1577-0288D1-normal
+var myMapsStylePattern = regexp.MustCompile(`id="(icon|line|poly)-\d+-[0-9A-F]{6}`)
+
+func (mi *MyMapsImporter) Name() string {
+	return "Google My Maps"
+}
+
+func (mi *MyMapsImporter) SupportedFormats() []string {
+	return []string{"kmz", "mymaps"}
+}
+
+// Sniff recognises KML with My Maps' icon styles. Other KML is left to
+// the Apple Maps source unless nothing else matches.
+func (mi *MyMapsImporter) Sniff(header []byte) Confidence {
+	if sniffXMLRoot(header) != "kml" {
+		return ConfidenceNone
+	}
+	if bytes.Contains(header, []byte("mapspro")) || myMapsStylePattern.Match(header) {
+		return ConfidenceHigh
+	}
+	return ConfidenceLow
+}
+
+func (mi *MyMapsImporter) ImportFromFile(filePath string) ([]*models.Place, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	format := "kml"
+	if strings.HasSuffix(strings.ToLower(filePath), ".kmz") {
+		format = "kmz"
+	}
+
+	return mi.ImportFromData(data, format)
+}
+
+func (mi *MyMapsImporter) ImportFromData(data []byte, format string) ([]*models.Place, error) {
+	switch format {
+	case "kmz":
+		kml, err := extractKMZ(data)
+		if err != nil {
+			return nil, err
+		}
+		return mi.parseKML(kml)
+	case "kml":
+		return mi.parseKML(data)
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// extractKMZ returns the main KML document of a KMZ archive: doc.kml, or
+// the first KML file when there is no doc.kml
+func extractKMZ(data []byte) ([]byte, error) {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open KMZ: %w", err)
+	}
+
+	var kml *zip.File
+	for _, file := range reader.File {
+		if !strings.EqualFold(path.Ext(file.Name), ".kml") {
+			continue
+		}
+		if kml == nil || strings.EqualFold(file.Name, "doc.kml") {
+			kml = file
+		}
+	}
+	if kml == nil {
+		return nil, fmt.Errorf("no KML document found in KMZ")
+	}
+
+	rc, err := kml.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", kml.Name, err)
+	}
+	defer rc.Close()
+
+	return io.ReadAll(rc)
+}
+
+func (mi *MyMapsImporter) parseKML(data []byte) ([]*models.Place, error) {
+	var kml MyMapsKML
+	if err := xml.Unmarshal(data, &kml); err != nil {
+		return nil, fmt.Errorf("failed to parse KML: %w", err)
+	}
+
+	styles := newMyMapsStyles(kml.Document)
+
+	var places []*models.Place
+	var walk func(folder MyMapsFolder, layers []string)
+	walk = func(folder MyMapsFolder, layers []string) {
+		for _, placemark := range folder.Placemarks {
+			if place := mi.convertPlacemark(placemark, kml.Document.Name, layers, styles); place != nil {
+				places = append(places, place)
+			}
+		}
+		for _, sub := range folder.Folders {
+			walk(sub, append(layers[:len(layers):len(layers)], sub.Name))
+		}
+	}
+	walk(kml.Document, nil)
+
+	return places, nil
+}
+
+// myMapsStyles resolves a placemark's styleUrl to its icon
+type myMapsStyles struct {
+	icons     map[string]string
+	styleMaps map[string]string
+}
+
+func newMyMapsStyles(doc MyMapsFolder) *myMapsStyles {
+	s := &myMapsStyles{icons: make(map[string]string), styleMaps: make(map[string]string)}
+
+	var collect func(folder MyMapsFolder)
+	collect = func(folder MyMapsFolder) {
+		for _, style := range folder.Styles {
+			s.icons[style.ID] = style.Icon
+		}
+		for _, styleMap := range folder.StyleMaps {
+			for _, pair := range styleMap.Pairs {
+				if pair.Key == "normal" {
+					s.styleMaps[styleMap.ID] = strings.TrimPrefix(pair.StyleURL, "#")
+				}
+			}
+		}
+		for _, sub := range folder.Folders {
+			collect(sub)
+		}
+	}
+	collect(doc)
+
+	return s
+}
+
+// resolve returns the style ID a styleUrl points at, following style maps
+// to their normal style, and the style's icon
+func (s *myMapsStyles) resolve(styleURL string) (id, icon string) {
+	id = strings.TrimPrefix(strings.TrimSpace(styleURL), "#")
+	if normal, ok := s.styleMaps[id]; ok {
+		id = normal
+	}
+	return id, s.icons[id]
+}
+
+// category maps a My Maps style to a place category, by icon number first
+// and then by icon file name
+func (s *myMapsStyles) category(styleURL string) string {
+	id, icon := s.resolve(styleURL)
+	if m := myMapsIconID.FindStringSubmatch(id); m != nil {
+		if category, ok := myMapsIconCategories[m[1]]; ok {
+			return category
+		}
+	}
+
+	name := strings.ToLower(path.Base(icon))
+	for _, entry := range iconNameCategories {
+		if strings.Contains(name, entry.keyword) {
+			return entry.category
+		}
+	}
+	return ""
+}
+
+func (mi *MyMapsImporter) convertPlacemark(pm MyMapsPlacemark, mapName string, layers []string, styles *myMapsStyles) *models.Place {
+	name := strings.TrimSpace(pm.Name)
+	if name == "" {
+		return nil // Skip placemarks without names
+	}
+
+	// Lines and polygons are placed at their centroid
+	coords, geometry, ok := placemarkGeometry(pm)
+	if !ok {
+		return nil // Skip placemarks without usable coordinates
+	}
+
+	var categories []string
+	if category := styles.category(pm.StyleURL); category != "" {
+		categories = append(categories, category)
+	}
+	geometryType := "Point"
+	if geometry != nil {
+		geometryType = geometry.Type
+		if geometry.IsArea() {
+			categories = append(categories, "Area")
+		} else {
+			categories = append(categories, "Route")
+		}
+	}
+
+	layer := strings.Join(layers, "/")
+	sourceData := fmt.Sprintf("mymaps|%s|%s|%s|%f,%f|%s",
+		mapName, layer, name, coords.Lat, coords.Lng, geometryType)
+	sourceHash := fmt.Sprintf("%x", sha256.Sum256([]byte(sourceData)))
+	placeID := fmt.Sprintf("mymaps_%s", sourceHash[:16])
+
+	now := time.Now()
+	place := &models.Place{
+		ID:          utils.GenerateID(placeID),
+		PlaceID:     placeID,
+		Name:        name,
+		Address:     strings.TrimSpace(pm.Address),
+		Coordinates: coords,
+		Geometry:    geometry,
+		Categories:  categories,
+		UserNotes:   cleanKMLDescription(pm.Description),
+		UserTags:    []string{},
+		Photos:      []models.Photo{},
+		Reviews:     []models.Review{},
+		CustomFields: map[string]interface{}{
+			"imported_from": "google_my_maps",
+			"import_date":   now.Format(time.RFC3339),
+		},
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		ImportedAt: &now,
+		SourceHash: sourceHash,
+	}
+
+	if mapName != "" {
+		place.CustomFields["mymaps_map"] = mapName
+	}
+	if len(layers) > 0 {
+		place.UserTags = append(place.UserTags, layers[len(layers)-1])
+		place.CustomFields["mymaps_layer"] = layer
+	}
+	if id, _ := styles.resolve(pm.StyleURL); id != "" {
+		place.CustomFields["mymaps_style"] = id
+	}
+
+	// Layer table columns become custom fields
+	for _, data := range pm.ExtendedData.Data {
+		if value := strings.TrimSpace(data.Value); data.Name != "" && value != "" {
+			place.CustomFields["mymaps_"+data.Name] = value
+		}
+	}
+	for _, data := range pm.ExtendedData.SimpleData {
+		if value := strings.TrimSpace(data.Value); data.Name != "" && value != "" {
+			place.CustomFields["mymaps_"+data.Name] = value
+		}
+	}
+
+	return place
+}
+
+// placemarkGeometry returns where to put a placemark and, for lines and
+// polygons, its geometry. Polygons win over lines and lines over points
+// when a MultiGeometry mixes them.
+func placemarkGeometry(pm MyMapsPlacemark) (models.Coordinates, *models.Geometry, bool) {
+	var points []string
+	var lines [][]models.Position
+	var polygons [][][]models.Position
+
+	addLine := func(line MyMapsLineString) {
+		lines = append(lines, parseKMLCoordinates(line.Coordinates))
+	}
+	addPolygon := func(polygon MyMapsPolygon) {
+		rings := [][]models.Position{parseKMLCoordinates(polygon.Outer)}
+		for _, inner := range polygon.Inner {
+			rings = append(rings, parseKMLCoordinates(inner))
+		}
+		polygons = append(polygons, rings)
+	}
+
+	if pm.Point != nil {
+		points = append(points, pm.Point.Coordinates)
+	}
+	if pm.LineString != nil {
+		addLine(*pm.LineString)
+	}
+	if pm.Polygon != nil {
+		addPolygon(*pm.Polygon)
+	}
+	if multi := pm.MultiGeometry; multi != nil {
+		for _, point := range multi.Points {
+			points = append(points, point.Coordinates)
+		}
+		for _, line := range multi.LineStrings {
+			addLine(line)
+		}
+		for _, polygon := range multi.Polygons {
+			addPolygon(polygon)
+		}
+	}
+
+	if geometry := models.NewPolygonGeometry(polygons...); geometry != nil {
+		return geometry.Centroid(), geometry, true
+	}
+	if geometry := models.NewLineGeometry(lines...); geometry != nil {
+		return geometry.Centroid(), geometry, true
+	}
+	for _, point := range points {
+		if coords := parseKMLCoordinates(point); len(coords) > 0 {
+			return coords[0].Coordinates(), nil, true
+		}
+	}
+	return models.Coordinates{}, nil, false
+}
+
+// parseKMLCoordinates parses a KML coordinate list of whitespace-separated
+// "lng,lat[,alt]" tuples
+func parseKMLCoordinates(value string) []models.Position {
+	var coords []models.Position
+	for _, tuple := range strings.Fields(value) {
+		parts := strings.Split(tuple, ",")
+		if len(parts) < 2 {
+			continue
+		}
+		lng, err1 := strconv.ParseFloat(parts[0], 64)
+		lat, err2 := strconv.ParseFloat(parts[1], 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		coords = append(coords, models.Position{lng, lat})
+	}
+	return coords
+}
+
+var (
+	kmlLineBreak = regexp.MustCompile(`(?i)<br\s*/?>`)
+	kmlTag       = regexp.MustCompile(`<[^>]+>`)
+)
+
+// cleanKMLDescription turns the HTML My Maps writes into descriptions into
+// plain text
+func cleanKMLDescription(description string) string {
+	text := kmlLineBreak.ReplaceAllString(description, "\n")
+	text = kmlTag.ReplaceAllString(text, "")
+	return strings.TrimSpace(html.UnescapeString(text))
+}
@@ -0,0 +1,123 @@
+package sources
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/placeli/internal/models"
+)
+
+const myMapsKML = `<?xml version="1.0" encoding="UTF-8"?>
+<kml xmlns="http://www.opengis.net/kml/2.2">
+  <Document>
+    <name>Team offsite</name>
+    <Style id="icon-1577-0288D1-normal">
+      <IconStyle><Icon><href>https://www.gstatic.com/mapspro/images/stock/503-wht-blank_maps.png</href></Icon></IconStyle>
+    </Style>
+    <StyleMap id="icon-1577-0288D1">
+      <Pair><key>normal</key><styleUrl>#icon-1577-0288D1-normal</styleUrl></Pair>
+      <Pair><key>highlight</key><styleUrl>#icon-1577-0288D1-highlight</styleUrl></Pair>
+    </StyleMap>
+    <Folder>
+      <name>Dinners</name>
+      <Placemark>
+        <name>Taberna da Rua das Flores</name>
+        <description><![CDATA[Book ahead<br>Cash only &amp; small]]></description>
+        <styleUrl>#icon-1577-0288D1</styleUrl>
+        <ExtendedData>
+          <Data name="Night"><value>Tuesday</value></Data>
+        </ExtendedData>
+        <Point><coordinates>
+          -9.1427,38.7107,0
+        </coordinates></Point>
+      </Placemark>
+    </Folder>
+    <Folder>
+      <name>Activities</name>
+      <Placemark>
+        <name>Riverside walk</name>
+        <styleUrl>#line-000000-1200-nodesc</styleUrl>
+        <LineString><tessellate>1</tessellate><coordinates>
+          -9.20,38.69,0 -9.18,38.69,0 -9.16,38.69,0
+        </coordinates></LineString>
+      </Placemark>
+      <Placemark>
+        <name>Picnic area</name>
+        <Polygon><outerBoundaryIs><LinearRing><coordinates>
+          -9.0,38.0,0 -8.0,38.0,0 -8.0,39.0,0 -9.0,39.0,0
+        </coordinates></LinearRing></outerBoundaryIs></Polygon>
+      </Placemark>
+    </Folder>
+  </Document>
+</kml>`
+
+func writeKMZ(t *testing.T, kml string) string {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("doc.kml")
+	require.NoError(t, err)
+	_, err = w.Write([]byte(kml))
+	require.NoError(t, err)
+	_, err = zw.Create("images/icon-1.png")
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	path := filepath.Join(t.TempDir(), "offsite.kmz")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0644))
+	return path
+}
+
+func TestMyMapsImporter_KMZ(t *testing.T) {
+	places, err := (&MyMapsImporter{}).ImportFromFile(writeKMZ(t, myMapsKML))
+	require.NoError(t, err)
+	require.Len(t, places, 3)
+
+	byName := make(map[string]*models.Place)
+	for _, place := range places {
+		byName[place.Name] = place
+	}
+
+	dinner := byName["Taberna da Rua das Flores"]
+	require.NotNil(t, dinner)
+	assert.Equal(t, []string{"Restaurant"}, dinner.Categories)
+	assert.Equal(t, []string{"Dinners"}, dinner.UserTags)
+	assert.Equal(t, "Book ahead\nCash only & small", dinner.UserNotes)
+	assert.Equal(t, "Tuesday", dinner.CustomFields["mymaps_Night"])
+	assert.Equal(t, "Team offsite", dinner.CustomFields["mymaps_map"])
+	assert.InDelta(t, 38.7107, dinner.Coordinates.Lat, 1e-9)
+	assert.Nil(t, dinner.Geometry)
+
+	walk := byName["Riverside walk"]
+	require.NotNil(t, walk)
+	assert.Equal(t, []string{"Route"}, walk.Categories)
+	assert.InDelta(t, -9.18, walk.Coordinates.Lng, 1e-6)
+	require.NotNil(t, walk.Geometry)
+	assert.Equal(t, "LineString", walk.Geometry.Type)
+	assert.InDelta(t, 3480, walk.Geometry.Length(), 20)
+
+	area := byName["Picnic area"]
+	require.NotNil(t, area)
+	assert.Equal(t, []string{"Area"}, area.Categories)
+	assert.InDelta(t, 38.5, area.Coordinates.Lat, 1e-9)
+	assert.InDelta(t, -8.5, area.Coordinates.Lng, 1e-9)
+	require.NotNil(t, area.Geometry)
+	assert.Equal(t, "Polygon", area.Geometry.Type)
+	assert.Len(t, area.Geometry.Polygons[0][0], 5, "ring should be closed")
+}
+
+func TestMyMapsImporter_InvalidKMZ(t *testing.T) {
+	_, err := (&MyMapsImporter{}).ImportFromData([]byte("not a zip"), "kmz")
+	assert.Error(t, err)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	_, _ = zw.Create("images/icon.png")
+	require.NoError(t, zw.Close())
+	_, err = (&MyMapsImporter{}).ImportFromData(buf.Bytes(), "kmz")
+	assert.ErrorContains(t, err, "no KML document")
+}
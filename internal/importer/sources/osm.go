@@ -1,6 +1,7 @@
 package sources
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/csv"
 	"encoding/json"
@@ -20,12 +21,32 @@ import (
 // OSMImporter handles OpenStreetMap data exports
 type OSMImporter struct{}
 
-// OSMNode represents a basic OSM node with POI data
+// OSMNode represents a basic OSM node with POI data. Ways and relations
+// exported by Overpass with "out geom" or "out center" use the same shape,
+// with their geometry or centre instead of Lat and Lon.
 type OSMNode struct {
+	Type string            `json:"type"`
 	ID   int64             `json:"id"`
 	Lat  float64           `json:"lat"`
 	Lon  float64           `json:"lon"`
 	Tags map[string]string `json:"tags"`
+
+	Center   *OSMLatLon  `json:"center,omitempty"`
+	Geometry []OSMLatLon `json:"geometry,omitempty"`
+	Members  []OSMMember `json:"members,omitempty"`
+}
+
+type OSMLatLon struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// OSMMember is a way in a relation, such as the outer and inner rings of a
+// multipolygon
+type OSMMember struct {
+	Type     string      `json:"type"`
+	Role     string      `json:"role"`
+	Geometry []OSMLatLon `json:"geometry"`
 }
 
 // OSMExport represents an OSM export file
@@ -43,68 +64,102 @@ func (oi *OSMImporter) SupportedFormats() []string {
 	return []string{"json", "csv"}
 }
 
+// Sniff recognises Overpass JSON. Its CSV layout is also read by the
+// generic CSV source, which maps more columns.
+func (oi *OSMImporter) Sniff(header []byte) Confidence {
+	if sample := sniffJSON(header); sample != nil {
+		if sample.Object && sample.Keys["elements"] {
+			return ConfidenceHigh
+		}
+		return ConfidenceNone
+	}
+	columns := sniffCSVHeader(header)
+	if hasColumn(columns, "name", "title") && hasColumn(columns, "lat", "latitude") &&
+		hasColumn(columns, "lon", "lng", "longitude") {
+		return ConfidenceLow
+	}
+	return ConfidenceNone
+}
+
 func (oi *OSMImporter) ImportFromFile(filePath string) ([]*models.Place, error) {
+	return collect(func(emit func(*models.Place) error) error {
+		return oi.StreamFromFile(filePath, emit, nil)
+	})
+}
+
+// StreamFromFile streams places from an OSM JSON or CSV export
+func (oi *OSMImporter) StreamFromFile(filePath string, emit func(*models.Place) error, progress ProgressFunc) error {
 	file, err := os.Open(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+		return fmt.Errorf("failed to open file: %w", err)
 	}
 	defer file.Close()
 
-	data, err := io.ReadAll(file)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read file: %w", err)
+	var size int64
+	if info, err := file.Stat(); err == nil {
+		size = info.Size()
 	}
+	tracker := newProgressTracker(size, progress)
 
 	// Determine format from file extension
-	format := "json"
 	if strings.HasSuffix(strings.ToLower(filePath), ".csv") {
-		format = "csv"
+		return oi.streamCSV(tracker.wrap(file), emit)
 	}
-
-	return oi.ImportFromData(data, format)
+	return oi.streamJSON(tracker.wrap(file), emit)
 }
 
 func (oi *OSMImporter) ImportFromData(data []byte, format string) ([]*models.Place, error) {
+	var stream func(io.Reader, func(*models.Place) error) error
 	switch format {
 	case "json":
-		return oi.parseJSON(data)
+		stream = oi.streamJSON
 	case "csv":
-		return oi.parseCSV(data)
+		stream = oi.streamCSV
 	default:
 		return nil, fmt.Errorf("unsupported format: %s", format)
 	}
+
+	return collect(func(emit func(*models.Place) error) error {
+		return stream(bytes.NewReader(data), emit)
+	})
 }
 
-func (oi *OSMImporter) parseJSON(data []byte) ([]*models.Place, error) {
-	var osmExport OSMExport
-	if err := json.Unmarshal(data, &osmExport); err != nil {
-		return nil, fmt.Errorf("failed to parse OSM JSON: %w", err)
+// streamJSON decodes the elements of an OSM export one at a time
+func (oi *OSMImporter) streamJSON(r io.Reader, emit func(*models.Place) error) error {
+	emit = guardEmit(emit)
+	dec := json.NewDecoder(r)
+	err := streamObject(dec, map[string]func(*json.Decoder) error{
+		"elements": func(dec *json.Decoder) error {
+			return streamArray(dec, func(element OSMNode) error {
+				if place := oi.convertOSMNode(element); place != nil {
+					return emit(place)
+				}
+				return nil
+			})
+		},
+	})
+	if emitErr, ok := unwrapEmitError(err); ok {
+		return emitErr
 	}
-
-	var places []*models.Place
-	for _, element := range osmExport.Elements {
-		place := oi.convertOSMNode(element)
-		if place != nil {
-			places = append(places, place)
-		}
+	if err != nil {
+		return fmt.Errorf("failed to parse OSM JSON: %w", err)
 	}
-
-	return places, nil
+	return nil
 }
 
-func (oi *OSMImporter) parseCSV(data []byte) ([]*models.Place, error) {
-	reader := csv.NewReader(strings.NewReader(string(data)))
-	records, err := reader.ReadAll()
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse CSV: %w", err)
-	}
+// streamCSV reads an OSM CSV export one record at a time
+func (oi *OSMImporter) streamCSV(r io.Reader, emit func(*models.Place) error) error {
+	reader := csv.NewReader(r)
 
-	if len(records) == 0 {
-		return nil, fmt.Errorf("empty CSV file")
+	// Expect header row with columns: name, lat, lon, type, description
+	header, err := reader.Read()
+	if err == io.EOF {
+		return fmt.Errorf("empty CSV file")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse CSV: %w", err)
 	}
 
-	// Expect header row with columns: name, lat, lon, type, description
-	header := records[0]
 	nameCol, latCol, lonCol, typeCol, descCol := -1, -1, -1, -1, -1
 
 	for i, col := range header {
@@ -123,11 +178,18 @@ func (oi *OSMImporter) parseCSV(data []byte) ([]*models.Place, error) {
 	}
 
 	if nameCol == -1 || latCol == -1 || lonCol == -1 {
-		return nil, fmt.Errorf("CSV must have name, lat, and lon columns")
+		return fmt.Errorf("CSV must have name, lat, and lon columns")
 	}
 
-	var places []*models.Place
-	for i, record := range records[1:] { // Skip header
+	for i := 0; ; i++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse CSV: %w", err)
+		}
+
 		if len(record) <= nameCol || len(record) <= latCol || len(record) <= lonCol {
 			continue // Skip incomplete records
 		}
@@ -161,13 +223,12 @@ func (oi *OSMImporter) parseCSV(data []byte) ([]*models.Place, error) {
 			osmNode.Tags["description"] = record[descCol]
 		}
 
-		place := oi.convertOSMNode(osmNode)
-		if place != nil {
-			places = append(places, place)
+		if place := oi.convertOSMNode(osmNode); place != nil {
+			if err := emit(place); err != nil {
+				return err
+			}
 		}
 	}
-
-	return places, nil
 }
 
 func (oi *OSMImporter) convertOSMNode(node OSMNode) *models.Place {
@@ -181,14 +242,27 @@ func (oi *OSMImporter) convertOSMNode(node OSMNode) *models.Place {
 		Lng: node.Lon,
 	}
 
+	// Ways and relations are placed at the centroid of their geometry
+	geometry := oi.elementGeometry(node)
+	if geometry != nil {
+		coords = geometry.Centroid()
+	} else if node.Center != nil {
+		coords = models.Coordinates{Lat: node.Center.Lat, Lng: node.Center.Lon}
+	}
+
 	// Generate unique IDs
 	sourceData := fmt.Sprintf("osm|%d|%s|%f,%f",
 		node.ID,
 		name,
 		coords.Lat,
 		coords.Lng)
-	sourceHash := fmt.Sprintf("%x", sha256.Sum256([]byte(sourceData)))
 	placeID := fmt.Sprintf("osm_%d", node.ID)
+	if node.Type == "way" || node.Type == "relation" {
+		// Node, way and relation IDs overlap
+		sourceData = node.Type + "|" + sourceData
+		placeID = fmt.Sprintf("osm_%s_%d", node.Type, node.ID)
+	}
+	sourceHash := fmt.Sprintf("%x", sha256.Sum256([]byte(sourceData)))
 
 	now := time.Now()
 	place := &models.Place{
@@ -197,6 +271,7 @@ func (oi *OSMImporter) convertOSMNode(node OSMNode) *models.Place {
 		Name:        name,
 		Address:     oi.buildAddress(node.Tags),
 		Coordinates: coords,
+		Geometry:    geometry,
 		Categories:  oi.extractCategories(node.Tags),
 		Phone:       node.Tags["phone"],
 		Website:     node.Tags["website"],
@@ -215,6 +290,17 @@ func (oi *OSMImporter) convertOSMNode(node OSMNode) *models.Place {
 		SourceHash: sourceHash,
 	}
 
+	if node.Type == "way" || node.Type == "relation" {
+		place.CustomFields["osm_element"] = node.Type
+	}
+
+	if openingHours := node.Tags["opening_hours"]; openingHours != "" {
+		place.Hours = openingHours
+		if hours, err := models.ParseOSMOpeningHours(openingHours); err == nil {
+			place.OpeningHours = hours
+		}
+	}
+
 	// Add OSM-specific tags as custom fields
 	for key, value := range node.Tags {
 		if key != "name" && key != "phone" && key != "website" &&
@@ -226,6 +312,73 @@ func (oi *OSMImporter) convertOSMNode(node OSMNode) *models.Place {
 	return place
 }
 
+// elementGeometry builds the geometry of a way or multipolygon relation
+// exported with "out geom". Closed ways become areas unless they are
+// tagged as linear features such as roundabouts or fences.
+func (oi *OSMImporter) elementGeometry(element OSMNode) *models.Geometry {
+	switch element.Type {
+	case "way":
+		ring := osmPositions(element.Geometry)
+		closed := len(ring) >= 4 && ring[0] == ring[len(ring)-1]
+		if closed && oi.isArea(element.Tags) {
+			return models.NewPolygonGeometry([][]models.Position{ring})
+		}
+		return models.NewLineGeometry(ring)
+
+	case "relation":
+		var polygons [][][]models.Position
+		var holes [][]models.Position
+		for _, member := range element.Members {
+			ring := osmPositions(member.Geometry)
+			if member.Type != "way" || len(ring) < 4 || ring[0] != ring[len(ring)-1] {
+				continue // only closed member ways are used, unjoined rings are skipped
+			}
+			switch member.Role {
+			case "outer", "":
+				polygons = append(polygons, [][]models.Position{ring})
+			case "inner":
+				holes = append(holes, ring)
+			}
+		}
+		// Each hole belongs to the outer ring around its first corner
+		for _, hole := range holes {
+			for i, polygon := range polygons {
+				outer := models.NewPolygonGeometry(polygon[:1])
+				if outer != nil && outer.Contains(hole[0].Coordinates()) {
+					polygons[i] = append(polygon, hole)
+					break
+				}
+			}
+		}
+		return models.NewPolygonGeometry(polygons...)
+	}
+	return nil
+}
+
+// isArea tells closed ways that enclose an area from closed linear ways
+func (oi *OSMImporter) isArea(tags map[string]string) bool {
+	switch tags["area"] {
+	case "yes":
+		return true
+	case "no":
+		return false
+	}
+	for _, linear := range []string{"highway", "barrier", "railway", "waterway", "route"} {
+		if _, ok := tags[linear]; ok {
+			return false
+		}
+	}
+	return true
+}
+
+func osmPositions(points []OSMLatLon) []models.Position {
+	positions := make([]models.Position, len(points))
+	for i, p := range points {
+		positions[i] = models.Position{p.Lon, p.Lat}
+	}
+	return positions
+}
+
 func (oi *OSMImporter) buildAddress(tags map[string]string) string {
 	var addressParts []string
 
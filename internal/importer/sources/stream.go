@@ -0,0 +1,183 @@
+package sources
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/user/placeli/internal/models"
+)
+
+// ProgressFunc reports how many bytes of an import have been read so far
+// out of total. total is 0 when the size isn't known up front.
+type ProgressFunc func(read, total int64)
+
+// StreamingSource is an ImportSource that hands places over one at a time
+// as it parses them, so very large exports never have to fit in memory
+type StreamingSource interface {
+	ImportSource
+
+	// StreamFromFile parses filePath and calls emit for every place found.
+	// An error returned by emit stops the stream and is returned as is.
+	// progress may be nil.
+	StreamFromFile(filePath string, emit func(*models.Place) error, progress ProgressFunc) error
+}
+
+// progressTracker counts bytes read through the readers it wraps, which
+// lets the entries of an archive share a single running total
+type progressTracker struct {
+	read     int64
+	total    int64
+	progress ProgressFunc
+}
+
+func newProgressTracker(total int64, progress ProgressFunc) *progressTracker {
+	return &progressTracker{total: total, progress: progress}
+}
+
+func (pt *progressTracker) wrap(r io.Reader) io.Reader {
+	return &progressReader{r: r, tracker: pt}
+}
+
+type progressReader struct {
+	r       io.Reader
+	tracker *progressTracker
+}
+
+func (pr *progressReader) Read(p []byte) (int, error) {
+	n, err := pr.r.Read(p)
+	if n > 0 {
+		pr.tracker.read += int64(n)
+		if pr.tracker.progress != nil {
+			pr.tracker.progress(pr.tracker.read, pr.tracker.total)
+		}
+	}
+	return n, err
+}
+
+// emitError marks an error returned by the caller's emit function. Those
+// always abort a stream, even where parse errors only skip a file.
+type emitError struct {
+	err error
+}
+
+func (e *emitError) Error() string { return e.err.Error() }
+func (e *emitError) Unwrap() error { return e.err }
+
+func guardEmit(emit func(*models.Place) error) func(*models.Place) error {
+	return func(place *models.Place) error {
+		if err := emit(place); err != nil {
+			return &emitError{err: err}
+		}
+		return nil
+	}
+}
+
+// unwrapEmitError returns the caller's error if err came from emit
+func unwrapEmitError(err error) (error, bool) {
+	var ee *emitError
+	if errors.As(err, &ee) {
+		return ee.err, true
+	}
+	return nil, false
+}
+
+// collect runs a stream to completion and gathers its places, returning no
+// places at all on error like the non-streaming importers do
+func collect(stream func(emit func(*models.Place) error) error) ([]*models.Place, error) {
+	var places []*models.Place
+	err := stream(func(place *models.Place) error {
+		places = append(places, place)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return places, nil
+}
+
+// streamObject walks the keys of the JSON object at the decoder's position,
+// passing the decoder to the handler of each known key so it can consume
+// the value. Values of other keys are skipped.
+func streamObject(dec *json.Decoder, handlers map[string]func(*json.Decoder) error) error {
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := tok.(string)
+		if handler, ok := handlers[key]; ok {
+			if err := handler(dec); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := skipValue(dec); err != nil {
+			return err
+		}
+	}
+	_, err := dec.Token()
+	return err
+}
+
+// skipValue consumes the JSON value at the decoder's position token by
+// token, so skipping a large array doesn't hold it in memory
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// streamArray decodes the elements of the JSON array at the decoder's
+// position one at a time. A null value is treated as an empty array.
+func streamArray[T any](dec *json.Decoder, fn func(T) error) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("expected JSON array, got %v", tok)
+	}
+	for dec.More() {
+		var v T
+		if err := dec.Decode(&v); err != nil {
+			return err
+		}
+		if err := fn(v); err != nil {
+			return err
+		}
+	}
+	_, err = dec.Token()
+	return err
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != want {
+		return fmt.Errorf("expected JSON %q, got %v", want, tok)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package sources
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/placeli/internal/models"
+)
+
+// writeTakeoutZip writes a Takeout-style archive with one large saved
+// places file and an unrelated entry
+func writeTakeoutZip(t *testing.T, features int) string {
+	path := filepath.Join(t.TempDir(), "takeout.zip")
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	zw := zip.NewWriter(file)
+	w, err := zw.Create("Takeout/Maps (your places)/Saved Places.json")
+	require.NoError(t, err)
+
+	fmt.Fprint(w, `{"type": "FeatureCollection", "features": [`)
+	for i := 0; i < features; i++ {
+		if i > 0 {
+			fmt.Fprint(w, ",")
+		}
+		fmt.Fprintf(w, `{"geometry": {"coordinates": [%f, 38.7], "type": "Point"},
+			"properties": {"location": {"name": "Place %d", "address": "Rua %d"}}}`, -9.1+float64(i)/1000, i, i)
+	}
+	fmt.Fprint(w, `], "extra": {"ignored": [1, 2, 3]}}`)
+
+	other, err := zw.Create("Takeout/Maps/Commute settings.json")
+	require.NoError(t, err)
+	fmt.Fprint(other, `[{"not": "places"}]`)
+
+	require.NoError(t, zw.Close())
+	return path
+}
+
+func TestTakeoutImporter_StreamZip(t *testing.T) {
+	path := writeTakeoutZip(t, 1200)
+
+	var names []string
+	var lastRead, lastTotal int64
+	err := (&TakeoutImporter{}).StreamFromFile(path, func(place *models.Place) error {
+		names = append(names, place.Name)
+		return nil
+	}, func(read, total int64) {
+		assert.GreaterOrEqual(t, read, lastRead)
+		lastRead, lastTotal = read, total
+	})
+	require.NoError(t, err)
+
+	require.Len(t, names, 1200)
+	assert.Equal(t, "Place 0", names[0])
+	assert.Equal(t, "Place 1199", names[1199])
+	assert.Positive(t, lastTotal)
+	assert.Equal(t, lastTotal, lastRead)
+}
+
+func TestTakeoutImporter_StreamStopsOnEmitError(t *testing.T) {
+	path := writeTakeoutZip(t, 100)
+	stop := errors.New("stop")
+
+	seen := 0
+	err := (&TakeoutImporter{}).StreamFromFile(path, func(place *models.Place) error {
+		seen++
+		if seen == 10 {
+			return stop
+		}
+		return nil
+	}, nil)
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 10, seen)
+}
+
+func TestTakeoutImporter_StreamJSONFormats(t *testing.T) {
+	ti := &TakeoutImporter{}
+
+	// Single list with its name after the places
+	places, err := ti.ImportFromData([]byte(`{
+		"places": [{"name": "Café A Brasileira", "address": "Rua Garrett 120"}],
+		"name": "Lisbon"
+	}`), "json")
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+	assert.Equal(t, []string{"Lisbon"}, places[0].UserTags)
+
+	places, err = ti.ImportFromData([]byte(`{"lists": [
+		{"name": "Want to go", "places": [{"name": "A"}, {"name": "B"}]},
+		{"name": "Favorites", "places": [{"name": "C"}]}
+	]}`), "json")
+	require.NoError(t, err)
+	assert.Len(t, places, 3)
+
+	places, err = ti.ImportFromData([]byte(`{"something": "else"}`), "json")
+	assert.Error(t, err)
+	assert.Nil(t, places)
+}
+
+func TestOSMImporter_StreamCSV(t *testing.T) {
+	var csv strings.Builder
+	csv.WriteString("name,lat,lon,type\n")
+	for i := 0; i < 500; i++ {
+		fmt.Fprintf(&csv, "Cafe %d,38.7,-9.1,cafe\n", i)
+	}
+	path := filepath.Join(t.TempDir(), "pois.csv")
+	require.NoError(t, os.WriteFile(path, []byte(csv.String()), 0644))
+
+	count := 0
+	var lastRead int64
+	err := (&OSMImporter{}).StreamFromFile(path, func(place *models.Place) error {
+		count++
+		return nil
+	}, func(read, total int64) {
+		lastRead = read
+		assert.Equal(t, int64(csv.Len()), total)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 500, count)
+	assert.Equal(t, int64(csv.Len()), lastRead)
+}
+
+func TestOSMImporter_WayAndRelationGeometry(t *testing.T) {
+	data := []byte(`{"elements": [
+		{"type": "way", "id": 10, "tags": {"name": "Parque", "leisure": "park"},
+		 "center": {"lat": 0.5, "lon": 0.5},
+		 "geometry": [{"lat": 0, "lon": 0}, {"lat": 0, "lon": 1}, {"lat": 1, "lon": 1}, {"lat": 1, "lon": 0}, {"lat": 0, "lon": 0}]},
+		{"type": "way", "id": 11, "tags": {"name": "Rua Augusta", "highway": "pedestrian"},
+		 "geometry": [{"lat": 0, "lon": 0}, {"lat": 0.01, "lon": 0}]},
+		{"type": "relation", "id": 12, "tags": {"name": "Lake", "natural": "water"},
+		 "members": [
+			{"type": "way", "role": "outer", "geometry": [{"lat": 0, "lon": 0}, {"lat": 0, "lon": 4}, {"lat": 4, "lon": 4}, {"lat": 4, "lon": 0}, {"lat": 0, "lon": 0}]},
+			{"type": "way", "role": "inner", "geometry": [{"lat": 1, "lon": 1}, {"lat": 1, "lon": 2}, {"lat": 2, "lon": 2}, {"lat": 1, "lon": 1}]}
+		 ]}
+	]}`)
+
+	places, err := (&OSMImporter{}).ImportFromData(data, "json")
+	require.NoError(t, err)
+	require.Len(t, places, 3)
+
+	park := places[0]
+	assert.Equal(t, "osm_way_10", park.PlaceID)
+	assert.Equal(t, "way", park.CustomFields["osm_element"])
+	require.NotNil(t, park.Geometry)
+	assert.Equal(t, "Polygon", park.Geometry.Type)
+	assert.InDelta(t, 0.5, park.Coordinates.Lat, 1e-9)
+
+	street := places[1]
+	require.NotNil(t, street.Geometry)
+	assert.Equal(t, "LineString", street.Geometry.Type)
+
+	lake := places[2]
+	require.NotNil(t, lake.Geometry)
+	assert.Equal(t, "Polygon", lake.Geometry.Type)
+	require.Len(t, lake.Geometry.Polygons[0], 2, "inner ring should become a hole")
+	assert.False(t, lake.Geometry.Contains(models.Coordinates{Lat: 1.2, Lng: 1.5}))
+	assert.True(t, lake.Geometry.Contains(models.Coordinates{Lat: 3, Lng: 3}))
+}
@@ -238,6 +238,13 @@ func convertTakeoutPlace(tp TakeoutPlace) *models.Place {
 		place.CustomFields["country_code"] = tp.Properties.Location.CountryCode
 	}
 
+	// Add structured opening hours if the hours object is keyed by weekday
+	if tp.Properties.Hours != nil {
+		if openingHours, err := models.ParseHoursMap(tp.Properties.Hours); err == nil {
+			place.OpeningHours = openingHours
+		}
+	}
+
 	// Add import date if available
 	if tp.Properties.Date != "" {
 		place.CustomFields["saved_date"] = tp.Properties.Date
@@ -248,59 +255,16 @@ func convertTakeoutPlace(tp TakeoutPlace) *models.Place {
 
 // parseListFile parses a JSON file containing takeout places
 func parseListFile(filePath string) ([]*models.Place, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
-	data, err := io.ReadAll(file)
-	if err != nil {
-		return nil, err
-	}
-
-	var takeoutList TakeoutList
-	if err := json.Unmarshal(data, &takeoutList); err != nil {
-		return nil, err
-	}
-
-	var places []*models.Place
-	for _, feature := range takeoutList.Features {
-		place := convertTakeoutPlace(feature)
-		if place != nil {
-			places = append(places, place)
-		}
-	}
-
-	return places, nil
-}
-
-// importFromTakeout imports places from an extracted takeout directory
-func importFromTakeout(takeoutPath string) ([]*models.Place, error) {
-	var places []*models.Place
-
-	err := filepath.Walk(takeoutPath, func(path string, info os.FileInfo, err error) error {
+	t := &TakeoutImporter{}
+	return collect(func(emit func(*models.Place) error) error {
+		file, err := os.Open(filePath)
 		if err != nil {
 			return err
 		}
+		defer file.Close()
 
-		if strings.HasSuffix(path, ".json") && strings.Contains(path, "Saved Places") {
-			listPlaces, err := parseListFile(path)
-			if err != nil {
-				fmt.Printf("Warning: Could not parse %s: %v\n", path, err)
-				return nil
-			}
-			places = append(places, listPlaces...)
-		}
-
-		return nil
+		return t.streamJSON(file, emit)
 	})
-
-	if err != nil {
-		return nil, err
-	}
-
-	return places, nil
 }
 
 // Name returns the name of this import source
@@ -313,87 +277,204 @@ func (t *TakeoutImporter) SupportedFormats() []string {
 	return []string{"zip", "json", "csv", "directory"}
 }
 
+// Sniff recognises saved places and saved lists JSON by their Google
+// properties, and the "Saved" CSV export by its columns
+func (t *TakeoutImporter) Sniff(header []byte) Confidence {
+	if sample := sniffJSON(header); sample != nil {
+		switch {
+		case !sample.Object:
+			return ConfidenceNone
+		case sample.hasAny("google_maps_url", "Google Maps URL"):
+			return ConfidenceHigh
+		case sample.Keys["lists"] && sample.Keys["places"]:
+			return ConfidenceHigh
+		case sample.Keys["features"] && sample.Keys["location"]:
+			return ConfidenceMedium
+		case sample.Keys["places"] && sample.Keys["name"]:
+			return ConfidenceMedium
+		}
+		return ConfidenceNone
+	}
+	if columns := sniffCSVHeader(header); hasColumn(columns, "title", "name", "place name") &&
+		hasColumn(columns, "url", "link", "google maps url") {
+		return ConfidenceHigh
+	}
+	return ConfidenceNone
+}
+
 // ImportFromFile imports places from a Google Takeout export
 func (t *TakeoutImporter) ImportFromFile(filePath string) ([]*models.Place, error) {
+	return collect(func(emit func(*models.Place) error) error {
+		return t.StreamFromFile(filePath, emit, nil)
+	})
+}
+
+// StreamFromFile streams places from an extracted Takeout directory, a
+// Takeout ZIP archive or a single JSON or CSV file
+func (t *TakeoutImporter) StreamFromFile(filePath string, emit func(*models.Place) error, progress ProgressFunc) error {
 	info, err := os.Stat(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to stat file: %w", err)
+		return fmt.Errorf("failed to stat file: %w", err)
 	}
 
 	// Handle directory (extracted takeout)
 	if info.IsDir() {
-		return importFromTakeout(filePath)
-	}
-
-	// Handle ZIP file
-	if strings.HasSuffix(strings.ToLower(filePath), ".zip") {
-		return t.importFromZip(filePath)
-	}
-
-	// Handle direct JSON file (Saved Places.json)
-	if strings.HasSuffix(strings.ToLower(filePath), ".json") {
-		data, err := os.ReadFile(filePath)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read file: %w", err)
-		}
-		return t.ImportFromData(data, "json")
+		return t.streamDirectory(filePath, emit, progress)
+	}
+
+	lower := strings.ToLower(filePath)
+	var stream func(io.Reader, func(*models.Place) error) error
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return t.streamZip(filePath, emit, progress)
+	case strings.HasSuffix(lower, ".json"):
+		// Direct JSON file (Saved Places.json)
+		stream = t.streamJSON
+	case strings.HasSuffix(lower, ".csv"):
+		// CSV file (Saved Places CSV from Google Takeout "Saved")
+		stream = t.streamCSV
+	default:
+		return fmt.Errorf("unsupported file type: %s", filePath)
 	}
 
-	// Handle CSV file (Saved Places CSV from Google Takeout "Saved")
-	if strings.HasSuffix(strings.ToLower(filePath), ".csv") {
-		data, err := os.ReadFile(filePath)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read file: %w", err)
-		}
-		return t.ImportFromData(data, "csv")
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
 	}
+	defer file.Close()
 
-	return nil, fmt.Errorf("unsupported file type: %s", filePath)
+	tracker := newProgressTracker(info.Size(), progress)
+	return stream(tracker.wrap(file), emit)
 }
 
 // ImportFromData imports places from raw data
 func (t *TakeoutImporter) ImportFromData(data []byte, format string) ([]*models.Place, error) {
+	var stream func(io.Reader, func(*models.Place) error) error
 	switch format {
 	case "json":
-		return t.importFromJSON(data)
+		stream = t.streamJSON
 	case "csv":
-		return t.importFromCSV(data)
+		stream = t.streamCSV
 	default:
 		return nil, fmt.Errorf("unsupported format: %s", format)
 	}
+
+	return collect(func(emit func(*models.Place) error) error {
+		return stream(bytes.NewReader(data), emit)
+	})
+}
+
+// streamDirectory streams the "Saved Places" JSON files of an extracted
+// takeout directory. Files that can't be parsed are skipped with a warning.
+func (t *TakeoutImporter) streamDirectory(takeoutPath string, emit func(*models.Place) error, progress ProgressFunc) error {
+	var paths []string
+	var total int64
+
+	err := filepath.Walk(takeoutPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if strings.HasSuffix(path, ".json") && strings.Contains(path, "Saved Places") {
+			paths = append(paths, path)
+			total += info.Size()
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	tracker := newProgressTracker(total, progress)
+	for _, path := range paths {
+		err := func() error {
+			file, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			return t.streamJSON(tracker.wrap(file), guardEmit(emit))
+		}()
+		if emitErr, ok := unwrapEmitError(err); ok {
+			return emitErr
+		}
+		if err != nil {
+			fmt.Printf("Warning: Could not parse %s: %v\n", path, err)
+		}
+	}
+
+	return nil
 }
 
-// importFromJSON handles JSON format imports
-func (t *TakeoutImporter) importFromJSON(data []byte) ([]*models.Place, error) {
-	// Try to parse as Takeout "Maps (your places)" format
-	var takeoutList TakeoutList
-	if err := json.Unmarshal(data, &takeoutList); err == nil && len(takeoutList.Features) > 0 {
-		return t.parseTakeoutPlaces(takeoutList.Features), nil
+// streamJSON decodes the Takeout JSON formats token by token: the "Maps (your
+// places)" feature collection, "Saved" lists and a single saved list. Only
+// one feature or list is held in memory at a time.
+func (t *TakeoutImporter) streamJSON(r io.Reader, emit func(*models.Place) error) error {
+	dec := json.NewDecoder(r)
+
+	recognized := false
+	var single SavedList
+
+	err := streamObject(dec, map[string]func(*json.Decoder) error{
+		// Takeout "Maps (your places)" format
+		"features": func(dec *json.Decoder) error {
+			recognized = true
+			return streamArray(dec, func(feature TakeoutPlace) error {
+				if place := convertTakeoutPlace(feature); place != nil {
+					return emit(place)
+				}
+				return nil
+			})
+		},
+		// Takeout "Saved" format (Lists)
+		"lists": func(dec *json.Decoder) error {
+			recognized = true
+			return streamArray(dec, func(list SavedList) error {
+				for _, place := range t.parseSavedList(&list) {
+					if err := emit(place); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		},
+		// Single list format; the list name may follow its places
+		"name": func(dec *json.Decoder) error {
+			return dec.Decode(&single.Name)
+		},
+		"places": func(dec *json.Decoder) error {
+			recognized = true
+			return dec.Decode(&single.Places)
+		},
+	})
+	if err != nil {
+		return err
 	}
 
-	// Try to parse as Takeout "Saved" format (Lists)
-	var savedLists SavedLists
-	if err := json.Unmarshal(data, &savedLists); err == nil && len(savedLists.Lists) > 0 {
-		return t.parseSavedLists(savedLists.Lists), nil
+	if !recognized {
+		return fmt.Errorf("unrecognized Google Takeout JSON format")
 	}
 
-	// Try single list format
-	var singleList SavedList
-	if err := json.Unmarshal(data, &singleList); err == nil && len(singleList.Places) > 0 {
-		return t.parseSavedList(&singleList), nil
+	for _, place := range t.parseSavedList(&single) {
+		if err := emit(place); err != nil {
+			return err
+		}
 	}
 
-	return nil, fmt.Errorf("unrecognized Google Takeout JSON format")
+	return nil
 }
 
-// importFromCSV handles CSV format imports (Google Takeout "Saved" export)
-func (t *TakeoutImporter) importFromCSV(data []byte) ([]*models.Place, error) {
-	reader := csv.NewReader(bytes.NewReader(data))
+// streamCSV handles CSV format imports (Google Takeout "Saved" export) one
+// record at a time
+func (t *TakeoutImporter) streamCSV(r io.Reader, emit func(*models.Place) error) error {
+	reader := csv.NewReader(r)
 
 	// Read header row
 	headers, err := reader.Read()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read CSV headers: %w", err)
+		return fmt.Errorf("failed to read CSV headers: %w", err)
 	}
 
 	// Map headers to indices
@@ -402,22 +483,21 @@ func (t *TakeoutImporter) importFromCSV(data []byte) ([]*models.Place, error) {
 		headerMap[strings.TrimSpace(h)] = i
 	}
 
-	var places []*models.Place
-
-	// Read all records
-	records, err := reader.ReadAll()
-	if err != nil {
-		return nil, fmt.Errorf("failed to read CSV: %w", err)
-	}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read CSV: %w", err)
+		}
 
-	for _, record := range records {
-		place := t.parseCSVRecord(record, headerMap)
-		if place != nil {
-			places = append(places, place)
+		if place := t.parseCSVRecord(record, headerMap); place != nil {
+			if err := emit(place); err != nil {
+				return err
+			}
 		}
 	}
-
-	return places, nil
 }
 
 // parseCSVRecord parses a single CSV record into a Place
@@ -527,64 +607,51 @@ func (t *TakeoutImporter) getCSVFloat(record []string, headers map[string]int, n
 	return 0
 }
 
-// importFromZip extracts and imports places from a Takeout ZIP file
-func (t *TakeoutImporter) importFromZip(zipPath string) ([]*models.Place, error) {
+// streamZip streams places from the Maps and Saved entries of a Takeout
+// ZIP archive, decompressing each entry on the fly instead of extracting the
+// archive. Entries that aren't place data are skipped.
+func (t *TakeoutImporter) streamZip(zipPath string, emit func(*models.Place) error, progress ProgressFunc) error {
 	reader, err := zip.OpenReader(zipPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open zip: %w", err)
+		return fmt.Errorf("failed to open zip: %w", err)
 	}
 	defer reader.Close()
 
-	var allPlaces []*models.Place
-
+	var entries []*zip.File
+	var total int64
 	for _, file := range reader.File {
-		// Look for relevant JSON and CSV files
-		isJSON := strings.HasSuffix(file.Name, ".json")
-		isCSV := strings.HasSuffix(file.Name, ".csv")
-
-		if !isJSON && !isCSV {
+		// Look for relevant JSON and CSV files with Maps data
+		if !strings.HasSuffix(file.Name, ".json") && !strings.HasSuffix(file.Name, ".csv") {
 			continue
 		}
-
-		// Check for Maps data
 		if strings.Contains(file.Name, "Maps") || strings.Contains(file.Name, "Saved") {
-			rc, err := file.Open()
-			if err != nil {
-				continue
-			}
+			entries = append(entries, file)
+			total += int64(file.UncompressedSize64)
+		}
+	}
 
-			data, err := io.ReadAll(rc)
-			rc.Close()
-			if err != nil {
-				continue
-			}
+	tracker := newProgressTracker(total, progress)
+	for _, file := range entries {
+		stream := t.streamJSON
+		if strings.HasSuffix(file.Name, ".csv") {
+			stream = t.streamCSV
+		}
 
-			var places []*models.Place
-			if isJSON {
-				places, err = t.ImportFromData(data, "json")
-			} else if isCSV {
-				places, err = t.ImportFromData(data, "csv")
+		err := func() error {
+			rc, err := file.Open()
+			if err != nil {
+				return err
 			}
+			defer rc.Close()
 
-			if err == nil && len(places) > 0 {
-				allPlaces = append(allPlaces, places...)
-			}
+			return stream(tracker.wrap(rc), guardEmit(emit))
+		}()
+		if emitErr, ok := unwrapEmitError(err); ok {
+			return emitErr
 		}
 	}
 
-	return allPlaces, nil
-}
-
-// parseTakeoutPlaces converts Takeout places to our model
-func (t *TakeoutImporter) parseTakeoutPlaces(features []TakeoutPlace) []*models.Place {
-	var places []*models.Place
-	for _, feature := range features {
-		place := convertTakeoutPlace(feature)
-		if place != nil {
-			places = append(places, place)
-		}
-	}
-	return places
+	return nil
 }
 
 // SavedLists represents the structure of Takeout "Saved" lists
@@ -619,16 +686,6 @@ type Coordinates struct {
 	Longitude float64 `json:"longitude"`
 }
 
-// parseSavedLists converts multiple saved lists to places
-func (t *TakeoutImporter) parseSavedLists(lists []SavedList) []*models.Place {
-	var places []*models.Place
-	for _, list := range lists {
-		listPlaces := t.parseSavedList(&list)
-		places = append(places, listPlaces...)
-	}
-	return places
-}
-
 // parseSavedList converts a saved list to places
 func (t *TakeoutImporter) parseSavedList(list *SavedList) []*models.Place {
 	var places []*models.Place
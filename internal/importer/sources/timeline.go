@@ -0,0 +1,441 @@
+package sources
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/user/placeli/internal/models"
+	"github.com/user/placeli/internal/utils"
+)
+
+// TimelineImporter reads visits from Google Timeline / Location History:
+// the Takeout "Semantic Location History" monthly files and the newer
+// on-device Timeline.json export (Android and iOS variants). Each visited
+// location becomes a place carrying its visits.
+type TimelineImporter struct{}
+
+// VisitSource is implemented by sources that record where you went rather
+// than places you saved. Their places are matched to saved places to log
+// visits instead of being imported as new places.
+type VisitSource interface {
+	ImportSource
+	VisitsOnly() bool
+}
+
+// Legacy Semantic Location History: Semantic Location History/2024/2024_MAY.json
+// holds {"timelineObjects": [...]}
+type timelineObject struct {
+	PlaceVisit *legacyPlaceVisit `json:"placeVisit"`
+}
+
+type legacyPlaceVisit struct {
+	Location struct {
+		LatitudeE7   int64  `json:"latitudeE7"`
+		LongitudeE7  int64  `json:"longitudeE7"`
+		PlaceID      string `json:"placeId"`
+		Address      string `json:"address"`
+		Name         string `json:"name"`
+		SemanticType string `json:"semanticType"`
+	} `json:"location"`
+	Duration struct {
+		StartTimestamp   string `json:"startTimestamp"`
+		StartTimestampMs string `json:"startTimestampMs"`
+	} `json:"duration"`
+}
+
+// On-device Timeline.json: {"semanticSegments": [...]} on Android, a bare
+// array of segments on iOS
+type timelineSegment struct {
+	StartTime string         `json:"startTime"`
+	Visit     *timelineVisit `json:"visit"`
+}
+
+type timelineVisit struct {
+	HierarchyLevel json.RawMessage `json:"hierarchyLevel"`
+	TopCandidate   struct {
+		PlaceID       string          `json:"placeId"`
+		PlaceIDiOS    string          `json:"placeID"`
+		SemanticType  string          `json:"semanticType"`
+		PlaceLocation json.RawMessage `json:"placeLocation"`
+	} `json:"topCandidate"`
+}
+
+// timelineVisitRecord is one visit in either format
+type timelineVisitRecord struct {
+	placeID      string
+	name         string
+	address      string
+	semanticType string
+	coords       models.Coordinates
+	start        time.Time
+}
+
+var latLngPattern = regexp.MustCompile(`(-?\d+(?:\.\d+)?)°?\s*,\s*(-?\d+(?:\.\d+)?)°?`)
+
+// Name returns the name of this import source
+func (ti *TimelineImporter) Name() string {
+	return "Google Timeline"
+}
+
+// SupportedFormats returns the formats this source handles. Timeline files
+// are plain JSON, so they are recognised by content rather than extension.
+func (ti *TimelineImporter) SupportedFormats() []string {
+	return []string{"timeline", "zip", "directory"}
+}
+
+// Sniff recognises Semantic Location History, Timeline.json and the iOS
+// location-history.json export
+func (ti *TimelineImporter) Sniff(header []byte) Confidence {
+	sample := sniffJSON(header)
+	if sample == nil {
+		return ConfidenceNone
+	}
+	if sample.hasAny("timelineObjects", "semanticSegments", "placeVisit") ||
+		(!sample.Object && sample.Keys["topCandidate"]) {
+		return ConfidenceHigh
+	}
+	return ConfidenceNone
+}
+
+// VisitsOnly marks timeline places as visit records
+func (ti *TimelineImporter) VisitsOnly() bool {
+	return true
+}
+
+// ImportFromFile imports a Timeline.json or Semantic Location History file,
+// an extracted Takeout directory, or a Takeout zip
+func (ti *TimelineImporter) ImportFromFile(filePath string) ([]*models.Place, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	var records []timelineVisitRecord
+
+	switch {
+	case info.IsDir():
+		err = filepath.Walk(filePath, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() || !isTimelineFile(path) {
+				return err
+			}
+			fileRecords, err := parseTimelineFile(path)
+			if err != nil {
+				return err
+			}
+			records = append(records, fileRecords...)
+			return nil
+		})
+
+	case strings.HasSuffix(strings.ToLower(filePath), ".zip"):
+		records, err = ti.readZip(filePath)
+
+	default:
+		records, err = parseTimelineFile(filePath)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return groupTimelineVisits(records), nil
+}
+
+// ImportFromData imports visits from one timeline JSON document
+func (ti *TimelineImporter) ImportFromData(data []byte, format string) ([]*models.Place, error) {
+	if format != "json" && format != "timeline" {
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
+	records, err := parseTimeline(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return groupTimelineVisits(records), nil
+}
+
+// parseTimelineFile decodes the visits of one timeline file as a stream, so
+// exports of hundreds of megabytes aren't read into memory whole
+func parseTimelineFile(path string) ([]timelineVisitRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defer file.Close()
+
+	records, err := parseTimeline(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return records, nil
+}
+
+func (ti *TimelineImporter) readZip(zipPath string) ([]timelineVisitRecord, error) {
+	reader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip file: %w", err)
+	}
+	defer reader.Close()
+
+	var records []timelineVisitRecord
+	for _, file := range reader.File {
+		if !isTimelineFile(file.Name) {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", file.Name, err)
+		}
+		fileRecords, err := parseTimeline(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file.Name, err)
+		}
+		records = append(records, fileRecords...)
+	}
+
+	return records, nil
+}
+
+// isTimelineFile reports whether an archive path holds timeline visits
+func isTimelineFile(path string) bool {
+	path = filepath.ToSlash(path)
+	base := strings.ToLower(filepath.Base(path))
+	if !strings.HasSuffix(base, ".json") {
+		return false
+	}
+	return strings.Contains(path, "Semantic Location History/") ||
+		base == "timeline.json" || base == "location-history.json"
+}
+
+// parseTimeline detects the timeline format and decodes its visits one at a
+// time
+func parseTimeline(r io.Reader) ([]timelineVisitRecord, error) {
+	br := bufio.NewReader(r)
+	first, err := firstNonSpace(br)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse timeline JSON: %w", err)
+	}
+
+	var records []timelineVisitRecord
+	addSegment := func(segment timelineSegment) error {
+		if record, ok := segmentVisit(segment); ok {
+			records = append(records, record)
+		}
+		return nil
+	}
+
+	dec := json.NewDecoder(br)
+	if first == '[' {
+		if err := streamArray(dec, addSegment); err != nil {
+			return nil, fmt.Errorf("failed to parse timeline JSON: %w", err)
+		}
+		return records, nil
+	}
+
+	found := false
+	err = streamObject(dec, map[string]func(*json.Decoder) error{
+		"semanticSegments": func(dec *json.Decoder) error {
+			found = true
+			return streamArray(dec, addSegment)
+		},
+		"timelineObjects": func(dec *json.Decoder) error {
+			found = true
+			return streamArray(dec, func(object timelineObject) error {
+				if record, ok := legacyVisit(object.PlaceVisit); ok {
+					records = append(records, record)
+				}
+				return nil
+			})
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse timeline JSON: %w", err)
+	}
+	if !found {
+		return nil, fmt.Errorf("not a Google Timeline export")
+	}
+	return records, nil
+}
+
+// firstNonSpace peeks at the first byte of r that isn't white space
+func firstNonSpace(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if b != ' ' && b != '\t' && b != '\n' && b != '\r' {
+			return b, r.UnreadByte()
+		}
+	}
+}
+
+func legacyVisit(visit *legacyPlaceVisit) (timelineVisitRecord, bool) {
+	if visit == nil {
+		return timelineVisitRecord{}, false
+	}
+
+	start, ok := parseTimelineTime(visit.Duration.StartTimestamp, visit.Duration.StartTimestampMs)
+	if !ok {
+		return timelineVisitRecord{}, false
+	}
+	loc := visit.Location
+	return timelineVisitRecord{
+		placeID:      loc.PlaceID,
+		name:         loc.Name,
+		address:      loc.Address,
+		semanticType: loc.SemanticType,
+		coords: models.Coordinates{
+			Lat: float64(loc.LatitudeE7) / 1e7,
+			Lng: float64(loc.LongitudeE7) / 1e7,
+		},
+		start: start,
+	}, true
+}
+
+func segmentVisit(segment timelineSegment) (timelineVisitRecord, bool) {
+	visit := segment.Visit
+	if visit == nil {
+		return timelineVisitRecord{}, false
+	}
+	// Higher levels are areas containing the visited place, such as a
+	// shopping centre around a shop
+	if level := strings.Trim(string(visit.HierarchyLevel), `"`); level != "" && level != "0" {
+		return timelineVisitRecord{}, false
+	}
+
+	start, ok := parseTimelineTime(segment.StartTime, "")
+	if !ok {
+		return timelineVisitRecord{}, false
+	}
+	candidate := visit.TopCandidate
+	placeID := candidate.PlaceID
+	if placeID == "" {
+		placeID = candidate.PlaceIDiOS
+	}
+	coords, ok := parsePlaceLocation(candidate.PlaceLocation)
+	if !ok && placeID == "" {
+		return timelineVisitRecord{}, false
+	}
+
+	return timelineVisitRecord{
+		placeID:      placeID,
+		semanticType: candidate.SemanticType,
+		coords:       coords,
+		start:        start,
+	}, true
+}
+
+// parsePlaceLocation reads {"latLng": "38.71°, -9.13°"} (Android) or
+// "geo:38.71,-9.13" (iOS)
+func parsePlaceLocation(raw json.RawMessage) (models.Coordinates, bool) {
+	var text string
+	if err := json.Unmarshal(raw, &text); err != nil {
+		var obj struct {
+			LatLng string `json:"latLng"`
+		}
+		if err := json.Unmarshal(raw, &obj); err != nil {
+			return models.Coordinates{}, false
+		}
+		text = obj.LatLng
+	}
+
+	matches := latLngPattern.FindStringSubmatch(strings.TrimPrefix(text, "geo:"))
+	if len(matches) != 3 {
+		return models.Coordinates{}, false
+	}
+	lat, _ := strconv.ParseFloat(matches[1], 64)
+	lng, _ := strconv.ParseFloat(matches[2], 64)
+	return models.Coordinates{Lat: lat, Lng: lng}, true
+}
+
+func parseTimelineTime(timestamp, millis string) (time.Time, bool) {
+	if timestamp != "" {
+		if t, err := time.Parse(time.RFC3339Nano, timestamp); err == nil {
+			return t, true
+		}
+	}
+	if millis != "" {
+		if ms, err := strconv.ParseInt(millis, 10, 64); err == nil {
+			return time.UnixMilli(ms), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// groupTimelineVisits turns visit records into one place per location, in
+// order of first appearance
+func groupTimelineVisits(records []timelineVisitRecord) []*models.Place {
+	byKey := make(map[string]*models.Place)
+	var places []*models.Place
+
+	for _, record := range records {
+		key := record.placeID
+		if key == "" {
+			key = fmt.Sprintf("%.4f,%.4f", record.coords.Lat, record.coords.Lng)
+		}
+
+		place, ok := byKey[key]
+		if !ok {
+			place = newTimelinePlace(key, record)
+			byKey[key] = place
+			places = append(places, place)
+		}
+		if place.Name == "" && record.name != "" {
+			place.Name = record.name
+			place.Address = record.address
+		}
+
+		place.Visits = append(place.Visits, models.Visit{
+			VisitedAt: record.start,
+			Source:    "timeline",
+			SourceID:  key + "|" + record.start.UTC().Format(time.RFC3339),
+		})
+	}
+
+	for _, place := range places {
+		if place.Name == "" {
+			place.Name = fmt.Sprintf("Visited place (%.5f, %.5f)", place.Coordinates.Lat, place.Coordinates.Lng)
+		}
+	}
+
+	return places
+}
+
+func newTimelinePlace(key string, record timelineVisitRecord) *models.Place {
+	now := time.Now()
+	placeID := record.placeID
+	if placeID == "" {
+		placeID = "timeline_" + key
+	}
+
+	place := &models.Place{
+		ID:          utils.GenerateID(placeID),
+		PlaceID:     placeID,
+		Name:        record.name,
+		Address:     record.address,
+		Coordinates: record.coords,
+		Categories:  []string{},
+		UserTags:    []string{},
+		CustomFields: map[string]interface{}{
+			"imported_from": "timeline",
+			"import_date":   now.Format(time.RFC3339),
+		},
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		ImportedAt: &now,
+		SourceHash: fmt.Sprintf("%x", generateHash([]byte("timeline|"+key))),
+	}
+	if record.semanticType != "" && record.semanticType != "TYPE_UNKNOWN" && record.semanticType != "UNKNOWN" {
+		place.CustomFields["semantic_type"] = record.semanticType
+	}
+	return place
+}
@@ -0,0 +1,125 @@
+package sources
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const legacyTimeline = `{"timelineObjects": [
+	{"activitySegment": {"distance": 1200}},
+	{"placeVisit": {
+		"location": {"latitudeE7": 387139000, "longitudeE7": -91334000, "placeId": "ChIJramiro",
+			"address": "Av. Almirante Reis 1, Lisboa", "name": "Cervejaria Ramiro", "semanticType": "TYPE_UNKNOWN"},
+		"duration": {"startTimestamp": "2024-05-17T19:30:00.000Z", "endTimestamp": "2024-05-17T21:00:00.000Z"}}},
+	{"placeVisit": {
+		"location": {"latitudeE7": 387139000, "longitudeE7": -91334000, "placeId": "ChIJramiro", "name": "Cervejaria Ramiro"},
+		"duration": {"startTimestampMs": "1690970000000"}}}
+]}`
+
+const androidTimeline = `{"semanticSegments": [
+	{"startTime": "2024-05-18T09:00:00.000+01:00", "endTime": "2024-05-18T10:00:00.000+01:00",
+	 "visit": {"hierarchyLevel": 0, "probability": 0.9,
+		"topCandidate": {"placeId": "ChIJcafe", "semanticType": "UNKNOWN", "placeLocation": {"latLng": "38.7100°, -9.1400°"}}}},
+	{"startTime": "2024-05-18T09:00:00.000+01:00",
+	 "visit": {"hierarchyLevel": 1,
+		"topCandidate": {"placeId": "ChIJmall", "placeLocation": {"latLng": "38.7000°, -9.1000°"}}}},
+	{"startTime": "2024-05-18T10:00:00.000+01:00", "timelinePath": [{"point": "38.7°, -9.1°"}]}
+], "rawSignals": []}`
+
+const iosTimeline = `[
+	{"startTime": "2024-05-19T12:00:00.000+01:00", "endTime": "2024-05-19T13:00:00.000+01:00",
+	 "visit": {"hierarchyLevel": "0", "probability": "0.8",
+		"topCandidate": {"placeID": "ChIJcafe", "semanticType": "Unknown", "placeLocation": "geo:38.710000,-9.140000"}}}
+]`
+
+func TestTimelineImporter_Legacy(t *testing.T) {
+	places, err := (&TimelineImporter{}).ImportFromData([]byte(legacyTimeline), "json")
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+
+	place := places[0]
+	assert.Equal(t, "Cervejaria Ramiro", place.Name)
+	assert.Equal(t, "ChIJramiro", place.PlaceID)
+	assert.InDelta(t, 38.7139, place.Coordinates.Lat, 1e-6)
+	assert.InDelta(t, -9.1334, place.Coordinates.Lng, 1e-6)
+	assert.NotContains(t, place.CustomFields, "semantic_type")
+
+	require.Len(t, place.Visits, 2)
+	assert.True(t, place.Visits[0].VisitedAt.Equal(time.Date(2024, 5, 17, 19, 30, 0, 0, time.UTC)))
+	assert.True(t, place.Visits[1].VisitedAt.Equal(time.UnixMilli(1690970000000)))
+	assert.Equal(t, "timeline", place.Visits[0].Source)
+	assert.NotEqual(t, place.Visits[0].SourceID, place.Visits[1].SourceID)
+}
+
+func TestTimelineImporter_OnDevice(t *testing.T) {
+	android, err := (&TimelineImporter{}).ImportFromData([]byte(androidTimeline), "json")
+	require.NoError(t, err)
+	require.Len(t, android, 1, "area visits and paths are skipped")
+	assert.Equal(t, "ChIJcafe", android[0].PlaceID)
+	assert.InDelta(t, 38.71, android[0].Coordinates.Lat, 1e-6)
+	assert.Contains(t, android[0].Name, "Visited place")
+
+	ios, err := (&TimelineImporter{}).ImportFromData([]byte(iosTimeline), "json")
+	require.NoError(t, err)
+	require.Len(t, ios, 1)
+	assert.Equal(t, "ChIJcafe", ios[0].PlaceID)
+	assert.InDelta(t, -9.14, ios[0].Coordinates.Lng, 1e-6)
+	assert.Equal(t, "Unknown", ios[0].CustomFields["semantic_type"])
+
+	// The same visit from both exports gets the same ID, so it is logged once
+	assert.Equal(t, android[0].ID, ios[0].ID)
+}
+
+func TestTimelineImporter_SkipsOtherKeys(t *testing.T) {
+	data := `{"rawSignals": [{"position": {"LatLng": "1°, 2°"}}, [[{}]]], "userLocationProfile": {"frequentPlaces": []},` +
+		strings.TrimPrefix(androidTimeline, "{")
+	places, err := (&TimelineImporter{}).ImportFromData([]byte(data), "json")
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+	assert.Equal(t, "ChIJcafe", places[0].PlaceID)
+}
+
+func TestTimelineImporter_NotTimeline(t *testing.T) {
+	_, err := (&TimelineImporter{}).ImportFromData([]byte(`{"checkins": []}`), "json")
+	assert.Error(t, err)
+}
+
+func TestTimelineImporter_ZipAndDirectory(t *testing.T) {
+	dir := t.TempDir()
+	monthDir := filepath.Join(dir, "Takeout", "Location History (Timeline)", "Semantic Location History", "2024")
+	require.NoError(t, os.MkdirAll(monthDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(monthDir, "2024_MAY.json"), []byte(legacyTimeline), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Takeout", "Saved Places.json"), []byte(`{}`), 0644))
+
+	importer := &TimelineImporter{}
+	places, err := importer.ImportFromFile(dir)
+	require.NoError(t, err)
+	require.Len(t, places, 1)
+	assert.Len(t, places[0].Visits, 2)
+
+	zipPath := filepath.Join(t.TempDir(), "takeout.zip")
+	f, err := os.Create(zipPath)
+	require.NoError(t, err)
+	zw := zip.NewWriter(f)
+	w, err := zw.Create("Takeout/Location History (Timeline)/Semantic Location History/2024/2024_MAY.json")
+	require.NoError(t, err)
+	_, err = w.Write([]byte(legacyTimeline))
+	require.NoError(t, err)
+	w, err = zw.Create("Takeout/Timeline.json")
+	require.NoError(t, err)
+	_, err = w.Write([]byte(androidTimeline))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	require.NoError(t, f.Close())
+
+	places, err = importer.ImportFromFile(zipPath)
+	require.NoError(t, err)
+	assert.Len(t, places, 2)
+}
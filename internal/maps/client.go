@@ -3,6 +3,7 @@ package maps
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -32,16 +33,41 @@ type PlaceDetails struct {
 	Website     string        `json:"website"`
 	Phone       string        `json:"formatted_phone_number"`
 	Hours       *OpeningHours `json:"opening_hours"`
-	Photos      []PlacePhoto  `json:"photos"`
-	Reviews     []PlaceReview `json:"reviews"`
-	Geometry    PlaceGeometry `json:"geometry"`
-	Types       []string      `json:"types"`
-	Address     string        `json:"formatted_address"`
+	// CurrentHours covers the next seven days, including holidays
+	CurrentHours *OpeningHours `json:"current_opening_hours"`
+	Photos       []PlacePhoto  `json:"photos"`
+	Reviews      []PlaceReview `json:"reviews"`
+	Geometry     PlaceGeometry `json:"geometry"`
+	Types        []string      `json:"types"`
+	Address      string        `json:"formatted_address"`
+	UTCOffset    *int          `json:"utc_offset"`
 }
 
 type OpeningHours struct {
-	OpenNow     bool     `json:"open_now"`
-	WeekdayText []string `json:"weekday_text"`
+	OpenNow     bool            `json:"open_now"`
+	WeekdayText []string        `json:"weekday_text"`
+	Periods     []OpeningPeriod `json:"periods"`
+	SpecialDays []SpecialDay    `json:"special_days"`
+}
+
+// SpecialDay is a date in current_opening_hours, such as a holiday, whose
+// hours may differ from the regular ones
+type SpecialDay struct {
+	Date             string `json:"date"`
+	ExceptionalHours bool   `json:"exceptional_hours"`
+}
+
+type OpeningPeriod struct {
+	Open  *PeriodTime `json:"open"`
+	Close *PeriodTime `json:"close"`
+}
+
+// PeriodTime is a point in the week; Day 0 is Sunday and Time is "HHMM".
+// Periods in current_opening_hours also carry their Date.
+type PeriodTime struct {
+	Day  int    `json:"day"`
+	Time string `json:"time"`
+	Date string `json:"date,omitempty"`
 }
 
 type PlacePhoto struct {
@@ -69,6 +95,33 @@ type PlaceLocation struct {
 	Lng float64 `json:"lng"`
 }
 
+// APIError is a request rejected by a provider, either at the HTTP level or
+// through a status field in the response body
+type APIError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *APIError) Error() string {
+	if e.Status != "" {
+		return "API returned status: " + e.Status
+	}
+	return fmt.Sprintf("API request failed with status %d", e.StatusCode)
+}
+
+// Retryable reports whether the request may succeed if tried again later
+func (e *APIError) Retryable() bool {
+	return e.Status == "OVER_QUERY_LIMIT" ||
+		e.StatusCode == http.StatusTooManyRequests ||
+		e.StatusCode >= 500
+}
+
+// IsRetryable reports whether err is a transient provider error
+func IsRetryable(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Retryable()
+}
+
 func NewClient(apiKey string) *Client {
 	return &Client{
 		apiKey: apiKey,
@@ -89,8 +142,8 @@ func (c *Client) GetPlaceDetails(ctx context.Context, placeID string) (*PlaceDet
 		"key":      {c.apiKey},
 		"fields": {
 			"place_id,name,rating,user_ratings_total,price_level,website," +
-				"formatted_phone_number,opening_hours,photos,reviews," +
-				"geometry,types,formatted_address",
+				"formatted_phone_number,opening_hours,current_opening_hours,photos,reviews," +
+				"geometry,types,formatted_address,utc_offset",
 		},
 	}
 
@@ -108,7 +161,7 @@ func (c *Client) GetPlaceDetails(ctx context.Context, placeID string) (*PlaceDet
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d", resp.StatusCode)
+		return nil, &APIError{StatusCode: resp.StatusCode}
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -122,7 +175,7 @@ func (c *Client) GetPlaceDetails(ctx context.Context, placeID string) (*PlaceDet
 	}
 
 	if response.Status != "OK" {
-		return nil, fmt.Errorf("API returned status: %s", response.Status)
+		return nil, &APIError{StatusCode: resp.StatusCode, Status: response.Status}
 	}
 
 	return &response.Result, nil
@@ -179,6 +232,7 @@ func (d *PlaceDetails) ToPlace() *models.Place {
 	if d.Hours != nil && len(d.Hours.WeekdayText) > 0 {
 		place.Hours = fmt.Sprintf("%v", d.Hours.WeekdayText)
 	}
+	place.OpeningHours = d.StructuredHours()
 
 	for _, photo := range d.Photos {
 		place.Photos = append(place.Photos, models.Photo{
@@ -200,3 +254,102 @@ func (d *PlaceDetails) ToPlace() *models.Place {
 
 	return place
 }
+
+// StructuredHours converts the Places API opening_hours periods into the
+// structured model, with the special days of current_opening_hours. It
+// returns nil when the response has neither.
+func (d *PlaceDetails) StructuredHours() *models.OpeningHours {
+	specialDays := d.specialDays()
+	if d.Hours == nil && len(specialDays) == 0 {
+		return nil
+	}
+
+	var hours *models.OpeningHours
+	switch {
+	case d.Hours == nil:
+		hours = &models.OpeningHours{}
+	case len(d.Hours.Periods) == 1 && d.Hours.Periods[0].Close == nil:
+		// The API represents "open 24 hours" as a single period without a close
+		hours = &models.OpeningHours{AlwaysOpen: true}
+	case len(d.Hours.Periods) > 0:
+		hours = &models.OpeningHours{}
+		for _, period := range d.Hours.Periods {
+			if period.Open == nil || period.Close == nil {
+				continue
+			}
+			hours.Periods = append(hours.Periods, models.OpeningPeriod{
+				Day: time.Weekday(period.Open.Day),
+				TimeRange: models.TimeRange{
+					Open:  formatPeriodTime(period.Open.Time),
+					Close: formatPeriodTime(period.Close.Time),
+				},
+			})
+		}
+	case len(d.Hours.WeekdayText) > 0:
+		parsed, err := models.ParseWeekdayText(d.Hours.WeekdayText)
+		if err != nil {
+			if len(specialDays) == 0 {
+				return nil
+			}
+			parsed = &models.OpeningHours{}
+		}
+		hours = parsed
+	case len(specialDays) > 0:
+		hours = &models.OpeningHours{}
+	default:
+		return nil
+	}
+	hours.SpecialDays = specialDays
+
+	if d.UTCOffset != nil {
+		offset := *d.UTCOffset
+		sign := "+"
+		if offset < 0 {
+			sign = "-"
+			offset = -offset
+		}
+		hours.Timezone = fmt.Sprintf("UTC%s%02d:%02d", sign, offset/60, offset%60)
+	}
+
+	return hours
+}
+
+// specialDays returns the dates current_opening_hours marks as exceptional,
+// with the periods it gives for them. A date without periods is closed.
+func (d *PlaceDetails) specialDays() []models.SpecialDay {
+	if d.CurrentHours == nil {
+		return nil
+	}
+
+	var days []models.SpecialDay
+	for _, special := range d.CurrentHours.SpecialDays {
+		if !special.ExceptionalHours || special.Date == "" {
+			continue
+		}
+		day := models.SpecialDay{Date: special.Date}
+		for _, period := range d.CurrentHours.Periods {
+			if period.Open == nil || period.Open.Date != special.Date {
+				continue
+			}
+			// A period without a close runs to the end of the day
+			close := "24:00"
+			if period.Close != nil {
+				close = formatPeriodTime(period.Close.Time)
+			}
+			day.Periods = append(day.Periods, models.TimeRange{
+				Open:  formatPeriodTime(period.Open.Time),
+				Close: close,
+			})
+		}
+		day.Closed = len(day.Periods) == 0
+		days = append(days, day)
+	}
+	return days
+}
+
+func formatPeriodTime(hhmm string) string {
+	if len(hhmm) != 4 {
+		return hhmm
+	}
+	return hhmm[:2] + ":" + hhmm[2:]
+}
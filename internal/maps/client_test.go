@@ -10,6 +10,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/user/placeli/internal/models"
 )
 
 func TestNewClient(t *testing.T) {
@@ -233,3 +234,73 @@ func TestPlaceDetailsToPlace_EmptyData(t *testing.T) {
 	assert.Empty(t, place.Categories)
 	assert.Empty(t, place.Hours)
 }
+
+func TestPlaceDetailsStructuredHours(t *testing.T) {
+	offset := 60
+	details := &PlaceDetails{
+		UTCOffset: &offset,
+		Hours: &OpeningHours{
+			Periods: []OpeningPeriod{
+				{Open: &PeriodTime{Day: 5, Time: "1800"}, Close: &PeriodTime{Day: 6, Time: "0200"}},
+				{Open: &PeriodTime{Day: 1, Time: "0900"}, Close: &PeriodTime{Day: 1, Time: "1730"}},
+			},
+		},
+	}
+
+	hours := details.StructuredHours()
+	require.NotNil(t, hours)
+	assert.Equal(t, "UTC+01:00", hours.Timezone)
+	require.Len(t, hours.Periods, 2)
+	assert.Equal(t, time.Friday, hours.Periods[0].Day)
+	assert.Equal(t, "18:00", hours.Periods[0].Open)
+	assert.Equal(t, "02:00", hours.Periods[0].Close)
+
+	// Saturday 00:30 UTC is 01:30 local, still inside Friday night's period
+	assert.True(t, hours.IsOpenAt(time.Date(2024, 6, 8, 0, 30, 0, 0, time.UTC)))
+
+	alwaysOpen := &PlaceDetails{Hours: &OpeningHours{
+		Periods: []OpeningPeriod{{Open: &PeriodTime{Day: 0, Time: "0000"}}},
+	}}
+	assert.True(t, alwaysOpen.StructuredHours().AlwaysOpen)
+
+	textOnly := &PlaceDetails{Hours: &OpeningHours{
+		WeekdayText: []string{"Monday: 11:00 AM – 10:00 PM", "Tuesday: Closed"},
+	}}
+	assert.Equal(t, "22:00", textOnly.StructuredHours().Periods[0].Close)
+
+	assert.Nil(t, (&PlaceDetails{}).StructuredHours())
+}
+
+func TestPlaceDetailsSpecialDays(t *testing.T) {
+	details := &PlaceDetails{
+		Hours: &OpeningHours{
+			Periods: []OpeningPeriod{
+				{Open: &PeriodTime{Day: 3, Time: "0900"}, Close: &PeriodTime{Day: 3, Time: "1700"}},
+				{Open: &PeriodTime{Day: 4, Time: "0900"}, Close: &PeriodTime{Day: 4, Time: "1700"}},
+			},
+		},
+		CurrentHours: &OpeningHours{
+			Periods: []OpeningPeriod{
+				{Open: &PeriodTime{Day: 3, Time: "1000", Date: "2024-12-25"}, Close: &PeriodTime{Day: 3, Time: "1400", Date: "2024-12-25"}},
+				{Open: &PeriodTime{Day: 4, Time: "0900", Date: "2024-12-26"}, Close: &PeriodTime{Day: 4, Time: "1700", Date: "2024-12-26"}},
+			},
+			SpecialDays: []SpecialDay{
+				{Date: "2024-12-25", ExceptionalHours: true},
+				{Date: "2024-12-26"},
+				{Date: "2025-01-01", ExceptionalHours: true},
+			},
+		},
+	}
+
+	hours := details.StructuredHours()
+	require.NotNil(t, hours)
+	assert.Len(t, hours.Periods, 2)
+	assert.Equal(t, []models.SpecialDay{
+		{Date: "2024-12-25", Periods: []models.TimeRange{{Open: "10:00", Close: "14:00"}}},
+		{Date: "2025-01-01", Closed: true},
+	}, hours.SpecialDays)
+
+	assert.False(t, hours.IsOpenAt(time.Date(2024, 12, 25, 15, 0, 0, 0, time.UTC)))
+	assert.True(t, hours.IsOpenAt(time.Date(2024, 12, 25, 11, 0, 0, 0, time.UTC)))
+	assert.True(t, hours.IsOpenAt(time.Date(2024, 12, 26, 15, 0, 0, 0, time.UTC)))
+}
@@ -2,22 +2,35 @@ package maps
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/user/placeli/internal/constants"
 	"github.com/user/placeli/internal/database"
 	"github.com/user/placeli/internal/logger"
 	"github.com/user/placeli/internal/models"
+	photostore "github.com/user/placeli/internal/photos"
 )
 
+// ErrNotEnrichable is returned by an Enricher when a place lacks the data
+// the provider needs to look it up, such as a Google PlaceID or coordinates
+var ErrNotEnrichable = errors.New("place cannot be enriched by this provider")
+
+// Enricher looks a place up with an external data provider and merges the
+// result into it
+type Enricher interface {
+	Name() string
+	Enrich(ctx context.Context, place *models.Place, opts EnrichmentOptions) error
+}
+
 type EnrichmentService struct {
-	client   *Client
+	enricher Enricher
 	db       *database.DB
-	photoDir string
+	// mu serializes database writes from concurrent workers
+	mu sync.Mutex
 }
 
 type EnrichmentOptions struct {
@@ -26,81 +39,90 @@ type EnrichmentOptions struct {
 	PhotoMaxWidth int
 }
 
+// NewEnrichmentService creates a service backed by the Google Places API
 func NewEnrichmentService(apiKey string, db *database.DB, photoDir string) *EnrichmentService {
+	return NewEnrichmentServiceWithEnricher(NewGoogleEnricher(apiKey, photoDir), db)
+}
+
+func NewEnrichmentServiceWithEnricher(enricher Enricher, db *database.DB) *EnrichmentService {
 	return &EnrichmentService{
-		client:   NewClient(apiKey),
+		enricher: enricher,
 		db:       db,
-		photoDir: photoDir,
 	}
 }
 
 func (s *EnrichmentService) EnrichPlace(ctx context.Context, place *models.Place, opts EnrichmentOptions) error {
-	if place.PlaceID == "" {
-		return fmt.Errorf("place %s has no PlaceID for enrichment", place.Name)
-	}
+	return s.saveResult(place, s.enricher.Enrich(ctx, place, opts))
+}
 
-	details, err := s.client.GetPlaceDetails(ctx, place.PlaceID)
-	if err != nil {
-		return fmt.Errorf("failed to get place details for %s: %w", place.Name, err)
+// saveResult stores an enriched place together with its enrichment state.
+// Failed lookups only update the state so the place is retried later.
+func (s *EnrichmentService) saveResult(place *models.Place, enrichErr error) error {
+	if errors.Is(enrichErr, ErrNotEnrichable) {
+		return enrichErr
 	}
 
-	s.mergeDetails(place, details, opts)
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	if opts.FetchPhotos && len(details.Photos) > 0 {
-		if err := s.downloadPhotos(ctx, place, details.Photos, opts.PhotoMaxWidth); err != nil {
-			return fmt.Errorf("failed to download photos for %s: %w", place.Name, err)
+	if enrichErr != nil {
+		if err := s.db.RecordEnrichment(place.ID, s.enricher.Name(), enrichErr); err != nil {
+			logger.Warn("Failed to record enrichment error", "name", place.Name, "error", err)
 		}
+		return enrichErr
 	}
 
 	place.UpdatedAt = time.Now()
 	if err := s.db.SavePlace(place); err != nil {
 		return fmt.Errorf("failed to save enriched place %s: %w", place.Name, err)
 	}
-
-	return nil
+	return s.db.RecordEnrichment(place.ID, s.enricher.Name(), nil)
 }
 
 func (s *EnrichmentService) EnrichAllPlaces(ctx context.Context, opts EnrichmentOptions) error {
-	return s.EnrichAllPlacesWithLimit(ctx, opts, 0)
+	_, err := s.EnrichPlaces(ctx, opts, BatchOptions{})
+	return err
+}
+
+// GoogleEnricher enriches places through the Google Places API
+type GoogleEnricher struct {
+	client   *Client
+	photoDir string
 }
 
-func (s *EnrichmentService) EnrichAllPlacesWithLimit(ctx context.Context, opts EnrichmentOptions, limit int) error {
-	if limit == 0 {
-		limit = constants.DefaultExportLimit // Use reasonable upper bound
+func NewGoogleEnricher(apiKey, photoDir string) *GoogleEnricher {
+	return &GoogleEnricher{
+		client:   NewClient(apiKey),
+		photoDir: photoDir,
 	}
-	places, err := s.db.ListPlaces(limit, 0)
-	if err != nil {
-		return fmt.Errorf("failed to retrieve places: %w", err)
+}
+
+func (g *GoogleEnricher) Name() string {
+	return "google"
+}
+
+func (g *GoogleEnricher) Enrich(ctx context.Context, place *models.Place, opts EnrichmentOptions) error {
+	if place.PlaceID == "" {
+		return fmt.Errorf("place %s has no PlaceID for enrichment: %w", place.Name, ErrNotEnrichable)
 	}
 
-	var enriched, skipped, failed int
+	details, err := g.client.GetPlaceDetails(ctx, place.PlaceID)
+	if err != nil {
+		return fmt.Errorf("failed to get place details for %s: %w", place.Name, err)
+	}
 
-	for _, place := range places {
-		if place.PlaceID == "" {
-			skipped++
-			logger.Debug("Skipping place without PlaceID", "name", place.Name)
-			continue
-		}
+	g.mergeDetails(place, details, opts)
 
-		logger.Debug("Enriching place", "name", place.Name, "place_id", place.PlaceID)
-		if err := s.EnrichPlace(ctx, place, opts); err != nil {
-			failed++
-			logger.Warn("Failed to enrich place", "name", place.Name, "error", err)
-			continue
+	if opts.FetchPhotos && len(details.Photos) > 0 {
+		if err := g.downloadPhotos(ctx, place, details.Photos, opts.PhotoMaxWidth); err != nil {
+			return fmt.Errorf("failed to download photos for %s: %w", place.Name, err)
 		}
-
-		enriched++
-		time.Sleep(100 * time.Millisecond)
 	}
 
-	logger.Info("Enrichment complete",
-		"enriched", enriched,
-		"skipped", skipped,
-		"failed", failed)
 	return nil
 }
 
-func (s *EnrichmentService) mergeDetails(place *models.Place, details *PlaceDetails, opts EnrichmentOptions) {
+func (g *GoogleEnricher) mergeDetails(place *models.Place, details *PlaceDetails, opts EnrichmentOptions) {
 	if details.Rating > 0 && details.Rating != place.Rating {
 		place.Rating = details.Rating
 	}
@@ -122,6 +144,9 @@ func (s *EnrichmentService) mergeDetails(place *models.Place, details *PlaceDeta
 			place.Hours = hoursText
 		}
 	}
+	if hours := details.StructuredHours(); hours != nil {
+		place.OpeningHours = hours
+	}
 
 	if opts.FetchReviews && len(details.Reviews) > 0 {
 		place.Reviews = nil
@@ -137,34 +162,41 @@ func (s *EnrichmentService) mergeDetails(place *models.Place, details *PlaceDeta
 	}
 
 	if len(details.Types) > 0 {
-		uniqueTypes := make(map[string]bool)
-		var newCategories []string
-
-		for _, category := range place.Categories {
-			if !uniqueTypes[category] {
-				newCategories = append(newCategories, category)
-				uniqueTypes[category] = true
-			}
-		}
+		place.Categories = mergeCategories(place.Categories, details.Types)
+	}
+}
 
-		for _, typ := range details.Types {
-			if !uniqueTypes[typ] {
-				newCategories = append(newCategories, typ)
-				uniqueTypes[typ] = true
-			}
-		}
+// mergeCategories appends new categories, dropping duplicates
+func mergeCategories(existing, added []string) []string {
+	seen := make(map[string]bool)
+	var merged []string
 
-		place.Categories = newCategories
+	for _, category := range append(append([]string{}, existing...), added...) {
+		if !seen[category] {
+			merged = append(merged, category)
+			seen[category] = true
+		}
 	}
+
+	return merged
 }
 
-func (s *EnrichmentService) downloadPhotos(ctx context.Context, place *models.Place, photos []PlacePhoto, maxWidth int) error {
-	if s.photoDir == "" {
+func (g *GoogleEnricher) downloadPhotos(ctx context.Context, place *models.Place, photos []PlacePhoto, maxWidth int) error {
+	if g.photoDir == "" {
 		return fmt.Errorf("photo directory not configured")
 	}
 
-	if err := os.MkdirAll(s.photoDir, 0755); err != nil {
-		return fmt.Errorf("failed to create photo directory: %w", err)
+	store, err := photostore.NewStore(g.photoDir)
+	if err != nil {
+		return err
+	}
+
+	// Photos already in the store are reused rather than downloaded again
+	known := make(map[string]models.Photo)
+	for _, photo := range place.Photos {
+		if photo.Hash != "" && store.Has(photo.Hash) {
+			known[photo.Reference] = photo
+		}
 	}
 
 	place.Photos = nil
@@ -174,26 +206,29 @@ func (s *EnrichmentService) downloadPhotos(ctx context.Context, place *models.Pl
 			break
 		}
 
-		photoData, err := s.client.DownloadPhoto(ctx, photo.PhotoReference, maxWidth)
-		if err != nil {
-			fmt.Printf("Failed to download photo %d for %s: %v\n", i+1, place.Name, err)
+		if existing, ok := known[photo.PhotoReference]; ok {
+			place.Photos = append(place.Photos, existing)
 			continue
 		}
 
-		filename := fmt.Sprintf("%s_%d.jpg",
-			strings.ReplaceAll(strings.ToLower(place.Name), " ", "_"), i+1)
-		photoPath := filepath.Join(s.photoDir, filename)
+		photoData, err := g.client.DownloadPhoto(ctx, photo.PhotoReference, maxWidth)
+		if err != nil {
+			logger.Warn("Failed to download photo", "name", place.Name, "index", i+1, "error", err)
+			continue
+		}
 
-		if err := os.WriteFile(photoPath, photoData, 0644); err != nil {
-			fmt.Printf("Failed to save photo %s: %v\n", photoPath, err)
+		hash, err := store.Put(photoData)
+		if err != nil {
+			logger.Warn("Failed to store photo", "name", place.Name, "index", i+1, "error", err)
 			continue
 		}
 
 		place.Photos = append(place.Photos, models.Photo{
 			Reference: photo.PhotoReference,
-			LocalPath: photoPath,
+			LocalPath: store.Path(hash),
 			Width:     photo.Width,
 			Height:    photo.Height,
+			Hash:      hash,
 		})
 
 		time.Sleep(constants.PhotoDownloadDelayMs * time.Millisecond)
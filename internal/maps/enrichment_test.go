@@ -11,7 +11,7 @@ import (
 )
 
 func TestMergeDetails(t *testing.T) {
-	service := &EnrichmentService{}
+	enricher := &GoogleEnricher{}
 
 	place := &models.Place{
 		Name:        "Old Pizza Place",
@@ -51,7 +51,7 @@ func TestMergeDetails(t *testing.T) {
 		FetchReviews: true,
 	}
 
-	service.mergeDetails(place, details, opts)
+	enricher.mergeDetails(place, details, opts)
 
 	assert.Equal(t, float32(4.2), place.Rating)
 	assert.Equal(t, 523, place.UserRatings)
@@ -71,7 +71,7 @@ func TestMergeDetails(t *testing.T) {
 }
 
 func TestMergeDetails_NoOverwrite(t *testing.T) {
-	service := &EnrichmentService{}
+	enricher := &GoogleEnricher{}
 
 	place := &models.Place{
 		Rating:      4.5,
@@ -91,7 +91,7 @@ func TestMergeDetails_NoOverwrite(t *testing.T) {
 
 	opts := EnrichmentOptions{}
 
-	service.mergeDetails(place, details, opts)
+	enricher.mergeDetails(place, details, opts)
 
 	assert.Equal(t, float32(4.5), place.Rating)
 	assert.Equal(t, 1000, place.UserRatings)
@@ -101,7 +101,7 @@ func TestMergeDetails_NoOverwrite(t *testing.T) {
 }
 
 func TestMergeDetails_UniqueCategories(t *testing.T) {
-	service := &EnrichmentService{}
+	enricher := &GoogleEnricher{}
 
 	place := &models.Place{
 		Categories: []string{"restaurant", "pizza"},
@@ -113,7 +113,7 @@ func TestMergeDetails_UniqueCategories(t *testing.T) {
 
 	opts := EnrichmentOptions{}
 
-	service.mergeDetails(place, details, opts)
+	enricher.mergeDetails(place, details, opts)
 
 	assert.Equal(t, 4, len(place.Categories))
 	assert.Contains(t, place.Categories, "restaurant")
@@ -132,7 +132,7 @@ func TestMergeDetails_UniqueCategories(t *testing.T) {
 }
 
 func TestMergeDetails_SkipReviewsWhenNotRequested(t *testing.T) {
-	service := &EnrichmentService{}
+	enricher := &GoogleEnricher{}
 
 	place := &models.Place{
 		Reviews: []models.Review{{Author: "Original Review", Text: "Original"}},
@@ -148,7 +148,7 @@ func TestMergeDetails_SkipReviewsWhenNotRequested(t *testing.T) {
 		FetchReviews: false,
 	}
 
-	service.mergeDetails(place, details, opts)
+	enricher.mergeDetails(place, details, opts)
 
 	require.Equal(t, 1, len(place.Reviews))
 	assert.Equal(t, "Original Review", place.Reviews[0].Author)
@@ -156,7 +156,7 @@ func TestMergeDetails_SkipReviewsWhenNotRequested(t *testing.T) {
 }
 
 func TestEnrichPlace_NoPlaceID(t *testing.T) {
-	service := &EnrichmentService{}
+	service := NewEnrichmentServiceWithEnricher(&GoogleEnricher{}, nil)
 	place := &models.Place{
 		Name:    "Test Place",
 		PlaceID: "",
@@ -165,4 +165,5 @@ func TestEnrichPlace_NoPlaceID(t *testing.T) {
 	err := service.EnrichPlace(context.Background(), place, EnrichmentOptions{})
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "has no PlaceID for enrichment")
+	assert.ErrorIs(t, err, ErrNotEnrichable)
 }
@@ -0,0 +1,259 @@
+package maps
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/user/placeli/internal/models"
+)
+
+const (
+	defaultOverpassURL = "https://overpass-api.de/api/interpreter"
+	// defaultOSMRadius is how far from a place's coordinates to look for a
+	// matching OSM feature, in metres
+	defaultOSMRadius = 75
+)
+
+// OSMEnricher enriches places from OpenStreetMap via the Overpass API. It
+// needs no API key; places are matched by coordinates and name.
+type OSMEnricher struct {
+	httpClient *http.Client
+	baseURL    string
+	radius     int
+}
+
+type overpassResponse struct {
+	Elements []overpassElement `json:"elements"`
+}
+
+type overpassElement struct {
+	Type   string            `json:"type"`
+	ID     int64             `json:"id"`
+	Lat    float64           `json:"lat"`
+	Lon    float64           `json:"lon"`
+	Center *overpassCenter   `json:"center"`
+	Tags   map[string]string `json:"tags"`
+}
+
+type overpassCenter struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+func NewOSMEnricher() *OSMEnricher {
+	return &OSMEnricher{
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		baseURL: defaultOverpassURL,
+		radius:  defaultOSMRadius,
+	}
+}
+
+func (o *OSMEnricher) Name() string {
+	return "osm"
+}
+
+func (o *OSMEnricher) Enrich(ctx context.Context, place *models.Place, opts EnrichmentOptions) error {
+	if place.Coordinates.Lat == 0 && place.Coordinates.Lng == 0 {
+		return fmt.Errorf("place %s has no coordinates for enrichment: %w", place.Name, ErrNotEnrichable)
+	}
+
+	elements, err := o.query(ctx, place.Coordinates)
+	if err != nil {
+		return fmt.Errorf("failed to query OpenStreetMap for %s: %w", place.Name, err)
+	}
+
+	match := bestOSMMatch(place, elements)
+	if match == nil {
+		return fmt.Errorf("no OpenStreetMap feature matching %s found nearby", place.Name)
+	}
+
+	mergeOSMTags(place, match)
+	return nil
+}
+
+func (o *OSMEnricher) query(ctx context.Context, coords models.Coordinates) ([]overpassElement, error) {
+	query := fmt.Sprintf("[out:json][timeout:25];nwr(around:%d,%f,%f)[name];out tags center;",
+		o.radius, coords.Lat, coords.Lng)
+
+	body := url.Values{"data": {query}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, "POST", o.baseURL, strings.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", "placeli")
+
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &APIError{StatusCode: resp.StatusCode}
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var response overpassResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return response.Elements, nil
+}
+
+// minPartialMatch is the shortest name, in letters and digits, that may
+// match as part of a longer one. Shorter names such as "Bar" are too
+// generic to identify a place.
+const minPartialMatch = 4
+
+// bestOSMMatch picks the nearest element whose name matches the place's.
+// An exact (normalized) name beats a partial one, where every word of the
+// shorter name is a word of the longer.
+func bestOSMMatch(place *models.Place, elements []overpassElement) *overpassElement {
+	want := normalizeName(place.Name)
+	if want == "" {
+		return nil
+	}
+	wantWords := nameWords(place.Name)
+
+	var best *overpassElement
+	bestScore, bestDistance := 0, math.MaxFloat64
+
+	for i := range elements {
+		el := &elements[i]
+		have := normalizeName(el.Tags["name"])
+		if have == "" {
+			continue
+		}
+
+		score := 0
+		switch {
+		case have == want:
+			score = 2
+		case partialNameMatch(wantWords, nameWords(el.Tags["name"])):
+			score = 1
+		default:
+			continue
+		}
+
+		lat, lon := el.Lat, el.Lon
+		if el.Center != nil {
+			lat, lon = el.Center.Lat, el.Center.Lon
+		}
+		distance := place.Coordinates.DistanceTo(models.Coordinates{Lat: lat, Lng: lon})
+
+		if score > bestScore || (score == bestScore && distance < bestDistance) {
+			best, bestScore, bestDistance = el, score, distance
+		}
+	}
+
+	return best
+}
+
+// partialNameMatch reports whether every word of the shorter name is a word
+// of the longer one, and the shorter name is long enough to be telling
+func partialNameMatch(a, b []string) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	if len(a) == 0 || len(strings.Join(a, "")) < minPartialMatch {
+		return false
+	}
+	words := make(map[string]bool, len(b))
+	for _, word := range b {
+		words[word] = true
+	}
+	for _, word := range a {
+		if !words[word] {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeOSMTags maps OSM tags onto the place, keeping existing values where
+// OSM has nothing to offer
+func mergeOSMTags(place *models.Place, el *overpassElement) {
+	tags := el.Tags
+
+	if website := firstTag(tags, "website", "contact:website", "url"); website != "" {
+		place.Website = website
+	}
+	if phone := firstTag(tags, "phone", "contact:phone"); phone != "" {
+		place.Phone = phone
+	}
+	if openingHours := tags["opening_hours"]; openingHours != "" {
+		place.Hours = openingHours
+		if hours, err := models.ParseOSMOpeningHours(openingHours); err == nil {
+			place.OpeningHours = hours
+		}
+	}
+
+	var categories []string
+	for _, key := range []string{"amenity", "shop", "tourism", "leisure"} {
+		if value := tags[key]; value != "" {
+			categories = append(categories, value)
+		}
+	}
+	for _, cuisine := range strings.Split(tags["cuisine"], ";") {
+		if cuisine = strings.TrimSpace(cuisine); cuisine != "" {
+			categories = append(categories, cuisine)
+		}
+	}
+	if len(categories) > 0 {
+		place.Categories = mergeCategories(place.Categories, categories)
+	}
+
+	if place.CustomFields == nil {
+		place.CustomFields = make(map[string]interface{})
+	}
+	place.CustomFields["osm_id"] = fmt.Sprintf("%s/%d", el.Type, el.ID)
+	if cuisine := tags["cuisine"]; cuisine != "" {
+		place.CustomFields["osm_cuisine"] = cuisine
+	}
+}
+
+func firstTag(tags map[string]string, keys ...string) string {
+	for _, key := range keys {
+		if value := tags[key]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// nameWords splits a name into normalized words. Apostrophes join rather
+// than split, so "Joe's" and "Joes" are the same word.
+func nameWords(name string) []string {
+	name = strings.NewReplacer("'", "", "’", "").Replace(strings.ToLower(name))
+	return strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// normalizeName lowercases a name and strips punctuation and spacing so
+// "Joe's Pizza" matches "Joes Pizza"
+func normalizeName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,231 @@
+package maps
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/placeli/internal/database"
+	"github.com/user/placeli/internal/models"
+	"github.com/user/placeli/internal/photos"
+)
+
+func newOverpassServer(t *testing.T, elements []overpassElement) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		require.NoError(t, r.ParseForm())
+		assert.Contains(t, r.Form.Get("data"), "around:75,40.689200,-74.044500")
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(overpassResponse{Elements: elements})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func testOSMEnricher(url string) *OSMEnricher {
+	enricher := NewOSMEnricher()
+	enricher.baseURL = url
+	return enricher
+}
+
+func TestOSMEnricher_Enrich(t *testing.T) {
+	server := newOverpassServer(t, []overpassElement{
+		{Type: "node", ID: 1, Lat: 40.6893, Lon: -74.0446, Tags: map[string]string{
+			"name": "Joe's Pizza Express", "amenity": "fast_food",
+		}},
+		{Type: "way", ID: 42, Center: &overpassCenter{Lat: 40.6895, Lon: -74.0448}, Tags: map[string]string{
+			"name":            "Joes Pizza",
+			"amenity":         "restaurant",
+			"cuisine":         "pizza;italian",
+			"opening_hours":   "Mo-Su 11:00-22:00",
+			"contact:website": "https://joespizza.example",
+			"phone":           "+1 718 555 0123",
+		}},
+		{Type: "node", ID: 2, Lat: 40.6892, Lon: -74.0445, Tags: map[string]string{
+			"name": "Corner Deli",
+		}},
+	})
+
+	place := &models.Place{
+		Name:        "Joe's Pizza",
+		Coordinates: models.Coordinates{Lat: 40.6892, Lng: -74.0445},
+		Website:     "old.example",
+		Categories:  []string{"restaurant"},
+	}
+
+	err := testOSMEnricher(server.URL).Enrich(context.Background(), place, EnrichmentOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "https://joespizza.example", place.Website)
+	assert.Equal(t, "+1 718 555 0123", place.Phone)
+	assert.Equal(t, "Mo-Su 11:00-22:00", place.Hours)
+	require.NotNil(t, place.OpeningHours)
+	assert.Len(t, place.OpeningHours.Periods, 7)
+	assert.Equal(t, []string{"restaurant", "pizza", "italian"}, place.Categories)
+	assert.Equal(t, "way/42", place.CustomFields["osm_id"])
+	assert.Equal(t, "pizza;italian", place.CustomFields["osm_cuisine"])
+}
+
+func TestOSMEnricher_NoMatch(t *testing.T) {
+	server := newOverpassServer(t, []overpassElement{
+		{Type: "node", ID: 2, Lat: 40.6892, Lon: -74.0445, Tags: map[string]string{"name": "Corner Deli"}},
+	})
+
+	place := &models.Place{
+		Name:        "Joe's Pizza",
+		Coordinates: models.Coordinates{Lat: 40.6892, Lng: -74.0445},
+		Phone:       "555-0000",
+	}
+
+	err := testOSMEnricher(server.URL).Enrich(context.Background(), place, EnrichmentOptions{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no OpenStreetMap feature")
+	assert.Equal(t, "555-0000", place.Phone)
+}
+
+func TestOSMEnricher_NoCoordinates(t *testing.T) {
+	err := NewOSMEnricher().Enrich(context.Background(), &models.Place{Name: "Nowhere"}, EnrichmentOptions{})
+	assert.ErrorIs(t, err, ErrNotEnrichable)
+}
+
+func TestOSMEnricher_HTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	place := &models.Place{Name: "Joe's Pizza", Coordinates: models.Coordinates{Lat: 1, Lng: 1}}
+	err := testOSMEnricher(server.URL).Enrich(context.Background(), place, EnrichmentOptions{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "status 429")
+}
+
+func TestEnrichmentService_WithOSMEnricher(t *testing.T) {
+	db, err := database.New(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, db.SavePlace(&models.Place{
+		ID:          "pizza",
+		Name:        "Joe's Pizza",
+		Coordinates: models.Coordinates{Lat: 40.6892, Lng: -74.0445},
+	}))
+	require.NoError(t, db.SavePlace(&models.Place{ID: "nowhere", Name: "No Coordinates"}))
+
+	server := newOverpassServer(t, []overpassElement{
+		{Type: "node", ID: 7, Lat: 40.6892, Lon: -74.0445, Tags: map[string]string{
+			"name": "Joe's Pizza", "website": "https://joespizza.example",
+		}},
+	})
+
+	service := NewEnrichmentServiceWithEnricher(testOSMEnricher(server.URL), db)
+	require.NoError(t, service.EnrichAllPlaces(context.Background(), EnrichmentOptions{}))
+
+	place, err := db.GetPlace("pizza")
+	require.NoError(t, err)
+	assert.Equal(t, "https://joespizza.example", place.Website)
+	assert.Equal(t, "node/7", place.CustomFields["osm_id"])
+
+	untouched, err := db.GetPlace("nowhere")
+	require.NoError(t, err)
+	assert.Empty(t, untouched.Website)
+}
+
+func TestGoogleEnricher_Enrich(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasSuffix(r.URL.Path, "/place/details/json"))
+		assert.Equal(t, "ChIJtest", r.URL.Query().Get("place_id"))
+		json.NewEncoder(w).Encode(PlaceDetailsResponse{
+			Status: "OK",
+			Result: PlaceDetails{Website: "https://google.example", Types: []string{"cafe"}},
+		})
+	}))
+	defer server.Close()
+
+	enricher := NewGoogleEnricher("key", "")
+	enricher.client.baseURL = server.URL
+	assert.Equal(t, "google", enricher.Name())
+
+	place := &models.Place{Name: "Cafe", PlaceID: "ChIJtest"}
+	require.NoError(t, enricher.Enrich(context.Background(), place, EnrichmentOptions{}))
+	assert.Equal(t, "https://google.example", place.Website)
+	assert.Equal(t, []string{"cafe"}, place.Categories)
+}
+
+func TestNormalizeName(t *testing.T) {
+	assert.Equal(t, "joespizza", normalizeName("Joe's Pizza"))
+	assert.Equal(t, "cafébrasileira", normalizeName("Café  Brasileira!"))
+	assert.Equal(t, "", normalizeName("---"))
+}
+
+func TestBestOSMMatch(t *testing.T) {
+	element := func(id int64, name string, lat float64) overpassElement {
+		return overpassElement{Type: "node", ID: id, Lat: lat, Lon: 2.17, Tags: map[string]string{"name": name}}
+	}
+	place := func(name string) *models.Place {
+		return &models.Place{Name: name, Coordinates: models.Coordinates{Lat: 41.38, Lng: 2.17}}
+	}
+
+	// Names inside other words, and very short names, don't match
+	elements := []overpassElement{element(1, "Barcelona Cathedral", 41.38), element(2, "B", 41.38)}
+	assert.Nil(t, bestOSMMatch(place("Bar"), elements))
+	assert.Nil(t, bestOSMMatch(place("Bar Centrale"), elements))
+
+	// Whole words do, and the nearest of equal matches wins
+	elements = []overpassElement{
+		element(1, "Cafe Zurich Terrace", 41.39),
+		element(2, "Café Zürich", 41.40),
+		element(3, "Cafe Zurich", 41.381),
+	}
+	assert.Equal(t, int64(3), bestOSMMatch(place("Cafe Zurich"), elements).ID)
+	assert.Equal(t, int64(1), bestOSMMatch(place("Cafe Zurich"), elements[:2]).ID)
+	assert.Equal(t, int64(1), bestOSMMatch(place("Zurich Terrace"), elements).ID)
+}
+
+func TestGoogleEnricher_StoresPhotos(t *testing.T) {
+	photoBytes := []byte("\x89PNG fake image bytes")
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/place/photo") {
+			downloads++
+			w.Write(photoBytes)
+			return
+		}
+		json.NewEncoder(w).Encode(PlaceDetailsResponse{
+			Status: "OK",
+			Result: PlaceDetails{Photos: []PlacePhoto{{PhotoReference: "ref1", Width: 10, Height: 10}}},
+		})
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	enricher := NewGoogleEnricher("key", dir)
+	enricher.client.baseURL = server.URL
+	opts := EnrichmentOptions{FetchPhotos: true, PhotoMaxWidth: 400}
+
+	// Two places with the same name no longer share a file name; identical
+	// images share one stored copy
+	first := &models.Place{Name: "Starbucks", PlaceID: "a"}
+	second := &models.Place{Name: "Starbucks", PlaceID: "b"}
+	require.NoError(t, enricher.Enrich(context.Background(), first, opts))
+	require.NoError(t, enricher.Enrich(context.Background(), second, opts))
+
+	require.Len(t, first.Photos, 1)
+	hash := first.Photos[0].Hash
+	assert.Equal(t, photos.HashBytes(photoBytes), hash)
+	assert.Equal(t, hash, second.Photos[0].Hash)
+	assert.FileExists(t, first.Photos[0].LocalPath)
+	assert.Equal(t, 2, downloads)
+
+	// Re-enriching reuses photos that are already stored
+	require.NoError(t, enricher.Enrich(context.Background(), first, opts))
+	assert.Equal(t, 2, downloads)
+	assert.Equal(t, hash, first.Photos[0].Hash)
+}
@@ -0,0 +1,276 @@
+package maps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/user/placeli/internal/constants"
+	"github.com/user/placeli/internal/database"
+	"github.com/user/placeli/internal/logger"
+	"github.com/user/placeli/internal/models"
+)
+
+const (
+	defaultWorkers    = 4
+	defaultMaxRetries = 5
+	defaultBackoff    = time.Second
+	maxBackoff        = time.Minute
+)
+
+// BatchOptions controls a bulk enrichment run
+type BatchOptions struct {
+	// Limit caps the number of places enriched; 0 means no limit
+	Limit int
+	// Workers is the number of concurrent lookups (default 4)
+	Workers int
+	// Rate is the maximum number of provider requests per second; 0 uses
+	// the provider's default
+	Rate float64
+	// Resume skips places the provider has already enriched successfully
+	Resume bool
+	// Stale re-enriches only places last enriched longer ago than this
+	Stale time.Duration
+	// MaxRetries bounds retries of rate-limited or failed requests (default 5)
+	MaxRetries int
+	// Backoff is the first retry delay, doubled on every attempt (default 1s)
+	Backoff time.Duration
+	// Progress is called after every place with the running totals
+	Progress func(BatchResult)
+}
+
+// BatchResult summarizes a bulk enrichment run
+type BatchResult struct {
+	Total    int // places selected for this run
+	Enriched int
+	Skipped  int // places the provider cannot look up
+	Failed   int
+	UpToDate int // places left alone because of Resume or Stale
+}
+
+// Done returns the number of selected places processed so far
+func (r BatchResult) Done() int {
+	return r.Enriched + r.Skipped + r.Failed
+}
+
+// DefaultRate returns a request rate that respects each provider's usage
+// policy. The public Overpass instance asks for about one request a second.
+func DefaultRate(provider string) float64 {
+	if provider == "osm" {
+		return 1
+	}
+	return 10
+}
+
+// EnrichPlaces enriches places concurrently, recording per-place state so
+// interrupted runs can be resumed
+func (s *EnrichmentService) EnrichPlaces(ctx context.Context, opts EnrichmentOptions, batch BatchOptions) (*BatchResult, error) {
+	if batch.Workers <= 0 {
+		batch.Workers = defaultWorkers
+	}
+	if batch.Rate <= 0 {
+		batch.Rate = DefaultRate(s.enricher.Name())
+	}
+	if batch.MaxRetries <= 0 {
+		batch.MaxRetries = defaultMaxRetries
+	}
+	if batch.Backoff <= 0 {
+		batch.Backoff = defaultBackoff
+	}
+
+	places, upToDate, err := s.selectPlaces(batch)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BatchResult{Total: len(places), UpToDate: upToDate}
+	var resultMu sync.Mutex
+	report := func(update func(*BatchResult)) {
+		resultMu.Lock()
+		defer resultMu.Unlock()
+		update(result)
+		if batch.Progress != nil {
+			batch.Progress(*result)
+		}
+	}
+
+	limiter := newRateLimiter(batch.Rate, 1)
+	jobs := make(chan *models.Place)
+	var wg sync.WaitGroup
+
+	for i := 0; i < batch.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for place := range jobs {
+				err := s.enrichWithRetry(ctx, place, opts, batch, limiter)
+				switch {
+				case err == nil:
+					report(func(r *BatchResult) { r.Enriched++ })
+				case ctx.Err() != nil:
+					// Interrupted lookups are left for the next run
+				case errors.Is(err, ErrNotEnrichable):
+					logger.Debug("Skipping place", "name", place.Name, "reason", err)
+					report(func(r *BatchResult) { r.Skipped++ })
+				default:
+					logger.Warn("Failed to enrich place", "name", place.Name, "error", err)
+					report(func(r *BatchResult) { r.Failed++ })
+				}
+			}
+		}()
+	}
+
+feed:
+	for _, place := range places {
+		if ctx.Err() != nil {
+			break feed
+		}
+		select {
+		case jobs <- place:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	logger.Info("Enrichment complete",
+		"provider", s.enricher.Name(),
+		"enriched", result.Enriched,
+		"skipped", result.Skipped,
+		"failed", result.Failed,
+		"up_to_date", result.UpToDate)
+
+	if err := ctx.Err(); err != nil {
+		return result, fmt.Errorf("enrichment interrupted after %d of %d places: %w", result.Done(), result.Total, err)
+	}
+	return result, nil
+}
+
+// selectPlaces returns the places that need enrichment and the number left
+// out because they are fresh enough
+func (s *EnrichmentService) selectPlaces(batch BatchOptions) ([]*models.Place, int, error) {
+	places, err := s.db.ListPlaces(constants.DefaultExportLimit, 0)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to retrieve places: %w", err)
+	}
+
+	var states map[string]*database.EnrichmentState
+	if batch.Resume || batch.Stale > 0 {
+		states, err = s.db.GetEnrichmentStates(s.enricher.Name())
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	now := time.Now()
+	var selected []*models.Place
+	upToDate := 0
+	for _, place := range places {
+		if state := states[place.ID]; state != nil && state.EnrichedAt != nil {
+			fresh := batch.Resume
+			if batch.Stale > 0 {
+				fresh = now.Sub(*state.EnrichedAt) < batch.Stale
+			}
+			if fresh {
+				upToDate++
+				continue
+			}
+		}
+		if batch.Limit > 0 && len(selected) >= batch.Limit {
+			break
+		}
+		selected = append(selected, place)
+	}
+
+	return selected, upToDate, nil
+}
+
+// enrichWithRetry looks a place up, backing off exponentially while the
+// provider reports rate limiting or server errors
+func (s *EnrichmentService) enrichWithRetry(ctx context.Context, place *models.Place, opts EnrichmentOptions, batch BatchOptions, limiter *rateLimiter) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = limiter.Wait(ctx); err != nil {
+			return err
+		}
+
+		err = s.enricher.Enrich(ctx, place, opts)
+		if err != nil && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err == nil || !IsRetryable(err) || attempt >= batch.MaxRetries {
+			break
+		}
+
+		delay := backoffDelay(batch.Backoff, attempt)
+		logger.Debug("Retrying after provider error", "name", place.Name, "attempt", attempt+1, "delay", delay, "error", err)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return s.saveResult(place, err)
+}
+
+// backoffDelay doubles the base delay per attempt, with up to 50% jitter
+func backoffDelay(base time.Duration, attempt int) time.Duration {
+	delay := base << attempt
+	if delay <= 0 || delay > maxBackoff {
+		delay = maxBackoff
+	}
+	return delay + time.Duration(rand.Int63n(int64(delay)/2+1))
+}
+
+// rateLimiter is a token bucket refilled at a fixed rate
+type rateLimiter struct {
+	mu       sync.Mutex
+	rate     float64 // tokens per second
+	burst    float64
+	tokens   float64
+	lastFill time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		rate:     rate,
+		burst:    float64(burst),
+		tokens:   float64(burst),
+		lastFill: time.Now(),
+	}
+}
+
+// Wait blocks until a token is available or the context is cancelled
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		l.tokens += now.Sub(l.lastFill).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+		l.lastFill = now
+
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
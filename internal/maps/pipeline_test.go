@@ -0,0 +1,223 @@
+package maps
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/placeli/internal/database"
+	"github.com/user/placeli/internal/models"
+)
+
+// fakeEnricher sets the website of every place it sees and can be told to
+// fail a number of times per place first
+type fakeEnricher struct {
+	mu       sync.Mutex
+	calls    map[string]int
+	failures map[string]int
+	failWith error
+}
+
+func newFakeEnricher() *fakeEnricher {
+	return &fakeEnricher{calls: make(map[string]int), failures: make(map[string]int)}
+}
+
+func (f *fakeEnricher) Name() string { return "fake" }
+
+func (f *fakeEnricher) Enrich(ctx context.Context, place *models.Place, opts EnrichmentOptions) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if place.PlaceID == "" {
+		return fmt.Errorf("no id: %w", ErrNotEnrichable)
+	}
+	f.calls[place.ID]++
+	if f.failures[place.ID] > 0 {
+		f.failures[place.ID]--
+		return f.failWith
+	}
+	place.Website = "https://example.com/" + place.ID
+	return nil
+}
+
+func (f *fakeEnricher) callCount(id string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[id]
+}
+
+func setupPipelineDB(t *testing.T, count int) *database.DB {
+	db, err := database.New(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	for i := 0; i < count; i++ {
+		require.NoError(t, db.SavePlace(&models.Place{
+			ID:      fmt.Sprintf("p%02d", i),
+			PlaceID: fmt.Sprintf("g%02d", i),
+			Name:    fmt.Sprintf("Place %d", i),
+		}))
+	}
+	return db
+}
+
+func fastBatch() BatchOptions {
+	return BatchOptions{Workers: 4, Rate: 1000, Backoff: time.Millisecond}
+}
+
+func TestEnrichPlaces_Concurrent(t *testing.T) {
+	db := setupPipelineDB(t, 20)
+	require.NoError(t, db.SavePlace(&models.Place{ID: "noid", Name: "No PlaceID"}))
+	enricher := newFakeEnricher()
+	service := NewEnrichmentServiceWithEnricher(enricher, db)
+
+	var updates []BatchResult
+	batch := fastBatch()
+	batch.Progress = func(r BatchResult) { updates = append(updates, r) }
+
+	result, err := service.EnrichPlaces(context.Background(), EnrichmentOptions{}, batch)
+	require.NoError(t, err)
+	assert.Equal(t, 21, result.Total)
+	assert.Equal(t, 20, result.Enriched)
+	assert.Equal(t, 1, result.Skipped)
+	assert.Len(t, updates, 21)
+	assert.Equal(t, 21, updates[len(updates)-1].Done())
+
+	place, err := db.GetPlace("p07")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/p07", place.Website)
+
+	states, err := db.GetEnrichmentStates("fake")
+	require.NoError(t, err)
+	assert.Len(t, states, 20)
+	assert.NotNil(t, states["p07"].EnrichedAt)
+	assert.Nil(t, states["noid"])
+}
+
+func TestEnrichPlaces_RetriesRateLimit(t *testing.T) {
+	db := setupPipelineDB(t, 2)
+	enricher := newFakeEnricher()
+	enricher.failWith = &APIError{StatusCode: 200, Status: "OVER_QUERY_LIMIT"}
+	enricher.failures["p00"] = 2
+	service := NewEnrichmentServiceWithEnricher(enricher, db)
+
+	result, err := service.EnrichPlaces(context.Background(), EnrichmentOptions{}, fastBatch())
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Enriched)
+	assert.Equal(t, 3, enricher.callCount("p00"))
+}
+
+func TestEnrichPlaces_RecordsFailures(t *testing.T) {
+	db := setupPipelineDB(t, 2)
+	enricher := newFakeEnricher()
+	enricher.failWith = &APIError{StatusCode: 404}
+	enricher.failures["p01"] = 10
+	service := NewEnrichmentServiceWithEnricher(enricher, db)
+
+	result, err := service.EnrichPlaces(context.Background(), EnrichmentOptions{}, fastBatch())
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Failed)
+	assert.Equal(t, 1, enricher.callCount("p01"), "client errors are not retried")
+
+	states, err := db.GetEnrichmentStates("fake")
+	require.NoError(t, err)
+	assert.Nil(t, states["p01"].EnrichedAt)
+	assert.Equal(t, 1, states["p01"].Attempts)
+	assert.Contains(t, states["p01"].LastError, "status 404")
+
+	// Resuming only retries the failed place
+	enricher.failures["p01"] = 0
+	batch := fastBatch()
+	batch.Resume = true
+	result, err = service.EnrichPlaces(context.Background(), EnrichmentOptions{}, batch)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Total)
+	assert.Equal(t, 1, result.UpToDate)
+	assert.Equal(t, 1, enricher.callCount("p00"))
+	assert.Equal(t, 2, enricher.callCount("p01"))
+
+	states, err = db.GetEnrichmentStates("fake")
+	require.NoError(t, err)
+	assert.NotNil(t, states["p01"].EnrichedAt)
+	assert.Empty(t, states["p01"].LastError)
+}
+
+func TestEnrichPlaces_StaleAndLimit(t *testing.T) {
+	db := setupPipelineDB(t, 5)
+	enricher := newFakeEnricher()
+	service := NewEnrichmentServiceWithEnricher(enricher, db)
+
+	batch := fastBatch()
+	batch.Limit = 3
+	result, err := service.EnrichPlaces(context.Background(), EnrichmentOptions{}, batch)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Enriched)
+
+	// Recently enriched places are fresh for a 30 day window
+	batch = fastBatch()
+	batch.Stale = 30 * 24 * time.Hour
+	result, err = service.EnrichPlaces(context.Background(), EnrichmentOptions{}, batch)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Enriched)
+	assert.Equal(t, 3, result.UpToDate)
+
+	// ...but not for a 1ns window
+	batch.Stale = time.Nanosecond
+	result, err = service.EnrichPlaces(context.Background(), EnrichmentOptions{}, batch)
+	require.NoError(t, err)
+	assert.Equal(t, 5, result.Enriched)
+}
+
+func TestEnrichPlaces_Cancelled(t *testing.T) {
+	db := setupPipelineDB(t, 3)
+	service := NewEnrichmentServiceWithEnricher(newFakeEnricher(), db)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := service.EnrichPlaces(ctx, EnrichmentOptions{}, fastBatch())
+	assert.ErrorIs(t, err, context.Canceled)
+	require.NotNil(t, result)
+	assert.Equal(t, 0, result.Enriched)
+}
+
+func TestRateLimiter(t *testing.T) {
+	limiter := newRateLimiter(50, 1)
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		require.NoError(t, limiter.Wait(context.Background()))
+	}
+	// One token up front, then three more at 20ms intervals
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	// Once the bucket is empty a cancelled context stops the wait
+	slow := newRateLimiter(0.001, 1)
+	require.NoError(t, slow.Wait(context.Background()))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, slow.Wait(ctx), context.Canceled)
+}
+
+func TestBackoffDelay(t *testing.T) {
+	for attempt, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		delay := backoffDelay(time.Second, attempt)
+		assert.GreaterOrEqual(t, delay, want)
+		assert.LessOrEqual(t, delay, want+want/2)
+	}
+	assert.LessOrEqual(t, backoffDelay(time.Second, 40), maxBackoff+maxBackoff/2)
+}
+
+func TestAPIErrorRetryable(t *testing.T) {
+	assert.True(t, IsRetryable(&APIError{Status: "OVER_QUERY_LIMIT"}))
+	assert.True(t, IsRetryable(fmt.Errorf("wrapped: %w", &APIError{StatusCode: 503})))
+	assert.True(t, IsRetryable(&APIError{StatusCode: 429}))
+	assert.False(t, IsRetryable(&APIError{StatusCode: 404}))
+	assert.False(t, IsRetryable(&APIError{Status: "NOT_FOUND"}))
+	assert.False(t, IsRetryable(fmt.Errorf("plain")))
+}
@@ -0,0 +1,311 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// Position is a [longitude, latitude] pair, in GeoJSON order
+type Position [2]float64
+
+// Coordinates returns the position as Coordinates
+func (p Position) Coordinates() Coordinates {
+	return Coordinates{Lat: p[1], Lng: p[0]}
+}
+
+// Geometry outlines a place that is more than a point, such as a trail or a
+// park. It is a GeoJSON LineString, MultiLineString, Polygon or MultiPolygon.
+type Geometry struct {
+	Type string
+	// Lines holds the line of a LineString or the lines of a MultiLineString
+	Lines [][]Position
+	// Polygons holds the polygon of a Polygon or the polygons of a
+	// MultiPolygon. Each polygon's first ring is its outer boundary and any
+	// further rings are holes. Rings are closed.
+	Polygons [][][]Position
+}
+
+// NewLineGeometry returns a LineString, or a MultiLineString for several
+// lines. Lines with fewer than two positions are dropped; nil is returned
+// when none are left.
+func NewLineGeometry(lines ...[]Position) *Geometry {
+	var kept [][]Position
+	for _, line := range lines {
+		if len(line) >= 2 {
+			kept = append(kept, line)
+		}
+	}
+	switch len(kept) {
+	case 0:
+		return nil
+	case 1:
+		return &Geometry{Type: "LineString", Lines: kept}
+	default:
+		return &Geometry{Type: "MultiLineString", Lines: kept}
+	}
+}
+
+// NewPolygonGeometry returns a Polygon, or a MultiPolygon for several
+// polygons. Rings are closed if needed and polygons whose outer ring has
+// fewer than three corners are dropped; nil is returned when none are left.
+func NewPolygonGeometry(polygons ...[][]Position) *Geometry {
+	var kept [][][]Position
+	for _, polygon := range polygons {
+		var rings [][]Position
+		for _, ring := range polygon {
+			if ring = closeRing(ring); len(ring) >= 4 {
+				rings = append(rings, ring)
+			} else if len(rings) == 0 {
+				break // invalid outer ring
+			}
+		}
+		if len(rings) > 0 {
+			kept = append(kept, rings)
+		}
+	}
+	switch len(kept) {
+	case 0:
+		return nil
+	case 1:
+		return &Geometry{Type: "Polygon", Polygons: kept}
+	default:
+		return &Geometry{Type: "MultiPolygon", Polygons: kept}
+	}
+}
+
+func closeRing(ring []Position) []Position {
+	if len(ring) > 0 && ring[0] != ring[len(ring)-1] {
+		ring = append(ring[:len(ring):len(ring)], ring[0])
+	}
+	return ring
+}
+
+// IsArea reports whether the geometry is a polygon
+func (g *Geometry) IsArea() bool {
+	return g != nil && len(g.Polygons) > 0
+}
+
+// Paths returns every line and polygon ring of the geometry, for drawing
+// outlines
+func (g *Geometry) Paths() [][]Position {
+	if g == nil {
+		return nil
+	}
+	paths := append([][]Position{}, g.Lines...)
+	for _, polygon := range g.Polygons {
+		paths = append(paths, polygon...)
+	}
+	return paths
+}
+
+// Bounds returns the south-west and north-east corners of the geometry
+func (g *Geometry) Bounds() (min, max Coordinates) {
+	first := true
+	for _, path := range g.Paths() {
+		for _, p := range path {
+			c := p.Coordinates()
+			if first {
+				min, max, first = c, c, false
+				continue
+			}
+			min.Lat, min.Lng = math.Min(min.Lat, c.Lat), math.Min(min.Lng, c.Lng)
+			max.Lat, max.Lng = math.Max(max.Lat, c.Lat), math.Max(max.Lng, c.Lng)
+		}
+	}
+	return min, max
+}
+
+// Length returns the total length of the geometry's lines in metres
+func (g *Geometry) Length() float64 {
+	var length float64
+	for _, line := range g.Lines {
+		for i := 1; i < len(line); i++ {
+			length += line[i-1].Coordinates().DistanceTo(line[i].Coordinates())
+		}
+	}
+	return length
+}
+
+// Centroid returns a representative point: the length-weighted centre of
+// the lines, or the area centroid of the largest polygon
+func (g *Geometry) Centroid() Coordinates {
+	if g.IsArea() {
+		var center Coordinates
+		largest := -1.0
+		for _, polygon := range g.Polygons {
+			if c, area := ringCentroid(polygon[0]); area > largest {
+				center, largest = c, area
+			}
+		}
+		return center
+	}
+
+	var length, lat, lng float64
+	for _, line := range g.Lines {
+		for i := 1; i < len(line); i++ {
+			a, b := line[i-1].Coordinates(), line[i].Coordinates()
+			d := a.DistanceTo(b)
+			length += d
+			lat += d * (a.Lat + b.Lat) / 2
+			lng += d * (a.Lng + b.Lng) / 2
+		}
+	}
+	if length == 0 {
+		if len(g.Lines) > 0 {
+			return g.Lines[0][0].Coordinates()
+		}
+		return Coordinates{}
+	}
+	return Coordinates{Lat: lat / length, Lng: lng / length}
+}
+
+// ringCentroid returns the area centroid of a closed ring and its planar
+// area in square degrees. Degenerate rings fall back to the mean of their
+// vertices.
+func ringCentroid(ring []Position) (Coordinates, float64) {
+	var area, cx, cy float64
+	for i := 0; i < len(ring)-1; i++ {
+		x0, y0 := ring[i][0], ring[i][1]
+		x1, y1 := ring[i+1][0], ring[i+1][1]
+		cross := x0*y1 - x1*y0
+		area += cross
+		cx += (x0 + x1) * cross
+		cy += (y0 + y1) * cross
+	}
+	area /= 2
+
+	if math.Abs(area) < 1e-12 {
+		var sumX, sumY float64
+		n := len(ring) - 1
+		for _, p := range ring[:n] {
+			sumX += p[0]
+			sumY += p[1]
+		}
+		return Coordinates{Lng: sumX / float64(n), Lat: sumY / float64(n)}, 0
+	}
+	return Coordinates{Lng: cx / (6 * area), Lat: cy / (6 * area)}, math.Abs(area)
+}
+
+// Contains reports whether a point lies inside the geometry's polygons and
+// outside their holes. Lines contain nothing.
+func (g *Geometry) Contains(c Coordinates) bool {
+	if g == nil {
+		return false
+	}
+	for _, polygon := range g.Polygons {
+		if !ringContains(polygon[0], c) {
+			continue
+		}
+		inHole := false
+		for _, hole := range polygon[1:] {
+			if ringContains(hole, c) {
+				inHole = true
+				break
+			}
+		}
+		if !inHole {
+			return true
+		}
+	}
+	return false
+}
+
+// ringContains is the even-odd ray casting test
+func ringContains(ring []Position, c Coordinates) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+		if (yi > c.Lat) != (yj > c.Lat) &&
+			c.Lng < (xj-xi)*(c.Lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+type geoJSONGeometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+// MarshalJSON writes the geometry as a GeoJSON geometry object
+func (g Geometry) MarshalJSON() ([]byte, error) {
+	var coordinates interface{}
+	switch g.Type {
+	case "LineString":
+		coordinates = g.Lines[0]
+	case "MultiLineString":
+		coordinates = g.Lines
+	case "Polygon":
+		coordinates = g.Polygons[0]
+	case "MultiPolygon":
+		coordinates = g.Polygons
+	default:
+		return nil, fmt.Errorf("unsupported geometry type: %q", g.Type)
+	}
+	return json.Marshal(map[string]interface{}{"type": g.Type, "coordinates": coordinates})
+}
+
+// UnmarshalJSON reads a GeoJSON geometry object. Points are not geometries
+// in this sense and are rejected, like any other unsupported type.
+func (g *Geometry) UnmarshalJSON(data []byte) error {
+	var raw geoJSONGeometry
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	var parsed *Geometry
+	switch raw.Type {
+	case "LineString":
+		var line []Position
+		if err := json.Unmarshal(raw.Coordinates, &line); err != nil {
+			return fmt.Errorf("invalid LineString: %w", err)
+		}
+		parsed = NewLineGeometry(line)
+	case "MultiLineString":
+		var lines [][]Position
+		if err := json.Unmarshal(raw.Coordinates, &lines); err != nil {
+			return fmt.Errorf("invalid MultiLineString: %w", err)
+		}
+		parsed = NewLineGeometry(lines...)
+	case "Polygon":
+		var rings [][]Position
+		if err := json.Unmarshal(raw.Coordinates, &rings); err != nil {
+			return fmt.Errorf("invalid Polygon: %w", err)
+		}
+		parsed = NewPolygonGeometry(rings)
+	case "MultiPolygon":
+		var polygons [][][]Position
+		if err := json.Unmarshal(raw.Coordinates, &polygons); err != nil {
+			return fmt.Errorf("invalid MultiPolygon: %w", err)
+		}
+		parsed = NewPolygonGeometry(polygons...)
+	default:
+		return fmt.Errorf("unsupported geometry type: %q", raw.Type)
+	}
+
+	if parsed == nil {
+		return fmt.Errorf("%s has too few positions", raw.Type)
+	}
+	*g = *parsed
+	return nil
+}
+
+// InsideMatcher returns a filter matching places that lie inside area's
+// geometry, leaving out area itself. It returns an error when area is not
+// a polygon.
+func InsideMatcher(area *Place) (func(*Place) bool, error) {
+	if !area.Geometry.IsArea() {
+		return nil, fmt.Errorf("%s has no area geometry", area.Name)
+	}
+	min, max := area.Geometry.Bounds()
+	return func(p *Place) bool {
+		c := p.Coordinates
+		if p.ID == area.ID || c.Lat < min.Lat || c.Lat > max.Lat || c.Lng < min.Lng || c.Lng > max.Lng {
+			return false
+		}
+		return area.Geometry.Contains(c)
+	}, nil
+}
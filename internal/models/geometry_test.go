@@ -0,0 +1,138 @@
+package models
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+// square returns an unclosed ring centred on (lng, lat) with sides of
+// 2*half degrees
+func square(lng, lat, half float64) []Position {
+	return []Position{
+		{lng - half, lat - half}, {lng + half, lat - half},
+		{lng + half, lat + half}, {lng - half, lat + half},
+	}
+}
+
+func TestGeometry_JSONRoundTrip(t *testing.T) {
+	tests := []string{
+		`{"type":"LineString","coordinates":[[-9.2,38.69],[-9.18,38.69,12]]}`,
+		`{"type":"MultiLineString","coordinates":[[[0,0],[1,1]],[[2,2],[3,3]]]}`,
+		`{"type":"Polygon","coordinates":[[[0,0],[4,0],[4,4],[0,4],[0,0]],[[1,1],[2,1],[2,2],[1,1]]]}`,
+		`{"type":"MultiPolygon","coordinates":[[[[0,0],[1,0],[1,1],[0,0]]],[[[5,5],[6,5],[6,6],[5,5]]]]}`,
+	}
+
+	for _, input := range tests {
+		var g Geometry
+		if err := json.Unmarshal([]byte(input), &g); err != nil {
+			t.Fatalf("Unmarshal(%s) failed: %v", input, err)
+		}
+
+		data, err := json.Marshal(g)
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		var again Geometry
+		if err := json.Unmarshal(data, &again); err != nil {
+			t.Fatalf("Unmarshal(%s) failed: %v", data, err)
+		}
+		if again.Type != g.Type || len(again.Paths()) != len(g.Paths()) {
+			t.Errorf("Round trip changed %s into %s", input, data)
+		}
+	}
+}
+
+func TestGeometry_UnmarshalInvalid(t *testing.T) {
+	for _, input := range []string{
+		`{"type":"Point","coordinates":[1,2]}`,
+		`{"type":"LineString","coordinates":[[1,2]]}`,
+		`{"type":"Polygon","coordinates":[[[0,0],[1,1]]]}`,
+		`{"type":"Polygon","coordinates":"nope"}`,
+	} {
+		var g Geometry
+		if err := json.Unmarshal([]byte(input), &g); err == nil {
+			t.Errorf("Expected error for %s", input)
+		}
+	}
+}
+
+func TestNewPolygonGeometry_ClosesRings(t *testing.T) {
+	g := NewPolygonGeometry([][]Position{square(0, 0, 1)})
+	if g == nil || g.Type != "Polygon" {
+		t.Fatalf("Expected a Polygon, got %+v", g)
+	}
+	ring := g.Polygons[0][0]
+	if len(ring) != 5 || ring[0] != ring[4] {
+		t.Errorf("Expected closed ring, got %v", ring)
+	}
+}
+
+func TestGeometry_Centroid(t *testing.T) {
+	area := NewPolygonGeometry([][]Position{square(10, 20, 1)})
+	c := area.Centroid()
+	if math.Abs(c.Lng-10) > 1e-9 || math.Abs(c.Lat-20) > 1e-9 {
+		t.Errorf("Expected polygon centroid 20,10, got %+v", c)
+	}
+
+	// The larger part of a multi-polygon decides
+	multi := NewPolygonGeometry([][]Position{square(0, 0, 0.1)}, [][]Position{square(5, 5, 2)})
+	if c := multi.Centroid(); math.Abs(c.Lng-5) > 1e-9 {
+		t.Errorf("Expected centroid of the larger part, got %+v", c)
+	}
+
+	// Lines are weighted by length, so a short spur barely moves the centre
+	route := NewLineGeometry([]Position{{0, 0}, {0.02, 0}, {0.02, 0.001}})
+	if c := route.Centroid(); math.Abs(c.Lng-0.0105) > 0.001 {
+		t.Errorf("Unexpected line centroid %+v", c)
+	}
+	if length := route.Length(); math.Abs(length-2335) > 10 {
+		t.Errorf("Expected about 2335 m, got %.0f", length)
+	}
+}
+
+func TestGeometry_Contains(t *testing.T) {
+	park := NewPolygonGeometry([][]Position{square(0, 0, 2), square(0, 0, 0.5)})
+
+	tests := []struct {
+		name string
+		c    Coordinates
+		want bool
+	}{
+		{"inside", Coordinates{Lat: 1, Lng: 1}, true},
+		{"in hole", Coordinates{Lat: 0, Lng: 0}, false},
+		{"outside", Coordinates{Lat: 3, Lng: 0}, false},
+	}
+	for _, tt := range tests {
+		if got := park.Contains(tt.c); got != tt.want {
+			t.Errorf("%s: Contains(%+v) = %v, want %v", tt.name, tt.c, got, tt.want)
+		}
+	}
+
+	if NewLineGeometry([]Position{{-1, 0}, {1, 0}}).Contains(Coordinates{}) {
+		t.Error("Lines should not contain points")
+	}
+}
+
+func TestInsideMatcher(t *testing.T) {
+	area := &Place{ID: "park", Name: "Park", Geometry: NewPolygonGeometry([][]Position{square(0, 0, 1)})}
+	area.Coordinates = area.Geometry.Centroid()
+
+	match, err := InsideMatcher(area)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !match(&Place{ID: "kiosk", Coordinates: Coordinates{Lat: 0.5, Lng: 0.5}}) {
+		t.Error("Expected kiosk inside the park")
+	}
+	if match(&Place{ID: "cafe", Coordinates: Coordinates{Lat: 2, Lng: 0}}) {
+		t.Error("Expected cafe outside the park")
+	}
+	if match(area) {
+		t.Error("An area should not match itself")
+	}
+
+	if _, err := InsideMatcher(&Place{Name: "Cafe"}); err == nil {
+		t.Error("Expected error for a place without an area")
+	}
+}
@@ -0,0 +1,617 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpeningHours is a structured weekly opening schedule
+type OpeningHours struct {
+	Periods     []OpeningPeriod `json:"periods,omitempty"`
+	SpecialDays []SpecialDay    `json:"special_days,omitempty"`
+	// Timezone is an IANA name ("Europe/Lisbon") or a fixed offset ("UTC+01:00")
+	Timezone   string `json:"timezone,omitempty"`
+	AlwaysOpen bool   `json:"always_open,omitempty"`
+}
+
+// TimeRange is an opening span in "HH:MM" local time. A Close before Open
+// continues past midnight; "24:00" closes at the end of the day.
+type TimeRange struct {
+	Open  string `json:"open"`
+	Close string `json:"close"`
+}
+
+// OpeningPeriod is a time range on a specific weekday
+type OpeningPeriod struct {
+	Day time.Weekday `json:"day"`
+	TimeRange
+}
+
+// SpecialDay overrides the weekly schedule on a single date
+type SpecialDay struct {
+	Date    string      `json:"date"`
+	Closed  bool        `json:"closed,omitempty"`
+	Periods []TimeRange `json:"periods,omitempty"`
+}
+
+// IsOpenAt reports whether the place is open at t, interpreted in the
+// schedule's timezone when one is set
+func (h *OpeningHours) IsOpenAt(t time.Time) bool {
+	if h == nil {
+		return false
+	}
+	if loc := h.location(); loc != nil {
+		t = t.In(loc)
+	}
+	return h.isOpen(t.Format("2006-01-02"), t.Weekday(), t.Hour()*60+t.Minute())
+}
+
+// IsOpenOn reports whether the place is open at a wall-clock time on a
+// weekday, ignoring special days
+func (h *OpeningHours) IsOpenOn(day time.Weekday, minute int) bool {
+	if h == nil {
+		return false
+	}
+	return h.isOpen("", day, minute)
+}
+
+func (h *OpeningHours) isOpen(date string, day time.Weekday, minute int) bool {
+	if h.AlwaysOpen {
+		return true
+	}
+
+	if date != "" {
+		for _, special := range h.SpecialDays {
+			if special.Date != date {
+				continue
+			}
+			if special.Closed {
+				return false
+			}
+			for _, r := range special.Periods {
+				open, close, ok := r.minutes()
+				if ok && minute >= open && (close <= open || minute < close) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	previous := (day + 6) % 7
+	for _, p := range h.Periods {
+		open, close, ok := p.minutes()
+		if !ok {
+			continue
+		}
+		if p.Day == day && minute >= open && (close <= open || minute < close) {
+			return true
+		}
+		// Spill-over from a period that started yesterday and runs past midnight
+		if p.Day == previous && close < open && minute < close {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (h *OpeningHours) location() *time.Location {
+	if h.Timezone == "" {
+		return nil
+	}
+	if loc, err := time.LoadLocation(h.Timezone); err == nil {
+		return loc
+	}
+
+	var sign byte
+	var hours, mins int
+	if _, err := fmt.Sscanf(h.Timezone, "UTC%c%d:%d", &sign, &hours, &mins); err == nil {
+		offset := hours*3600 + mins*60
+		if sign == '-' {
+			offset = -offset
+		}
+		return time.FixedZone(h.Timezone, offset)
+	}
+
+	return nil
+}
+
+// Days returns the weekly periods grouped by weekday, in time order
+func (h *OpeningHours) Days() map[time.Weekday][]TimeRange {
+	days := make(map[time.Weekday][]TimeRange)
+	if h == nil {
+		return days
+	}
+	for _, p := range h.Periods {
+		days[p.Day] = append(days[p.Day], p.TimeRange)
+	}
+	for _, ranges := range days {
+		sort.Slice(ranges, func(i, j int) bool { return ranges[i].Open < ranges[j].Open })
+	}
+	return days
+}
+
+func (r TimeRange) minutes() (open, close int, ok bool) {
+	open, err1 := parseClock(r.Open)
+	close, err2 := parseClock(r.Close)
+	if err1 != nil || err2 != nil {
+		return 0, 0, false
+	}
+	return open, close, true
+}
+
+// parseClock parses "HH:MM" or "HHMM" into minutes after midnight
+func parseClock(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	s = strings.Replace(s, ":", "", 1)
+	if len(s) == 3 {
+		s = "0" + s
+	}
+	if len(s) != 4 {
+		return 0, fmt.Errorf("invalid time: %q", s)
+	}
+
+	hours, err := strconv.Atoi(s[:2])
+	if err != nil {
+		return 0, fmt.Errorf("invalid time: %q", s)
+	}
+	mins, err := strconv.Atoi(s[2:])
+	if err != nil || hours > 24 || mins > 59 || (hours == 24 && mins != 0) {
+		return 0, fmt.Errorf("invalid time: %q", s)
+	}
+
+	return hours*60 + mins, nil
+}
+
+func formatClock(minute int) string {
+	return fmt.Sprintf("%02d:%02d", minute/60, minute%60)
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"su": time.Sunday, "sun": time.Sunday, "sunday": time.Sunday,
+	"mo": time.Monday, "mon": time.Monday, "monday": time.Monday,
+	"tu": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday, "tuesday": time.Tuesday,
+	"we": time.Wednesday, "wed": time.Wednesday, "wednesday": time.Wednesday,
+	"th": time.Thursday, "thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday, "thursday": time.Thursday,
+	"fr": time.Friday, "fri": time.Friday, "friday": time.Friday,
+	"sa": time.Saturday, "sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// ParseWeekday parses English weekday names and common abbreviations
+func ParseWeekday(s string) (time.Weekday, bool) {
+	day, ok := weekdayNames[strings.ToLower(strings.TrimSpace(s))]
+	return day, ok
+}
+
+// ParseOpenAt parses a wall-clock specification such as "sat 20:00" or
+// "20:00" (meaning today, relative to now) into a weekday and minute
+func ParseOpenAt(spec string, now time.Time) (time.Weekday, int, error) {
+	fields := strings.Fields(spec)
+	day := now.Weekday()
+
+	switch len(fields) {
+	case 1:
+	case 2:
+		d, ok := ParseWeekday(fields[0])
+		if !ok {
+			return 0, 0, fmt.Errorf("unknown weekday: %q", fields[0])
+		}
+		day = d
+		fields = fields[1:]
+	default:
+		return 0, 0, fmt.Errorf("expected \"[weekday] HH:MM\", got %q", spec)
+	}
+
+	minute, err := parseClock(fields[0])
+	if err != nil || minute >= 24*60 {
+		return 0, 0, fmt.Errorf("invalid time: %q", fields[0])
+	}
+
+	return day, minute, nil
+}
+
+// ParseOSMOpeningHours parses the common subset of the OpenStreetMap
+// opening_hours syntax, e.g. "Mo-Fr 08:00-18:00; Sa 10:00-14:00; Su off".
+// Rules with selectors other than weekdays (months, holidays) are skipped.
+func ParseOSMOpeningHours(value string) (*OpeningHours, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, fmt.Errorf("empty opening_hours")
+	}
+	if value == "24/7" {
+		return &OpeningHours{AlwaysOpen: true}, nil
+	}
+
+	schedule := make(map[time.Weekday][]TimeRange)
+	parsed := false
+
+	for _, rule := range strings.Split(value, ";") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		days, rest, ok := parseOSMDays(rule)
+		if !ok {
+			continue
+		}
+
+		var ranges []TimeRange
+		rest = strings.TrimSpace(rest)
+		switch strings.ToLower(rest) {
+		case "off", "closed":
+		case "24/7", "00:00-24:00":
+			ranges = []TimeRange{{Open: "00:00", Close: "24:00"}}
+		default:
+			for _, span := range strings.Split(rest, ",") {
+				r, err := parseSpan(span)
+				if err != nil {
+					return nil, fmt.Errorf("invalid opening_hours rule %q: %w", rule, err)
+				}
+				ranges = append(ranges, r)
+			}
+		}
+
+		// Later rules replace earlier ones for the days they mention
+		for _, day := range days {
+			schedule[day] = ranges
+		}
+		parsed = true
+	}
+
+	if !parsed {
+		return nil, fmt.Errorf("unsupported opening_hours: %q", value)
+	}
+
+	return fromSchedule(schedule), nil
+}
+
+// parseOSMDays splits a rule into the weekdays it applies to and the time
+// part. A rule without a weekday selector applies to every day.
+func parseOSMDays(rule string) ([]time.Weekday, string, bool) {
+	fields := strings.SplitN(rule, " ", 2)
+	selector := fields[0]
+
+	if selector == "" || (selector[0] >= '0' && selector[0] <= '9') {
+		return allWeekdays(), rule, true
+	}
+
+	var days []time.Weekday
+	for _, item := range strings.Split(selector, ",") {
+		if from, to, found := strings.Cut(item, "-"); found {
+			start, ok1 := ParseWeekday(from)
+			end, ok2 := ParseWeekday(to)
+			if !ok1 || !ok2 {
+				return nil, "", false
+			}
+			for d := start; ; d = (d + 1) % 7 {
+				days = append(days, d)
+				if d == end {
+					break
+				}
+			}
+			continue
+		}
+		day, ok := ParseWeekday(item)
+		if !ok {
+			return nil, "", false
+		}
+		days = append(days, day)
+	}
+
+	if len(fields) < 2 {
+		return days, "00:00-24:00", true
+	}
+	return days, fields[1], true
+}
+
+func parseSpan(span string) (TimeRange, error) {
+	span = strings.TrimSpace(span)
+	if strings.HasSuffix(span, "+") {
+		open, err := parseClock(strings.TrimSuffix(span, "+"))
+		if err != nil {
+			return TimeRange{}, err
+		}
+		return TimeRange{Open: formatClock(open), Close: "24:00"}, nil
+	}
+
+	from, to, found := strings.Cut(span, "-")
+	if !found {
+		return TimeRange{}, fmt.Errorf("invalid time span: %q", span)
+	}
+	open, err := parseClock(from)
+	if err != nil {
+		return TimeRange{}, err
+	}
+	close, err := parseClock(to)
+	if err != nil {
+		// OSM allows extended times such as 26:00 for 02:00 the next day
+		if h, m, ok := parseExtendedClock(to); ok {
+			close = (h-24)*60 + m
+		} else {
+			return TimeRange{}, err
+		}
+	}
+
+	return TimeRange{Open: formatClock(open), Close: formatClock(close)}, nil
+}
+
+func parseExtendedClock(s string) (int, int, bool) {
+	var h, m int
+	if _, err := fmt.Sscanf(strings.TrimSpace(s), "%d:%d", &h, &m); err != nil {
+		return 0, 0, false
+	}
+	if h < 24 || h >= 48 || m > 59 {
+		return 0, 0, false
+	}
+	return h, m, true
+}
+
+var weekdayTextPattern = regexp.MustCompile(`(?i)\b(monday|tuesday|wednesday|thursday|friday|saturday|sunday)\s*:`)
+
+// ParseHoursText parses Google-style weekday text such as
+// "Monday: 9:00 AM – 5:00 PM, Tuesday: Closed". It also accepts the JSON
+// object Takeout uses for hours ({"monday": "9:00 AM – 5:00 PM", ...}).
+func ParseHoursText(text string) (*OpeningHours, error) {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "{") {
+		var byDay map[string]interface{}
+		if err := json.Unmarshal([]byte(text), &byDay); err != nil {
+			return nil, fmt.Errorf("invalid hours object: %w", err)
+		}
+		return ParseHoursMap(byDay)
+	}
+
+	matches := weekdayTextPattern.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no weekdays found in hours text")
+	}
+
+	var lines []string
+	for i, m := range matches {
+		end := len(text)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		lines = append(lines, text[m[0]:end])
+	}
+
+	return ParseWeekdayText(lines)
+}
+
+// ParseHoursMap parses hours keyed by weekday name, with either a single
+// string or a list of strings per day
+func ParseHoursMap(byDay map[string]interface{}) (*OpeningHours, error) {
+	var lines []string
+	for key, value := range byDay {
+		if _, ok := ParseWeekday(key); !ok {
+			continue
+		}
+		switch v := value.(type) {
+		case string:
+			lines = append(lines, key+": "+v)
+		case []interface{}:
+			var parts []string
+			for _, item := range v {
+				parts = append(parts, fmt.Sprintf("%v", item))
+			}
+			lines = append(lines, key+": "+strings.Join(parts, ", "))
+		}
+	}
+
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("no weekdays found in hours")
+	}
+	return ParseWeekdayText(lines)
+}
+
+// ParseWeekdayText parses lines of the form "Monday: 11:00 AM – 2:30 PM, 5:00 – 10:00 PM"
+func ParseWeekdayText(lines []string) (*OpeningHours, error) {
+	schedule := make(map[time.Weekday][]TimeRange)
+
+	for _, line := range lines {
+		line = strings.Trim(strings.TrimSpace(line), "[],")
+		name, rest, found := strings.Cut(line, ":")
+		if !found {
+			return nil, fmt.Errorf("invalid hours line: %q", line)
+		}
+		day, ok := ParseWeekday(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown weekday in %q", line)
+		}
+
+		rest = normalizeHoursText(rest)
+		lower := strings.ToLower(rest)
+		switch {
+		case lower == "" || lower == "closed":
+			schedule[day] = nil
+			continue
+		case strings.Contains(lower, "24 hours"):
+			schedule[day] = []TimeRange{{Open: "00:00", Close: "24:00"}}
+			continue
+		}
+
+		var ranges []TimeRange
+		for _, span := range strings.Split(rest, ",") {
+			span = strings.TrimSpace(span)
+			if span == "" {
+				continue
+			}
+			r, err := parseTextSpan(span)
+			if err != nil {
+				return nil, fmt.Errorf("invalid hours for %s: %w", name, err)
+			}
+			ranges = append(ranges, r)
+		}
+		schedule[day] = ranges
+	}
+
+	return fromSchedule(schedule), nil
+}
+
+func normalizeHoursText(s string) string {
+	replacer := strings.NewReplacer(
+		"\u202f", " ", "\u00a0", " ", "\u2009", " ",
+		"–", "-", "—", "-", " to ", "-",
+	)
+	return strings.TrimSpace(replacer.Replace(s))
+}
+
+func parseTextSpan(span string) (TimeRange, error) {
+	from, to, found := strings.Cut(span, "-")
+	if !found {
+		return TimeRange{}, fmt.Errorf("invalid time span: %q", span)
+	}
+
+	closeMin, closeMeridiem, err := parseTextClock(to)
+	if err != nil {
+		return TimeRange{}, err
+	}
+	openMin, openMeridiem, err := parseTextClock(from)
+	if err != nil {
+		return TimeRange{}, err
+	}
+
+	// "5:00 – 10:00 PM" shares the closing meridiem unless that would
+	// place the opening after the closing ("11:00 – 2:00 PM")
+	if openMeridiem == "" && closeMeridiem != "" {
+		withMeridiem := applyMeridiem(openMin, closeMeridiem)
+		if withMeridiem <= closeMin {
+			openMin = withMeridiem
+		} else {
+			openMin = applyMeridiem(openMin, "am")
+		}
+	}
+
+	if closeMin == 0 && openMin > 0 {
+		closeMin = 24 * 60
+	}
+
+	return TimeRange{Open: formatClock(openMin), Close: formatClock(closeMin)}, nil
+}
+
+// parseTextClock parses "9", "9:30", "17:00", "9:30 AM" or "9PM"
+func parseTextClock(s string) (int, string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	meridiem := ""
+	for _, suffix := range []string{"am", "pm", "a.m.", "p.m."} {
+		if strings.HasSuffix(s, suffix) {
+			meridiem = suffix[:1] + "m"
+			s = strings.TrimSpace(strings.TrimSuffix(s, suffix))
+			break
+		}
+	}
+
+	hourStr, minStr, hasMinutes := strings.Cut(s, ":")
+	hours, err := strconv.Atoi(hourStr)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid time: %q", s)
+	}
+	mins := 0
+	if hasMinutes {
+		mins, err = strconv.Atoi(minStr)
+		if err != nil || mins > 59 {
+			return 0, "", fmt.Errorf("invalid time: %q", s)
+		}
+	}
+	if hours > 24 || (meridiem != "" && hours > 12) {
+		return 0, "", fmt.Errorf("invalid time: %q", s)
+	}
+
+	minute := hours*60 + mins
+	if meridiem != "" {
+		minute = applyMeridiem(minute, meridiem)
+	}
+	return minute, meridiem, nil
+}
+
+func applyMeridiem(minute int, meridiem string) int {
+	hours := (minute / 60) % 12
+	if meridiem == "pm" {
+		hours += 12
+	}
+	return hours*60 + minute%60
+}
+
+func allWeekdays() []time.Weekday {
+	return []time.Weekday{
+		time.Monday, time.Tuesday, time.Wednesday, time.Thursday,
+		time.Friday, time.Saturday, time.Sunday,
+	}
+}
+
+func fromSchedule(schedule map[time.Weekday][]TimeRange) *OpeningHours {
+	hours := &OpeningHours{}
+	for _, day := range allWeekdays() {
+		for _, r := range schedule[day] {
+			hours.Periods = append(hours.Periods, OpeningPeriod{Day: day, TimeRange: r})
+		}
+	}
+	return hours
+}
+
+// OpenMatcher builds a place predicate for "open now" and "open at"
+// filters. It returns nil when neither filter is requested.
+func OpenMatcher(openNow bool, openAt string, now time.Time) (func(*Place) bool, error) {
+	if openAt != "" {
+		day, minute, err := ParseOpenAt(openAt, now)
+		if err != nil {
+			return nil, err
+		}
+		return func(p *Place) bool {
+			return p.GetOpeningHours().IsOpenOn(day, minute)
+		}, nil
+	}
+
+	if openNow {
+		return func(p *Place) bool {
+			return p.IsOpenAt(now)
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// MatchAll combines predicates, skipping nil ones. It returns nil when there
+// is nothing to match.
+func MatchAll(matchers ...func(*Place) bool) func(*Place) bool {
+	var active []func(*Place) bool
+	for _, match := range matchers {
+		if match != nil {
+			active = append(active, match)
+		}
+	}
+	if len(active) == 0 {
+		return nil
+	}
+
+	return func(p *Place) bool {
+		for _, match := range active {
+			if !match(p) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// FilterPlaces returns the places matching the predicate
+func FilterPlaces(places []*Place, match func(*Place) bool) []*Place {
+	if match == nil {
+		return places
+	}
+
+	filtered := make([]*Place, 0, len(places))
+	for _, place := range places {
+		if match(place) {
+			filtered = append(filtered, place)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,171 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 2024-06-03 is a Monday
+func at(day int, hour, minute int) time.Time {
+	return time.Date(2024, 6, 3+day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestParseOSMOpeningHours(t *testing.T) {
+	hours, err := ParseOSMOpeningHours("Mo-Fr 08:00-12:00,13:00-18:00; Sa 10:00-14:00; Su off")
+	require.NoError(t, err)
+
+	days := hours.Days()
+	assert.Equal(t, []TimeRange{{"08:00", "12:00"}, {"13:00", "18:00"}}, days[time.Monday])
+	assert.Equal(t, []TimeRange{{"08:00", "12:00"}, {"13:00", "18:00"}}, days[time.Friday])
+	assert.Equal(t, []TimeRange{{"10:00", "14:00"}}, days[time.Saturday])
+	assert.Empty(t, days[time.Sunday])
+
+	assert.True(t, hours.IsOpenAt(at(0, 9, 0)))
+	assert.False(t, hours.IsOpenAt(at(0, 12, 30)))
+	assert.False(t, hours.IsOpenAt(at(6, 11, 0)))
+}
+
+func TestParseOSMOpeningHours_Special(t *testing.T) {
+	hours, err := ParseOSMOpeningHours("24/7")
+	require.NoError(t, err)
+	assert.True(t, hours.AlwaysOpen)
+
+	hours, err = ParseOSMOpeningHours("Fr,Sa 18:00-02:00")
+	require.NoError(t, err)
+	assert.True(t, hours.IsOpenAt(at(4, 23, 0)))
+	assert.True(t, hours.IsOpenAt(at(5, 1, 30)))
+	assert.True(t, hours.IsOpenAt(at(6, 1, 30)), "Saturday night spills into Sunday")
+	assert.False(t, hours.IsOpenAt(at(6, 3, 0)))
+
+	_, err = ParseOSMOpeningHours("")
+	assert.Error(t, err)
+	_, err = ParseOSMOpeningHours("sunrise-sunset")
+	assert.Error(t, err)
+}
+
+func TestParseHoursText(t *testing.T) {
+	hours, err := ParseHoursText("Monday: 9:00 AM – 5:00 PM, Tuesday: 11 AM – 2:30 PM, 5:00 – 10:00 PM, Wednesday: Closed, Sunday: Open 24 hours")
+	require.NoError(t, err)
+
+	days := hours.Days()
+	assert.Equal(t, []TimeRange{{"09:00", "17:00"}}, days[time.Monday])
+	assert.Equal(t, []TimeRange{{"11:00", "14:30"}, {"17:00", "22:00"}}, days[time.Tuesday])
+	assert.Empty(t, days[time.Wednesday])
+	assert.Equal(t, []TimeRange{{"00:00", "24:00"}}, days[time.Sunday])
+
+	assert.True(t, hours.IsOpenAt(at(1, 21, 0)))
+	assert.False(t, hours.IsOpenAt(at(1, 15, 0)))
+	assert.True(t, hours.IsOpenAt(at(6, 23, 59)))
+
+	_, err = ParseHoursText("call ahead")
+	assert.Error(t, err)
+}
+
+func TestParseHoursMap(t *testing.T) {
+	hours, err := ParseHoursMap(map[string]interface{}{
+		"monday":   "8:00 AM – 4:00 PM",
+		"saturday": []interface{}{"10:00 AM – 1:00 PM", "2:00 – 6:00 PM"},
+		"notes":    "ignored",
+	})
+	require.NoError(t, err)
+
+	days := hours.Days()
+	assert.Equal(t, []TimeRange{{"08:00", "16:00"}}, days[time.Monday])
+	assert.Equal(t, []TimeRange{{"10:00", "13:00"}, {"14:00", "18:00"}}, days[time.Saturday])
+
+	fromJSON, err := ParseHoursText(`{"monday": "8:00 AM – 4:00 PM"}`)
+	require.NoError(t, err)
+	assert.Equal(t, []TimeRange{{"08:00", "16:00"}}, fromJSON.Days()[time.Monday])
+}
+
+func TestOpeningHours_Timezone(t *testing.T) {
+	hours := &OpeningHours{
+		Periods:  []OpeningPeriod{{Day: time.Monday, TimeRange: TimeRange{"09:00", "17:00"}}},
+		Timezone: "UTC+02:00",
+	}
+
+	// 07:30 UTC is 09:30 at UTC+2
+	assert.True(t, hours.IsOpenAt(at(0, 7, 30)))
+	assert.False(t, hours.IsOpenAt(at(0, 15, 30)))
+
+	hours.Timezone = "America/New_York"
+	assert.True(t, hours.IsOpenAt(at(0, 14, 0)))
+	assert.False(t, hours.IsOpenAt(at(0, 10, 0)))
+}
+
+func TestOpeningHours_SpecialDays(t *testing.T) {
+	hours := &OpeningHours{
+		Periods: []OpeningPeriod{{Day: time.Monday, TimeRange: TimeRange{"09:00", "17:00"}}},
+		SpecialDays: []SpecialDay{
+			{Date: "2024-06-03", Closed: true},
+			{Date: "2024-06-09", Periods: []TimeRange{{"12:00", "14:00"}}},
+		},
+	}
+
+	assert.False(t, hours.IsOpenAt(at(0, 10, 0)))
+	assert.True(t, hours.IsOpenAt(at(7, 10, 0)), "the following Monday uses the weekly schedule")
+	assert.True(t, hours.IsOpenAt(at(6, 13, 0)))
+
+	// The weekday form ignores special days
+	assert.True(t, hours.IsOpenOn(time.Monday, 10*60))
+
+	var unknown *OpeningHours
+	assert.False(t, unknown.IsOpenAt(at(0, 10, 0)))
+}
+
+func TestParseOpenAt(t *testing.T) {
+	now := at(2, 12, 0) // Wednesday
+
+	day, minute, err := ParseOpenAt("sat 20:00", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Saturday, day)
+	assert.Equal(t, 20*60, minute)
+
+	day, minute, err = ParseOpenAt("07:15", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Wednesday, day)
+	assert.Equal(t, 7*60+15, minute)
+
+	for _, spec := range []string{"someday 10:00", "sat", "sat 25:00", "on sat at 10:00"} {
+		_, _, err := ParseOpenAt(spec, now)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestOpenMatcher(t *testing.T) {
+	open := &Place{Name: "Bar", Hours: "Fr 18:00-02:00", CustomFields: map[string]interface{}{}}
+	unknown := &Place{Name: "Mystery", CustomFields: map[string]interface{}{}}
+	places := []*Place{open, unknown}
+
+	match, err := OpenMatcher(false, "", at(4, 20, 0))
+	require.NoError(t, err)
+	assert.Nil(t, match)
+	assert.Len(t, FilterPlaces(places, match), 2)
+
+	match, err = OpenMatcher(true, "", at(4, 20, 0))
+	require.NoError(t, err)
+	assert.Equal(t, []*Place{open}, FilterPlaces(places, match))
+
+	match, err = OpenMatcher(false, "sat 01:00", at(0, 0, 0))
+	require.NoError(t, err)
+	assert.Equal(t, []*Place{open}, FilterPlaces(places, match))
+
+	_, err = OpenMatcher(false, "whenever", at(0, 0, 0))
+	assert.Error(t, err)
+}
+
+func TestPlace_SetHours(t *testing.T) {
+	place := &Place{OpeningHours: &OpeningHours{AlwaysOpen: true}}
+
+	place.SetHours("Mo-Fr 09:00-17:00")
+	require.NotNil(t, place.OpeningHours)
+	assert.False(t, place.OpeningHours.AlwaysOpen)
+	assert.True(t, place.IsOpenAt(at(0, 10, 0)))
+
+	place.SetHours("by appointment")
+	assert.Nil(t, place.OpeningHours)
+	assert.Equal(t, "by appointment", place.Hours)
+}
@@ -2,6 +2,8 @@ package models
 
 import (
 	"encoding/json"
+	"math"
+	"strings"
 	"time"
 )
 
@@ -11,12 +13,20 @@ type Place struct {
 	Name        string      `json:"name" db:"name"`
 	Address     string      `json:"address" db:"address"`
 	Coordinates Coordinates `json:"coordinates"`
+	// Geometry outlines areas and routes; Coordinates is then its centroid
+	Geometry *Geometry `json:"geometry,omitempty"`
 
 	Categories []string `json:"categories"`
 
 	Photos  []Photo  `json:"photos"`
 	Reviews []Review `json:"reviews"`
 
+	Attachments []Attachment `json:"attachments,omitempty"`
+
+	// Visits carries check-ins found by an importer until they are saved to
+	// the visit log; places loaded from the database leave it empty
+	Visits []Visit `json:"visits,omitempty"`
+
 	Rating      float32 `json:"rating"`
 	UserRatings int     `json:"user_ratings"`
 	PriceLevel  int     `json:"price_level"`
@@ -24,14 +34,26 @@ type Place struct {
 	Phone       string  `json:"phone"`
 	Website     string  `json:"website"`
 
+	OpeningHours *OpeningHours `json:"opening_hours,omitempty"`
+
+	// User fields belong to the user the place was loaded for
 	UserNotes    string                 `json:"user_notes"`
 	UserTags     []string               `json:"user_tags"`
 	CustomFields map[string]interface{} `json:"custom_fields"`
 
+	// SharedTags are team tags every user sees
+	SharedTags []string `json:"shared_tags,omitempty"`
+
 	CreatedAt  time.Time  `json:"created_at"`
 	UpdatedAt  time.Time  `json:"updated_at"`
 	ImportedAt *time.Time `json:"imported_at,omitempty"`
 	SourceHash string     `json:"source_hash,omitempty"`
+
+	// Snapshot holds the shared fields as the database last loaded or saved
+	// them. Saving writes only the shared fields changed since, so users
+	// editing a place at the same time keep each other's changes. Places
+	// without one are saved whole.
+	Snapshot []byte `json:"-"`
 }
 
 type Coordinates struct {
@@ -39,11 +61,42 @@ type Coordinates struct {
 	Lng float64 `json:"lng"`
 }
 
+// DistanceTo returns the haversine distance to other in metres
+func (c Coordinates) DistanceTo(other Coordinates) float64 {
+	const earthRadius = 6371000
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(other.Lat - c.Lat)
+	dLng := toRad(other.Lng - c.Lng)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(c.Lat))*math.Cos(toRad(other.Lat))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return earthRadius * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
 type Photo struct {
 	Reference string `json:"reference"`
 	LocalPath string `json:"local_path"`
 	Width     int    `json:"width"`
 	Height    int    `json:"height"`
+	// Hash is the photo's key in the content-addressed photo store
+	Hash string `json:"hash,omitempty"`
+}
+
+// Attachment is a personal file, such as a photo, menu, ticket or receipt,
+// kept in the photo store
+type Attachment struct {
+	Hash        string       `json:"hash"`
+	Name        string       `json:"name"`
+	ContentType string       `json:"content_type"`
+	Size        int64        `json:"size"`
+	TakenAt     *time.Time   `json:"taken_at,omitempty"`
+	Coordinates *Coordinates `json:"coordinates,omitempty"`
+	AddedAt     time.Time    `json:"added_at"`
+}
+
+// IsImage reports whether the attachment can be shown as a picture
+func (a Attachment) IsImage() bool {
+	return strings.HasPrefix(a.ContentType, "image/")
 }
 
 type Review struct {
@@ -77,6 +130,59 @@ func (p *Place) AddTag(tag string) {
 	}
 }
 
+// HasSharedTag reports whether tag is one of the place's team tags
+func (p *Place) HasSharedTag(tag string) bool {
+	for _, t := range p.SharedTags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Place) AddSharedTag(tag string) {
+	if !p.HasSharedTag(tag) {
+		p.SharedTags = append(p.SharedTags, tag)
+	}
+}
+
+func (p *Place) RemoveSharedTag(tag string) {
+	for i, t := range p.SharedTags {
+		if t == tag {
+			p.SharedTags = append(p.SharedTags[:i], p.SharedTags[i+1:]...)
+			break
+		}
+	}
+}
+
+// NearestPlace returns the place closest to coords within radius metres, or
+// nil when none is that close. Places without coordinates are skipped.
+func NearestPlace(places []*Place, coords Coordinates, radius float64) (*Place, float64) {
+	var nearest *Place
+	best := radius
+	for _, place := range places {
+		if place.Coordinates.Lat == 0 && place.Coordinates.Lng == 0 {
+			continue
+		}
+		if d := coords.DistanceTo(place.Coordinates); d <= best {
+			nearest, best = place, d
+		}
+	}
+	return nearest, best
+}
+
+// AddAttachment adds a to the place unless a file with the same content is
+// already attached, and reports whether it was added
+func (p *Place) AddAttachment(a Attachment) bool {
+	for _, existing := range p.Attachments {
+		if existing.Hash == a.Hash {
+			return false
+		}
+	}
+	p.Attachments = append(p.Attachments, a)
+	return true
+}
+
 func (p *Place) RemoveTag(tag string) {
 	for i, t := range p.UserTags {
 		if t == tag {
@@ -85,3 +191,43 @@ func (p *Place) RemoveTag(tag string) {
 		}
 	}
 }
+
+// GetOpeningHours returns the structured opening hours, falling back to
+// parsing the free-form Hours text or an imported OSM opening_hours tag
+func (p *Place) GetOpeningHours() *OpeningHours {
+	if p.OpeningHours != nil {
+		return p.OpeningHours
+	}
+	if osm, ok := p.CustomFields["osm_opening_hours"].(string); ok {
+		if hours, err := ParseOSMOpeningHours(osm); err == nil {
+			return hours
+		}
+	}
+	if p.Hours != "" {
+		if hours, err := ParseHoursText(p.Hours); err == nil {
+			return hours
+		}
+		if hours, err := ParseOSMOpeningHours(p.Hours); err == nil {
+			return hours
+		}
+	}
+	return nil
+}
+
+// SetHours replaces the free-text hours and re-derives the structured
+// schedule from them
+func (p *Place) SetHours(text string) {
+	p.Hours = text
+	p.OpeningHours = nil
+	if hours, err := ParseHoursText(text); err == nil {
+		p.OpeningHours = hours
+	} else if hours, err := ParseOSMOpeningHours(text); err == nil {
+		p.OpeningHours = hours
+	}
+}
+
+// IsOpenAt reports whether the place is open at t. Places without known
+// opening hours are reported as closed.
+func (p *Place) IsOpenAt(t time.Time) bool {
+	return p.GetOpeningHours().IsOpenAt(t)
+}
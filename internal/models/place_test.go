@@ -3,6 +3,9 @@ package models
 import (
 	"testing"
 	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPlace_ToJSON(t *testing.T) {
@@ -89,3 +92,49 @@ func TestPlace_RemoveTag(t *testing.T) {
 		t.Error("Expected 'favorite' tag to remain")
 	}
 }
+
+func TestPlace_AddAttachment(t *testing.T) {
+	place := &Place{}
+
+	if !place.AddAttachment(Attachment{Hash: "abc", Name: "menu.pdf", ContentType: "application/pdf"}) {
+		t.Error("Expected first attachment to be added")
+	}
+	if place.AddAttachment(Attachment{Hash: "abc", Name: "menu copy.pdf"}) {
+		t.Error("Expected duplicate content not to be added")
+	}
+	if len(place.Attachments) != 1 {
+		t.Errorf("Expected 1 attachment, got %d", len(place.Attachments))
+	}
+	if place.Attachments[0].IsImage() {
+		t.Error("Expected PDF not to be an image")
+	}
+}
+
+func TestCoordinates_DistanceTo(t *testing.T) {
+	london := Coordinates{Lat: 51.5074, Lng: -0.1278}
+	paris := Coordinates{Lat: 48.8566, Lng: 2.3522}
+
+	distance := london.DistanceTo(paris)
+	if distance < 340000 || distance > 345000 {
+		t.Errorf("Expected London-Paris distance around 343km, got %.0fm", distance)
+	}
+	if london.DistanceTo(london) != 0 {
+		t.Error("Expected zero distance to self")
+	}
+}
+
+func TestNearestPlace(t *testing.T) {
+	places := []*Place{
+		{ID: "far", Coordinates: Coordinates{Lat: 38.7200, Lng: -9.1400}},
+		{ID: "near", Coordinates: Coordinates{Lat: 38.7140, Lng: -9.1335}},
+		{ID: "unknown"},
+	}
+
+	place, distance := NearestPlace(places, Coordinates{Lat: 38.7139, Lng: -9.1334}, 100)
+	require.NotNil(t, place)
+	assert.Equal(t, "near", place.ID)
+	assert.Less(t, distance, 20.0)
+
+	place, _ = NearestPlace(places, Coordinates{Lat: 40, Lng: -8}, 100)
+	assert.Nil(t, place)
+}
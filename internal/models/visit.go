@@ -0,0 +1,72 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Visit is one logged trip to a place
+type Visit struct {
+	ID         int64     `json:"id,omitempty"`
+	PlaceID    string    `json:"place_id"`
+	VisitedAt  time.Time `json:"visited_at"`
+	Companions []string  `json:"companions,omitempty"`
+	Rating     int       `json:"rating,omitempty"` // 1-5, 0 when not rated
+	Note       string    `json:"note,omitempty"`
+	// Source and SourceID identify imported visits so re-imports don't
+	// duplicate them
+	Source   string `json:"source,omitempty"`
+	SourceID string `json:"source_id,omitempty"`
+}
+
+// VisitSummary holds the fields derived from a place's visits
+type VisitSummary struct {
+	Count int       `json:"count"`
+	First time.Time `json:"first"`
+	Last  time.Time `json:"last"`
+}
+
+// VisitPeriod is a half-open time range [From, To)
+type VisitPeriod struct {
+	From time.Time
+	To   time.Time
+}
+
+// Contains reports whether t falls within the period
+func (p VisitPeriod) Contains(t time.Time) bool {
+	return !t.Before(p.From) && t.Before(p.To)
+}
+
+// ParseVisitPeriod parses a year ("2024"), a month ("2024-05"), a day
+// ("2024-05-17") or a range of those ("2024-03..2024-06", both ends
+// inclusive) in the given location
+func ParseVisitPeriod(value string, loc *time.Location) (VisitPeriod, error) {
+	if from, to, ok := strings.Cut(value, ".."); ok {
+		start, err := ParseVisitPeriod(from, loc)
+		if err != nil {
+			return VisitPeriod{}, err
+		}
+		end, err := ParseVisitPeriod(to, loc)
+		if err != nil {
+			return VisitPeriod{}, err
+		}
+		return VisitPeriod{From: start.From, To: end.To}, nil
+	}
+
+	value = strings.TrimSpace(value)
+	for _, layout := range []struct {
+		format string
+		next   func(time.Time) time.Time
+	}{
+		{"2006-01-02", func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }},
+		{"2006-01", func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }},
+		{"2006", func(t time.Time) time.Time { return t.AddDate(1, 0, 0) }},
+	} {
+		if t, err := time.ParseInLocation(layout.format, value, loc); err == nil {
+			return VisitPeriod{From: t, To: layout.next(t)}, nil
+		}
+	}
+
+	return VisitPeriod{}, fmt.Errorf("invalid period %q: use YYYY, YYYY-MM, YYYY-MM-DD or FROM..TO", value)
+}
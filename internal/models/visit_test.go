@@ -0,0 +1,63 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseVisitPeriod(t *testing.T) {
+	utc := time.UTC
+	tests := []struct {
+		value    string
+		from, to time.Time
+	}{
+		{"2024", time.Date(2024, 1, 1, 0, 0, 0, 0, utc), time.Date(2025, 1, 1, 0, 0, 0, 0, utc)},
+		{"2024-05", time.Date(2024, 5, 1, 0, 0, 0, 0, utc), time.Date(2024, 6, 1, 0, 0, 0, 0, utc)},
+		{"2024-05-17", time.Date(2024, 5, 17, 0, 0, 0, 0, utc), time.Date(2024, 5, 18, 0, 0, 0, 0, utc)},
+		{"2024-03..2024-06", time.Date(2024, 3, 1, 0, 0, 0, 0, utc), time.Date(2024, 7, 1, 0, 0, 0, 0, utc)},
+	}
+
+	for _, tt := range tests {
+		period, err := ParseVisitPeriod(tt.value, utc)
+		if err != nil {
+			t.Errorf("ParseVisitPeriod(%q) returned error: %v", tt.value, err)
+			continue
+		}
+		if !period.From.Equal(tt.from) || !period.To.Equal(tt.to) {
+			t.Errorf("ParseVisitPeriod(%q) = %v..%v, want %v..%v", tt.value, period.From, period.To, tt.from, tt.to)
+		}
+	}
+
+	for _, value := range []string{"", "last year", "2024-13", "2024..soon"} {
+		if _, err := ParseVisitPeriod(value, utc); err == nil {
+			t.Errorf("ParseVisitPeriod(%q) expected error", value)
+		}
+	}
+}
+
+func TestVisitPeriod_Contains(t *testing.T) {
+	period, _ := ParseVisitPeriod("2024", time.UTC)
+	if !period.Contains(time.Date(2024, 12, 31, 23, 59, 0, 0, time.UTC)) {
+		t.Error("Expected last minute of 2024 to be in period")
+	}
+	if period.Contains(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Error("Expected period end to be exclusive")
+	}
+}
+
+func TestMatchAll(t *testing.T) {
+	if MatchAll(nil, nil) != nil {
+		t.Error("Expected nil matcher when nothing to match")
+	}
+
+	named := func(p *Place) bool { return p.Name != "" }
+	tagged := func(p *Place) bool { return len(p.UserTags) > 0 }
+	match := MatchAll(named, nil, tagged)
+
+	if !match(&Place{Name: "Cafe", UserTags: []string{"coffee"}}) {
+		t.Error("Expected place matching all predicates to match")
+	}
+	if match(&Place{Name: "Cafe"}) {
+		t.Error("Expected place failing one predicate not to match")
+	}
+}
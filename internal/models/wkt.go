@@ -0,0 +1,194 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseWKT parses a Well-Known Text geometry such as "POINT (-9.14 38.71)"
+// or "POLYGON ((...))", as found in spreadsheets exported from GIS tools.
+// It returns where to put the place and, for lines and polygons, their
+// geometry. An EWKT "SRID=4326;" prefix is accepted; Z and M values are
+// ignored.
+func ParseWKT(text string) (Coordinates, *Geometry, error) {
+	text = strings.TrimSpace(text)
+	if prefix, rest, ok := strings.Cut(text, ";"); ok && strings.HasPrefix(strings.ToUpper(prefix), "SRID=") {
+		text = rest
+	}
+
+	p := &wktParser{s: text}
+	kind := strings.ToUpper(p.word())
+	if dims := strings.ToUpper(p.word()); dims != "" && dims != "Z" && dims != "M" && dims != "ZM" {
+		if dims == "EMPTY" {
+			return Coordinates{}, nil, fmt.Errorf("empty %s", kind)
+		}
+		return Coordinates{}, nil, fmt.Errorf("unexpected %q after %s", dims, kind)
+	}
+
+	var geometry *Geometry
+	var point []Position
+	var err error
+	switch kind {
+	case "POINT", "MULTIPOINT":
+		point, err = p.positions()
+	case "LINESTRING":
+		var line []Position
+		if line, err = p.positions(); err == nil {
+			geometry = NewLineGeometry(line)
+		}
+	case "MULTILINESTRING":
+		var lines [][]Position
+		if lines, err = p.lists(); err == nil {
+			geometry = NewLineGeometry(lines...)
+		}
+	case "POLYGON":
+		var rings [][]Position
+		if rings, err = p.lists(); err == nil {
+			geometry = NewPolygonGeometry(rings)
+		}
+	case "MULTIPOLYGON":
+		var polygons [][][]Position
+		if polygons, err = p.polygons(); err == nil {
+			geometry = NewPolygonGeometry(polygons...)
+		}
+	case "":
+		return Coordinates{}, nil, fmt.Errorf("empty WKT geometry")
+	default:
+		return Coordinates{}, nil, fmt.Errorf("unsupported WKT geometry: %s", kind)
+	}
+	if err != nil {
+		return Coordinates{}, nil, fmt.Errorf("invalid %s: %w", kind, err)
+	}
+	if p.skipSpace(); p.pos < len(p.s) {
+		return Coordinates{}, nil, fmt.Errorf("invalid %s: unexpected %q", kind, p.s[p.pos:])
+	}
+
+	if len(point) > 0 {
+		c := point[0].Coordinates()
+		if c.Lat < -90 || c.Lat > 90 || c.Lng < -180 || c.Lng > 180 {
+			return Coordinates{}, nil, fmt.Errorf("%s out of range: %v", kind, point[0])
+		}
+		return c, nil, nil
+	}
+	if geometry == nil {
+		return Coordinates{}, nil, fmt.Errorf("%s has too few positions", kind)
+	}
+	return geometry.Centroid(), geometry, nil
+}
+
+type wktParser struct {
+	s   string
+	pos int
+}
+
+func (p *wktParser) skipSpace() {
+	for p.pos < len(p.s) && strings.ContainsRune(" \t\r\n", rune(p.s[p.pos])) {
+		p.pos++
+	}
+}
+
+// word reads a keyword, returning "" if the next token is not one
+func (p *wktParser) word() string {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.s) && (p.s[p.pos] >= 'A' && p.s[p.pos] <= 'Z' || p.s[p.pos] >= 'a' && p.s[p.pos] <= 'z') {
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+// accept consumes c if it is the next character
+func (p *wktParser) accept(c byte) bool {
+	p.skipSpace()
+	if p.pos < len(p.s) && p.s[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *wktParser) expect(c byte) error {
+	if !p.accept(c) {
+		return fmt.Errorf("expected %q at offset %d", c, p.pos)
+	}
+	return nil
+}
+
+// list parses "(item, item, ...)"
+func (p *wktParser) list(item func() error) error {
+	if err := p.expect('('); err != nil {
+		return err
+	}
+	for {
+		if err := item(); err != nil {
+			return err
+		}
+		if !p.accept(',') {
+			return p.expect(')')
+		}
+	}
+}
+
+// positions parses "(x y, x y, ...)". MULTIPOINT's "((x y), (x y))" form
+// is accepted too.
+func (p *wktParser) positions() ([]Position, error) {
+	var positions []Position
+	err := p.list(func() error {
+		nested := p.accept('(')
+		position, err := p.position()
+		if err != nil {
+			return err
+		}
+		positions = append(positions, position)
+		if nested {
+			return p.expect(')')
+		}
+		return nil
+	})
+	return positions, err
+}
+
+func (p *wktParser) lists() ([][]Position, error) {
+	var lists [][]Position
+	err := p.list(func() error {
+		positions, err := p.positions()
+		lists = append(lists, positions)
+		return err
+	})
+	return lists, err
+}
+
+func (p *wktParser) polygons() ([][][]Position, error) {
+	var polygons [][][]Position
+	err := p.list(func() error {
+		rings, err := p.lists()
+		polygons = append(polygons, rings)
+		return err
+	})
+	return polygons, err
+}
+
+// position reads "x y" followed by optional z and m values
+func (p *wktParser) position() (Position, error) {
+	var values []float64
+	for {
+		p.skipSpace()
+		start := p.pos
+		for p.pos < len(p.s) && strings.ContainsRune("0123456789+-.eE", rune(p.s[p.pos])) {
+			p.pos++
+		}
+		if start == p.pos {
+			break
+		}
+		v, err := strconv.ParseFloat(p.s[start:p.pos], 64)
+		if err != nil {
+			return Position{}, fmt.Errorf("invalid number %q", p.s[start:p.pos])
+		}
+		values = append(values, v)
+	}
+	if len(values) < 2 || len(values) > 4 {
+		return Position{}, fmt.Errorf("expected 2 to 4 numbers at offset %d", p.pos)
+	}
+	return Position{values[0], values[1]}, nil
+}
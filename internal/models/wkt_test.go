@@ -0,0 +1,61 @@
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseWKT(t *testing.T) {
+	tests := []struct {
+		input    string
+		wantType string // "" for points
+		lat, lng float64
+	}{
+		{"POINT (-9.1393 38.7223)", "", 38.7223, -9.1393},
+		{"point z(-9.1393 38.7223 12)", "", 38.7223, -9.1393},
+		{"SRID=4326;POINT(-9.1393 38.7223)", "", 38.7223, -9.1393},
+		{"MULTIPOINT ((1 2), (3 4))", "", 2, 1},
+		{"MULTIPOINT (1 2, 3 4)", "", 2, 1},
+		{"LINESTRING (0 0, 2 0)", "LineString", 0, 1},
+		{"MULTILINESTRING ((0 0, 0 2), (5 5, 5 6))", "MultiLineString", 2.5, 1.67},
+		{"POLYGON ((0 0, 4 0, 4 4, 0 4, 0 0), (1 1, 2 1, 2 2, 1 2, 1 1))", "Polygon", 2, 2},
+		{"MULTIPOLYGON (((0 0, 1 0, 1 1, 0 0)), ((10 10, 14 10, 14 14, 10 14)))", "MultiPolygon", 12, 12},
+	}
+
+	for _, tt := range tests {
+		c, g, err := ParseWKT(tt.input)
+		if err != nil {
+			t.Errorf("ParseWKT(%q) failed: %v", tt.input, err)
+			continue
+		}
+		gotType := ""
+		if g != nil {
+			gotType = g.Type
+		}
+		if gotType != tt.wantType {
+			t.Errorf("ParseWKT(%q) type = %q, want %q", tt.input, gotType, tt.wantType)
+		}
+		if math.Abs(c.Lat-tt.lat) > 0.05 || math.Abs(c.Lng-tt.lng) > 0.05 {
+			t.Errorf("ParseWKT(%q) = %+v, want %v,%v", tt.input, c, tt.lat, tt.lng)
+		}
+	}
+}
+
+func TestParseWKT_Invalid(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"POINT EMPTY",
+		"POINT (1)",
+		"POINT (1 2",
+		"POINT (200 2)",
+		"LINESTRING (1 2)",
+		"POLYGON ((0 0, 1 1))",
+		"CIRCULARSTRING (0 0, 1 1, 2 0)",
+		"POINT (1 2) trailing",
+		"38.7, -9.1",
+	} {
+		if _, _, err := ParseWKT(input); err == nil {
+			t.Errorf("Expected error for %q", input)
+		}
+	}
+}
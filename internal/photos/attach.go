@@ -0,0 +1,53 @@
+package photos
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/user/placeli/internal/models"
+)
+
+// Attach stores a personal file and describes it as an attachment, with the
+// capture time and position from its Exif data when present
+func (s *Store) Attach(path string) (*models.Attachment, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	hash, err := s.Put(data)
+	if err != nil {
+		return nil, err
+	}
+
+	attachment := &models.Attachment{
+		Hash:        hash,
+		Name:        filepath.Base(path),
+		ContentType: contentType(path, data),
+		Size:        int64(len(data)),
+		AddedAt:     time.Now(),
+	}
+	if meta, err := ReadMetadata(data); err == nil {
+		attachment.TakenAt = meta.TakenAt
+		attachment.Coordinates = meta.Coordinates
+	}
+
+	return attachment, nil
+}
+
+// contentType prefers the file extension and falls back to sniffing
+func contentType(path string, data []byte) string {
+	t := mime.TypeByExtension(strings.ToLower(filepath.Ext(path)))
+	if t == "" {
+		t = http.DetectContentType(data)
+	}
+	if i := strings.Index(t, ";"); i >= 0 {
+		t = t[:i]
+	}
+	return t
+}
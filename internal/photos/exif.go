@@ -0,0 +1,242 @@
+package photos
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/user/placeli/internal/models"
+)
+
+// ErrNoExif is returned for files without readable Exif metadata
+var ErrNoExif = errors.New("no exif metadata")
+
+// Exif tags we read
+const (
+	tagDateTime           = 0x0132
+	tagExifIFD            = 0x8769
+	tagGPSIFD             = 0x8825
+	tagDateTimeOriginal   = 0x9003
+	tagOffsetTimeOriginal = 0x9011
+	tagGPSLatitudeRef     = 0x0001
+	tagGPSLatitude        = 0x0002
+	tagGPSLongitudeRef    = 0x0003
+	tagGPSLongitude       = 0x0004
+)
+
+const exifTimeLayout = "2006:01:02 15:04:05"
+
+// Metadata is what a photo's Exif block says about when and where it was
+// taken. Either field may be nil.
+type Metadata struct {
+	TakenAt     *time.Time
+	Coordinates *models.Coordinates
+}
+
+// ReadMetadata extracts the capture time and GPS position from a JPEG's
+// Exif block. Capture times without an offset are read as local time.
+func ReadMetadata(data []byte) (*Metadata, error) {
+	tiff, err := findExif(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("%w: bad byte order", ErrNoExif)
+	}
+	if order.Uint16(tiff[2:4]) != 42 {
+		return nil, fmt.Errorf("%w: bad tiff header", ErrNoExif)
+	}
+
+	r := &tiffReader{data: tiff, order: order}
+	ifd0, err := r.readIFD(order.Uint32(tiff[4:8]))
+	if err != nil {
+		return nil, err
+	}
+
+	meta := &Metadata{}
+
+	taken := r.ascii(ifd0[tagDateTime])
+	offset := ""
+	if entry, ok := ifd0[tagExifIFD]; ok {
+		if exif, err := r.readIFD(r.long(entry)); err == nil {
+			if original := r.ascii(exif[tagDateTimeOriginal]); original != "" {
+				taken = original
+			}
+			offset = r.ascii(exif[tagOffsetTimeOriginal])
+		}
+	}
+	if t, ok := parseExifTime(taken, offset); ok {
+		meta.TakenAt = &t
+	}
+
+	if entry, ok := ifd0[tagGPSIFD]; ok {
+		if gps, err := r.readIFD(r.long(entry)); err == nil {
+			meta.Coordinates = r.coordinates(gps)
+		}
+	}
+
+	return meta, nil
+}
+
+// findExif returns the TIFF structure inside a JPEG's APP1 Exif segment
+func findExif(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, fmt.Errorf("%w: not a JPEG", ErrNoExif)
+	}
+
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			break
+		}
+		marker := data[pos+1]
+		// Start of scan: image data follows, no more metadata
+		if marker == 0xDA || marker == 0xD9 {
+			break
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		if length < 2 || pos+2+length > len(data) {
+			break
+		}
+		segment := data[pos+4 : pos+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) && len(segment) >= 14 {
+			return segment[6:], nil
+		}
+		pos += 2 + length
+	}
+
+	return nil, ErrNoExif
+}
+
+type ifdEntry struct {
+	typ   uint16
+	count uint32
+	value []byte
+}
+
+type tiffReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// typeSizes maps TIFF field types to their size in bytes
+var typeSizes = map[uint16]int{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 7: 1, 9: 4, 10: 8}
+
+func (r *tiffReader) readIFD(offset uint32) (map[uint16]ifdEntry, error) {
+	start := int(offset)
+	if start+2 > len(r.data) {
+		return nil, fmt.Errorf("%w: ifd out of range", ErrNoExif)
+	}
+	n := int(r.order.Uint16(r.data[start:]))
+
+	entries := make(map[uint16]ifdEntry, n)
+	for i := 0; i < n; i++ {
+		pos := start + 2 + i*12
+		if pos+12 > len(r.data) {
+			return nil, fmt.Errorf("%w: truncated ifd", ErrNoExif)
+		}
+		tag := r.order.Uint16(r.data[pos:])
+		typ := r.order.Uint16(r.data[pos+2:])
+		count := r.order.Uint32(r.data[pos+4:])
+
+		size, ok := typeSizes[typ]
+		if !ok {
+			continue
+		}
+		total := size * int(count)
+		value := r.data[pos+8 : pos+12]
+		if total > 4 {
+			valueOffset := int(r.order.Uint32(r.data[pos+8:]))
+			if valueOffset < 0 || valueOffset+total > len(r.data) {
+				continue
+			}
+			value = r.data[valueOffset : valueOffset+total]
+		}
+		entries[tag] = ifdEntry{typ: typ, count: count, value: value[:min(total, len(value))]}
+	}
+
+	return entries, nil
+}
+
+func (r *tiffReader) long(e ifdEntry) uint32 {
+	switch {
+	case e.typ == 4 && len(e.value) >= 4:
+		return r.order.Uint32(e.value)
+	case e.typ == 3 && len(e.value) >= 2:
+		return uint32(r.order.Uint16(e.value))
+	}
+	return 0
+}
+
+func (r *tiffReader) ascii(e ifdEntry) string {
+	if e.typ != 2 {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(string(e.value), "\x00"))
+}
+
+func (r *tiffReader) rationals(e ifdEntry) []float64 {
+	if e.typ != 5 {
+		return nil
+	}
+	values := make([]float64, 0, e.count)
+	for i := 0; i+8 <= len(e.value); i += 8 {
+		num := r.order.Uint32(e.value[i:])
+		den := r.order.Uint32(e.value[i+4:])
+		if den == 0 {
+			return nil
+		}
+		values = append(values, float64(num)/float64(den))
+	}
+	return values
+}
+
+// coordinates converts GPS degrees/minutes/seconds to decimal degrees
+func (r *tiffReader) coordinates(gps map[uint16]ifdEntry) *models.Coordinates {
+	lat := r.rationals(gps[tagGPSLatitude])
+	lng := r.rationals(gps[tagGPSLongitude])
+	if len(lat) != 3 || len(lng) != 3 {
+		return nil
+	}
+
+	coords := &models.Coordinates{
+		Lat: lat[0] + lat[1]/60 + lat[2]/3600,
+		Lng: lng[0] + lng[1]/60 + lng[2]/3600,
+	}
+	if r.ascii(gps[tagGPSLatitudeRef]) == "S" {
+		coords.Lat = -coords.Lat
+	}
+	if r.ascii(gps[tagGPSLongitudeRef]) == "W" {
+		coords.Lng = -coords.Lng
+	}
+	if coords.Lat == 0 && coords.Lng == 0 {
+		return nil
+	}
+	return coords
+}
+
+func parseExifTime(value, offset string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	if offset != "" {
+		if t, err := time.Parse(exifTimeLayout+"-07:00", value+offset); err == nil {
+			return t, true
+		}
+	}
+	t, err := time.ParseInLocation(exifTimeLayout, value, time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
@@ -0,0 +1,139 @@
+package photos
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exifJPEG builds a small JPEG carrying DateTimeOriginal and a GPS position
+func exifJPEG(t *testing.T, taken string, lat, lng float64) []byte {
+	le := binary.LittleEndian
+	tiff := make([]byte, 178)
+	copy(tiff, "II")
+	le.PutUint16(tiff[2:], 42)
+	le.PutUint32(tiff[4:], 8)
+
+	entry := func(pos int, tag, typ uint16, count, value uint32) {
+		le.PutUint16(tiff[pos:], tag)
+		le.PutUint16(tiff[pos+2:], typ)
+		le.PutUint32(tiff[pos+4:], count)
+		le.PutUint32(tiff[pos+8:], value)
+	}
+	dms := func(pos int, deg float64) {
+		deg = math.Abs(deg)
+		d := math.Floor(deg)
+		m := math.Floor((deg - d) * 60)
+		s := ((deg-d)*60 - m) * 60
+		for i, v := range []uint32{uint32(d), 1, uint32(m), 1, uint32(s * 1000), 1000} {
+			le.PutUint32(tiff[pos+i*4:], v)
+		}
+	}
+
+	// IFD0 at 8: pointers to the Exif and GPS IFDs
+	le.PutUint16(tiff[8:], 2)
+	entry(10, tagExifIFD, 4, 1, 38)
+	entry(22, tagGPSIFD, 4, 1, 76)
+
+	// Exif IFD at 38, capture time stored at 56
+	le.PutUint16(tiff[38:], 1)
+	entry(40, tagDateTimeOriginal, 2, 20, 56)
+	copy(tiff[56:], taken+"\x00")
+
+	// GPS IFD at 76, rationals stored at 130 and 154
+	latRef, lngRef := "N", "E"
+	if lat < 0 {
+		latRef = "S"
+	}
+	if lng < 0 {
+		lngRef = "W"
+	}
+	le.PutUint16(tiff[76:], 4)
+	entry(78, tagGPSLatitudeRef, 2, 2, uint32(latRef[0]))
+	entry(90, tagGPSLatitude, 5, 3, 130)
+	entry(102, tagGPSLongitudeRef, 2, 2, uint32(lngRef[0]))
+	entry(114, tagGPSLongitude, 5, 3, 154)
+	dms(130, lat)
+	dms(154, lng)
+
+	app1 := append([]byte("Exif\x00\x00"), tiff...)
+
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	img.Set(0, 0, color.White)
+	var encoded bytes.Buffer
+	require.NoError(t, jpeg.Encode(&encoded, img, nil))
+
+	var out bytes.Buffer
+	out.Write(encoded.Bytes()[:2])
+	out.Write([]byte{0xFF, 0xE1})
+	binary.Write(&out, binary.BigEndian, uint16(len(app1)+2))
+	out.Write(app1)
+	out.Write(encoded.Bytes()[2:])
+	return out.Bytes()
+}
+
+func TestReadMetadata(t *testing.T) {
+	data := exifJPEG(t, "2024:05:17 13:45:10", 38.7139, -9.1334)
+
+	meta, err := ReadMetadata(data)
+	require.NoError(t, err)
+
+	require.NotNil(t, meta.TakenAt)
+	want := time.Date(2024, 5, 17, 13, 45, 10, 0, time.Local)
+	assert.True(t, want.Equal(*meta.TakenAt), "got %v", meta.TakenAt)
+
+	require.NotNil(t, meta.Coordinates)
+	assert.InDelta(t, 38.7139, meta.Coordinates.Lat, 0.0001)
+	assert.InDelta(t, -9.1334, meta.Coordinates.Lng, 0.0001)
+}
+
+func TestReadMetadata_NoExif(t *testing.T) {
+	_, err := ReadMetadata(testImage(t, 4, 4, color.White))
+	assert.ErrorIs(t, err, ErrNoExif)
+
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, nil))
+	_, err = ReadMetadata(buf.Bytes())
+	assert.ErrorIs(t, err, ErrNoExif)
+
+	_, err = ReadMetadata([]byte{0xFF, 0xD8, 0xFF, 0xE1, 0x00})
+	assert.ErrorIs(t, err, ErrNoExif)
+}
+
+func TestStore_Attach(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	photo := filepath.Join(dir, "IMG_0412.JPG")
+	require.NoError(t, os.WriteFile(photo, exifJPEG(t, "2024:05:17 13:45:10", 38.7139, -9.1334), 0644))
+	menu := filepath.Join(dir, "menu.pdf")
+	require.NoError(t, os.WriteFile(menu, []byte("%PDF-1.4 menu"), 0644))
+
+	attachment, err := store.Attach(photo)
+	require.NoError(t, err)
+	assert.Equal(t, "IMG_0412.JPG", attachment.Name)
+	assert.Equal(t, "image/jpeg", attachment.ContentType)
+	assert.True(t, attachment.IsImage())
+	assert.True(t, store.Has(attachment.Hash))
+	assert.NotNil(t, attachment.TakenAt)
+	assert.NotNil(t, attachment.Coordinates)
+	assert.FileExists(t, store.ThumbnailPath(attachment.Hash, 128))
+
+	doc, err := store.Attach(menu)
+	require.NoError(t, err)
+	assert.Equal(t, "application/pdf", doc.ContentType)
+	assert.Nil(t, doc.TakenAt)
+	assert.Equal(t, int64(13), doc.Size)
+}
@@ -0,0 +1,351 @@
+// Package photos stores place photos by content hash, so identical images
+// are kept once no matter how many places reference them.
+package photos
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/user/placeli/internal/models"
+)
+
+// ThumbnailSizes are the bounding boxes, in pixels, thumbnails are made at
+var ThumbnailSizes = []int{128, 512}
+
+var (
+	// ErrNotFound is returned for hashes that are not in the store
+	ErrNotFound = errors.New("photo not found")
+	// ErrUnsupportedSize is returned for thumbnail sizes not in ThumbnailSizes
+	ErrUnsupportedSize = errors.New("unsupported thumbnail size")
+)
+
+// Store is a content-addressed photo directory:
+//
+//	objects/ab/abcdef...        original images named by SHA-256
+//	thumbs/128/ab/abcdef....jpg  thumbnails per size
+type Store struct {
+	root string
+}
+
+// DefaultDir returns the default photo store location, ~/.placeli/photos
+func DefaultDir() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".placeli", "photos")
+}
+
+func NewStore(root string) (*Store, error) {
+	for _, dir := range []string{"objects", "thumbs"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create photo store: %w", err)
+		}
+	}
+	return &Store{root: root}, nil
+}
+
+// Root returns the store directory
+func (s *Store) Root() string {
+	return s.root
+}
+
+// HashBytes returns the content hash used as a photo's key
+func HashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Put stores an image and returns its hash. Storing the same bytes again is
+// a no-op. Thumbnails are generated for decodable images; other data is
+// stored as-is.
+func (s *Store) Put(data []byte) (string, error) {
+	hash := HashBytes(data)
+	path := s.Path(hash)
+
+	if !s.Has(hash) {
+		if err := writeAtomic(path, data); err != nil {
+			return "", fmt.Errorf("failed to store photo: %w", err)
+		}
+	}
+
+	for _, size := range ThumbnailSizes {
+		if _, err := s.Thumbnail(hash, size); err != nil && !errors.Is(err, ErrUndecodable) {
+			return hash, err
+		}
+	}
+
+	return hash, nil
+}
+
+// PutFile stores the contents of a file
+func (s *Store) PutFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read photo: %w", err)
+	}
+	return s.Put(data)
+}
+
+// Has reports whether the store holds the image
+func (s *Store) Has(hash string) bool {
+	if !validHash(hash) {
+		return false
+	}
+	_, err := os.Stat(s.Path(hash))
+	return err == nil
+}
+
+// Path returns where the original image for hash is stored
+func (s *Store) Path(hash string) string {
+	return filepath.Join(s.root, "objects", shard(hash), hash)
+}
+
+// ThumbnailPath returns where the thumbnail of hash at size is stored
+func (s *Store) ThumbnailPath(hash string, size int) string {
+	return filepath.Join(s.root, "thumbs", strconv.Itoa(size), shard(hash), hash+".jpg")
+}
+
+// Thumbnail returns the path of the thumbnail for hash at one of the
+// ThumbnailSizes, generating it when missing
+func (s *Store) Thumbnail(hash string, size int) (string, error) {
+	if !validSize(size) {
+		return "", fmt.Errorf("%w: %d", ErrUnsupportedSize, size)
+	}
+	if !s.Has(hash) {
+		return "", ErrNotFound
+	}
+
+	path := s.ThumbnailPath(hash, size)
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	data, err := os.ReadFile(s.Path(hash))
+	if err != nil {
+		return "", fmt.Errorf("failed to read photo: %w", err)
+	}
+	thumb, err := makeThumbnail(data, size)
+	if err != nil {
+		return "", err
+	}
+	if err := writeAtomic(path, thumb); err != nil {
+		return "", fmt.Errorf("failed to store thumbnail: %w", err)
+	}
+
+	return path, nil
+}
+
+// GCResult reports what a garbage collection removed
+type GCResult struct {
+	Objects    int
+	Thumbnails int
+	Bytes      int64
+}
+
+// GC removes images and thumbnails whose hash is not in referenced. With
+// dryRun set it only reports what would be removed.
+func (s *Store) GC(referenced map[string]bool, dryRun bool) (*GCResult, error) {
+	result := &GCResult{}
+
+	err := s.walk(func(kind string, hash string, path string, info fs.FileInfo) error {
+		if referenced[hash] && (kind == "objects" || s.Has(hash)) {
+			return nil
+		}
+		if kind == "objects" {
+			result.Objects++
+		} else {
+			result.Thumbnails++
+		}
+		result.Bytes += info.Size()
+		if dryRun {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// VerifyResult lists problems found by Verify, sorted by hash
+type VerifyResult struct {
+	Checked int
+	// Corrupt images no longer match their hash
+	Corrupt []string
+	// Missing images are referenced but not in the store
+	Missing []string
+}
+
+// OK reports whether no problems were found
+func (r *VerifyResult) OK() bool {
+	return len(r.Corrupt) == 0 && len(r.Missing) == 0
+}
+
+// Verify re-hashes every stored image and checks that each referenced hash
+// is present
+func (s *Store) Verify(referenced map[string]bool) (*VerifyResult, error) {
+	result := &VerifyResult{}
+
+	err := s.walk(func(kind string, hash string, path string, info fs.FileInfo) error {
+		if kind != "objects" {
+			return nil
+		}
+		result.Checked++
+
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", path, err)
+		}
+		defer f.Close()
+
+		h := sha256.New()
+		if _, err := io.Copy(h, f); err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if hex.EncodeToString(h.Sum(nil)) != hash {
+			result.Corrupt = append(result.Corrupt, hash)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for hash := range referenced {
+		if !s.Has(hash) {
+			result.Missing = append(result.Missing, hash)
+		}
+	}
+
+	sort.Strings(result.Corrupt)
+	sort.Strings(result.Missing)
+	return result, nil
+}
+
+// Stats describes the store's contents
+type Stats struct {
+	Objects        int
+	ObjectBytes    int64
+	Thumbnails     int
+	ThumbnailBytes int64
+}
+
+func (s *Store) Stats() (*Stats, error) {
+	stats := &Stats{}
+
+	err := s.walk(func(kind string, hash string, path string, info fs.FileInfo) error {
+		if kind == "objects" {
+			stats.Objects++
+			stats.ObjectBytes += info.Size()
+		} else {
+			stats.Thumbnails++
+			stats.ThumbnailBytes += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// walk calls fn for every stored image ("objects") and thumbnail ("thumbs")
+func (s *Store) walk(fn func(kind, hash, path string, info fs.FileInfo) error) error {
+	for _, kind := range []string{"objects", "thumbs"} {
+		dir := filepath.Join(s.root, kind)
+		err := filepath.Walk(dir, func(path string, info fs.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+			hash := info.Name()
+			if kind == "thumbs" {
+				hash = hash[:len(hash)-len(filepath.Ext(hash))]
+			}
+			if !validHash(hash) {
+				return nil
+			}
+			return fn(kind, hash, path, info)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to scan photo store: %w", err)
+		}
+	}
+	return nil
+}
+
+func shard(hash string) string {
+	if len(hash) < 2 {
+		return "00"
+	}
+	return hash[:2]
+}
+
+func validHash(hash string) bool {
+	if len(hash) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
+
+func validSize(size int) bool {
+	for _, s := range ThumbnailSizes {
+		if s == size {
+			return true
+		}
+	}
+	return false
+}
+
+// writeAtomic writes data through a temporary file so readers never see a
+// partial image
+func writeAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// References collects the photo and attachment hashes used by places
+func References(places []*models.Place) map[string]bool {
+	referenced := make(map[string]bool)
+	for _, place := range places {
+		for _, photo := range place.Photos {
+			if photo.Hash != "" {
+				referenced[photo.Hash] = true
+			}
+		}
+		for _, attachment := range place.Attachments {
+			referenced[attachment.Hash] = true
+		}
+	}
+	return referenced
+}
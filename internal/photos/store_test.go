@@ -0,0 +1,160 @@
+package photos
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/placeli/internal/models"
+)
+
+func testImage(t *testing.T, w, h int, c color.Color) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestStore_PutDeduplicates(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	data := testImage(t, 10, 10, color.White)
+	hash1, err := store.Put(data)
+	require.NoError(t, err)
+	hash2, err := store.Put(data)
+	require.NoError(t, err)
+
+	assert.Equal(t, hash1, hash2)
+	assert.Equal(t, HashBytes(data), hash1)
+	assert.True(t, store.Has(hash1))
+
+	stored, err := os.ReadFile(store.Path(hash1))
+	require.NoError(t, err)
+	assert.Equal(t, data, stored)
+
+	stats, err := store.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Objects)
+	assert.Equal(t, len(ThumbnailSizes), stats.Thumbnails)
+}
+
+func TestStore_Thumbnail(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	hash, err := store.Put(testImage(t, 800, 400, color.RGBA{R: 200, A: 255}))
+	require.NoError(t, err)
+
+	path, err := store.Thumbnail(hash, 128)
+	require.NoError(t, err)
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	thumb, err := jpeg.Decode(f)
+	require.NoError(t, err)
+	assert.Equal(t, 128, thumb.Bounds().Dx())
+	assert.Equal(t, 64, thumb.Bounds().Dy())
+
+	r, _, _, _ := thumb.At(10, 10).RGBA()
+	assert.InDelta(t, 200, r>>8, 8)
+
+	_, err = store.Thumbnail(hash, 100)
+	assert.ErrorIs(t, err, ErrUnsupportedSize)
+
+	_, err = store.Thumbnail(HashBytes([]byte("missing")), 128)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestStore_NonImage(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	hash, err := store.Put([]byte("not an image"))
+	require.NoError(t, err)
+	assert.True(t, store.Has(hash))
+
+	_, err = store.Thumbnail(hash, 128)
+	assert.ErrorIs(t, err, ErrUndecodable)
+}
+
+func TestStore_GC(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	keep, err := store.Put(testImage(t, 4, 4, color.White))
+	require.NoError(t, err)
+	drop, err := store.Put(testImage(t, 4, 4, color.Black))
+	require.NoError(t, err)
+
+	places := []*models.Place{{Photos: []models.Photo{{Hash: keep}, {LocalPath: "legacy.jpg"}}}}
+	referenced := References(places)
+	assert.Equal(t, map[string]bool{keep: true}, referenced)
+
+	result, err := store.GC(referenced, true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Objects)
+	assert.Equal(t, 2, result.Thumbnails)
+	assert.True(t, store.Has(drop), "dry run keeps files")
+
+	result, err = store.GC(referenced, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Objects)
+	assert.False(t, store.Has(drop))
+	assert.True(t, store.Has(keep))
+	assert.NoFileExists(t, store.ThumbnailPath(drop, 128))
+	assert.FileExists(t, store.ThumbnailPath(keep, 128))
+}
+
+func TestStore_Verify(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	good, err := store.Put(testImage(t, 4, 4, color.White))
+	require.NoError(t, err)
+	bad, err := store.Put(testImage(t, 4, 4, color.Black))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(store.Path(bad), []byte("bit rot"), 0644))
+	missing := HashBytes([]byte("gone"))
+
+	result, err := store.Verify(map[string]bool{good: true, bad: true, missing: true})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Checked)
+	assert.Equal(t, []string{bad}, result.Corrupt)
+	assert.Equal(t, []string{missing}, result.Missing)
+	assert.False(t, result.OK())
+}
+
+func TestResize(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 300, 900))
+	assert.Equal(t, image.Rect(0, 0, 42, 128), resize(src, 128).Bounds())
+
+	small := image.NewRGBA(image.Rect(0, 0, 50, 20))
+	assert.Equal(t, small, resize(small, 128))
+}
+
+func TestMakeThumbnail_Transparent(t *testing.T) {
+	data := testImage(t, 400, 200, color.Transparent)
+
+	thumb, err := makeThumbnail(data, 128)
+	require.NoError(t, err)
+	img, err := jpeg.Decode(bytes.NewReader(thumb))
+	require.NoError(t, err)
+
+	r, g, b, _ := img.At(10, 10).RGBA()
+	assert.Greater(t, r>>8, uint32(245), "transparent areas are white")
+	assert.Greater(t, g>>8, uint32(245))
+	assert.Greater(t, b>>8, uint32(245))
+}
@@ -0,0 +1,176 @@
+package tiles
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultURL is the OpenStreetMap tile server the web map uses
+	DefaultURL = "https://tile.openstreetmap.org/{z}/{x}/{y}.png"
+
+	// DefaultWorkers keeps bulk downloads within what public tile servers
+	// such as OpenStreetMap's allow
+	DefaultWorkers = 2
+
+	userAgent   = "placeli (+https://github.com/user/placeli)"
+	maxTileSize = 4 << 20
+)
+
+// Fetcher downloads tiles from a URL template with {z}, {x} and {y}
+// placeholders, and optionally {s} for a/b/c subdomains
+type Fetcher struct {
+	httpClient  *http.Client
+	urlTemplate string
+}
+
+func NewFetcher(urlTemplate string) *Fetcher {
+	return &Fetcher{
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		urlTemplate: urlTemplate,
+	}
+}
+
+// URL returns where a tile is downloaded from
+func (f *Fetcher) URL(t Tile) string {
+	return strings.NewReplacer(
+		"{z}", strconv.Itoa(t.Z),
+		"{x}", strconv.Itoa(t.X),
+		"{y}", strconv.Itoa(t.Y),
+		"{s}", string(rune('a'+(t.X+t.Y)%3)),
+	).Replace(f.urlTemplate)
+}
+
+// Fetch downloads a tile's image. Missing tiles return ErrNotFound.
+func (f *Fetcher) Fetch(ctx context.Context, t Tile) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", f.URL(t), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tile %s: %w", t, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("failed to fetch tile %s: %s", t, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxTileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tile %s: %w", t, err)
+	}
+	if len(data) > maxTileSize {
+		return nil, fmt.Errorf("tile %s is larger than %d bytes", t, maxTileSize)
+	}
+	return data, nil
+}
+
+// FetchOptions control a bulk download
+type FetchOptions struct {
+	Workers int
+	// Refresh downloads tiles that are already cached again
+	Refresh bool
+	// Progress is called after each tile
+	Progress func(FetchResult)
+}
+
+// FetchResult counts the outcome of a bulk download
+type FetchResult struct {
+	Total   int
+	Fetched int
+	Cached  int
+	Failed  int
+	Bytes   int64
+}
+
+// Done returns how many tiles have been handled
+func (r FetchResult) Done() int {
+	return r.Fetched + r.Cached + r.Failed
+}
+
+// FetchAll downloads tiles into the cache, skipping cached ones unless
+// opts.Refresh is set. A failed tile is counted and doesn't stop the
+// others; the result so far is returned when ctx is cancelled.
+func (f *Fetcher) FetchAll(ctx context.Context, cache *Cache, tiles []Tile, opts FetchOptions) (*FetchResult, error) {
+	workers := opts.Workers
+	if workers < 1 {
+		workers = DefaultWorkers
+	}
+
+	result := &FetchResult{Total: len(tiles)}
+	var mu sync.Mutex
+	record := func(update func(*FetchResult)) {
+		mu.Lock()
+		defer mu.Unlock()
+		update(result)
+		if opts.Progress != nil {
+			opts.Progress(*result)
+		}
+	}
+
+	queue := make(chan Tile)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range queue {
+				if !opts.Refresh {
+					if cached, err := cache.Has(t); err == nil && cached {
+						record(func(r *FetchResult) { r.Cached++ })
+						continue
+					}
+				}
+
+				data, err := f.Fetch(ctx, t)
+				if err == nil {
+					err = cache.Put(t, data)
+				}
+				if err != nil {
+					record(func(r *FetchResult) { r.Failed++ })
+					continue
+				}
+				record(func(r *FetchResult) {
+					r.Fetched++
+					r.Bytes += int64(len(data))
+				})
+			}
+		}()
+	}
+
+	var err error
+feed:
+	for _, t := range tiles {
+		select {
+		case queue <- t:
+		case <-ctx.Done():
+			err = ctx.Err()
+			break feed
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return result, fmt.Errorf("download interrupted: %w", err)
+	}
+	return result, nil
+}
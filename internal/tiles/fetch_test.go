@@ -0,0 +1,110 @@
+package tiles
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tileServer stands in for a public tile server. It returns each tile's
+// coordinates as its image and 404 for tiles in column 1.
+func tileServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		assert.Contains(t, r.Header.Get("User-Agent"), "placeli")
+		var z, x, y int
+		if _, err := fmt.Sscanf(r.URL.Path, "/%d/%d/%d.png", &z, &x, &y); err != nil || x == 1 {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, "tile %d/%d/%d", z, x, y)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func openCache(t *testing.T) *Cache {
+	cache, err := Open(filepath.Join(t.TempDir(), "tiles.mbtiles"))
+	require.NoError(t, err)
+	t.Cleanup(func() { cache.Close() })
+	return cache
+}
+
+func TestCache_PutGet(t *testing.T) {
+	cache := openCache(t)
+	tile := Tile{Z: 3, X: 2, Y: 1}
+
+	_, err := cache.Get(tile)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, cache.Put(tile, []byte("png")))
+	data, err := cache.Get(tile)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("png"), data)
+
+	// Rows are stored in the TMS scheme MBTiles readers expect
+	var row int
+	require.NoError(t, cache.conn.QueryRow("SELECT tile_row FROM tiles").Scan(&row))
+	assert.Equal(t, 6, row)
+
+	stats, err := cache.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Tiles: 1, Bytes: 3, MinZoom: 3, MaxZoom: 3}, stats)
+}
+
+func TestFetcher_URL(t *testing.T) {
+	f := NewFetcher("https://{s}.tiles.example/{z}/{x}/{y}.png")
+	assert.Equal(t, "https://c.tiles.example/4/3/5.png", f.URL(Tile{Z: 4, X: 3, Y: 5}))
+}
+
+func TestFetcher_FetchAll(t *testing.T) {
+	server, requests := tileServer(t)
+	cache := openCache(t)
+	f := NewFetcher(server.URL + "/{z}/{x}/{y}.png")
+
+	tiles := []Tile{{0, 0, 0}, {1, 0, 0}, {1, 0, 1}, {1, 1, 0}}
+	var progress int
+	result, err := f.FetchAll(context.Background(), cache, tiles, FetchOptions{
+		Workers:  3,
+		Progress: func(FetchResult) { progress++ },
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Fetched)
+	assert.Equal(t, 1, result.Failed)
+	assert.Equal(t, 4, progress)
+
+	data, err := cache.Get(Tile{1, 0, 1})
+	require.NoError(t, err)
+	assert.Equal(t, "tile 1/0/1", string(data))
+
+	// A second run only asks for the tile it is missing
+	requests.Store(0)
+	result, err = f.FetchAll(context.Background(), cache, tiles, FetchOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Cached)
+	assert.Equal(t, int32(1), requests.Load())
+
+	result, err = f.FetchAll(context.Background(), cache, tiles, FetchOptions{Refresh: true})
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Fetched)
+}
+
+func TestFetcher_FetchAllCancelled(t *testing.T) {
+	server, _ := tileServer(t)
+	cache := openCache(t)
+	f := NewFetcher(server.URL + "/{z}/{x}/{y}.png")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err := f.FetchAll(ctx, cache, Covering(BBox{MinLng: -10, MinLat: 30, MaxLng: 10, MaxLat: 50}, 0, 6), FetchOptions{})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, result.Done(), result.Total)
+}
@@ -0,0 +1,149 @@
+package tiles
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	_ "modernc.org/sqlite"
+)
+
+// ErrNotFound is returned for tiles that are not in the cache
+var ErrNotFound = errors.New("tile not found")
+
+// Cache is an MBTiles file: an SQLite database of tile images that map
+// tools such as QGIS can open directly. MBTiles numbers rows from the south
+// (the TMS scheme), so rows are flipped on the way in and out.
+type Cache struct {
+	conn *sql.DB
+	path string
+}
+
+// DefaultPath returns the default tile cache location,
+// ~/.placeli/tiles.mbtiles
+func DefaultPath() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".placeli", "tiles.mbtiles")
+}
+
+// Open opens the MBTiles file at path, creating it if needed
+func Open(path string) (*Cache, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create tile cache directory: %w", err)
+	}
+	conn, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tile cache: %w", err)
+	}
+	// A single connection queues concurrent writes instead of failing them
+	// with SQLITE_BUSY
+	conn.SetMaxOpenConns(1)
+
+	schema := `
+	CREATE TABLE IF NOT EXISTS metadata (name TEXT PRIMARY KEY, value TEXT);
+	CREATE TABLE IF NOT EXISTS tiles (
+		zoom_level INTEGER NOT NULL,
+		tile_column INTEGER NOT NULL,
+		tile_row INTEGER NOT NULL,
+		tile_data BLOB NOT NULL,
+		PRIMARY KEY (zoom_level, tile_column, tile_row)
+	);
+	INSERT OR IGNORE INTO metadata (name, value) VALUES
+		('name', 'placeli'),
+		('format', 'png'),
+		('type', 'baselayer');
+	`
+	if _, err := conn.Exec(schema); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create tile cache %s: %w", path, err)
+	}
+	return &Cache{conn: conn, path: path}, nil
+}
+
+func (c *Cache) Close() error {
+	return c.conn.Close()
+}
+
+// Path returns the MBTiles file location
+func (c *Cache) Path() string {
+	return c.path
+}
+
+// tmsRow returns the MBTiles row of a tile
+func tmsRow(t Tile) int {
+	return (1 << t.Z) - 1 - t.Y
+}
+
+// Get returns a tile's image
+func (c *Cache) Get(t Tile) ([]byte, error) {
+	var data []byte
+	err := c.conn.QueryRow(
+		"SELECT tile_data FROM tiles WHERE zoom_level = ? AND tile_column = ? AND tile_row = ?",
+		t.Z, t.X, tmsRow(t),
+	).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tile %s: %w", t, err)
+	}
+	return data, nil
+}
+
+// Has reports whether a tile is cached
+func (c *Cache) Has(t Tile) (bool, error) {
+	var n int
+	err := c.conn.QueryRow(
+		"SELECT COUNT(*) FROM tiles WHERE zoom_level = ? AND tile_column = ? AND tile_row = ?",
+		t.Z, t.X, tmsRow(t),
+	).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("failed to check tile %s: %w", t, err)
+	}
+	return n > 0, nil
+}
+
+// Put stores a tile's image, replacing any cached copy
+func (c *Cache) Put(t Tile, data []byte) error {
+	_, err := c.conn.Exec(
+		"INSERT OR REPLACE INTO tiles (zoom_level, tile_column, tile_row, tile_data) VALUES (?, ?, ?, ?)",
+		t.Z, t.X, tmsRow(t), data,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store tile %s: %w", t, err)
+	}
+	return nil
+}
+
+// Stats describes what a cache holds
+type Stats struct {
+	Tiles   int
+	Bytes   int64
+	MinZoom int
+	MaxZoom int
+}
+
+func (c *Cache) Stats() (Stats, error) {
+	var stats Stats
+	var minZoom, maxZoom sql.NullInt64
+	err := c.conn.QueryRow(
+		"SELECT COUNT(*), COALESCE(SUM(LENGTH(tile_data)), 0), MIN(zoom_level), MAX(zoom_level) FROM tiles",
+	).Scan(&stats.Tiles, &stats.Bytes, &minZoom, &maxZoom)
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed to read tile cache stats: %w", err)
+	}
+	stats.MinZoom = int(minZoom.Int64)
+	stats.MaxZoom = int(maxZoom.Int64)
+	return stats, nil
+}
+
+// SetMetadata records a value in the MBTiles metadata table, such as the
+// attribution of the tile source
+func (c *Cache) SetMetadata(name, value string) error {
+	if _, err := c.conn.Exec("INSERT OR REPLACE INTO metadata (name, value) VALUES (?, ?)", name, value); err != nil {
+		return fmt.Errorf("failed to write tile cache metadata: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,294 @@
+package tiles
+
+import (
+	"encoding/binary"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+
+	"github.com/user/placeli/internal/models"
+)
+
+// VectorExtent is the size of the coordinate grid vector tile geometries
+// are drawn on
+const VectorExtent = 4096
+
+// VectorLayer is a named layer of a Mapbox Vector Tile
+type VectorLayer struct {
+	Name     string
+	Features []VectorFeature
+}
+
+// VectorFeature is a point, or a line or area when Geometry is set
+type VectorFeature struct {
+	ID       uint64
+	Point    models.Coordinates
+	Geometry *models.Geometry
+	// Properties are strings, bools, integers or floats; other values are
+	// written as strings
+	Properties map[string]interface{}
+}
+
+// Vector tile geometry types and commands, from the Mapbox Vector Tile
+// specification version 2
+const (
+	mvtPoint   = 1
+	mvtLine    = 2
+	mvtPolygon = 3
+
+	cmdMoveTo    = 1
+	cmdLineTo    = 2
+	cmdClosePath = 7
+)
+
+// EncodeVectorTile encodes layers as a Mapbox Vector Tile for tile t.
+// Geometries are not clipped to the tile; map clients clip them when
+// drawing. Features whose geometry vanishes at the tile's resolution are
+// left out.
+func EncodeVectorTile(t Tile, layers ...VectorLayer) []byte {
+	var buf []byte
+	for _, layer := range layers {
+		if encoded := encodeLayer(t, layer); encoded != nil {
+			buf = appendBytes(buf, 3, encoded)
+		}
+	}
+	return buf
+}
+
+func encodeLayer(t Tile, layer VectorLayer) []byte {
+	keys := map[string]uint64{}
+	values := map[string]uint64{}
+	var keyList []string
+	var valueList [][]byte
+
+	var features []byte
+	count := 0
+	for _, f := range layer.Features {
+		kind, geometry := encodeGeometry(t, f)
+		if geometry == nil {
+			continue
+		}
+
+		var tags []uint64
+		for _, name := range slices.Sorted(maps.Keys(f.Properties)) {
+			value := encodeValue(f.Properties[name])
+			k, ok := keys[name]
+			if !ok {
+				k = uint64(len(keyList))
+				keys[name] = k
+				keyList = append(keyList, name)
+			}
+			v, ok := values[string(value)]
+			if !ok {
+				v = uint64(len(valueList))
+				values[string(value)] = v
+				valueList = append(valueList, value)
+			}
+			tags = append(tags, k, v)
+		}
+
+		var feature []byte
+		if f.ID != 0 {
+			feature = appendVarintField(feature, 1, f.ID)
+		}
+		if len(tags) > 0 {
+			feature = appendPacked(feature, 2, tags)
+		}
+		feature = appendVarintField(feature, 3, kind)
+		feature = appendPacked(feature, 4, geometry)
+		features = appendBytes(features, 2, feature)
+		count++
+	}
+	if count == 0 {
+		return nil
+	}
+
+	buf := appendVarintField(nil, 15, 2)
+	buf = appendBytes(buf, 1, []byte(layer.Name))
+	buf = append(buf, features...)
+	for _, key := range keyList {
+		buf = appendBytes(buf, 3, []byte(key))
+	}
+	for _, value := range valueList {
+		buf = appendBytes(buf, 4, value)
+	}
+	return appendVarintField(buf, 5, VectorExtent)
+}
+
+// encodeValue encodes a property value as a tile Value message
+func encodeValue(v interface{}) []byte {
+	switch v := v.(type) {
+	case string:
+		return appendBytes(nil, 1, []byte(v))
+	case bool:
+		n := uint64(0)
+		if v {
+			n = 1
+		}
+		return appendVarintField(nil, 7, n)
+	case int:
+		return appendVarintField(nil, 6, zigzag(int64(v)))
+	case int64:
+		return appendVarintField(nil, 6, zigzag(v))
+	case uint64:
+		return appendVarintField(nil, 5, v)
+	case float32:
+		buf := appendTag(nil, 2, 5)
+		return binary.LittleEndian.AppendUint32(buf, math.Float32bits(v))
+	case float64:
+		buf := appendTag(nil, 3, 1)
+		return binary.LittleEndian.AppendUint64(buf, math.Float64bits(v))
+	default:
+		return appendBytes(nil, 1, []byte(fmt.Sprint(v)))
+	}
+}
+
+// encodeGeometry returns a feature's geometry type and command integers,
+// or nil when nothing is left of it at the tile's resolution
+func encodeGeometry(t Tile, f VectorFeature) (uint64, []uint64) {
+	g := &geometryWriter{}
+	switch {
+	case f.Geometry.IsArea():
+		for _, polygon := range f.Geometry.Polygons {
+			for i, ring := range polygon {
+				points := ringPoints(t, ring, i == 0)
+				if points == nil {
+					if i == 0 {
+						break // no outer ring, so no holes either
+					}
+					continue
+				}
+				g.moveTo(points[0])
+				g.lineTo(points[1:])
+				g.closePath()
+			}
+		}
+		return mvtPolygon, g.commands
+	case f.Geometry != nil && len(f.Geometry.Lines) > 0:
+		for _, line := range f.Geometry.Lines {
+			points := projectPath(t, line)
+			if len(points) < 2 {
+				continue
+			}
+			g.moveTo(points[0])
+			g.lineTo(points[1:])
+		}
+		return mvtLine, g.commands
+	default:
+		g.moveTo(t.project(f.Point.Lat, f.Point.Lng))
+		return mvtPoint, g.commands
+	}
+}
+
+type point struct{ x, y int64 }
+
+// project returns a point's position on the tile's grid
+func (t Tile) project(lat, lng float64) point {
+	mx, my := mercator(lat, lng)
+	n := float64(int(1) << t.Z)
+	return point{
+		x: int64(math.Round((mx*n - float64(t.X)) * VectorExtent)),
+		y: int64(math.Round((my*n - float64(t.Y)) * VectorExtent)),
+	}
+}
+
+// projectPath projects a path, dropping points that land on the one before
+func projectPath(t Tile, path []models.Position) []point {
+	var points []point
+	for _, p := range path {
+		pt := t.project(p[1], p[0])
+		if len(points) == 0 || pt != points[len(points)-1] {
+			points = append(points, pt)
+		}
+	}
+	return points
+}
+
+// ringPoints projects a polygon ring without its closing point, wound the
+// way the specification asks: outer rings clockwise on screen, holes
+// counter-clockwise. Rings with no area left return nil.
+func ringPoints(t Tile, ring []models.Position, outer bool) []point {
+	points := projectPath(t, ring)
+	if len(points) > 1 && points[0] == points[len(points)-1] {
+		points = points[:len(points)-1]
+	}
+	if len(points) < 3 {
+		return nil
+	}
+
+	// Twice the signed area; positive is clockwise with y pointing down
+	var area int64
+	for i, p := range points {
+		q := points[(i+1)%len(points)]
+		area += p.x*q.y - q.x*p.y
+	}
+	if area == 0 {
+		return nil
+	}
+	if (area > 0) != outer {
+		for i, j := 0, len(points)-1; i < j; i, j = i+1, j-1 {
+			points[i], points[j] = points[j], points[i]
+		}
+	}
+	return points
+}
+
+// geometryWriter writes geometry commands, with each point stored
+// relative to the one before across the whole feature
+type geometryWriter struct {
+	commands []uint64
+	cursor   point
+}
+
+func (g *geometryWriter) moveTo(p point) {
+	g.commands = append(g.commands, command(cmdMoveTo, 1))
+	g.add(p)
+}
+
+func (g *geometryWriter) lineTo(points []point) {
+	g.commands = append(g.commands, command(cmdLineTo, len(points)))
+	for _, p := range points {
+		g.add(p)
+	}
+}
+
+func (g *geometryWriter) closePath() {
+	g.commands = append(g.commands, command(cmdClosePath, 1))
+}
+
+func (g *geometryWriter) add(p point) {
+	g.commands = append(g.commands, zigzag(p.x-g.cursor.x), zigzag(p.y-g.cursor.y))
+	g.cursor = p
+}
+
+func command(id, count int) uint64 {
+	return uint64(id&7 | count<<3)
+}
+
+func zigzag(n int64) uint64 {
+	return uint64(n<<1) ^ uint64(n>>63)
+}
+
+// Protocol buffer encoding, just the parts vector tiles need
+
+func appendTag(buf []byte, field, wireType int) []byte {
+	return binary.AppendUvarint(buf, uint64(field<<3|wireType))
+}
+
+func appendVarintField(buf []byte, field int, v uint64) []byte {
+	return binary.AppendUvarint(appendTag(buf, field, 0), v)
+}
+
+func appendBytes(buf []byte, field int, data []byte) []byte {
+	buf = binary.AppendUvarint(appendTag(buf, field, 2), uint64(len(data)))
+	return append(buf, data...)
+}
+
+func appendPacked(buf []byte, field int, values []uint64) []byte {
+	var packed []byte
+	for _, v := range values {
+		packed = binary.AppendUvarint(packed, v)
+	}
+	return appendBytes(buf, field, packed)
+}
@@ -0,0 +1,207 @@
+package tiles
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/placeli/internal/models"
+)
+
+// pbField is a decoded protocol buffer field
+type pbField struct {
+	num   int
+	value uint64
+	data  []byte
+}
+
+// decodeFields splits a protocol buffer message into its fields
+func decodeFields(t *testing.T, buf []byte) []pbField {
+	t.Helper()
+	var fields []pbField
+	for len(buf) > 0 {
+		key, n := binary.Uvarint(buf)
+		require.Positive(t, n)
+		buf = buf[n:]
+		f := pbField{num: int(key >> 3)}
+		switch key & 7 {
+		case 0:
+			f.value, n = binary.Uvarint(buf)
+			require.Positive(t, n)
+			buf = buf[n:]
+		case 1:
+			f.data, buf = buf[:8], buf[8:]
+		case 2:
+			size, n := binary.Uvarint(buf)
+			require.Positive(t, n)
+			f.data, buf = buf[n:n+int(size)], buf[n+int(size):]
+		case 5:
+			f.data, buf = buf[:4], buf[4:]
+		default:
+			t.Fatalf("unexpected wire type %d", key&7)
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+func decodePacked(t *testing.T, buf []byte) []uint64 {
+	t.Helper()
+	var values []uint64
+	for len(buf) > 0 {
+		v, n := binary.Uvarint(buf)
+		require.Positive(t, n)
+		values = append(values, v)
+		buf = buf[n:]
+	}
+	return values
+}
+
+// decodedFeature is a vector tile feature with its properties resolved
+type decodedFeature struct {
+	id       uint64
+	kind     uint64
+	geometry []uint64
+	props    map[string]pbField
+}
+
+// decodeLayers decodes a vector tile into features by layer name
+func decodeLayers(t *testing.T, tile []byte) map[string][]decodedFeature {
+	t.Helper()
+	layers := map[string][]decodedFeature{}
+	for _, l := range decodeFields(t, tile) {
+		require.Equal(t, 3, l.num)
+		var name string
+		var keys []string
+		var values []pbField
+		var features [][]pbField
+		for _, f := range decodeFields(t, l.data) {
+			switch f.num {
+			case 15:
+				assert.EqualValues(t, 2, f.value, "version")
+			case 1:
+				name = string(f.data)
+			case 2:
+				features = append(features, decodeFields(t, f.data))
+			case 3:
+				keys = append(keys, string(f.data))
+			case 4:
+				values = append(values, decodeFields(t, f.data)[0])
+			case 5:
+				assert.EqualValues(t, VectorExtent, f.value)
+			}
+		}
+		for _, fields := range features {
+			feature := decodedFeature{props: map[string]pbField{}}
+			for _, f := range fields {
+				switch f.num {
+				case 1:
+					feature.id = f.value
+				case 2:
+					tags := decodePacked(t, f.data)
+					for i := 0; i < len(tags); i += 2 {
+						feature.props[keys[tags[i]]] = values[tags[i+1]]
+					}
+				case 3:
+					feature.kind = f.value
+				case 4:
+					feature.geometry = decodePacked(t, f.data)
+				}
+			}
+			layers[name] = append(layers[name], feature)
+		}
+	}
+	return layers
+}
+
+func TestZigzag(t *testing.T) {
+	for n, want := range map[int64]uint64{0: 0, -1: 1, 1: 2, -2: 3, 2: 4, 2048: 4096} {
+		assert.Equal(t, want, zigzag(n), "zigzag(%d)", n)
+	}
+	assert.EqualValues(t, 9, command(cmdMoveTo, 1))
+	assert.EqualValues(t, 26, command(cmdLineTo, 3))
+	assert.EqualValues(t, 15, command(cmdClosePath, 1))
+}
+
+func TestEncodeVectorTile_Points(t *testing.T) {
+	tile := Tile{Z: 1, X: 0, Y: 0}
+	data := EncodeVectorTile(tile, VectorLayer{
+		Name: "places",
+		Features: []VectorFeature{
+			{ID: 7, Point: models.Coordinates{Lat: 0, Lng: 0}, Properties: map[string]interface{}{
+				"name": "Null Island", "rating": float32(4.5), "open": true,
+			}},
+			{ID: 8, Point: models.Coordinates{Lat: 0, Lng: -90}, Properties: map[string]interface{}{
+				"name": "West", "visits": -3,
+			}},
+		},
+	}, VectorLayer{Name: "empty"})
+
+	layers := decodeLayers(t, data)
+	require.Len(t, layers, 1, "layers without features are left out")
+	places := layers["places"]
+	require.Len(t, places, 2)
+
+	// (0, 0) is the south-east corner of the north-west tile at zoom 1
+	first := places[0]
+	assert.EqualValues(t, 7, first.id)
+	assert.EqualValues(t, mvtPoint, first.kind)
+	assert.Equal(t, []uint64{9, zigzag(4096), zigzag(4096)}, first.geometry)
+	assert.Equal(t, "Null Island", string(places[0].props["name"].data))
+	assert.Equal(t, 2, places[0].props["rating"].num, "float")
+	assert.Equal(t, pbField{num: 7, value: 1}, places[0].props["open"])
+
+	second := places[1]
+	assert.Equal(t, []uint64{9, zigzag(2048), zigzag(4096)}, second.geometry)
+	assert.Equal(t, pbField{num: 6, value: zigzag(-3)}, second.props["visits"])
+}
+
+func TestEncodeVectorTile_Shapes(t *testing.T) {
+	tile := Tile{Z: 0, X: 0, Y: 0}
+
+	// Wound counter-clockwise on screen, so the outer ring gets reversed
+	square := []models.Position{{0, 0}, {90, 0}, {90, 60}, {0, 60}, {0, 0}}
+	line := models.NewLineGeometry([]models.Position{{0, 0}, {0.001, 0}, {90, 0}})
+	layers := decodeLayers(t, EncodeVectorTile(tile, VectorLayer{
+		Name: "shapes",
+		Features: []VectorFeature{
+			{Geometry: models.NewPolygonGeometry([][]models.Position{square})},
+			{Geometry: line},
+			{Geometry: models.NewPolygonGeometry([][]models.Position{{{0, 0}, {0.001, 0}, {0, 0.001}}})},
+		},
+	}))
+
+	shapes := layers["shapes"]
+	require.Len(t, shapes, 2, "a polygon too small to see is left out")
+
+	polygon := shapes[0]
+	assert.EqualValues(t, mvtPolygon, polygon.kind)
+	require.Len(t, polygon.geometry, 11)
+	assert.EqualValues(t, 9, polygon.geometry[0])
+	assert.EqualValues(t, command(cmdLineTo, 3), polygon.geometry[3])
+	assert.EqualValues(t, 15, polygon.geometry[10])
+
+	// Undo the deltas and check the ring runs clockwise with y down
+	var x, y, area int64
+	var ring [][2]int64
+	for _, i := range []int{1, 4, 6, 8} {
+		x += unzigzag(polygon.geometry[i])
+		y += unzigzag(polygon.geometry[i+1])
+		ring = append(ring, [2]int64{x, y})
+	}
+	assert.Equal(t, [2]int64{2048, 1189}, ring[0], "starts at the last corner once reversed")
+	for i, p := range ring {
+		q := ring[(i+1)%len(ring)]
+		area += p[0]*q[1] - q[0]*p[1]
+	}
+	assert.Positive(t, area)
+
+	// The point that lands on the one before it is dropped
+	assert.EqualValues(t, mvtLine, shapes[1].kind)
+	assert.Equal(t, []uint64{9, zigzag(2048), zigzag(2048), command(cmdLineTo, 1), zigzag(1024), 0}, shapes[1].geometry)
+}
+
+func unzigzag(v uint64) int64 {
+	return int64(v>>1) ^ -int64(v&1)
+}
@@ -0,0 +1,145 @@
+// Package tiles caches web map tiles in an MBTiles file, so the web map
+// keeps working without a network connection.
+package tiles
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MaxZoom is the deepest zoom level tiles are fetched or served at
+	MaxZoom = 22
+
+	// maxLat is the latitude Web Mercator tiles stop at
+	maxLat = 85.05112878
+)
+
+// Tile is a slippy map tile in the XYZ scheme used by Leaflet and
+// OpenStreetMap, with y counting down from the north
+type Tile struct {
+	Z, X, Y int
+}
+
+func (t Tile) String() string {
+	return fmt.Sprintf("%d/%d/%d", t.Z, t.X, t.Y)
+}
+
+// Valid reports whether the tile exists at its zoom level
+func (t Tile) Valid() bool {
+	if t.Z < 0 || t.Z > MaxZoom {
+		return false
+	}
+	n := 1 << t.Z
+	return t.X >= 0 && t.X < n && t.Y >= 0 && t.Y < n
+}
+
+// mercator projects a point onto the Web Mercator square, with x and y
+// from 0 to 1 starting at the north-west corner
+func mercator(lat, lng float64) (float64, float64) {
+	lat = math.Max(-maxLat, math.Min(maxLat, lat))
+	latRad := lat * math.Pi / 180
+	return (lng + 180) / 360, (1 - math.Asinh(math.Tan(latRad))/math.Pi) / 2
+}
+
+// TileAt returns the tile containing a point at zoom level z
+func TileAt(lat, lng float64, z int) Tile {
+	mx, my := mercator(lat, lng)
+	n := float64(int(1) << z)
+	x, y := int(mx*n), int(my*n)
+
+	limit := int(n) - 1
+	return Tile{Z: z, X: min(max(x, 0), limit), Y: min(max(y, 0), limit)}
+}
+
+// Bounds returns the area a tile covers
+func (t Tile) Bounds() BBox {
+	n := float64(int(1) << t.Z)
+	lng := func(x int) float64 { return float64(x)/n*360 - 180 }
+	lat := func(y int) float64 { return math.Atan(math.Sinh(math.Pi*(1-2*float64(y)/n))) * 180 / math.Pi }
+	return BBox{MinLng: lng(t.X), MinLat: lat(t.Y + 1), MaxLng: lng(t.X + 1), MaxLat: lat(t.Y)}
+}
+
+// BBox is an area given by its south-west and north-east corners
+type BBox struct {
+	MinLng, MinLat, MaxLng, MaxLat float64
+}
+
+// ParseBBox parses "minLng,minLat,maxLng,maxLat", the order used by GeoJSON
+// and most tile tools
+func ParseBBox(s string) (BBox, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return BBox{}, fmt.Errorf("invalid bbox %q: want minLng,minLat,maxLng,maxLat", s)
+	}
+	var v [4]float64
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return BBox{}, fmt.Errorf("invalid bbox %q: %q is not a number", s, part)
+		}
+		v[i] = f
+	}
+	b := BBox{MinLng: v[0], MinLat: v[1], MaxLng: v[2], MaxLat: v[3]}
+	switch {
+	case b.MinLng < -180 || b.MaxLng > 180 || b.MinLat < -90 || b.MaxLat > 90:
+		return BBox{}, fmt.Errorf("invalid bbox %q: out of range", s)
+	case b.MinLng > b.MaxLng || b.MinLat > b.MaxLat:
+		return BBox{}, fmt.Errorf("invalid bbox %q: minimum is greater than maximum", s)
+	}
+	return b, nil
+}
+
+// Contains reports whether a point lies in the box, edges included
+func (b BBox) Contains(lat, lng float64) bool {
+	return lat >= b.MinLat && lat <= b.MaxLat && lng >= b.MinLng && lng <= b.MaxLng
+}
+
+// ParseZoomRange parses a zoom level ("12") or range ("0-15")
+func ParseZoomRange(s string) (int, int, error) {
+	lo, hi, isRange := strings.Cut(s, "-")
+	minZoom, err := strconv.Atoi(strings.TrimSpace(lo))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid zoom %q", s)
+	}
+	maxZoom := minZoom
+	if isRange {
+		if maxZoom, err = strconv.Atoi(strings.TrimSpace(hi)); err != nil {
+			return 0, 0, fmt.Errorf("invalid zoom %q", s)
+		}
+	}
+	if minZoom < 0 || maxZoom > MaxZoom || minZoom > maxZoom {
+		return 0, 0, fmt.Errorf("invalid zoom %q: levels go from 0 to %d", s, MaxZoom)
+	}
+	return minZoom, maxZoom, nil
+}
+
+// Covering returns the tiles that cover a box at each zoom level from
+// minZoom to maxZoom, lowest zoom first
+func Covering(b BBox, minZoom, maxZoom int) []Tile {
+	var tiles []Tile
+	for z := minZoom; z <= maxZoom; z++ {
+		nw := TileAt(b.MaxLat, b.MinLng, z)
+		se := TileAt(b.MinLat, b.MaxLng, z)
+		for x := nw.X; x <= se.X; x++ {
+			for y := nw.Y; y <= se.Y; y++ {
+				tiles = append(tiles, Tile{Z: z, X: x, Y: y})
+			}
+		}
+	}
+	return tiles
+}
+
+// CountCovering returns len(Covering(b, minZoom, maxZoom)) without listing
+// the tiles, to check a download's size before starting it
+func CountCovering(b BBox, minZoom, maxZoom int) int {
+	count := 0
+	for z := minZoom; z <= maxZoom; z++ {
+		nw := TileAt(b.MaxLat, b.MinLng, z)
+		se := TileAt(b.MinLat, b.MaxLng, z)
+		count += (se.X - nw.X + 1) * (se.Y - nw.Y + 1)
+	}
+	return count
+}
@@ -0,0 +1,90 @@
+package tiles
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTileAt(t *testing.T) {
+	tests := []struct {
+		name     string
+		lat, lng float64
+		z        int
+		want     Tile
+	}{
+		{"World", 38.72, -9.14, 0, Tile{0, 0, 0}},
+		{"Lisbon", 38.72, -9.14, 12, Tile{12, 1944, 1569}},
+		{"Sydney", -33.87, 151.21, 10, Tile{10, 942, 614}},
+		{"Beyond the pole", 89.9, 0, 3, Tile{3, 4, 0}},
+		{"Date line", 0, 180, 2, Tile{2, 3, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, TileAt(tt.lat, tt.lng, tt.z))
+		})
+	}
+}
+
+func TestTile_Bounds(t *testing.T) {
+	tile := TileAt(38.72, -9.14, 12)
+	b := tile.Bounds()
+	assert.True(t, b.Contains(38.72, -9.14))
+	assert.Less(t, b.MaxLng-b.MinLng, 0.1)
+
+	world := Tile{}.Bounds()
+	assert.InDelta(t, -180, world.MinLng, 1e-9)
+	assert.InDelta(t, maxLat, world.MaxLat, 1e-6)
+}
+
+func TestTile_Valid(t *testing.T) {
+	assert.True(t, Tile{0, 0, 0}.Valid())
+	assert.True(t, Tile{2, 3, 3}.Valid())
+	assert.False(t, Tile{2, 4, 0}.Valid())
+	assert.False(t, Tile{-1, 0, 0}.Valid())
+	assert.False(t, Tile{MaxZoom + 1, 0, 0}.Valid())
+}
+
+func TestParseBBox(t *testing.T) {
+	b, err := ParseBBox("-9.23, 38.69,-9.09,38.80")
+	require.NoError(t, err)
+	assert.Equal(t, BBox{MinLng: -9.23, MinLat: 38.69, MaxLng: -9.09, MaxLat: 38.80}, b)
+
+	for _, s := range []string{"", "1,2,3", "a,b,c,d", "0,0,200,10", "10,0,0,10", "0,10,10,0", "0,0,NaN,1"} {
+		_, err := ParseBBox(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestParseZoomRange(t *testing.T) {
+	lo, hi, err := ParseZoomRange("0-15")
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 15}, []int{lo, hi})
+
+	lo, hi, err = ParseZoomRange("12")
+	require.NoError(t, err)
+	assert.Equal(t, []int{12, 12}, []int{lo, hi})
+
+	for _, s := range []string{"", "x", "5-2", "0-30", "-1", "3-"} {
+		_, _, err := ParseZoomRange(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestCovering(t *testing.T) {
+	b := BBox{MinLng: -9.23, MinLat: 38.69, MaxLng: -9.09, MaxLat: 38.80}
+
+	tiles := Covering(b, 0, 14)
+	assert.Len(t, tiles, CountCovering(b, 0, 14))
+	assert.Equal(t, Tile{0, 0, 0}, tiles[0])
+
+	for _, tile := range tiles {
+		assert.True(t, tile.Valid())
+		bounds := tile.Bounds()
+		overlaps := bounds.MinLng <= b.MaxLng && bounds.MaxLng >= b.MinLng &&
+			bounds.MinLat <= b.MaxLat && bounds.MaxLat >= b.MinLat
+		assert.True(t, overlaps, tile.String())
+	}
+}
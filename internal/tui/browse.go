@@ -3,6 +3,7 @@ package tui
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -42,6 +43,7 @@ type BrowseModel struct {
 	tagMode     bool
 	tagInput    string
 	tagAction   string // "add" or "remove"
+	openNow     bool
 }
 
 func NewBrowseModel(db *database.DB) BrowseModel {
@@ -57,17 +59,22 @@ func (m BrowseModel) Init() tea.Cmd {
 
 func (m BrowseModel) loadPlaces() tea.Cmd {
 	return tea.Cmd(func() tea.Msg {
+		var places []*models.Place
+		var err error
 		if m.search != "" {
-			places, err := m.db.SearchPlaces(m.search)
-			if err != nil {
-				return errMsg{err}
-			}
-			return placesLoadedMsg{places}
+			places, err = m.db.SearchPlaces(m.search)
+		} else {
+			places, err = m.db.ListPlaces(1000, 0) // Increased from hardcoded 100
 		}
-		places, err := m.db.ListPlaces(1000, 0) // Increased from hardcoded 100
 		if err != nil {
 			return errMsg{err}
 		}
+		if m.openNow {
+			now := time.Now()
+			places = models.FilterPlaces(places, func(p *models.Place) bool {
+				return p.IsOpenAt(now)
+			})
+		}
 		return placesLoadedMsg{places}
 	})
 }
@@ -174,6 +181,15 @@ func (m BrowseModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "r":
 			return m, m.loadPlaces()
 
+		case "o":
+			m.openNow = !m.openNow
+			if m.openNow {
+				m.message = "Showing places open now"
+			} else {
+				m.message = "Showing all places"
+			}
+			return m, m.loadPlaces()
+
 		case "t":
 			// Add tag to selected places
 			if len(m.selected) > 0 {
@@ -284,6 +300,9 @@ func (m BrowseModel) View() string {
 	} else if m.search != "" {
 		b.WriteString(fmt.Sprintf("🔍 Active search: %s (press 'c' to clear)\n\n", m.search))
 	}
+	if m.openNow {
+		b.WriteString("🕒 Open now only (press 'o' to show all)\n\n")
+	}
 
 	// Tag interface
 	if m.tagMode {
@@ -367,7 +386,7 @@ func (m BrowseModel) View() string {
 		help := helpStyle.Render("Type tag name • enter confirm • esc cancel")
 		b.WriteString(fmt.Sprintf("\n%s", help))
 	} else {
-		help := helpStyle.Render("↑/k up • ↓/j down • enter/space select • t add tag • T remove tag • g top • G bottom • / search • c clear • r refresh • q quit")
+		help := helpStyle.Render("↑/k up • ↓/j down • enter/space select • t add tag • T remove tag • g top • G bottom • / search • c clear • o open now • r refresh • q quit")
 		b.WriteString(fmt.Sprintf("\n%s", help))
 	}
 
@@ -416,6 +435,9 @@ func (m BrowseModel) calculateViewport() (start, end int) {
 	if m.searchMode || m.search != "" {
 		usedLines += 3 // search interface
 	}
+	if m.openNow {
+		usedLines += 2 // open-now filter
+	}
 	if m.tagMode {
 		usedLines += 3 // tag interface
 	}
@@ -34,6 +34,13 @@ type BoundingBox struct {
 	MinLng, MaxLng float64
 }
 
+func (b *BoundingBox) extend(c models.Coordinates) {
+	b.MinLat = math.Min(b.MinLat, c.Lat)
+	b.MaxLat = math.Max(b.MaxLat, c.Lat)
+	b.MinLng = math.Min(b.MinLng, c.Lng)
+	b.MaxLng = math.Max(b.MaxLng, c.Lng)
+}
+
 // Point represents a screen coordinate
 type Point struct {
 	X, Y int
@@ -86,6 +93,9 @@ func (mv *MapView) Render() string {
 	// Draw grid lines
 	mv.drawGrid(grid)
 
+	// Outline areas and routes under the markers
+	mv.drawShapes(grid)
+
 	// Place markers
 	markers := mv.createMarkers()
 	mv.placeMarkers(grid, markers)
@@ -133,17 +143,11 @@ func (mv *MapView) getBounds() BoundingBox {
 	}
 
 	for _, place := range mv.Places {
-		if place.Coordinates.Lat < bounds.MinLat {
-			bounds.MinLat = place.Coordinates.Lat
-		}
-		if place.Coordinates.Lat > bounds.MaxLat {
-			bounds.MaxLat = place.Coordinates.Lat
-		}
-		if place.Coordinates.Lng < bounds.MinLng {
-			bounds.MinLng = place.Coordinates.Lng
-		}
-		if place.Coordinates.Lng > bounds.MaxLng {
-			bounds.MaxLng = place.Coordinates.Lng
+		bounds.extend(place.Coordinates)
+		if place.Geometry != nil {
+			min, max := place.Geometry.Bounds()
+			bounds.extend(min)
+			bounds.extend(max)
 		}
 	}
 
@@ -321,6 +325,54 @@ func (mv *MapView) drawGrid(grid [][]rune) {
 	}
 }
 
+// drawShapes traces route lines with '·' and area outlines with '░'
+func (mv *MapView) drawShapes(grid [][]rune) {
+	for _, place := range mv.Places {
+		if place.Geometry == nil {
+			continue
+		}
+		symbol := '·'
+		if place.Geometry.IsArea() {
+			symbol = '░'
+		}
+		for _, path := range place.Geometry.Paths() {
+			for i := 1; i < len(path); i++ {
+				from := mv.coordToScreen(path[i-1][1], path[i-1][0])
+				to := mv.coordToScreen(path[i][1], path[i][0])
+				mv.drawSegment(grid, from, to, symbol)
+			}
+		}
+	}
+}
+
+// drawSegment draws a straight line between two screen points, clipped to
+// the inside of the border
+func (mv *MapView) drawSegment(grid [][]rune, from, to Point, symbol rune) {
+	// Skip segments entirely off one side of the map
+	if (from.X < 1 && to.X < 1) || (from.X >= mv.Width-1 && to.X >= mv.Width-1) ||
+		(from.Y < 1 && to.Y < 1) || (from.Y >= mv.Height-1 && to.Y >= mv.Height-1) {
+		return
+	}
+
+	dx, dy := to.X-from.X, to.Y-from.Y
+	steps := int(math.Max(math.Abs(float64(dx)), math.Abs(float64(dy))))
+	// Very long segments at high zoom are sampled rather than walked cell by cell
+	if limit := 4 * (mv.Width + mv.Height); steps > limit {
+		steps = limit
+	}
+	if steps == 0 {
+		steps = 1
+	}
+
+	for i := 0; i <= steps; i++ {
+		x := from.X + int(math.Round(float64(dx*i)/float64(steps)))
+		y := from.Y + int(math.Round(float64(dy*i)/float64(steps)))
+		if x > 0 && x < mv.Width-1 && y > 0 && y < mv.Height-1 {
+			grid[y][x] = symbol
+		}
+	}
+}
+
 // placeMarkers places all markers on the grid
 func (mv *MapView) placeMarkers(grid [][]rune, markers []PlaceMarker) {
 	for _, marker := range markers {
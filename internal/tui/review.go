@@ -3,6 +3,7 @@ package tui
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -51,6 +52,7 @@ type ReviewModel struct {
 	search      string
 	searchMode  bool
 	searchInput string
+	visits      map[string]models.VisitSummary
 }
 
 func NewReviewModel(db *database.DB) ReviewModel {
@@ -61,7 +63,17 @@ func NewReviewModel(db *database.DB) ReviewModel {
 }
 
 func (m ReviewModel) Init() tea.Cmd {
-	return m.loadPlaces()
+	return tea.Batch(m.loadPlaces(), m.loadVisits())
+}
+
+func (m ReviewModel) loadVisits() tea.Cmd {
+	return tea.Cmd(func() tea.Msg {
+		summaries, err := m.db.GetVisitSummaries()
+		if err != nil {
+			return errMsg{err}
+		}
+		return visitsLoadedMsg{summaries}
+	})
 }
 
 func (m ReviewModel) loadPlaces() tea.Cmd {
@@ -106,6 +118,16 @@ func (m ReviewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case saveSuccessMsg:
 		m.message = "Saved successfully"
 
+	case visitsLoadedMsg:
+		m.visits = msg.summaries
+
+	case visitLoggedMsg:
+		m.message = "Visit logged"
+		m.mode = ReviewModeDetail
+		m.editField = ""
+		m.editValue = ""
+		return m, m.loadVisits()
+
 	case deleteSuccessMsg:
 		m.message = "Place deleted"
 		m.mode = ReviewModeList
@@ -254,6 +276,11 @@ func (m ReviewModel) updateDetail(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.editField = "website"
 		m.editValue = m.current.Website
 
+	case "v":
+		m.mode = ReviewModeEdit
+		m.editField = "visit"
+		m.editValue = ""
+
 	case "d":
 		return m, m.deletePlace()
 	}
@@ -287,6 +314,10 @@ func (m ReviewModel) updateEdit(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 }
 
 func (m ReviewModel) saveEdit() tea.Cmd {
+	if m.editField == "visit" {
+		return m.logVisit()
+	}
+
 	return tea.Cmd(func() tea.Msg {
 		switch m.editField {
 		case "notes":
@@ -302,7 +333,7 @@ func (m ReviewModel) saveEdit() tea.Cmd {
 				m.current.UserTags = tags
 			}
 		case "hours":
-			m.current.Hours = m.editValue
+			m.current.SetHours(m.editValue)
 		case "phone":
 			m.current.Phone = m.editValue
 		case "website":
@@ -336,8 +367,30 @@ func (m ReviewModel) deletePlace() tea.Cmd {
 	})
 }
 
+// logVisit records a visit to the current place now, with the edit value
+// as its note
+func (m ReviewModel) logVisit() tea.Cmd {
+	return tea.Cmd(func() tea.Msg {
+		visit := &models.Visit{
+			PlaceID:   m.current.ID,
+			VisitedAt: time.Now(),
+			Note:      strings.TrimSpace(m.editValue),
+			Source:    "manual",
+		}
+		if _, err := m.db.AddVisit(visit); err != nil {
+			return errMsg{err}
+		}
+		return visitLoggedMsg{}
+	})
+}
+
 type saveSuccessMsg struct{}
 type deleteSuccessMsg struct{}
+type visitLoggedMsg struct{}
+
+type visitsLoadedMsg struct {
+	summaries map[string]models.VisitSummary
+}
 
 func (m ReviewModel) View() string {
 	switch m.mode {
@@ -439,7 +492,15 @@ func (m ReviewModel) viewDetail() string {
 	}
 
 	if m.current.Hours != "" {
-		content += fmt.Sprintf("%s %s\n", fieldStyle.Render("Hours:"), valueStyle.Render(m.current.Hours))
+		hours := m.current.Hours
+		if m.current.GetOpeningHours() != nil {
+			if m.current.IsOpenAt(time.Now()) {
+				hours += " (open now)"
+			} else {
+				hours += " (closed now)"
+			}
+		}
+		content += fmt.Sprintf("%s %s\n", fieldStyle.Render("Hours:"), valueStyle.Render(hours))
 	}
 
 	// Photos
@@ -448,6 +509,19 @@ func (m ReviewModel) viewDetail() string {
 			fieldStyle.Render("Photos:"), len(m.current.Photos))
 	}
 
+	// Attachments
+	if len(m.current.Attachments) > 0 {
+		content += fmt.Sprintf("%s %d files\n",
+			fieldStyle.Render("Attachments:"), len(m.current.Attachments))
+		for _, a := range m.current.Attachments {
+			line := a.Name
+			if a.TakenAt != nil {
+				line += " · " + a.TakenAt.Format("2006-01-02")
+			}
+			content += fmt.Sprintf("  %s\n", valueStyle.Render(line))
+		}
+	}
+
 	// Reviews
 	if len(m.current.Reviews) > 0 {
 		content += fmt.Sprintf("%s %d reviews\n",
@@ -481,11 +555,21 @@ func (m ReviewModel) viewDetail() string {
 		content += fmt.Sprintf("%s %s\n", fieldStyle.Render("Tags:"), valueStyle.Render("(none)"))
 	}
 
+	if summary, ok := m.visits[m.current.ID]; ok {
+		visits := fmt.Sprintf("%d, last %s", summary.Count, summary.Last.Local().Format("2006-01-02"))
+		if summary.Count > 1 {
+			visits += fmt.Sprintf(", first %s", summary.First.Local().Format("2006-01-02"))
+		}
+		content += fmt.Sprintf("%s %s\n", fieldStyle.Render("Visits:"), valueStyle.Render(visits))
+	} else {
+		content += fmt.Sprintf("%s %s\n", fieldStyle.Render("Visits:"), valueStyle.Render("(none)"))
+	}
+
 	box := detailBoxStyle.Render(content)
 	b.WriteString(box)
 
 	b.WriteString("\n\n")
-	help := helpStyle.Render("← → navigate • [n]otes • [t]ags • h[o]urs • [p]hone • [w]ebsite • [d]elete • esc back • q quit")
+	help := helpStyle.Render("← → navigate • [n]otes • [t]ags • h[o]urs • [p]hone • [w]ebsite • log [v]isit • [d]elete • esc back • q quit")
 	b.WriteString(help)
 
 	return b.String()
@@ -502,6 +586,9 @@ func (m ReviewModel) viewEdit() string {
 	if m.editField == "tags" {
 		prompt += " (comma-separated)"
 	}
+	if m.editField == "visit" {
+		prompt = "Log a visit now. Note (optional):"
+	}
 
 	content := fmt.Sprintf("%s\n\n%s", prompt, m.editValue+"_")
 	box := detailBoxStyle.Render(content)
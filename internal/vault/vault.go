@@ -0,0 +1,353 @@
+package vault
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/user/placeli/internal/constants"
+	"github.com/user/placeli/internal/database"
+	"github.com/user/placeli/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// stateFileName is the file inside the vault that remembers what was synced
+const stateFileName = ".placeli-sync.json"
+
+// ConflictPolicy decides what happens when a place changed both in the
+// database and in its note since the last sync
+type ConflictPolicy string
+
+const (
+	// ConflictSkip leaves both sides untouched and reports the conflict
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictPreferDB overwrites the note with the database version
+	ConflictPreferDB ConflictPolicy = "db"
+	// ConflictPreferFile overwrites the database with the note version
+	ConflictPreferFile ConflictPolicy = "file"
+)
+
+// Options controls a vault sync run
+type Options struct {
+	OnConflict ConflictPolicy
+	DryRun     bool
+}
+
+// Result summarizes what a sync run did
+type Result struct {
+	Written   int
+	Imported  int
+	Unchanged int
+	Conflicts []string
+}
+
+// FrontMatter is the YAML header of a place note
+type FrontMatter struct {
+	ID           string                 `yaml:"id"`
+	PlaceID      string                 `yaml:"place_id,omitempty"`
+	Name         string                 `yaml:"name"`
+	Address      string                 `yaml:"address,omitempty"`
+	Coordinates  []float64              `yaml:"coordinates,flow"`
+	Categories   []string               `yaml:"categories,omitempty,flow"`
+	Tags         []string               `yaml:"tags"`
+	CustomFields map[string]interface{} `yaml:"custom_fields,omitempty"`
+}
+
+// noteState records the last synced version of a single note
+type noteState struct {
+	File      string    `json:"file"`
+	ModTime   time.Time `json:"mod_time"`
+	Hash      string    `json:"hash"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type syncState struct {
+	Notes map[string]noteState `json:"notes"`
+}
+
+// Sync performs a two-way sync between the database and a directory of
+// Markdown notes, one note per place
+func Sync(db *database.DB, dir string, opts Options) (*Result, error) {
+	if opts.OnConflict == "" {
+		opts.OnConflict = ConflictSkip
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create vault directory: %w", err)
+	}
+
+	state, err := loadState(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	places, err := db.ListPlaces(constants.DefaultExportLimit, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve places: %w", err)
+	}
+
+	result := &Result{}
+
+	for _, place := range places {
+		prev, known := state.Notes[place.ID]
+		if !known {
+			prev.File = NoteFileName(place)
+		}
+		path := filepath.Join(dir, prev.File)
+
+		info, statErr := os.Stat(path)
+		if statErr != nil && !os.IsNotExist(statErr) {
+			return nil, fmt.Errorf("failed to stat %s: %w", path, statErr)
+		}
+
+		// New place, or its note was removed: (re)create the note
+		if !known || os.IsNotExist(statErr) {
+			if err := writeNote(dir, place, &prev, opts.DryRun); err != nil {
+				return nil, err
+			}
+			state.Notes[place.ID] = prev
+			result.Written++
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		fileChanged := !info.ModTime().Equal(prev.ModTime) && hashBytes(data) != prev.Hash
+		dbChanged := !place.UpdatedAt.Equal(prev.UpdatedAt)
+
+		switch {
+		case fileChanged && dbChanged && opts.OnConflict == ConflictSkip:
+			result.Conflicts = append(result.Conflicts, prev.File)
+			continue
+		case fileChanged && (!dbChanged || opts.OnConflict == ConflictPreferFile):
+			if err := readNote(db, place, data, &prev, info.ModTime(), opts.DryRun); err != nil {
+				return nil, fmt.Errorf("failed to import %s: %w", prev.File, err)
+			}
+			result.Imported++
+		case dbChanged:
+			if err := writeNote(dir, place, &prev, opts.DryRun); err != nil {
+				return nil, err
+			}
+			result.Written++
+		default:
+			result.Unchanged++
+			continue
+		}
+
+		state.Notes[place.ID] = prev
+	}
+
+	if opts.DryRun {
+		return result, nil
+	}
+
+	if err := saveState(dir, state); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// NoteFileName returns the note file name used for a newly synced place
+func NoteFileName(place *models.Place) string {
+	name := unsafeFileChars.ReplaceAllString(place.Name, "")
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "Untitled"
+	}
+	// Cut by characters, so a multibyte name stays valid UTF-8
+	if runes := []rune(name); len(runes) > 80 {
+		name = strings.TrimSpace(string(runes[:80]))
+	}
+	return fmt.Sprintf("%s (%s).md", name, place.ID)
+}
+
+var unsafeFileChars = regexp.MustCompile(`[\\/:*?"<>|#^\[\]]`)
+
+// RenderNote renders a place as a Markdown note with YAML front-matter
+func RenderNote(place *models.Place) ([]byte, error) {
+	fm := FrontMatter{
+		ID:           place.ID,
+		PlaceID:      place.PlaceID,
+		Name:         place.Name,
+		Address:      place.Address,
+		Coordinates:  []float64{place.Coordinates.Lat, place.Coordinates.Lng},
+		Categories:   place.Categories,
+		Tags:         place.UserTags,
+		CustomFields: place.CustomFields,
+	}
+	if fm.Tags == nil {
+		fm.Tags = []string{}
+	}
+
+	header, err := yaml.Marshal(&fm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode front-matter: %w", err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	buf.Write(header)
+	buf.WriteString("---\n\n")
+	if place.UserNotes != "" {
+		buf.WriteString(strings.TrimRight(place.UserNotes, "\n"))
+		buf.WriteString("\n")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ParseNote splits a note into its front-matter and notes body
+func ParseNote(data []byte) (*FrontMatter, string, error) {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if !strings.HasPrefix(text, "---\n") {
+		return nil, "", fmt.Errorf("note has no front-matter")
+	}
+
+	rest := text[len("---\n"):]
+	end := strings.Index(rest, "\n---")
+	if end < 0 {
+		return nil, "", fmt.Errorf("front-matter is not terminated")
+	}
+
+	var fm FrontMatter
+	if err := yaml.Unmarshal([]byte(rest[:end]), &fm); err != nil {
+		return nil, "", fmt.Errorf("failed to parse front-matter: %w", err)
+	}
+
+	body := rest[end+len("\n---"):]
+	body = strings.TrimPrefix(body, "\n")
+	body = strings.Trim(body, "\n")
+
+	return &fm, body, nil
+}
+
+func writeNote(dir string, place *models.Place, st *noteState, dryRun bool) error {
+	data, err := RenderNote(place)
+	if err != nil {
+		return err
+	}
+
+	st.Hash = hashBytes(data)
+	st.UpdatedAt = place.UpdatedAt
+	if dryRun {
+		return nil
+	}
+
+	path := filepath.Join(dir, st.File)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write note %s: %w", path, err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat note %s: %w", path, err)
+	}
+	st.ModTime = info.ModTime()
+
+	return nil
+}
+
+func readNote(db *database.DB, place *models.Place, data []byte, st *noteState, modTime time.Time, dryRun bool) error {
+	fm, body, err := ParseNote(data)
+	if err != nil {
+		return err
+	}
+	if fm.ID != "" && fm.ID != place.ID {
+		return fmt.Errorf("note id %q does not match place %q", fm.ID, place.ID)
+	}
+
+	place.UserNotes = body
+	place.UserTags = fm.Tags
+	if place.UserTags == nil {
+		place.UserTags = []string{}
+	}
+	place.CustomFields = normalizeFields(fm.CustomFields)
+
+	st.Hash = hashBytes(data)
+	st.ModTime = modTime
+	if dryRun {
+		return nil
+	}
+
+	if err := db.SavePlace(place); err != nil {
+		return err
+	}
+	st.UpdatedAt = place.UpdatedAt
+
+	return nil
+}
+
+// normalizeFields round-trips YAML values through JSON so they have the
+// same shape as custom fields loaded from the database
+func normalizeFields(fields map[string]interface{}) map[string]interface{} {
+	if len(fields) == 0 {
+		return map[string]interface{}{}
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return fields
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fields
+	}
+	return result
+}
+
+func loadState(dir string) (*syncState, error) {
+	state := &syncState{Notes: make(map[string]noteState)}
+
+	data, err := os.ReadFile(filepath.Join(dir, stateFileName))
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sync state: %w", err)
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse sync state: %w", err)
+	}
+	if state.Notes == nil {
+		state.Notes = make(map[string]noteState)
+	}
+
+	return state, nil
+}
+
+func saveState(dir string, state *syncState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode sync state: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, stateFileName), data, 0644); err != nil {
+		return fmt.Errorf("failed to write sync state: %w", err)
+	}
+
+	return nil
+}
+
+func hashBytes(data []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+// SortedConflicts returns the conflicting note names in a stable order
+func (r *Result) SortedConflicts() []string {
+	conflicts := append([]string(nil), r.Conflicts...)
+	sort.Strings(conflicts)
+	return conflicts
+}
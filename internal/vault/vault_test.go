@@ -0,0 +1,198 @@
+package vault
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/placeli/internal/database"
+	"github.com/user/placeli/internal/models"
+)
+
+func setupTestDB(t *testing.T) *database.DB {
+	db, err := database.New(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	place := &models.Place{
+		ID:          "place1",
+		PlaceID:     "ChIJtest",
+		Name:        "Café Lisboa",
+		Address:     "Rua Augusta 1, Lisbon",
+		Coordinates: models.Coordinates{Lat: 38.7101, Lng: -9.1366},
+		Categories:  []string{"Cafe"},
+		UserNotes:   "Try the pastel de nata",
+		UserTags:    []string{"lisbon"},
+		CustomFields: map[string]interface{}{
+			"priority": "high",
+		},
+	}
+	require.NoError(t, db.SavePlace(place))
+
+	return db
+}
+
+// touchLater bumps the modification time so edits within the same clock
+// tick are still seen as changes
+func touchLater(t *testing.T, path string) {
+	later := time.Now().Add(2 * time.Second)
+	require.NoError(t, os.Chtimes(path, later, later))
+}
+
+func TestRenderAndParseNote(t *testing.T) {
+	place := &models.Place{
+		ID:           "abc",
+		Name:         "Test Place",
+		Coordinates:  models.Coordinates{Lat: 1.5, Lng: 2.5},
+		UserNotes:    "Line one\nLine two",
+		UserTags:     []string{"a", "b"},
+		CustomFields: map[string]interface{}{"visited": true},
+	}
+
+	data, err := RenderNote(place)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), "---\n"))
+
+	fm, body, err := ParseNote(data)
+	require.NoError(t, err)
+	assert.Equal(t, "abc", fm.ID)
+	assert.Equal(t, "Test Place", fm.Name)
+	assert.Equal(t, []float64{1.5, 2.5}, fm.Coordinates)
+	assert.Equal(t, []string{"a", "b"}, fm.Tags)
+	assert.Equal(t, true, fm.CustomFields["visited"])
+	assert.Equal(t, "Line one\nLine two", body)
+}
+
+func TestParseNote_Invalid(t *testing.T) {
+	_, _, err := ParseNote([]byte("no front-matter here"))
+	assert.Error(t, err)
+
+	_, _, err = ParseNote([]byte("---\nid: x\n"))
+	assert.Error(t, err)
+}
+
+func TestNoteFileName(t *testing.T) {
+	place := &models.Place{ID: "id1", Name: "A/B: C?"}
+	assert.Equal(t, "AB C (id1).md", NoteFileName(place))
+
+	place.Name = ""
+	assert.Equal(t, "Untitled (id1).md", NoteFileName(place))
+
+	place.Name = strings.Repeat("é", 79) + "東京"
+	name := NoteFileName(place)
+	assert.True(t, utf8.ValidString(name))
+	assert.Equal(t, strings.Repeat("é", 79)+"東 (id1).md", name)
+}
+
+func TestSync_WritesNotes(t *testing.T) {
+	db := setupTestDB(t)
+	dir := t.TempDir()
+
+	result, err := Sync(db, dir, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Written)
+
+	data, err := os.ReadFile(filepath.Join(dir, "Café Lisboa (place1).md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "id: place1")
+	assert.Contains(t, string(data), "Try the pastel de nata")
+
+	// A second run without changes does nothing
+	result, err = Sync(db, dir, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Written)
+	assert.Equal(t, 1, result.Unchanged)
+}
+
+func TestSync_ReadsEditsBack(t *testing.T) {
+	db := setupTestDB(t)
+	dir := t.TempDir()
+
+	_, err := Sync(db, dir, Options{})
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "Café Lisboa (place1).md")
+	edited := `---
+id: place1
+name: Café Lisboa
+coordinates: [38.7101, -9.1366]
+tags: [lisbon, breakfast]
+custom_fields:
+  priority: low
+  visits: 3
+---
+
+Edited in Obsidian
+`
+	require.NoError(t, os.WriteFile(path, []byte(edited), 0644))
+	touchLater(t, path)
+
+	result, err := Sync(db, dir, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Imported)
+
+	place, err := db.GetPlace("place1")
+	require.NoError(t, err)
+	assert.Equal(t, "Edited in Obsidian", place.UserNotes)
+	assert.Equal(t, []string{"lisbon", "breakfast"}, place.UserTags)
+	assert.Equal(t, "low", place.CustomFields["priority"])
+	assert.Equal(t, float64(3), place.CustomFields["visits"])
+
+	// Importing does not immediately rewrite the note
+	result, err = Sync(db, dir, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Unchanged)
+}
+
+func TestSync_Conflict(t *testing.T) {
+	db := setupTestDB(t)
+	dir := t.TempDir()
+
+	_, err := Sync(db, dir, Options{})
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "Café Lisboa (place1).md")
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, []byte(strings.Replace(string(data), "pastel de nata", "bifana", 1)), 0644))
+	touchLater(t, path)
+
+	place, err := db.GetPlace("place1")
+	require.NoError(t, err)
+	place.UserNotes = "Changed in placeli"
+	require.NoError(t, db.SavePlace(place))
+
+	result, err := Sync(db, dir, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Café Lisboa (place1).md"}, result.Conflicts)
+
+	place, err = db.GetPlace("place1")
+	require.NoError(t, err)
+	assert.Equal(t, "Changed in placeli", place.UserNotes)
+
+	result, err = Sync(db, dir, Options{OnConflict: ConflictPreferFile})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Imported)
+
+	place, err = db.GetPlace("place1")
+	require.NoError(t, err)
+	assert.Equal(t, "Try the bifana", place.UserNotes)
+}
+
+func TestSync_DryRun(t *testing.T) {
+	db := setupTestDB(t)
+	dir := t.TempDir()
+
+	result, err := Sync(db, dir, Options{DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Written)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
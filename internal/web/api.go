@@ -0,0 +1,754 @@
+package web
+
+import (
+	"bytes"
+	"crypto/rand"
+	"database/sql"
+	_ "embed"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/user/placeli/internal/database"
+	"github.com/user/placeli/internal/logger"
+	"github.com/user/placeli/internal/models"
+	"github.com/user/placeli/internal/utils"
+)
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+
+	// maxBodySize bounds request bodies; places are small
+	maxBodySize = 1 << 20
+)
+
+type apiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// listResponse is the envelope of every list endpoint
+type listResponse struct {
+	Data       interface{} `json:"data"`
+	Total      int         `json:"total"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+type tagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+type customField struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+}
+
+// registerAPI adds the versioned REST API. Handlers switch on the method
+// themselves so that every error, including 405s, has a JSON body.
+func (s *Server) registerAPI(mux *http.ServeMux) {
+	mux.HandleFunc("/api/v1/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "not_found", "no such endpoint: %s", r.URL.Path)
+	})
+	mux.HandleFunc("/api/v1/openapi.json", s.handleOpenAPI)
+	mux.HandleFunc("/api/v1/places", s.handleV1Places)
+	mux.HandleFunc("/api/v1/places/{id}", s.handleV1Place)
+	mux.HandleFunc("/api/v1/places/{id}/tags", s.handleV1PlaceTags)
+	mux.HandleFunc("/api/v1/places/{id}/tags/{tag}", s.handleV1PlaceTag)
+	mux.HandleFunc("/api/v1/places/{id}/fields", s.handleV1PlaceFields)
+	mux.HandleFunc("/api/v1/places/{id}/fields/{name}", s.handleV1PlaceField)
+	mux.HandleFunc("/api/v1/tags", s.handleV1Tags)
+	mux.HandleFunc("/api/v1/tags/{tag}/places", s.handleV1TagPlaces)
+	mux.HandleFunc("/api/v1/events", s.handleV1Events)
+	mux.HandleFunc("/api/v1/stats", s.handleV1Stats)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Error("Failed to encode response", "error", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, code, format string, args ...interface{}) {
+	writeJSON(w, status, struct {
+		Error apiError `json:"error"`
+	}{apiError{Code: code, Message: fmt.Sprintf(format, args...)}})
+}
+
+func methodNotAllowed(w http.ResponseWriter, r *http.Request, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "method %s not allowed", r.Method)
+}
+
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		methodNotAllowed(w, r, http.MethodGet)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(openAPISpec)
+}
+
+func (s *Server) handleV1Places(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.listPlacesV1(w, r)
+	case http.MethodPost:
+		s.createPlaceV1(w, r)
+	default:
+		methodNotAllowed(w, r, http.MethodGet, http.MethodPost)
+	}
+}
+
+// listPlacesV1 lists places sorted by name, filtered by search, tag,
+// category, open_now, open_at and inside
+func (s *Server) listPlacesV1(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit, after, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	openNow, err := boolParam(query.Get("open_now"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_parameter", "open_now must be true or false")
+		return
+	}
+	openMatch, err := models.OpenMatcher(openNow, query.Get("open_at"), time.Now())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_parameter", "invalid open_at: %v", err)
+		return
+	}
+
+	var insideMatch func(*models.Place) bool
+	if inside := query.Get("inside"); inside != "" {
+		area, ok := s.loadPlace(w, r, inside)
+		if !ok {
+			return
+		}
+		if insideMatch, err = models.InsideMatcher(area); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_parameter", "invalid inside: %v", err)
+			return
+		}
+	}
+
+	var tagMatch, categoryMatch func(*models.Place) bool
+	if tag := query.Get("tag"); tag != "" {
+		tagMatch = func(p *models.Place) bool { return p.HasTag(tag) || p.HasSharedTag(tag) }
+	}
+	if category := query.Get("category"); category != "" {
+		categoryMatch = func(p *models.Place) bool {
+			for _, c := range p.Categories {
+				if strings.EqualFold(c, category) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	// Walk every place, keeping only the page after the cursor, so the
+	// total and the pages cover the whole library
+	match := models.MatchAll(openMatch, insideMatch, tagMatch, categoryMatch)
+	page := placePage{after: after, limit: limit}
+	err = s.dbFor(r).ForEachPlace(query.Get("search"), func(place *models.Place) error {
+		if match == nil || match(place) {
+			page.add(place)
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("Failed to fetch places", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "failed to fetch places")
+		return
+	}
+
+	places, next := page.result()
+	writeJSON(w, http.StatusOK, listResponse{Data: places, Total: page.total, NextCursor: next})
+}
+
+// placePage collects one page of places sorted by placeKey from places
+// given in any order, holding no more than the page and one place beyond
+// it, which tells whether there is a next page
+type placePage struct {
+	after  string
+	limit  int
+	total  int
+	places []*models.Place
+}
+
+func (p *placePage) add(place *models.Place) {
+	p.total++
+	key := placeKey(place)
+	if p.after != "" && key <= p.after {
+		return
+	}
+	i := sort.Search(len(p.places), func(i int) bool { return placeKey(p.places[i]) > key })
+	if i > p.limit {
+		return
+	}
+	p.places = slices.Insert(p.places, i, place)
+	if len(p.places) > p.limit+1 {
+		p.places = p.places[:p.limit+1]
+	}
+}
+
+// result returns the page and the cursor of the next one, if any
+func (p *placePage) result() ([]*models.Place, string) {
+	if len(p.places) <= p.limit {
+		return p.places, ""
+	}
+	page := p.places[:p.limit]
+	return page, base64.RawURLEncoding.EncodeToString([]byte(placeKey(page[len(page)-1])))
+}
+
+// placeKey orders places by name, then ID, for keyset pagination
+func placeKey(p *models.Place) string {
+	return strings.ToLower(p.Name) + "\x00" + p.ID
+}
+
+// pageParams reads the limit and cursor query parameters, writing an
+// error response if either is invalid
+func pageParams(w http.ResponseWriter, r *http.Request) (int, string, bool) {
+	query := r.URL.Query()
+	limit := defaultPageSize
+	if limitStr := query.Get("limit"); limitStr != "" {
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l < 1 {
+			writeError(w, http.StatusBadRequest, "invalid_parameter", "limit must be a positive integer")
+			return 0, "", false
+		}
+		limit = min(l, maxPageSize)
+	}
+
+	var after string
+	if cursor := query.Get("cursor"); cursor != "" {
+		key, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil || len(key) == 0 {
+			writeError(w, http.StatusBadRequest, "invalid_cursor", "invalid cursor")
+			return 0, "", false
+		}
+		after = string(key)
+	}
+	return limit, after, true
+}
+
+// boolParam reads a boolean query parameter, which is false when missing
+func boolParam(value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+// pageOf returns up to limit items whose key sorts after the cursor key,
+// and the cursor of the next page if there is one. Items must be sorted
+// by key. Keyset cursors stay valid when items are added or removed.
+func pageOf[T any](items []T, key func(T) string, after string, limit int) ([]T, string) {
+	start := 0
+	if after != "" {
+		start = sort.Search(len(items), func(i int) bool { return key(items[i]) > after })
+	}
+	end := min(start+limit, len(items))
+	page := items[start:end]
+	if end < len(items) && len(page) > 0 {
+		return page, base64.RawURLEncoding.EncodeToString([]byte(key(page[len(page)-1])))
+	}
+	return page, ""
+}
+
+// createPlaceV1 saves a new place. Without an id, one is derived from the
+// place_id, which is generated if missing too.
+func (s *Server) createPlaceV1(w http.ResponseWriter, r *http.Request) {
+	var place models.Place
+	if !decodeBody(w, r, &place) {
+		return
+	}
+	if strings.TrimSpace(place.Name) == "" {
+		writeError(w, http.StatusBadRequest, "invalid_place", "name is required")
+		return
+	}
+
+	if place.ID == "" {
+		if place.PlaceID == "" {
+			place.PlaceID = "api_" + randomHex(8)
+		}
+		place.ID = utils.GenerateID(place.PlaceID)
+	}
+	if _, err := s.dbFor(r).GetPlace(place.ID); err == nil {
+		writeError(w, http.StatusConflict, "conflict", "place %s already exists", place.ID)
+		return
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		logger.Error("Failed to check place", "id", place.ID, "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "failed to check place")
+		return
+	}
+
+	place.CreatedAt = time.Time{}
+	normalizePlace(&place)
+	if !s.savePlace(w, r, &place) {
+		return
+	}
+
+	w.Header().Set("Location", "/api/v1/places/"+place.ID)
+	writePlace(w, http.StatusCreated, &place)
+}
+
+func (s *Server) handleV1Place(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodPatch, http.MethodDelete:
+	default:
+		methodNotAllowed(w, r, http.MethodGet, http.MethodPatch, http.MethodDelete)
+		return
+	}
+
+	place, ok := s.loadPlace(w, r, r.PathValue("id"))
+	if !ok || !checkIfMatch(w, r, place) {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writePlace(w, http.StatusOK, place)
+
+	case http.MethodPatch:
+		updated, ok := patchPlace(w, r, place)
+		if !ok || !s.savePlace(w, r, updated) {
+			return
+		}
+		writePlace(w, http.StatusOK, updated)
+
+	case http.MethodDelete:
+		if err := s.dbFor(r).DeletePlace(place.ID); err != nil {
+			logger.Error("Failed to delete place", "id", place.ID, "error", err)
+			writeError(w, http.StatusInternalServerError, "internal", "failed to delete place")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// patchPlace applies a JSON Merge Patch (RFC 7396) to a place: members set
+// to null are cleared and members left out keep their value. The id and
+// timestamps are read-only and keep their value whatever the patch says.
+func patchPlace(w http.ResponseWriter, r *http.Request, place *models.Place) (*models.Place, bool) {
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, _ := mime.ParseMediaType(contentType)
+		if mediaType != "application/merge-patch+json" && mediaType != "application/json" {
+			writeError(w, http.StatusUnsupportedMediaType, "unsupported_media_type",
+				"expected application/merge-patch+json, got %s", contentType)
+			return nil, false
+		}
+	}
+
+	var patch interface{}
+	if !decodeBody(w, r, &patch) {
+		return nil, false
+	}
+	if _, ok := patch.(map[string]interface{}); !ok {
+		writeError(w, http.StatusBadRequest, "invalid_body", "merge patch must be a JSON object")
+		return nil, false
+	}
+
+	original, err := json.Marshal(place)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal", "failed to encode place")
+		return nil, false
+	}
+	var doc interface{}
+	dec := json.NewDecoder(bytes.NewReader(original))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal", "failed to encode place")
+		return nil, false
+	}
+
+	merged, err := json.Marshal(mergePatch(doc, patch))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_body", "invalid merge patch: %v", err)
+		return nil, false
+	}
+	var updated models.Place
+	if err := json.Unmarshal(merged, &updated); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_place", "invalid place: %v", err)
+		return nil, false
+	}
+	if strings.TrimSpace(updated.Name) == "" {
+		writeError(w, http.StatusBadRequest, "invalid_place", "name is required")
+		return nil, false
+	}
+
+	updated.ID = place.ID
+	updated.CreatedAt = place.CreatedAt
+	updated.UpdatedAt = place.UpdatedAt
+	updated.ImportedAt = place.ImportedAt
+	updated.SourceHash = place.SourceHash
+	updated.Snapshot = place.Snapshot
+	normalizePlace(&updated)
+	return &updated, true
+}
+
+// mergePatch returns target with patch applied as RFC 7396 describes
+func mergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{})
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+		} else {
+			targetObject[key] = mergePatch(targetObject[key], value)
+		}
+	}
+	return targetObject
+}
+
+func (s *Server) handleV1PlaceTags(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, r, http.MethodGet)
+		return
+	}
+	place, ok := s.loadPlace(w, r, r.PathValue("id"))
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, listResponse{Data: place.UserTags, Total: len(place.UserTags)})
+}
+
+// handleV1PlaceTag adds a tag with PUT, which is idempotent, and removes
+// it with DELETE
+func (s *Server) handleV1PlaceTag(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut && r.Method != http.MethodDelete {
+		methodNotAllowed(w, r, http.MethodPut, http.MethodDelete)
+		return
+	}
+	place, ok := s.loadPlace(w, r, r.PathValue("id"))
+	if !ok || !checkIfMatch(w, r, place) {
+		return
+	}
+	tag := strings.TrimSpace(r.PathValue("tag"))
+	if tag == "" {
+		writeError(w, http.StatusBadRequest, "invalid_tag", "tag must not be empty")
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		if !place.HasTag(tag) {
+			place.AddTag(tag)
+			if !s.savePlace(w, r, place) {
+				return
+			}
+		}
+		w.Header().Set("ETag", placeETag(place))
+		writeJSON(w, http.StatusOK, listResponse{Data: place.UserTags, Total: len(place.UserTags)})
+		return
+	}
+
+	if !place.HasTag(tag) {
+		writeError(w, http.StatusNotFound, "not_found", "place %s has no tag %q", place.ID, tag)
+		return
+	}
+	place.RemoveTag(tag)
+	if s.savePlace(w, r, place) {
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *Server) handleV1PlaceFields(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, r, http.MethodGet)
+		return
+	}
+	place, ok := s.loadPlace(w, r, r.PathValue("id"))
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, place.CustomFields)
+}
+
+// handleV1PlaceField reads, sets and deletes one custom field. PUT takes
+// {"value": ...} with any JSON value.
+func (s *Server) handleV1PlaceField(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodPut, http.MethodDelete:
+	default:
+		methodNotAllowed(w, r, http.MethodGet, http.MethodPut, http.MethodDelete)
+		return
+	}
+	place, ok := s.loadPlace(w, r, r.PathValue("id"))
+	if !ok || !checkIfMatch(w, r, place) {
+		return
+	}
+	name := r.PathValue("name")
+	value, exists := place.CustomFields[name]
+
+	switch r.Method {
+	case http.MethodGet:
+		if !exists {
+			writeError(w, http.StatusNotFound, "not_found", "place %s has no field %q", place.ID, name)
+			return
+		}
+		writeJSON(w, http.StatusOK, customField{Name: name, Value: value})
+
+	case http.MethodPut:
+		var body struct {
+			Value *json.RawMessage `json:"value"`
+		}
+		if !decodeBody(w, r, &body) {
+			return
+		}
+		if body.Value == nil {
+			writeError(w, http.StatusBadRequest, "invalid_body", "value is required")
+			return
+		}
+		var newValue interface{}
+		if err := json.Unmarshal(*body.Value, &newValue); err != nil || newValue == nil {
+			writeError(w, http.StatusBadRequest, "invalid_body", "value must not be null")
+			return
+		}
+		place.CustomFields[name] = newValue
+		if !s.savePlace(w, r, place) {
+			return
+		}
+		w.Header().Set("ETag", placeETag(place))
+		writeJSON(w, http.StatusOK, customField{Name: name, Value: newValue})
+
+	case http.MethodDelete:
+		if !exists {
+			writeError(w, http.StatusNotFound, "not_found", "place %s has no field %q", place.ID, name)
+			return
+		}
+		delete(place.CustomFields, name)
+		if s.savePlace(w, r, place) {
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
+
+// handleV1Tags lists the user's tags and the shared tags in use with how
+// many places have each, sorted by tag
+func (s *Server) handleV1Tags(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, r, http.MethodGet)
+		return
+	}
+	limit, after, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	counts := make(map[string]int)
+	err := s.dbFor(r).ForEachPlace("", func(place *models.Place) error {
+		for _, tag := range place.UserTags {
+			counts[tag]++
+		}
+		for _, tag := range place.SharedTags {
+			if !place.HasTag(tag) {
+				counts[tag]++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("Failed to fetch places", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "failed to fetch places")
+		return
+	}
+	tags := make([]tagCount, 0, len(counts))
+	for tag, count := range counts {
+		tags = append(tags, tagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Tag < tags[j].Tag })
+
+	page, next := pageOf(tags, func(t tagCount) string { return t.Tag }, after, limit)
+	writeJSON(w, http.StatusOK, listResponse{Data: page, Total: len(tags), NextCursor: next})
+}
+
+// handleV1TagPlaces adds a tag to many places at once, such as a selection
+// on the map. It takes {"ids": [...]}, with "shared": true for a team tag,
+// and fails without changing anything if a place doesn't exist.
+func (s *Server) handleV1TagPlaces(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, r, http.MethodPost)
+		return
+	}
+	tag := strings.TrimSpace(r.PathValue("tag"))
+	if tag == "" {
+		writeError(w, http.StatusBadRequest, "invalid_tag", "tag must not be empty")
+		return
+	}
+	var body struct {
+		IDs    []string `json:"ids"`
+		Shared bool     `json:"shared"`
+	}
+	if !decodeBody(w, r, &body) {
+		return
+	}
+	if len(body.IDs) == 0 {
+		writeError(w, http.StatusBadRequest, "invalid_body", "ids must list at least one place")
+		return
+	}
+
+	var changed []*models.Place
+	for _, id := range body.IDs {
+		place, ok := s.loadPlace(w, r, id)
+		if !ok {
+			return
+		}
+		if body.Shared && !place.HasSharedTag(tag) {
+			place.AddSharedTag(tag)
+			changed = append(changed, place)
+		} else if !body.Shared && !place.HasTag(tag) {
+			place.AddTag(tag)
+			changed = append(changed, place)
+		}
+	}
+	if len(changed) > 0 {
+		if err := s.dbFor(r).SavePlaces(changed); err != nil {
+			logger.Error("Failed to tag places", "tag", tag, "error", err)
+			writeError(w, http.StatusInternalServerError, "internal", "failed to save places")
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, struct {
+		Tag     string `json:"tag"`
+		Tagged  int    `json:"tagged"`
+		Already int    `json:"already"`
+	}{Tag: tag, Tagged: len(changed), Already: len(body.IDs) - len(changed)})
+}
+
+// placeETag is the entity tag of a place: its update time. Clients that
+// only have a place's JSON can quote its updated_at to get an equivalent
+// tag.
+func placeETag(place *models.Place) string {
+	return `"` + place.UpdatedAt.UTC().Format(time.RFC3339Nano) + `"`
+}
+
+// checkIfMatch checks a request's If-Match header against the place, so an
+// edit based on an outdated copy fails with 412 instead of overwriting
+// someone else's change. Tags compare as times, whatever their time zone.
+func checkIfMatch(w http.ResponseWriter, r *http.Request, place *models.Place) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" || isSafeMethod(r.Method) {
+		return true
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" {
+			return true
+		}
+		updatedAt, err := time.Parse(time.RFC3339Nano, strings.Trim(tag, `"`))
+		if err == nil && updatedAt.Equal(place.UpdatedAt) {
+			return true
+		}
+	}
+	w.Header().Set("ETag", placeETag(place))
+	writeError(w, http.StatusPreconditionFailed, "precondition_failed",
+		"place %s was changed by someone else; reload it and try again", place.ID)
+	return false
+}
+
+// writePlace writes a place with its ETag
+func writePlace(w http.ResponseWriter, status int, place *models.Place) {
+	w.Header().Set("ETag", placeETag(place))
+	writeJSON(w, status, place)
+}
+
+// loadPlace fetches a place, writing a 404 or 500 response if it can't
+func (s *Server) loadPlace(w http.ResponseWriter, r *http.Request, id string) (*models.Place, bool) {
+	place, err := s.dbFor(r).GetPlace(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, "not_found", "place %s not found", id)
+		return nil, false
+	}
+	if err != nil {
+		logger.Error("Failed to fetch place", "id", id, "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "failed to fetch place")
+		return nil, false
+	}
+	normalizePlace(place)
+	return place, true
+}
+
+// savePlace saves a place, writing a 500 response if it can't. A request
+// with If-Match only saves the place if nobody else saved it since it was
+// loaded, and gets a 412 response otherwise; checkIfMatch alone can't tell,
+// as another save may land between the check and this one.
+func (s *Server) savePlace(w http.ResponseWriter, r *http.Request, place *models.Place) bool {
+	var err error
+	if header := r.Header.Get("If-Match"); header != "" && strings.TrimSpace(header) != "*" {
+		err = s.dbFor(r).SavePlaceIfUnchanged(place, place.UpdatedAt)
+	} else {
+		err = s.dbFor(r).SavePlace(place)
+	}
+	if errors.Is(err, database.ErrConflict) {
+		writeError(w, http.StatusPreconditionFailed, "precondition_failed",
+			"place %s was changed by someone else; reload it and try again", place.ID)
+		return false
+	}
+	if err != nil {
+		logger.Error("Failed to save place", "id", place.ID, "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "failed to save place")
+		return false
+	}
+	return true
+}
+
+// decodeBody decodes a JSON request body into v, writing a 400 response
+// if it is missing, too large or malformed
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_body", "invalid JSON body: %v", err)
+		return false
+	}
+	return true
+}
+
+// normalizePlace replaces nil lists and maps, so clients always see [] and
+// {} rather than null
+func normalizePlace(place *models.Place) {
+	if place.Categories == nil {
+		place.Categories = []string{}
+	}
+	if place.Photos == nil {
+		place.Photos = []models.Photo{}
+	}
+	if place.Reviews == nil {
+		place.Reviews = []models.Review{}
+	}
+	if place.UserTags == nil {
+		place.UserTags = []string{}
+	}
+	if place.CustomFields == nil {
+		place.CustomFields = make(map[string]interface{})
+	}
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,501 @@
+package web
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/placeli/internal/database"
+	"github.com/user/placeli/internal/models"
+)
+
+func apiRequest(t *testing.T, handler http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	var req *http.Request
+	if body != "" {
+		req = httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+	} else {
+		req = httptest.NewRequest(method, path, nil)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func decodeResponse(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), v), w.Body.String())
+}
+
+type apiList[T any] struct {
+	Data       []T    `json:"data"`
+	Total      int    `json:"total"`
+	NextCursor string `json:"next_cursor"`
+}
+
+func assertAPIError(t *testing.T, w *httptest.ResponseRecorder, status int, code string) {
+	t.Helper()
+	assert.Equal(t, status, w.Code, w.Body.String())
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	var body struct {
+		Error apiError `json:"error"`
+	}
+	decodeResponse(t, w, &body)
+	assert.Equal(t, code, body.Error.Code)
+	assert.NotEmpty(t, body.Error.Message)
+}
+
+func TestAPIv1_PlaceCRUD(t *testing.T) {
+	server, db := setupTestServer(t)
+	defer db.Close()
+	h := server.Handler()
+
+	w := apiRequest(t, h, "POST", "/api/v1/places", `{"name": "Corner Cafe", "coordinates": {"lat": 38.7, "lng": -9.1}, "user_tags": ["coffee"]}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created models.Place
+	decodeResponse(t, w, &created)
+	assert.NotEmpty(t, created.ID)
+	assert.True(t, strings.HasPrefix(created.PlaceID, "api_"))
+	assert.Equal(t, "/api/v1/places/"+created.ID, w.Header().Get("Location"))
+	assert.Equal(t, []string{}, created.Categories)
+
+	w = apiRequest(t, h, "GET", "/api/v1/places/"+created.ID, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var fetched models.Place
+	decodeResponse(t, w, &fetched)
+	assert.Equal(t, "Corner Cafe", fetched.Name)
+	assert.Equal(t, []string{"coffee"}, fetched.UserTags)
+
+	// Creating the same place again conflicts
+	body := fmt.Sprintf(`{"id": %q, "name": "Again"}`, created.ID)
+	assertAPIError(t, apiRequest(t, h, "POST", "/api/v1/places", body), http.StatusConflict, "conflict")
+
+	w = apiRequest(t, h, "DELETE", "/api/v1/places/"+created.ID, "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assertAPIError(t, apiRequest(t, h, "GET", "/api/v1/places/"+created.ID, ""), http.StatusNotFound, "not_found")
+	assertAPIError(t, apiRequest(t, h, "DELETE", "/api/v1/places/"+created.ID, ""), http.StatusNotFound, "not_found")
+}
+
+func TestAPIv1_CreateValidation(t *testing.T) {
+	server, db := setupTestServer(t)
+	defer db.Close()
+	h := server.Handler()
+
+	assertAPIError(t, apiRequest(t, h, "POST", "/api/v1/places", `{"address": "Nowhere"}`), http.StatusBadRequest, "invalid_place")
+	assertAPIError(t, apiRequest(t, h, "POST", "/api/v1/places", `{"name": `), http.StatusBadRequest, "invalid_body")
+	assertAPIError(t, apiRequest(t, h, "POST", "/api/v1/places", `{"name": 3}`), http.StatusBadRequest, "invalid_body")
+}
+
+func TestAPIv1_MergePatch(t *testing.T) {
+	server, db := setupTestServer(t)
+	defer db.Close()
+	h := server.Handler()
+
+	req := httptest.NewRequest("PATCH", "/api/v1/places/test-place-1",
+		strings.NewReader(`{"user_notes": "Try the soup", "coordinates": {"lat": 40}, "rating": null, "id": "other"}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	place, err := db.GetPlace("test-place-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Try the soup", place.UserNotes)
+	assert.Equal(t, 40.0, place.Coordinates.Lat)
+	assert.Equal(t, -122.4194, place.Coordinates.Lng, "unpatched members of an object are kept")
+	assert.Zero(t, place.Rating, "null clears a member")
+	// Fields left out of the patch keep their value
+	assert.Equal(t, "Test Place", place.Name)
+	assert.Equal(t, "123 Test St", place.Address)
+	assert.Equal(t, []string{"favorite"}, place.UserTags)
+	assert.Equal(t, []string{"Restaurant"}, place.Categories)
+
+	_, err = db.GetPlace("other")
+	assert.Error(t, err, "id is read-only")
+
+	w = apiRequest(t, h, "PATCH", "/api/v1/places/test-place-1", `{"user_tags": null}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	var patched models.Place
+	decodeResponse(t, w, &patched)
+	assert.Equal(t, []string{}, patched.UserTags)
+
+	assertAPIError(t, apiRequest(t, h, "PATCH", "/api/v1/places/test-place-1", `{"name": null}`), http.StatusBadRequest, "invalid_place")
+	assertAPIError(t, apiRequest(t, h, "PATCH", "/api/v1/places/test-place-1", `["name"]`), http.StatusBadRequest, "invalid_body")
+	assertAPIError(t, apiRequest(t, h, "PATCH", "/api/v1/places/test-place-1", `{"rating": "high"}`), http.StatusBadRequest, "invalid_place")
+	assertAPIError(t, apiRequest(t, h, "PATCH", "/api/v1/places/missing", `{"name": "x"}`), http.StatusNotFound, "not_found")
+
+	req = httptest.NewRequest("PATCH", "/api/v1/places/test-place-1", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "text/plain")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assertAPIError(t, w, http.StatusUnsupportedMediaType, "unsupported_media_type")
+}
+
+func TestMergePatch(t *testing.T) {
+	// Examples from RFC 7396, appendix A
+	tests := []struct {
+		target, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		var target, patch interface{}
+		require.NoError(t, json.Unmarshal([]byte(tt.target), &target))
+		require.NoError(t, json.Unmarshal([]byte(tt.patch), &patch))
+		got, err := json.Marshal(mergePatch(target, patch))
+		require.NoError(t, err)
+		assert.JSONEq(t, tt.want, string(got), "%s + %s", tt.target, tt.patch)
+	}
+}
+
+func TestAPIv1_TagsAndFields(t *testing.T) {
+	server, db := setupTestServer(t)
+	defer db.Close()
+	h := server.Handler()
+
+	w := apiRequest(t, h, "PUT", "/api/v1/places/test-place-1/tags/date%20night", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var tags apiList[string]
+	decodeResponse(t, w, &tags)
+	assert.Equal(t, []string{"favorite", "date night"}, tags.Data)
+
+	// Adding twice is harmless
+	w = apiRequest(t, h, "PUT", "/api/v1/places/test-place-1/tags/favorite", "")
+	decodeResponse(t, w, &tags)
+	assert.Equal(t, 2, tags.Total)
+
+	w = apiRequest(t, h, "DELETE", "/api/v1/places/test-place-1/tags/favorite", "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assertAPIError(t, apiRequest(t, h, "DELETE", "/api/v1/places/test-place-1/tags/favorite", ""), http.StatusNotFound, "not_found")
+
+	w = apiRequest(t, h, "GET", "/api/v1/places/test-place-1/tags", "")
+	decodeResponse(t, w, &tags)
+	assert.Equal(t, []string{"date night"}, tags.Data)
+
+	w = apiRequest(t, h, "PUT", "/api/v1/places/test-place-1/fields/visited", `{"value": true}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = apiRequest(t, h, "PUT", "/api/v1/places/test-place-1/fields/wifi", `{"value": {"ssid": "cafe", "speed": 50}}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = apiRequest(t, h, "GET", "/api/v1/places/test-place-1/fields/wifi", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"name": "wifi", "value": {"ssid": "cafe", "speed": 50}}`, w.Body.String())
+
+	w = apiRequest(t, h, "GET", "/api/v1/places/test-place-1/fields", "")
+	var fields map[string]interface{}
+	decodeResponse(t, w, &fields)
+	assert.Equal(t, true, fields["visited"])
+
+	assertAPIError(t, apiRequest(t, h, "PUT", "/api/v1/places/test-place-1/fields/wifi", `{"other": 1}`), http.StatusBadRequest, "invalid_body")
+	assertAPIError(t, apiRequest(t, h, "PUT", "/api/v1/places/test-place-1/fields/wifi", `{"value": null}`), http.StatusBadRequest, "invalid_body")
+
+	w = apiRequest(t, h, "DELETE", "/api/v1/places/test-place-1/fields/wifi", "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assertAPIError(t, apiRequest(t, h, "GET", "/api/v1/places/test-place-1/fields/wifi", ""), http.StatusNotFound, "not_found")
+
+	place, err := db.GetPlace("test-place-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"date night"}, place.UserTags)
+	assert.Equal(t, true, place.CustomFields["visited"])
+	assert.NotContains(t, place.CustomFields, "wifi")
+}
+
+func TestAPIv1_ConditionalWrites(t *testing.T) {
+	server, db := setupTestServer(t)
+	defer db.Close()
+	h := server.Handler()
+
+	ifMatch := func(method, path, etag, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("If-Match", etag)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := apiRequest(t, h, "GET", "/api/v1/places/test-place-1", "")
+	etag := w.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	var place models.Place
+	decodeResponse(t, w, &place)
+
+	// The quoted updated_at from the JSON works as well as the ETag
+	w = ifMatch("PATCH", "/api/v1/places/test-place-1", fmt.Sprintf("%q", place.UpdatedAt.Format("2006-01-02T15:04:05.999999999-07:00")), `{"user_notes": "first"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	newTag := w.Header().Get("ETag")
+	assert.NotEqual(t, etag, newTag)
+
+	// A second writer with the old version is refused
+	w = ifMatch("PATCH", "/api/v1/places/test-place-1", etag, `{"user_notes": "second"}`)
+	assertAPIError(t, w, http.StatusPreconditionFailed, "precondition_failed")
+	assert.Equal(t, newTag, w.Header().Get("ETag"))
+	assertAPIError(t, ifMatch("PUT", "/api/v1/places/test-place-1/tags/late", etag, ""), http.StatusPreconditionFailed, "precondition_failed")
+	assertAPIError(t, ifMatch("PUT", "/api/v1/places/test-place-1/fields/late", etag, `{"value": 1}`), http.StatusPreconditionFailed, "precondition_failed")
+	assertAPIError(t, ifMatch("DELETE", "/api/v1/places/test-place-1", etag, ""), http.StatusPreconditionFailed, "precondition_failed")
+
+	w = ifMatch("PUT", "/api/v1/places/test-place-1/tags/late", newTag, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.NotEqual(t, newTag, w.Header().Get("ETag"))
+	assert.Equal(t, http.StatusOK, ifMatch("PATCH", "/api/v1/places/test-place-1", "*", `{"user_notes": "any"}`).Code)
+
+	stored, err := db.GetPlace("test-place-1")
+	require.NoError(t, err)
+	assert.Equal(t, "any", stored.UserNotes)
+	assert.Equal(t, []string{"favorite", "late"}, stored.UserTags)
+}
+
+func TestAPIv1_ConcurrentConditionalWrites(t *testing.T) {
+	// A file, as every connection to :memory: opens a database of its own
+	db, err := database.New(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, db.SavePlace(&models.Place{ID: "test-place-1", Name: "Test Place"}))
+	server, err := NewServer(db, 8080, "")
+	require.NoError(t, err)
+	h := server.Handler()
+
+	etag := apiRequest(t, h, "GET", "/api/v1/places/test-place-1", "").Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	// Two editors send the same version at once; only one may win
+	notes := []string{"first", "second"}
+	codes := make([]int, len(notes))
+	var wg sync.WaitGroup
+	for i, note := range notes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest("PATCH", "/api/v1/places/test-place-1", strings.NewReader(`{"user_notes": "`+note+`"}`))
+			req.Header.Set("If-Match", etag)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			codes[i] = w.Code
+		}()
+	}
+	wg.Wait()
+
+	winners := 0
+	for _, code := range codes {
+		if code == http.StatusOK {
+			winners++
+		}
+	}
+	require.Equal(t, 1, winners, "status codes %v", codes)
+
+	stored, err := db.GetPlace("test-place-1")
+	require.NoError(t, err)
+	winner := notes[slices.Index(codes, http.StatusOK)]
+	assert.Equal(t, winner, stored.UserNotes)
+}
+
+func TestAPIv1_TagPlaces(t *testing.T) {
+	server, db := setupTestServer(t)
+	defer db.Close()
+	h := server.Handler()
+
+	w := apiRequest(t, h, "POST", "/api/v1/places", `{"id": "second", "name": "Second"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = apiRequest(t, h, "POST", "/api/v1/tags/favorite/places", `{"ids": ["test-place-1", "second"]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"tag": "favorite", "tagged": 1, "already": 1}`, w.Body.String())
+
+	w = apiRequest(t, h, "POST", "/api/v1/tags/team/places", `{"ids": ["second"], "shared": true}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// Nothing is tagged when any place is missing
+	assertAPIError(t, apiRequest(t, h, "POST", "/api/v1/tags/later/places", `{"ids": ["second", "missing"]}`), http.StatusNotFound, "not_found")
+	assertAPIError(t, apiRequest(t, h, "POST", "/api/v1/tags/later/places", `{"ids": []}`), http.StatusBadRequest, "invalid_body")
+
+	second, err := db.GetPlace("second")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"favorite"}, second.UserTags)
+	assert.Equal(t, []string{"team"}, second.SharedTags)
+}
+
+func TestAPIv1_Stats(t *testing.T) {
+	server, db := setupTestServer(t)
+	defer db.Close()
+	h := server.Handler()
+
+	w := apiRequest(t, h, "POST", "/api/v1/places", `{"id": "second", "name": "Second", "rating": 3.9, "categories": ["Cafe", "Restaurant"], "shared_tags": ["team"]}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	_, err := db.AddVisits("test-place-1", []models.Visit{
+		{VisitedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+		{VisitedAt: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)},
+	})
+	require.NoError(t, err)
+
+	w = apiRequest(t, h, "GET", "/api/v1/stats", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{
+		"places": 2, "mapped": 1, "areas": 0, "with_notes": 1, "tagged": 2,
+		"visited": 1, "visits": 2,
+		"rated": 2, "average_rating": 4.2, "ratings": [0, 0, 1, 1, 0],
+		"categories": [{"category": "Restaurant", "count": 2}, {"category": "Cafe", "count": 1}],
+		"tags": [{"tag": "favorite", "count": 1}, {"tag": "team", "count": 1}]
+	}`, w.Body.String())
+
+	assertAPIError(t, apiRequest(t, h, "POST", "/api/v1/stats", ""), http.StatusMethodNotAllowed, "method_not_allowed")
+}
+
+func TestAPIv1_ListPagination(t *testing.T) {
+	server, db := setupTestServer(t)
+	defer db.Close()
+	h := server.Handler()
+
+	for i := 0; i < 7; i++ {
+		tags := []string{"bulk"}
+		if i%2 == 0 {
+			tags = append(tags, "even")
+		}
+		require.NoError(t, db.SavePlace(&models.Place{
+			ID:       fmt.Sprintf("bulk-%d", i),
+			Name:     fmt.Sprintf("Bulk %d", i),
+			UserTags: tags,
+		}))
+	}
+
+	var names []string
+	cursor := ""
+	for pages := 0; pages < 10; pages++ {
+		w := apiRequest(t, h, "GET", "/api/v1/places?limit=3&cursor="+cursor, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var page apiList[models.Place]
+		decodeResponse(t, w, &page)
+		assert.Equal(t, 8, page.Total)
+		assert.LessOrEqual(t, len(page.Data), 3)
+		for _, p := range page.Data {
+			names = append(names, p.Name)
+		}
+		if cursor = page.NextCursor; cursor == "" {
+			break
+		}
+	}
+	assert.Len(t, names, 8)
+	assert.True(t, sort.StringsAreSorted(names), "places are sorted by name: %v", names)
+	assert.Equal(t, "Test Place", names[7])
+
+	w := apiRequest(t, h, "GET", "/api/v1/places?tag=even", "")
+	var filtered apiList[models.Place]
+	decodeResponse(t, w, &filtered)
+	assert.Equal(t, 4, filtered.Total)
+	assert.Empty(t, filtered.NextCursor)
+
+	w = apiRequest(t, h, "GET", "/api/v1/places?search=Test&category=restaurant", "")
+	decodeResponse(t, w, &filtered)
+	assert.Equal(t, 1, filtered.Total)
+
+	assertAPIError(t, apiRequest(t, h, "GET", "/api/v1/places?cursor=!!", ""), http.StatusBadRequest, "invalid_cursor")
+	assertAPIError(t, apiRequest(t, h, "GET", "/api/v1/places?limit=0", ""), http.StatusBadRequest, "invalid_parameter")
+	assertAPIError(t, apiRequest(t, h, "GET", "/api/v1/places?open_now=yes", ""), http.StatusBadRequest, "invalid_parameter")
+	assertAPIError(t, apiRequest(t, h, "GET", "/api/v1/places?inside=missing", ""), http.StatusNotFound, "not_found")
+
+	w = apiRequest(t, h, "GET", "/api/v1/tags?limit=2", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var tags apiList[tagCount]
+	decodeResponse(t, w, &tags)
+	assert.Equal(t, 3, tags.Total)
+	assert.Equal(t, []tagCount{{"bulk", 7}, {"even", 4}}, tags.Data)
+
+	w = apiRequest(t, h, "GET", "/api/v1/tags?cursor="+tags.NextCursor, "")
+	var rest apiList[tagCount]
+	decodeResponse(t, w, &rest)
+	assert.Equal(t, []tagCount{{"favorite", 1}}, rest.Data)
+	assert.Empty(t, rest.NextCursor)
+}
+
+func TestPlacePage(t *testing.T) {
+	var places []*models.Place
+	for _, i := range []int{7, 2, 19, 0, 11, 4, 16, 9, 13, 1, 18, 5, 14, 3, 8, 17, 6, 12, 10, 15} {
+		places = append(places, &models.Place{ID: fmt.Sprintf("p%02d", i), Name: fmt.Sprintf("Place %02d", i)})
+	}
+
+	// Following the cursors visits every place once, in order
+	var seen []string
+	after := ""
+	for {
+		page := placePage{after: after, limit: 6}
+		for _, place := range places {
+			page.add(place)
+		}
+		assert.Equal(t, len(places), page.total)
+		result, next := page.result()
+		for _, place := range result {
+			seen = append(seen, place.ID)
+		}
+		if next == "" {
+			break
+		}
+		key, err := base64.RawURLEncoding.DecodeString(next)
+		require.NoError(t, err)
+		after = string(key)
+	}
+	require.Len(t, seen, len(places))
+	assert.True(t, sort.StringsAreSorted(seen))
+}
+
+func TestAPIv1_Errors(t *testing.T) {
+	server, db := setupTestServer(t)
+	defer db.Close()
+	h := server.Handler()
+
+	assertAPIError(t, apiRequest(t, h, "GET", "/api/v1/nothing", ""), http.StatusNotFound, "not_found")
+	w := apiRequest(t, h, "PUT", "/api/v1/places", "")
+	assertAPIError(t, w, http.StatusMethodNotAllowed, "method_not_allowed")
+	assert.Equal(t, "GET, POST", w.Header().Get("Allow"))
+	assertAPIError(t, apiRequest(t, h, "POST", "/api/v1/places/test-place-1", ""), http.StatusMethodNotAllowed, "method_not_allowed")
+
+	// The legacy endpoints used by the web interface still work
+	w = apiRequest(t, h, "GET", "/api/places", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var places []models.Place
+	decodeResponse(t, w, &places)
+	assert.Len(t, places, 1)
+}
+
+func TestAPIv1_OpenAPI(t *testing.T) {
+	server, db := setupTestServer(t)
+	defer db.Close()
+
+	w := apiRequest(t, server.Handler(), "GET", "/api/v1/openapi.json", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var spec struct {
+		OpenAPI string                     `json:"openapi"`
+		Paths   map[string]json.RawMessage `json:"paths"`
+	}
+	decodeResponse(t, w, &spec)
+	assert.True(t, strings.HasPrefix(spec.OpenAPI, "3."))
+
+	for _, path := range []string{
+		"/places", "/places/{id}", "/places/{id}/tags", "/places/{id}/tags/{tag}",
+		"/places/{id}/fields", "/places/{id}/fields/{name}", "/tags", "/tags/{tag}/places", "/stats", "/openapi.json",
+	} {
+		assert.Contains(t, spec.Paths, path)
+	}
+}
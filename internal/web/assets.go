@@ -0,0 +1,235 @@
+package web
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+//go:generate go run ./genleaflet static/vendor/leaflet
+//go:generate go run ./gencompress static
+
+// assetPrefix is the URL path the asset bundle is served under
+const assetPrefix = "/static/"
+
+// gzipMinSize is the size below which assets aren't worth compressing
+const gzipMinSize = 512
+
+// asset is a file of the embedded bundle with its precompressed forms
+type asset struct {
+	contentType string
+	// hash identifies the content, for ETags and versioned URLs
+	hash string
+	// encoded maps a content coding ("br", "gzip") to the file in it
+	encoded map[string][]byte
+	data    []byte
+}
+
+// assetServer serves the embedded web app bundle. Files are compressed
+// once, rather than per request: a file's .br or .gz sibling in the
+// bundle is used as is, and text files without a .gz sibling are gzipped
+// when the server starts. The .br files are made by go generate. URLs carrying the file's hash as ?v= are cached for
+// good; other requests revalidate with the ETag.
+type assetServer struct {
+	assets map[string]*asset
+}
+
+func newAssetServer(fsys fs.FS) (*assetServer, error) {
+	a := &assetServer{assets: make(map[string]*asset)}
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || isPrecompressed(name) {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+
+		contentType := mime.TypeByExtension(path.Ext(name))
+		if contentType == "" {
+			contentType = http.DetectContentType(data)
+		}
+		sum := sha256.Sum256(data)
+		file := &asset{
+			contentType: contentType,
+			hash:        hex.EncodeToString(sum[:6]),
+			encoded:     make(map[string][]byte),
+			data:        data,
+		}
+
+		for coding, ext := range map[string]string{"br": ".br", "gzip": ".gz"} {
+			if compressed, err := fs.ReadFile(fsys, name+ext); err == nil {
+				file.encoded[coding] = compressed
+			}
+		}
+		if _, ok := file.encoded["gzip"]; !ok && isCompressible(contentType) && len(data) >= gzipMinSize {
+			compressed, err := gzipBytes(data)
+			if err != nil {
+				return fmt.Errorf("failed to compress %s: %w", name, err)
+			}
+			if len(compressed) < len(data) {
+				file.encoded["gzip"] = compressed
+			}
+		}
+
+		a.assets[name] = file
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load web assets: %w", err)
+	}
+	return a, nil
+}
+
+func isPrecompressed(name string) bool {
+	return strings.HasSuffix(name, ".br") || strings.HasSuffix(name, ".gz")
+}
+
+func isCompressible(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return strings.HasPrefix(mediaType, "text/") || mediaType == "image/svg+xml" ||
+		strings.HasSuffix(mediaType, "javascript") || strings.HasSuffix(mediaType, "json")
+}
+
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// url returns the versioned URL of an asset, which browsers may cache for
+// good since it changes with the content
+func (a *assetServer) url(name string) string {
+	if file, ok := a.assets[name]; ok {
+		return assetPrefix + name + "?v=" + file.hash
+	}
+	return assetPrefix + name
+}
+
+// vendorFallbacks are where third-party files come from when the bundle
+// doesn't have them, until go generate has vendored them
+var vendorFallbacks = map[string]string{
+	"vendor/leaflet/leaflet.js":  "https://unpkg.com/leaflet@1.9.4/dist/leaflet.js",
+	"vendor/leaflet/leaflet.css": "https://unpkg.com/leaflet@1.9.4/dist/leaflet.css",
+}
+
+// vendorURL returns the versioned URL of a vendored third-party file, or
+// the URL it is published at if the bundle doesn't have it
+func (a *assetServer) vendorURL(name string) string {
+	if fallback, ok := vendorFallbacks[name]; ok && a.assets[name] == nil {
+		return fallback
+	}
+	return a.url(name)
+}
+
+// importMap maps the app's JavaScript modules to their versioned URLs, so
+// modules importing each other by plain path still get cacheable URLs
+func (a *assetServer) importMap() string {
+	imports := make(map[string]string)
+	for name := range a.assets {
+		if path.Ext(name) == ".js" {
+			imports[assetPrefix+name] = a.url(name)
+		}
+	}
+	data, _ := json.Marshal(map[string]interface{}{"imports": imports})
+	return string(data)
+}
+
+func (a *assetServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	file, ok := a.assets[strings.TrimPrefix(r.URL.Path, assetPrefix)]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	body, coding := file.data, ""
+	for _, c := range []string{"br", "gzip"} {
+		if encoded, ok := file.encoded[c]; ok && acceptsEncoding(r.Header.Get("Accept-Encoding"), c) {
+			body, coding = encoded, c
+			break
+		}
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", file.contentType)
+	h.Set("Vary", "Accept-Encoding")
+	etag := `"` + file.hash
+	if coding != "" {
+		h.Set("Content-Encoding", coding)
+		etag += "-" + coding
+	}
+	etag += `"`
+	h.Set("ETag", etag)
+	if r.URL.Query().Get("v") == file.hash {
+		h.Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		h.Set("Cache-Control", "no-cache")
+	}
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	h.Set("Content-Length", strconv.Itoa(len(body)))
+	if r.Method == http.MethodHead {
+		return
+	}
+	w.Write(body)
+}
+
+// acceptsEncoding reports whether an Accept-Encoding header allows a
+// content coding, honouring q=0 and the * wildcard
+func acceptsEncoding(header, coding string) bool {
+	accepted := false
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != coding && name != "*" {
+			continue
+		}
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		if name == coding {
+			return q > 0
+		}
+		accepted = q > 0
+	}
+	return accepted
+}
+
+// etagMatches reports whether an If-None-Match header lists an ETag
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
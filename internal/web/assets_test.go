@@ -0,0 +1,183 @@
+package web
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/andybalholm/brotli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func assetRequest(t *testing.T, h http.Handler, method, target string, header map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, nil)
+	for name, value := range header {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestAssetServer(t *testing.T) {
+	script := []byte(strings.Repeat("console.log('placeli');\n", 100))
+	assets, err := newAssetServer(fstest.MapFS{
+		"js/app.js":    {Data: script},
+		"js/app.js.br": {Data: []byte("brotli bytes")},
+		"small.css":    {Data: []byte("body { color: red; }")},
+		"icon.png":     {Data: bytes.Repeat([]byte{0x89, 'P', 'N', 'G'}, 200)},
+	})
+	require.NoError(t, err)
+
+	hash := assets.assets["js/app.js"].hash
+	assert.Equal(t, "/static/js/app.js?v="+hash, assets.url("js/app.js"))
+	assert.Equal(t, "/static/missing.js", assets.url("missing.js"))
+
+	var importMap struct {
+		Imports map[string]string `json:"imports"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(assets.importMap()), &importMap))
+	assert.Equal(t, map[string]string{"/static/js/app.js": "/static/js/app.js?v=" + hash}, importMap.Imports)
+
+	// Brotli is preferred when the bundle has it
+	w := assetRequest(t, assets, "GET", "/static/js/app.js", map[string]string{"Accept-Encoding": "gzip, deflate, br"})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "br", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "brotli bytes", w.Body.String())
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+	assert.Contains(t, w.Header().Get("Content-Type"), "javascript")
+	assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"), "unversioned URLs revalidate")
+
+	// Gzip is made when the server starts
+	w = assetRequest(t, assets, "GET", "/static/js/app.js", map[string]string{"Accept-Encoding": "br;q=0, gzip"})
+	require.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	zr, err := gzip.NewReader(w.Body)
+	require.NoError(t, err)
+	body, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Equal(t, script, body)
+	etag := w.Header().Get("ETag")
+	assert.Equal(t, `"`+hash+`-gzip"`, etag)
+
+	w = assetRequest(t, assets, "GET", "/static/js/app.js", nil)
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, script, w.Body.Bytes())
+
+	// Versioned URLs are cached for good
+	w = assetRequest(t, assets, "GET", "/static/js/app.js?v="+hash, nil)
+	assert.Equal(t, "public, max-age=31536000, immutable", w.Header().Get("Cache-Control"))
+	w = assetRequest(t, assets, "GET", "/static/js/app.js?v=stale", nil)
+	assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+
+	w = assetRequest(t, assets, "GET", "/static/js/app.js", map[string]string{"Accept-Encoding": "gzip", "If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+	w = assetRequest(t, assets, "GET", "/static/js/app.js", map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusOK, w.Code, "the ETag names the encoding")
+
+	w = assetRequest(t, assets, "HEAD", "/static/js/app.js", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2400", w.Header().Get("Content-Length"))
+	assert.Empty(t, w.Body.String())
+
+	// Small files and images aren't worth compressing
+	for _, path := range []string{"/static/small.css", "/static/icon.png"} {
+		w = assetRequest(t, assets, "GET", path, map[string]string{"Accept-Encoding": "gzip"})
+		assert.Equal(t, http.StatusOK, w.Code, path)
+		assert.Empty(t, w.Header().Get("Content-Encoding"), path)
+	}
+
+	assert.Equal(t, http.StatusNotFound, assetRequest(t, assets, "GET", "/static/js/app.js.br", nil).Code)
+	assert.Equal(t, http.StatusNotFound, assetRequest(t, assets, "GET", "/static/missing.js", nil).Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, assetRequest(t, assets, "POST", "/static/small.css", nil).Code)
+}
+
+func TestAcceptsEncoding(t *testing.T) {
+	tests := []struct {
+		header string
+		coding string
+		want   bool
+	}{
+		{"", "gzip", false},
+		{"gzip", "gzip", true},
+		{"GZIP", "gzip", true},
+		{"deflate, gzip;q=0.5", "gzip", true},
+		{"gzip;q=0", "gzip", false},
+		{"gzip; q=0.0", "gzip", false},
+		{"*", "br", true},
+		{"*;q=0", "br", false},
+		{"br;q=0, *", "br", false},
+		{"identity", "gzip", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, acceptsEncoding(tt.header, tt.coding), "%q accepts %s", tt.header, tt.coding)
+	}
+}
+
+func TestEmbeddedAssets(t *testing.T) {
+	server, db := setupTestServer(t)
+	defer db.Close()
+	h := server.Handler()
+
+	// The page links the app's files by version
+	w := assetRequest(t, h, "GET", "/", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	for _, name := range []string{"style.css", "js/app.js"} {
+		assert.Contains(t, w.Body.String(), server.assets.url(name))
+	}
+	assert.Contains(t, w.Body.String(), `<script type="importmap">{"imports":{`)
+
+	for _, name := range []string{"style.css", "js/app.js", "js/views/list.js", "js/views/map.js", "js/views/detail.js", "js/views/stats.js"} {
+		w := assetRequest(t, h, "GET", server.assets.url(name), map[string]string{"Accept-Encoding": "gzip"})
+		require.Equal(t, http.StatusOK, w.Code, name)
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"), name)
+		assert.Equal(t, "public, max-age=31536000, immutable", w.Header().Get("Cache-Control"), name)
+	}
+}
+
+// TestBundleBrotli checks the bundle's .br files match their sources, so a
+// change to the bundle without go generate fails here rather than serving
+// stale files to browsers that accept Brotli
+func TestBundleBrotli(t *testing.T) {
+	bundle, err := fs.Sub(static, "static")
+	require.NoError(t, err)
+	assets, err := newAssetServer(bundle)
+	require.NoError(t, err)
+
+	for name, file := range assets.assets {
+		if !isCompressible(file.contentType) || len(file.data) < gzipMinSize {
+			continue
+		}
+		compressed, ok := file.encoded["br"]
+		if !assert.True(t, ok, "%s has no .br file, run go generate ./internal/web", name) {
+			continue
+		}
+		data, err := io.ReadAll(brotli.NewReader(bytes.NewReader(compressed)))
+		require.NoError(t, err, name)
+		assert.True(t, bytes.Equal(file.data, data), "%s.br is out of date, run go generate ./internal/web", name)
+	}
+
+	w := assetRequest(t, assets, "GET", "/static/js/app.js", map[string]string{"Accept-Encoding": "gzip, br"})
+	assert.Equal(t, "br", w.Header().Get("Content-Encoding"))
+}
+
+func TestVendorURL(t *testing.T) {
+	vendored, err := newAssetServer(fstest.MapFS{
+		"vendor/leaflet/leaflet.js": {Data: []byte("/* Leaflet */")},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, vendored.url("vendor/leaflet/leaflet.js"), vendored.vendorURL("vendor/leaflet/leaflet.js"))
+	assert.Equal(t, vendorFallbacks["vendor/leaflet/leaflet.css"], vendored.vendorURL("vendor/leaflet/leaflet.css"))
+
+	// Files without a published fallback are always served from the bundle
+	assert.Equal(t, "/static/vendor/other.js", vendored.vendorURL("vendor/other.js"))
+}
@@ -0,0 +1,324 @@
+package web
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/user/placeli/internal/database"
+	"github.com/user/placeli/internal/logger"
+)
+
+const (
+	sessionCookie = "placeli_session"
+	sessionTTL    = 7 * 24 * time.Hour
+
+	// csrfHeader carries the session's CSRF token on browser writes
+	csrfHeader = "X-CSRF-Token"
+)
+
+// session is a browser login. It refers to the token used to log in, so
+// revoking the token ends the session too.
+type session struct {
+	tokenID   string
+	csrf      string
+	expiresAt time.Time
+}
+
+// sessionStore keeps sessions in memory; restarting the server logs
+// everyone out
+type sessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]*session
+}
+
+func newSessionStore() *sessionStore {
+	return &sessionStore{sessions: make(map[string]*session)}
+}
+
+func (s *sessionStore) create(tokenID string) (string, *session) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for id, sess := range s.sessions {
+		if now.After(sess.expiresAt) {
+			delete(s.sessions, id)
+		}
+	}
+
+	id := randomHex(32)
+	sess := &session{tokenID: tokenID, csrf: randomHex(32), expiresAt: now.Add(sessionTTL)}
+	s.sessions[id] = sess
+	return id, sess
+}
+
+func (s *sessionStore) get(id string) *session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.sessions[id]
+	if !ok {
+		return nil
+	}
+	if time.Now().After(sess.expiresAt) {
+		delete(s.sessions, id)
+		return nil
+	}
+	return sess
+}
+
+func (s *sessionStore) delete(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, id)
+}
+
+// identity is who made a request: the token used directly as a bearer
+// token, or through a session cookie
+type identity struct {
+	token   *database.APIToken
+	session *session
+}
+
+type identityKey struct{}
+
+// requestIdentity returns the authenticated identity of a request, or nil
+// when auth is off
+func requestIdentity(r *http.Request) *identity {
+	id, _ := r.Context().Value(identityKey{}).(*identity)
+	return id
+}
+
+// dbFor returns the database handle for the user a request acts as: the
+// token's user with auth, or the default user without it
+func (s *Server) dbFor(r *http.Request) *database.DB {
+	if id := requestIdentity(r); id != nil {
+		return s.db.ForUser(id.token.User)
+	}
+	return s.db
+}
+
+// isSafeMethod reports whether a method only reads
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// withAuth guards every route except the login page and static files.
+// Writes from other sites are always refused. With auth required, requests
+// need a bearer token or a session, writes need a write-scoped token, and
+// writes made with a session cookie need the session's CSRF token.
+func (s *Server) withAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isSafeMethod(r.Method) && isCrossSite(r) {
+			s.denyRequest(w, r, http.StatusForbidden, "cross_site", "cross-site requests may not change data")
+			return
+		}
+		if !s.authRequired || isPublicPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		id, err := s.authenticate(r)
+		if err != nil {
+			logger.Error("Failed to authenticate request", "error", err)
+			s.denyRequest(w, r, http.StatusInternalServerError, "internal", "failed to authenticate")
+			return
+		}
+		if id == nil {
+			if isSafeMethod(r.Method) && !isAPIPath(r.URL.Path) {
+				http.Redirect(w, r, "/login?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusSeeOther)
+				return
+			}
+			w.Header().Set("WWW-Authenticate", `Bearer realm="placeli"`)
+			s.denyRequest(w, r, http.StatusUnauthorized, "unauthorized", "authentication required")
+			return
+		}
+
+		if !isSafeMethod(r.Method) {
+			if !id.token.CanWrite() {
+				s.denyRequest(w, r, http.StatusForbidden, "forbidden", "token %s is read-only", id.token.Name)
+				return
+			}
+			if id.session != nil && !validCSRF(r, id.session) {
+				s.denyRequest(w, r, http.StatusForbidden, "csrf", "missing or invalid CSRF token")
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), identityKey{}, id)))
+	})
+}
+
+// authenticate resolves a bearer token or session cookie. It returns nil
+// without an error when neither is present or valid.
+func (s *Server) authenticate(r *http.Request) (*identity, error) {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		secret, ok := strings.CutPrefix(auth, "Bearer ")
+		if !ok {
+			return nil, nil
+		}
+		token, err := s.db.AuthenticateAPIToken(strings.TrimSpace(secret))
+		if errors.Is(err, database.ErrInvalidToken) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &identity{token: token}, nil
+	}
+
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return nil, nil
+	}
+	sess := s.sessions.get(cookie.Value)
+	if sess == nil {
+		return nil, nil
+	}
+	token, err := s.db.GetAPIToken(sess.tokenID)
+	if err != nil || token.Revoked() {
+		s.sessions.delete(cookie.Value)
+		return nil, nil
+	}
+	return &identity{token: token, session: sess}, nil
+}
+
+// denyRequest writes a JSON error for API routes and plain text otherwise
+func (s *Server) denyRequest(w http.ResponseWriter, r *http.Request, status int, code, format string, args ...interface{}) {
+	if isAPIPath(r.URL.Path) {
+		writeError(w, status, code, format, args...)
+		return
+	}
+	http.Error(w, http.StatusText(status), status)
+}
+
+func validCSRF(r *http.Request, sess *session) bool {
+	token := r.Header.Get(csrfHeader)
+	if token == "" {
+		token = r.PostFormValue("csrf_token")
+	}
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(sess.csrf)) == 1
+}
+
+// isCrossSite reports whether the browser says a request came from another
+// site. Requests without these headers, from curl say, are not cross-site.
+func isCrossSite(r *http.Request) bool {
+	if site := r.Header.Get("Sec-Fetch-Site"); site != "" {
+		return site == "cross-site"
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" || origin == "null" {
+		return origin == "null"
+	}
+	u, err := url.Parse(origin)
+	return err != nil || !strings.EqualFold(u.Host, r.Host)
+}
+
+// isPublicPath reports whether a path is served without a login. Share
+// links check their own secret.
+func isPublicPath(path string) bool {
+	return path == "/login" || path == "/logout" ||
+		strings.HasPrefix(path, "/static/") || strings.HasPrefix(path, "/s/")
+}
+
+func isAPIPath(path string) bool {
+	return strings.HasPrefix(path, "/api/")
+}
+
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	next := safeRedirect(r.FormValue("next"))
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		if !s.authRequired {
+			http.Redirect(w, r, next, http.StatusSeeOther)
+			return
+		}
+		s.renderLogin(w, http.StatusOK, next, "")
+
+	case http.MethodPost:
+		token, err := s.db.AuthenticateAPIToken(strings.TrimSpace(r.PostFormValue("token")))
+		if errors.Is(err, database.ErrInvalidToken) {
+			s.renderLogin(w, http.StatusUnauthorized, next, "Invalid or revoked token")
+			return
+		}
+		if err != nil {
+			logger.Error("Failed to authenticate login", "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		id, _ := s.sessions.create(token.ID)
+		http.SetCookie(w, &http.Cookie{
+			Name:     sessionCookie,
+			Value:    id,
+			Path:     "/",
+			Expires:  time.Now().Add(sessionTTL),
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteLaxMode,
+		})
+		http.Redirect(w, r, next, http.StatusSeeOther)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if cookie, err := r.Cookie(sessionCookie); err == nil {
+		s.sessions.delete(cookie.Value)
+	}
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: "", Path: "/", MaxAge: -1, HttpOnly: true})
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+func (s *Server) renderLogin(w http.ResponseWriter, status int, next, message string) {
+	data := struct {
+		Title string
+		Next  string
+		Error string
+	}{
+		Title: "Placeli - Log in",
+		Next:  next,
+		Error: message,
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := s.tmpl.ExecuteTemplate(w, "login.html", data); err != nil {
+		logger.Error("Failed to render template", "error", err)
+	}
+}
+
+// safeRedirect only allows redirects to paths on this server
+func safeRedirect(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
+
+// IsLoopback reports whether a listen host only accepts local connections
+func IsLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
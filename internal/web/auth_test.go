@@ -0,0 +1,216 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/placeli/internal/database"
+)
+
+func setupAuthServer(t *testing.T) (http.Handler, *database.DB, string, string) {
+	server, db := setupTestServer(t)
+	t.Cleanup(func() { db.Close() })
+	server.RequireAuth(true)
+
+	_, readSecret, err := db.CreateAPIToken("viewer", database.ScopeRead)
+	require.NoError(t, err)
+	_, writeSecret, err := db.CreateAPIToken("editor", database.ScopeWrite)
+	require.NoError(t, err)
+	return server.Handler(), db, readSecret, writeSecret
+}
+
+func bearerRequest(t *testing.T, h http.Handler, method, path, secret, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if secret != "" {
+		req.Header.Set("Authorization", "Bearer "+secret)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuth_BearerScopes(t *testing.T) {
+	h, db, readSecret, writeSecret := setupAuthServer(t)
+	patch := `{"user_notes": "changed"}`
+
+	w := bearerRequest(t, h, "GET", "/api/v1/places", "", "")
+	assertAPIError(t, w, http.StatusUnauthorized, "unauthorized")
+	assert.Contains(t, w.Header().Get("WWW-Authenticate"), "Bearer")
+	assertAPIError(t, bearerRequest(t, h, "GET", "/api/v1/places", "plc_wrong", ""), http.StatusUnauthorized, "unauthorized")
+
+	assert.Equal(t, http.StatusOK, bearerRequest(t, h, "GET", "/api/v1/places", readSecret, "").Code)
+	assert.Equal(t, http.StatusOK, bearerRequest(t, h, "GET", "/api/places", readSecret, "").Code)
+	assertAPIError(t, bearerRequest(t, h, "PATCH", "/api/v1/places/test-place-1", readSecret, patch), http.StatusForbidden, "forbidden")
+	assertAPIError(t, bearerRequest(t, h, "PUT", "/api/place/test-place-1", readSecret, `{"name": "x"}`), http.StatusForbidden, "forbidden")
+
+	w = bearerRequest(t, h, "PATCH", "/api/v1/places/test-place-1", writeSecret, patch)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	place, err := db.GetPlace("test-place-1")
+	require.NoError(t, err)
+	assert.Equal(t, "changed", place.UserNotes)
+
+	_, err = db.RevokeAPIToken("editor")
+	require.NoError(t, err)
+	assertAPIError(t, bearerRequest(t, h, "PATCH", "/api/v1/places/test-place-1", writeSecret, patch), http.StatusUnauthorized, "unauthorized")
+}
+
+func TestAuth_BrowserLogin(t *testing.T) {
+	h, db, _, writeSecret := setupAuthServer(t)
+
+	// Pages redirect to the login form; static files stay public
+	w := bearerRequest(t, h, "GET", "/?q=1", "", "")
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+	assert.Equal(t, "/login?next="+url.QueryEscape("/?q=1"), w.Header().Get("Location"))
+	assert.Equal(t, http.StatusOK, bearerRequest(t, h, "GET", "/login", "", "").Code)
+	assert.Equal(t, http.StatusOK, bearerRequest(t, h, "GET", "/static/style.css", "", "").Code)
+
+	login := func(secret, next string) *httptest.ResponseRecorder {
+		form := url.Values{"token": {secret}, "next": {next}}
+		req := httptest.NewRequest("POST", "/login", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, login("plc_wrong", "/").Code)
+	w = login(writeSecret, "//evil.example")
+	require.Equal(t, http.StatusSeeOther, w.Code)
+	assert.Equal(t, "/", w.Header().Get("Location"), "only local redirects")
+
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	cookie := cookies[0]
+	assert.True(t, cookie.HttpOnly)
+
+	withSession := func(method, path, csrf string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(`{"user_notes": "from the browser"}`))
+		req.AddCookie(cookie)
+		if csrf != "" {
+			req.Header.Set(csrfHeader, csrf)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w = withSession("GET", "/", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "editor")
+	match := regexp.MustCompile(`name="csrf-token" content="([0-9a-f]+)"`).FindStringSubmatch(w.Body.String())
+	require.Len(t, match, 2)
+	csrf := match[1]
+
+	assertAPIError(t, withSession("PATCH", "/api/v1/places/test-place-1", ""), http.StatusForbidden, "csrf")
+	assertAPIError(t, withSession("PATCH", "/api/v1/places/test-place-1", "bogus"), http.StatusForbidden, "csrf")
+	assert.Equal(t, http.StatusOK, withSession("PATCH", "/api/v1/places/test-place-1", csrf).Code)
+
+	// Revoking the token ends its sessions
+	_, err := db.RevokeAPIToken("editor")
+	require.NoError(t, err)
+	assertAPIError(t, withSession("GET", "/api/v1/places", ""), http.StatusUnauthorized, "unauthorized")
+}
+
+func TestAuth_Logout(t *testing.T) {
+	h, _, readSecret, _ := setupAuthServer(t)
+
+	form := url.Values{"token": {readSecret}}
+	req := httptest.NewRequest("POST", "/login", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	cookie := w.Result().Cookies()[0]
+
+	req = httptest.NewRequest("POST", "/logout", nil)
+	req.AddCookie(cookie)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+
+	req = httptest.NewRequest("GET", "/api/v1/places", nil)
+	req.AddCookie(cookie)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAuth_CrossSiteWrites(t *testing.T) {
+	// Refused even with auth off, so other sites can't write to a local server
+	server, db := setupTestServer(t)
+	defer db.Close()
+	h := server.Handler()
+
+	tests := []struct {
+		name     string
+		header   string
+		value    string
+		wantCode int
+	}{
+		{"Cross-site fetch", "Sec-Fetch-Site", "cross-site", http.StatusForbidden},
+		{"Foreign origin", "Origin", "http://evil.example", http.StatusForbidden},
+		{"Same origin", "Origin", "http://example.com", http.StatusOK},
+		{"Same-origin fetch", "Sec-Fetch-Site", "same-origin", http.StatusOK},
+		{"No browser headers", "", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("PATCH", "/api/v1/places/test-place-1", strings.NewReader(`{"user_notes": "x"}`))
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			assert.Equal(t, tt.wantCode, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestIsLoopback(t *testing.T) {
+	assert.True(t, IsLoopback("127.0.0.1"))
+	assert.True(t, IsLoopback("localhost"))
+	assert.True(t, IsLoopback("::1"))
+	assert.False(t, IsLoopback("0.0.0.0"))
+	assert.False(t, IsLoopback(""))
+	assert.False(t, IsLoopback("192.168.1.10"))
+}
+
+func TestAuth_TokensActAsTheirUser(t *testing.T) {
+	h, db, _, writeSecret := setupAuthServer(t)
+	_, err := db.AddUser("alice")
+	require.NoError(t, err)
+	_, aliceSecret, err := db.ForUser("alice").CreateAPIToken("alice-laptop", database.ScopeWrite)
+	require.NoError(t, err)
+
+	w := bearerRequest(t, h, "PATCH", "/api/v1/places/test-place-1", aliceSecret, `{"user_notes": "Alice was here", "user_tags": ["lunch"]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// The default user's notes and tags are untouched
+	place, err := db.GetPlace("test-place-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Great food", place.UserNotes)
+	assert.Equal(t, []string{"favorite"}, place.UserTags)
+
+	w = bearerRequest(t, h, "GET", "/api/v1/places?tag=lunch", aliceSecret, "")
+	var list apiList[map[string]interface{}]
+	decodeResponse(t, w, &list)
+	assert.Equal(t, 1, list.Total)
+
+	w = bearerRequest(t, h, "GET", "/api/v1/places?tag=lunch", writeSecret, "")
+	decodeResponse(t, w, &list)
+	assert.Equal(t, 0, list.Total)
+
+	// Shared tags are seen, and matched, by everyone
+	w = bearerRequest(t, h, "PATCH", "/api/v1/places/test-place-1", aliceSecret, `{"shared_tags": ["team"]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = bearerRequest(t, h, "GET", "/api/v1/places?tag=team", writeSecret, "")
+	decodeResponse(t, w, &list)
+	assert.Equal(t, 1, list.Total)
+}
@@ -0,0 +1,77 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/user/placeli/internal/logger"
+)
+
+const (
+	// watchInterval is how often the database is polled for writes made
+	// by other processes, such as the TUI
+	watchInterval = time.Second
+
+	// keepAliveInterval keeps idle event streams from being closed by
+	// proxies
+	keepAliveInterval = 30 * time.Second
+)
+
+// watchChanges publishes writes made by other processes to event stream
+// subscribers until ctx is done
+func (s *Server) watchChanges(ctx context.Context) {
+	if err := s.db.WatchExternalChanges(ctx, watchInterval); err != nil {
+		logger.Error("Failed to watch database for changes", "error", err)
+	}
+}
+
+// handleV1Events streams place changes as Server-Sent Events named
+// place.created, place.updated and place.deleted, with {"kind", "id"} as
+// data. Clients fetch the place to apply an update.
+func (s *Server) handleV1Events(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, r, http.MethodGet)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "internal", "streaming not supported")
+		return
+	}
+
+	changes, unsubscribe := s.db.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 3000\n\n")
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case change, ok := <-changes:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(change)
+			if err != nil {
+				logger.Error("Failed to encode change", "error", err)
+				continue
+			}
+			fmt.Fprintf(w, "event: place.%s\ndata: %s\n\n", change.Kind, data)
+		}
+		flusher.Flush()
+	}
+}
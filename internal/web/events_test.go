@@ -0,0 +1,68 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/placeli/internal/models"
+)
+
+// readEvent reads one Server-Sent Event, skipping comments and retry hints
+func readEvent(t *testing.T, reader *bufio.Reader) (string, string) {
+	t.Helper()
+	var event, data string
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "" && event != "":
+			return event, data
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestAPIv1_Events(t *testing.T) {
+	server, db := setupTestServer(t)
+	defer db.Close()
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", ts.URL+"/api/v1/events", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	reader := bufio.NewReader(resp.Body)
+
+	// The subscription exists once the headers are sent
+	require.NoError(t, db.SavePlace(&models.Place{ID: "new-place", Name: "New"}))
+	event, data := readEvent(t, reader)
+	assert.Equal(t, "place.created", event)
+	assert.JSONEq(t, `{"kind": "created", "id": "new-place"}`, data)
+
+	w := apiRequest(t, server.Handler(), "PATCH", "/api/v1/places/test-place-1", `{"user_notes": "edited"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	event, data = readEvent(t, reader)
+	assert.Equal(t, "place.updated", event)
+	assert.JSONEq(t, `{"kind": "updated", "id": "test-place-1"}`, data)
+
+	require.NoError(t, db.DeletePlace("new-place"))
+	event, _ = readEvent(t, reader)
+	assert.Equal(t, "place.deleted", event)
+}
@@ -0,0 +1,87 @@
+// Command gencompress writes a Brotli compressed .br sibling for each text
+// file of the web app bundle, which the asset server sends to browsers
+// that accept Brotli. It also removes .br files whose source is gone. Run
+// it through go generate after changing the bundle:
+//
+//	go generate ./internal/web
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// minSize matches the asset server's gzipMinSize: smaller files aren't
+// worth compressing
+const minSize = 512
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: gencompress <dir>")
+		os.Exit(2)
+	}
+	if err := compressDir(os.Args[1]); err != nil {
+		fmt.Fprintln(os.Stderr, "gencompress:", err)
+		os.Exit(1)
+	}
+}
+
+func compressDir(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if source, ok := strings.CutSuffix(path, ".br"); ok {
+			if _, err := os.Stat(source); os.IsNotExist(err) {
+				return os.Remove(path)
+			}
+			return nil
+		}
+		if strings.HasSuffix(path, ".gz") || !isCompressible(path) {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		compressed, err := compress(data)
+		if err != nil {
+			return fmt.Errorf("failed to compress %s: %w", path, err)
+		}
+		if len(data) < minSize || len(compressed) >= len(data) {
+			if err := os.Remove(path + ".br"); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			return nil
+		}
+		return os.WriteFile(path+".br", compressed, 0644)
+	})
+}
+
+// isCompressible reports whether a file holds text, going by its
+// extension as the asset server goes by its content type
+func isCompressible(path string) bool {
+	mediaType, _, _ := mime.ParseMediaType(mime.TypeByExtension(filepath.Ext(path)))
+	return strings.HasPrefix(mediaType, "text/") || mediaType == "image/svg+xml" ||
+		strings.HasSuffix(mediaType, "javascript") || strings.HasSuffix(mediaType, "json")
+}
+
+func compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	bw := brotli.NewWriterLevel(&buf, brotli.BestCompression)
+	if _, err := bw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := bw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
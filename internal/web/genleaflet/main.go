@@ -0,0 +1,174 @@
+// Command genleaflet vendors the Leaflet map library into the web app
+// bundle, so the map works without reaching a CDN. It downloads the
+// pinned release from the npm registry, checks it against the registry's
+// integrity hash, and writes the script, stylesheet, images and license to
+// a directory. It does nothing if that directory already has the release.
+// Run it through go generate:
+//
+//	go generate ./internal/web
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// version is the Leaflet release the web app is written against
+const version = "1.9.4"
+
+// files maps the files taken from the package to where they go
+var files = map[string]string{
+	"package/dist/leaflet.js":                "leaflet.js",
+	"package/dist/leaflet.css":               "leaflet.css",
+	"package/dist/images/layers.png":         "images/layers.png",
+	"package/dist/images/layers-2x.png":      "images/layers-2x.png",
+	"package/dist/images/marker-icon.png":    "images/marker-icon.png",
+	"package/dist/images/marker-icon-2x.png": "images/marker-icon-2x.png",
+	"package/dist/images/marker-shadow.png":  "images/marker-shadow.png",
+	"package/LICENSE":                        "LICENSE",
+}
+
+var client = &http.Client{Timeout: time.Minute}
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: genleaflet <dir>")
+		os.Exit(2)
+	}
+	if err := vendor(os.Args[1]); err != nil {
+		fmt.Fprintln(os.Stderr, "genleaflet:", err)
+		os.Exit(1)
+	}
+}
+
+func vendor(dir string) error {
+	if current(dir) {
+		return nil
+	}
+
+	var release struct {
+		Dist struct {
+			Tarball   string `json:"tarball"`
+			Integrity string `json:"integrity"`
+		} `json:"dist"`
+	}
+	meta, err := fetch("https://registry.npmjs.org/leaflet/" + version)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(meta, &release); err != nil {
+		return fmt.Errorf("invalid registry response: %w", err)
+	}
+	tarball, err := fetch(release.Dist.Tarball)
+	if err != nil {
+		return err
+	}
+	if err := checkIntegrity(tarball, release.Dist.Integrity); err != nil {
+		return err
+	}
+
+	extracted, err := extract(tarball)
+	if err != nil {
+		return err
+	}
+	for source, name := range files {
+		data, ok := extracted[source]
+		if !ok {
+			return fmt.Errorf("leaflet %s has no %s", version, source)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// current reports whether dir already holds the pinned release
+func current(dir string) bool {
+	script, err := os.ReadFile(filepath.Join(dir, "leaflet.js"))
+	if err != nil || !bytes.Contains(script, []byte("Leaflet "+version+",")) {
+		return false
+	}
+	for _, name := range files {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func fetch(url string) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// checkIntegrity checks data against an npm integrity string, the
+// Subresource Integrity form "sha512-<base64 digest>"
+func checkIntegrity(data []byte, integrity string) error {
+	want, ok := strings.CutPrefix(integrity, "sha512-")
+	if !ok {
+		return fmt.Errorf("unsupported integrity %q", integrity)
+	}
+	sum := sha512.Sum512(data)
+	if got := base64.StdEncoding.EncodeToString(sum[:]); got != want {
+		return errors.New("leaflet " + version + " tarball doesn't match its integrity hash")
+	}
+	return nil
+}
+
+// extract returns the regular files of a gzipped tarball by path
+func extract(tarball []byte) (map[string][]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(tarball))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	extracted := make(map[string][]byte)
+	tr := tar.NewReader(zr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return extracted, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid tarball: %w", err)
+		}
+		name := path.Clean(header.Name)
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if _, wanted := files[name]; !wanted {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		extracted[name] = data
+	}
+}
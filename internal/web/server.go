@@ -1,77 +1,232 @@
 package web
 
 import (
+	"bytes"
+	"context"
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
+	"io/fs"
+	"net"
 	"net/http"
+	"path"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/user/placeli/internal/constants"
 	"github.com/user/placeli/internal/database"
 	"github.com/user/placeli/internal/logger"
 	"github.com/user/placeli/internal/models"
+	"github.com/user/placeli/internal/photos"
+	"github.com/user/placeli/internal/tiles"
 )
 
 //go:embed templates/*
 var templates embed.FS
 
-//go:embed static/*
+// static is the web app: its stylesheet and JavaScript modules, with
+// optional precompressed .br and .gz siblings
+//
+//go:embed static
 var static embed.FS
 
 type Server struct {
 	db     *database.DB
 	tmpl   *template.Template
+	host   string
 	port   int
 	apiKey string
+	photos *photos.Store
+	assets *assetServer
+
+	tiles        *tiles.Cache
+	tileUpstream *tiles.Fetcher
+
+	authRequired bool
+	sessions     *sessionStore
 }
 
 func NewServer(db *database.DB, port int, apiKey string) (*Server, error) {
-	tmpl, err := template.ParseFS(templates, "templates/*.html")
+	bundle, err := fs.Sub(static, "static")
+	if err != nil {
+		return nil, err
+	}
+	assets, err := newAssetServer(bundle)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := template.New("").Funcs(template.FuncMap{
+		"asset":  assets.url,
+		"vendor": assets.vendorURL,
+		// Import maps aren't JavaScript to html/template, which would
+		// escape the JSON as HTML text
+		"importMap": func() template.HTML { return template.HTML(assets.importMap()) },
+	}).ParseFS(templates, "templates/*.html")
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse templates: %w", err)
 	}
 
 	return &Server{
-		db:     db,
-		tmpl:   tmpl,
-		port:   port,
-		apiKey: apiKey,
+		db:       db,
+		tmpl:     tmpl,
+		assets:   assets,
+		host:     "127.0.0.1",
+		port:     port,
+		apiKey:   apiKey,
+		sessions: newSessionStore(),
 	}, nil
 }
 
-func (s *Server) Start() error {
+// SetHost sets the address to listen on. The default, 127.0.0.1, only
+// accepts connections from this machine.
+func (s *Server) SetHost(host string) {
+	s.host = host
+}
+
+// RequireAuth makes every request, except the login page, authenticate
+// with an API token or a session cookie from logging in with one
+func (s *Server) RequireAuth(required bool) {
+	s.authRequired = required
+}
+
+// SetPhotoStore enables serving photos and thumbnails under /photos/
+func (s *Server) SetPhotoStore(store *photos.Store) {
+	s.photos = store
+}
+
+// Handler returns the server's routes: the web interface, the legacy
+// /api endpoints it uses, the /api/v1 REST API, photos, map tiles, share
+// links and static files, all behind the auth checks
+func (s *Server) Handler() http.Handler {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/", s.handleIndex)
+	mux.HandleFunc("/login", s.handleLogin)
+	mux.HandleFunc("/logout", s.handleLogout)
 	mux.HandleFunc("/api/places", s.handleAPIPlaces)
 	mux.HandleFunc("/api/place/", s.handleAPIPlace)
-	mux.Handle("/static/", http.FileServer(http.FS(static)))
+	s.registerAPI(mux)
+	mux.HandleFunc("/photos/", s.handlePhoto)
+	mux.HandleFunc("/tiles/{z}/{x}/{y}", s.handleTile)
+	mux.HandleFunc("/tiles/places/{z}/{x}/{y}", s.handlePlaceTile)
+	mux.HandleFunc("/s/{token}", s.handleShare)
+	mux.HandleFunc("/s/{token}/tiles/{z}/{x}/{y}", s.handleShareTile)
+	mux.Handle(assetPrefix, s.assets)
 
-	addr := fmt.Sprintf(":%d", s.port)
-	logger.Info("Starting web server", "address", addr)
-	fmt.Printf("Web interface available at http://localhost:%d\n", s.port)
+	return s.withAuth(mux)
+}
+
+func (s *Server) Start() error {
+	addr := net.JoinHostPort(s.host, strconv.Itoa(s.port))
+	logger.Info("Starting web server", "address", addr, "auth", s.authRequired)
 
-	return http.ListenAndServe(addr, mux)
+	host := s.host
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	base := "http://" + net.JoinHostPort(host, strconv.Itoa(s.port))
+	fmt.Printf("Web interface available at %s\n", base)
+	fmt.Printf("REST API available at %s/api/v1 (see /api/v1/openapi.json)\n", base)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.watchChanges(ctx)
+
+	return http.ListenAndServe(addr, s.Handler())
 }
 
+// appConfig is what the web app needs to know from the server, embedded
+// in its page as JSON
+type appConfig struct {
+	TileURL  string `json:"tile_url"`
+	User     string `json:"user,omitempty"`
+	CanWrite bool   `json:"can_write"`
+}
+
+// reservedPrefixes are the paths the server handles itself, which never
+// fall back to the web app
+var reservedPrefixes = []string{"/api/", "/photos/", "/tiles/", "/static/", "/s/"}
+
+// isAppRoute reports whether a path is one of the web app's views: the
+// list, the map, the stats and a place's details
+func isAppRoute(p string) bool {
+	switch p {
+	case "/", "/map", "/stats":
+		return true
+	}
+	id, ok := strings.CutPrefix(p, "/places/")
+	return ok && id != "" && !strings.Contains(id, "/")
+}
+
+// handleIndex serves the web app's page, whose router shows the view for
+// the URL. Other page paths get the app too, with a 404, so it can say so
+// in the app's own layout; paths under the server's other prefixes and
+// paths that look like files get a plain 404, so a missing script or
+// endpoint never comes back as HTML.
 func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
-	if r.URL.Path != "/" {
-		http.NotFound(w, r)
+	status := http.StatusOK
+	if !isAppRoute(r.URL.Path) {
+		for _, prefix := range reservedPrefixes {
+			if strings.HasPrefix(r.URL.Path, prefix) {
+				http.NotFound(w, r)
+				return
+			}
+		}
+		if path.Ext(r.URL.Path) != "" {
+			http.NotFound(w, r)
+			return
+		}
+		status = http.StatusNotFound
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
 	data := struct {
-		Title  string
-		APIKey string
+		Title     string
+		User      string
+		CanWrite  bool
+		CSRFToken string
+		Config    appConfig
 	}{
-		Title:  "Placeli - Saved Places",
-		APIKey: s.apiKey,
+		Title:    "Placeli - Saved Places",
+		CanWrite: true,
+	}
+	if id := requestIdentity(r); id != nil {
+		data.User = id.token.Name
+		if id.token.User != "" {
+			data.User = id.token.User
+		}
+		data.CanWrite = id.token.CanWrite()
+		if id.session != nil {
+			data.CSRFToken = id.session.csrf
+		}
+	}
+	data.Config = appConfig{
+		TileURL:  s.mapTileURL(),
+		User:     data.User,
+		CanWrite: data.CanWrite,
 	}
 
-	if err := s.tmpl.ExecuteTemplate(w, "index.html", data); err != nil {
+	var buf bytes.Buffer
+	if err := s.tmpl.ExecuteTemplate(&buf, "index.html", data); err != nil {
 		logger.Error("Failed to render template", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	// The page names the current asset versions and carries the session's
+	// CSRF token, so it is checked every time
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.WriteHeader(status)
+	if r.Method != http.MethodHead {
+		w.Write(buf.Bytes())
 	}
 }
 
@@ -82,6 +237,10 @@ func (s *Server) handleAPIPlaces(w http.ResponseWriter, r *http.Request) {
 	}
 
 	query := r.URL.Query()
+	if query.Has("bbox") {
+		s.handlePlacesInView(w, r)
+		return
+	}
 	limitStr := query.Get("limit")
 	offsetStr := query.Get("offset")
 	search := query.Get("search")
@@ -101,13 +260,40 @@ func (s *Server) handleAPIPlaces(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	openNow, err := boolParam(query.Get("open_now"))
+	if err != nil {
+		http.Error(w, "Invalid open_now: must be true or false", http.StatusBadRequest)
+		return
+	}
+	openMatch, err := models.OpenMatcher(openNow, query.Get("open_at"), time.Now())
+	if err != nil {
+		http.Error(w, "Invalid open_at: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var insideMatch func(*models.Place) bool
+	if inside := query.Get("inside"); inside != "" {
+		area, err := s.dbFor(r).GetPlace(inside)
+		if err != nil {
+			http.Error(w, "Area not found", http.StatusNotFound)
+			return
+		}
+		if insideMatch, err = models.InsideMatcher(area); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	match := models.MatchAll(openMatch, insideMatch)
+
 	var places []*models.Place
-	var err error
 
-	if search != "" {
-		places, err = s.db.SearchPlaces(search)
-	} else {
-		places, err = s.db.ListPlaces(limit, offset)
+	switch {
+	case search != "":
+		places, err = s.dbFor(r).SearchPlaces(search)
+	case match != nil:
+		places, err = s.dbFor(r).ListPlaces(constants.DefaultPlaceLimit, 0)
+	default:
+		places, err = s.dbFor(r).ListPlaces(limit, offset)
 	}
 
 	if err != nil {
@@ -116,12 +302,30 @@ func (s *Server) handleAPIPlaces(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if match != nil {
+		places = models.FilterPlaces(places, match)
+		if search == "" {
+			places = paginate(places, limit, offset)
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(places); err != nil {
 		logger.Error("Failed to encode response", "error", err)
 	}
 }
 
+func paginate(places []*models.Place, limit, offset int) []*models.Place {
+	if offset >= len(places) {
+		return []*models.Place{}
+	}
+	end := offset + limit
+	if end > len(places) {
+		end = len(places)
+	}
+	return places[offset:end]
+}
+
 func (s *Server) handleAPIPlace(w http.ResponseWriter, r *http.Request) {
 	id := r.URL.Path[len("/api/place/"):]
 	if id == "" {
@@ -131,7 +335,7 @@ func (s *Server) handleAPIPlace(w http.ResponseWriter, r *http.Request) {
 
 	switch r.Method {
 	case http.MethodGet:
-		place, err := s.db.GetPlace(id)
+		place, err := s.dbFor(r).GetPlace(id)
 		if err != nil {
 			http.Error(w, "Place not found", http.StatusNotFound)
 			return
@@ -143,21 +347,25 @@ func (s *Server) handleAPIPlace(w http.ResponseWriter, r *http.Request) {
 		}
 
 	case http.MethodPut:
-		var place models.Place
-		if err := json.NewDecoder(r.Body).Decode(&place); err != nil {
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
+		// The body is merged into the place like a v1 PATCH, so fields it
+		// leaves out, and other users' shared data, are kept
+		place, err := s.dbFor(r).GetPlace(id)
+		if err != nil {
+			http.Error(w, "Place not found", http.StatusNotFound)
 			return
 		}
-
-		place.ID = id
-		if err := s.db.SavePlace(&place); err != nil {
+		updated, ok := patchPlace(w, r, place)
+		if !ok {
+			return
+		}
+		if err := s.dbFor(r).SavePlace(updated); err != nil {
 			logger.Error("Failed to update place", "error", err)
 			http.Error(w, "Failed to update place", http.StatusInternalServerError)
 			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(&place); err != nil {
+		if err := json.NewEncoder(w).Encode(updated); err != nil {
 			logger.Error("Failed to encode response", "error", err)
 		}
 
@@ -165,3 +373,81 @@ func (s *Server) handleAPIPlace(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 	}
 }
+
+// handlePlacesInView serves /api/places?bbox=minLng,minLat,maxLng,maxLat&zoom=z
+// for the map: clusters and place summaries instead of full places, for
+// only the part of the map on screen
+func (s *Server) handlePlacesInView(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	bbox, err := tiles.ParseBBox(query.Get("bbox"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	zoom := database.ClusterMaxZoom
+	if zoomStr := query.Get("zoom"); zoomStr != "" {
+		zoom, err = strconv.Atoi(zoomStr)
+		if err != nil || zoom < 0 || zoom > tiles.MaxZoom {
+			http.Error(w, "Invalid zoom", http.StatusBadRequest)
+			return
+		}
+	}
+
+	view, err := s.dbFor(r).PlacesInView(
+		models.Coordinates{Lat: bbox.MinLat, Lng: bbox.MinLng},
+		models.Coordinates{Lat: bbox.MaxLat, Lng: bbox.MaxLng},
+		zoom)
+	if err != nil {
+		logger.Error("Failed to fetch places in view", "error", err)
+		http.Error(w, "Failed to fetch places", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(view); err != nil {
+		logger.Error("Failed to encode response", "error", err)
+	}
+}
+
+// handlePhoto serves /photos/{hash}, or a thumbnail with ?size=128|512
+func (s *Server) handlePhoto(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	hash := strings.TrimPrefix(r.URL.Path, "/photos/")
+	if s.photos == nil || !s.photos.Has(hash) {
+		http.NotFound(w, r)
+		return
+	}
+
+	path := s.photos.Path(hash)
+	if sizeStr := r.URL.Query().Get("size"); sizeStr != "" {
+		size, err := strconv.Atoi(sizeStr)
+		if err != nil {
+			http.Error(w, "Invalid size", http.StatusBadRequest)
+			return
+		}
+
+		thumb, err := s.photos.Thumbnail(hash, size)
+		switch {
+		case err == nil:
+			path = thumb
+		case errors.Is(err, photos.ErrUnsupportedSize):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, photos.ErrUndecodable):
+			// Serve the original when no thumbnail can be made
+		default:
+			logger.Error("Failed to create thumbnail", "hash", hash, "error", err)
+			http.Error(w, "Failed to create thumbnail", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// Content-addressed files never change
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	http.ServeFile(w, r, path)
+}
@@ -1,9 +1,13 @@
 package web
 
 import (
+	"bytes"
 	"encoding/json"
+	"image"
+	"image/png"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -11,6 +15,8 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/user/placeli/internal/database"
 	"github.com/user/placeli/internal/models"
+	"github.com/user/placeli/internal/photos"
+	"github.com/user/placeli/internal/tiles"
 )
 
 func setupTestServer(t *testing.T) (*Server, *database.DB) {
@@ -51,7 +57,47 @@ func TestHandleIndex(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), "Placeli")
-	assert.Contains(t, w.Body.String(), "test-api-key")
+	assert.NotContains(t, w.Body.String(), "test-api-key", "the Google API key stays on the server")
+}
+
+func TestHandleIndex_Routes(t *testing.T) {
+	server, db := setupTestServer(t)
+	defer db.Close()
+	h := server.Handler()
+
+	request := func(method, target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	// Every view is the same page; the app's router shows the right one
+	for _, path := range []string{"/", "/map", "/stats", "/places/test-place-1", "/?q=pizza&open=1"} {
+		w := request("GET", path)
+		assert.Equal(t, http.StatusOK, w.Code, path)
+		assert.Contains(t, w.Body.String(), `id="view"`, path)
+		assert.Equal(t, "private, no-cache", w.Header().Get("Cache-Control"), path)
+	}
+
+	// Unknown pages get the app, to say so in its layout, with a 404
+	w := request("GET", "/nowhere/else")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), `id="view"`)
+
+	// Missing files and endpoints don't fall back to the app
+	for _, path := range []string{"/favicon.ico", "/api/nope", "/static/missing.js", "/photos/", "/tiles/1/2", "/s/"} {
+		w := request("GET", path)
+		assert.Equal(t, http.StatusNotFound, w.Code, path)
+		assert.NotContains(t, w.Body.String(), `id="view"`, path)
+	}
+
+	w = request("HEAD", "/map")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Body.String())
+	w = request("POST", "/map")
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "GET, HEAD", w.Header().Get("Allow"))
 }
 
 func TestHandleAPIPlaces(t *testing.T) {
@@ -109,6 +155,123 @@ func TestHandleAPIPlaces(t *testing.T) {
 	}
 }
 
+func TestHandleAPIPlacesOpenFilter(t *testing.T) {
+	server, db := setupTestServer(t)
+	defer db.Close()
+
+	place := &models.Place{
+		ID:    "always-open",
+		Name:  "All Night Diner",
+		Hours: "24/7",
+	}
+	require.NoError(t, db.SavePlace(place))
+
+	tests := []struct {
+		name     string
+		url      string
+		wantCode int
+		wantLen  int
+	}{
+		{"Open now", "/api/places?open_now=1", http.StatusOK, 1},
+		{"Open at", "/api/places?open_at=sun+03:00", http.StatusOK, 1},
+		{"Open now with search", "/api/places?open_now=1&search=Test", http.StatusOK, 0},
+		{"Open now off", "/api/places?open_now=false", http.StatusOK, 2},
+		{"Open now zero", "/api/places?open_now=0", http.StatusOK, 2},
+		{"Invalid open_now", "/api/places?open_now=maybe", http.StatusBadRequest, 0},
+		{"Invalid open_at", "/api/places?open_at=later", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.url, nil)
+			w := httptest.NewRecorder()
+
+			server.handleAPIPlaces(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+
+			if tt.wantCode == http.StatusOK {
+				var places []*models.Place
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &places))
+				assert.Len(t, places, tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestHandleAPIPlacesInView(t *testing.T) {
+	server, db := setupTestServer(t)
+	defer db.Close()
+
+	tests := []struct {
+		name       string
+		url        string
+		wantCode   int
+		wantPlaces int
+	}{
+		{"San Francisco", "/api/places?bbox=-122.5,37.7,-122.3,37.8&zoom=12", http.StatusOK, 1},
+		{"Default zoom", "/api/places?bbox=-122.5,37.7,-122.3,37.8", http.StatusOK, 1},
+		{"Elsewhere", "/api/places?bbox=-9.2,38.7,-9.1,38.8&zoom=12", http.StatusOK, 0},
+		{"Invalid bbox", "/api/places?bbox=1,2,3", http.StatusBadRequest, 0},
+		{"Invalid zoom", "/api/places?bbox=-122.5,37.7,-122.3,37.8&zoom=99", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.url, nil)
+			w := httptest.NewRecorder()
+
+			server.handleAPIPlaces(w, req)
+
+			require.Equal(t, tt.wantCode, w.Code, w.Body.String())
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			var view database.MapView
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &view))
+			assert.Len(t, view.Places, tt.wantPlaces)
+			assert.Equal(t, tt.wantPlaces, view.Total)
+			assert.NotNil(t, view.Clusters)
+			if tt.wantPlaces > 0 {
+				place := view.Places[0]
+				assert.Equal(t, "test-place-1", place.ID)
+				assert.Equal(t, []string{"favorite"}, place.Tags)
+				assert.NotContains(t, w.Body.String(), "Great food", "summaries leave out notes")
+			}
+		})
+	}
+}
+
+func TestHandlePlaceTile(t *testing.T) {
+	server, db := setupTestServer(t)
+	defer db.Close()
+	h := server.Handler()
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	tile := tiles.TileAt(37.7749, -122.4194, 14)
+	w := get("/tiles/places/" + tile.String() + ".mvt")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/vnd.mapbox-vector-tile", w.Header().Get("Content-Type"))
+	body := w.Body.String()
+	for _, want := range []string{"places", "Test Place", "test-place-1", "favorite", "Restaurant", "rating"} {
+		assert.Contains(t, body, want)
+	}
+	assert.NotContains(t, body, "shapes", "no place has an outline")
+
+	// Tiles without places are empty
+	w = get("/tiles/places/14/0/0.mvt")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Zero(t, w.Body.Len())
+
+	assert.Equal(t, http.StatusNotFound, get("/tiles/places/1/2/0.mvt").Code)
+	assert.Equal(t, http.StatusNotFound, get("/tiles/places/a/0/0.mvt").Code)
+}
+
 func TestHandleAPIPlacesInvalidMethod(t *testing.T) {
 	server, db := setupTestServer(t)
 	defer db.Close()
@@ -158,6 +321,24 @@ func TestHandleAPIPlace(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, "Updated Place", place.Name)
 		assert.Equal(t, "456 New St", place.Address)
+
+		// Fields the body leaves out keep their value
+		saved, err := db.GetPlace(placeID)
+		require.NoError(t, err)
+		assert.Equal(t, "Updated Place", saved.Name)
+		assert.NotEmpty(t, saved.Categories)
+		assert.NotZero(t, saved.Coordinates.Lat)
+	})
+
+	t.Run("Update missing place", func(t *testing.T) {
+		req := httptest.NewRequest("PUT", "/api/place/99999", strings.NewReader(`{"name":"Ghost"}`))
+		w := httptest.NewRecorder()
+
+		server.handleAPIPlace(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		_, err := db.GetPlace("99999")
+		assert.Error(t, err)
 	})
 
 	t.Run("Invalid place ID", func(t *testing.T) {
@@ -202,3 +383,102 @@ func TestNewServer(t *testing.T) {
 	assert.NotNil(t, server.db)
 	assert.NotNil(t, server.tmpl)
 }
+
+func TestHandlePhoto(t *testing.T) {
+	server, db := setupTestServer(t)
+	defer db.Close()
+
+	store, err := photos.NewStore(t.TempDir())
+	require.NoError(t, err)
+	server.SetPhotoStore(store)
+
+	img := image.NewRGBA(image.Rect(0, 0, 600, 300))
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	hash, err := store.Put(buf.Bytes())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		url         string
+		wantCode    int
+		wantType    string
+		wantPNGSize bool
+	}{
+		{"Original", "/photos/" + hash, http.StatusOK, "image/png", true},
+		{"Thumbnail", "/photos/" + hash + "?size=128", http.StatusOK, "image/jpeg", false},
+		{"Unsupported size", "/photos/" + hash + "?size=77", http.StatusBadRequest, "", false},
+		{"Unknown hash", "/photos/" + photos.HashBytes([]byte("x")), http.StatusNotFound, "", false},
+		{"Invalid hash", "/photos/../places.db", http.StatusNotFound, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.url, nil)
+			w := httptest.NewRecorder()
+
+			server.handlePhoto(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			if tt.wantType != "" {
+				assert.Equal(t, tt.wantType, w.Header().Get("Content-Type"))
+			}
+			if tt.wantPNGSize {
+				assert.Equal(t, buf.Len(), w.Body.Len())
+			}
+		})
+	}
+}
+
+func TestHandleTile(t *testing.T) {
+	server, db := setupTestServer(t)
+	defer db.Close()
+
+	var upstreamHits int
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamHits++
+		if r.URL.Path == "/2/1/1.png" {
+			w.Write([]byte("\x89PNG\r\n\x1a\nupstream"))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer upstream.Close()
+
+	cache, err := tiles.Open(filepath.Join(t.TempDir(), "tiles.mbtiles"))
+	require.NoError(t, err)
+	defer cache.Close()
+	require.NoError(t, cache.Put(tiles.Tile{Z: 1, X: 0, Y: 1}, []byte("\x89PNG\r\n\x1a\ncached")))
+	server.SetTileCache(cache, upstream.URL+"/{z}/{x}/{y}.png")
+	h := server.Handler()
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	w := get("/tiles/1/0/1.png")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "cached")
+	assert.Equal(t, 0, upstreamHits)
+
+	// Missing tiles come from upstream once, then from the cache
+	assert.Contains(t, get("/tiles/2/1/1.png").Body.String(), "upstream")
+	assert.Contains(t, get("/tiles/2/1/1.png").Body.String(), "upstream")
+	assert.Equal(t, 1, upstreamHits)
+
+	assert.Equal(t, http.StatusNotFound, get("/tiles/2/3/3.png").Code)
+	assert.Equal(t, http.StatusNotFound, get("/tiles/1/2/0.png").Code, "outside the zoom level")
+	assert.Equal(t, http.StatusNotFound, get("/tiles/a/0/0.png").Code)
+
+	// Offline, only cached tiles are served
+	server.SetTileCache(cache, "")
+	h = server.Handler()
+	assert.Equal(t, http.StatusOK, get("/tiles/2/1/1.png").Code)
+	assert.Equal(t, http.StatusNotFound, get("/tiles/2/0/0.png").Code)
+
+	w = get("/")
+	assert.Contains(t, w.Body.String(), `"tile_url":"/tiles/{z}/{x}/{y}.png"`)
+}
@@ -0,0 +1,94 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/user/placeli/internal/database"
+	"github.com/user/placeli/internal/logger"
+	"github.com/user/placeli/internal/models"
+	"github.com/user/placeli/internal/tiles"
+)
+
+// handleShare serves /s/{token}, the read-only page of a share link. It
+// needs no login: the link's secret is the credential, and the page shows
+// only the share's tagged places, redacted by its policy.
+func (s *Server) handleShare(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	share, ok := s.loadShare(w, r)
+	if !ok {
+		return
+	}
+
+	places, err := s.db.ForUser(share.User).PlacesWithTag(share.Tag)
+	if err != nil {
+		logger.Error("Failed to fetch shared places", "share", share.ID, "error", err)
+		http.Error(w, "Failed to fetch places", http.StatusInternalServerError)
+		return
+	}
+	shared := make([]*models.Place, len(places))
+	for i, place := range places {
+		shared[i] = share.Redact(place)
+	}
+
+	mapTiles := tiles.DefaultURL
+	if s.tiles != nil {
+		mapTiles = "/s/" + r.PathValue("token") + tileURL
+	}
+
+	data := struct {
+		Title     string
+		Tag       string
+		Places    []*models.Place
+		ExpiresAt *time.Time
+		TileURL   string
+	}{
+		Title:     "Placeli - " + share.Tag,
+		Tag:       share.Tag,
+		Places:    shared,
+		ExpiresAt: share.ExpiresAt,
+		TileURL:   mapTiles,
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if err := s.tmpl.ExecuteTemplate(w, "share.html", data); err != nil {
+		logger.Error("Failed to render template", "error", err)
+	}
+}
+
+// handleShareTile serves the map tiles of a share page, so they work when
+// the rest of the server needs a login
+func (s *Server) handleShareTile(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.loadShare(w, r); ok {
+		s.handleTile(w, r)
+	}
+}
+
+// loadShare looks up the share link in the token path value, writing an
+// error page if it doesn't work. Links are kept out of Referer headers
+// and search engines.
+func (s *Server) loadShare(w http.ResponseWriter, r *http.Request) (*database.Share, bool) {
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("X-Robots-Tag", "noindex, nofollow")
+
+	share, err := s.db.GetShareBySecret(r.PathValue("token"))
+	switch {
+	case errors.Is(err, database.ErrInvalidShare):
+		http.NotFound(w, r)
+		return nil, false
+	case errors.Is(err, database.ErrShareExpired):
+		http.Error(w, "This link has expired or was revoked.", http.StatusGone)
+		return nil, false
+	case err != nil:
+		logger.Error("Failed to look up share", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil, false
+	}
+	return share, true
+}
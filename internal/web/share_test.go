@@ -0,0 +1,85 @@
+package web
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/user/placeli/internal/database"
+	"github.com/user/placeli/internal/models"
+	"github.com/user/placeli/internal/tiles"
+)
+
+func TestShareLinks(t *testing.T) {
+	h, db, _, _ := setupAuthServer(t)
+
+	private := &models.Place{
+		ID:           "private-place",
+		Name:         "Private Place",
+		Coordinates:  models.Coordinates{Lat: 38.71, Lng: -9.14},
+		UserNotes:    "door code 1234",
+		UserTags:     []string{"lisbon-trip", "secret-tag"},
+		CustomFields: map[string]interface{}{"dish": "bacalhau", "budget": 20},
+	}
+	other := &models.Place{ID: "other-place", Name: "Not Shared", UserTags: []string{"porto"}}
+	require.NoError(t, db.SavePlaces([]*models.Place{private, other}))
+
+	expires := time.Now().Add(24 * time.Hour)
+	_, secret, err := db.CreateShare("lisbon-trip", database.SharePolicy{Fields: []string{"dish"}}, &expires)
+	require.NoError(t, err)
+
+	// Share links need no login, even with auth on
+	w := bearerRequest(t, h, "GET", "/s/"+secret, "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "no-referrer", w.Header().Get("Referrer-Policy"))
+	body := w.Body.String()
+	for _, want := range []string{"Private Place", "bacalhau", "lisbon-trip", "link expires"} {
+		assert.Contains(t, body, want)
+	}
+	for _, hidden := range []string{"door code", "secret-tag", "budget", "Not Shared", "Test Place"} {
+		assert.NotContains(t, body, hidden)
+	}
+	assert.Contains(t, body, "tile.openstreetmap.org", "no cache, so tiles come straight from upstream")
+
+	assert.Equal(t, http.StatusNotFound, bearerRequest(t, h, "GET", "/s/"+secret+"x", "", "").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, bearerRequest(t, h, "POST", "/s/"+secret, "", "").Code)
+
+	// The rest of the server still needs a login
+	assert.Equal(t, http.StatusUnauthorized, bearerRequest(t, h, "GET", "/api/v1/places", "", "").Code)
+
+	shares, err := db.ListShares()
+	require.NoError(t, err)
+	_, err = db.RevokeShare(shares[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusGone, bearerRequest(t, h, "GET", "/s/"+secret, "", "").Code)
+}
+
+func TestShareTiles(t *testing.T) {
+	server, db := setupTestServer(t)
+	defer db.Close()
+	server.RequireAuth(true)
+
+	cache, err := tiles.Open(filepath.Join(t.TempDir(), "tiles.mbtiles"))
+	require.NoError(t, err)
+	defer cache.Close()
+	require.NoError(t, cache.Put(tiles.Tile{Z: 1, X: 0, Y: 1}, []byte("\x89PNG\r\n\x1a\ncached")))
+	server.SetTileCache(cache, "")
+	h := server.Handler()
+
+	_, secret, err := db.CreateShare("favorite", database.SharePolicy{}, nil)
+	require.NoError(t, err)
+
+	w := bearerRequest(t, h, "GET", "/s/"+secret, "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `\/s\/`+secret+`\/tiles\/{z}\/{x}\/{y}.png`)
+	assert.NotContains(t, w.Body.String(), "Great food", "notes are private by default")
+
+	w = bearerRequest(t, h, "GET", "/s/"+secret+"/tiles/1/0/1.png", "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "cached")
+	assert.Equal(t, http.StatusNotFound, bearerRequest(t, h, "GET", "/s/nope/tiles/1/0/1.png", "", "").Code)
+	assert.Equal(t, http.StatusSeeOther, bearerRequest(t, h, "GET", "/tiles/1/0/1.png", "", "").Code, "other tiles need a login")
+}
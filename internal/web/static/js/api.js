@@ -0,0 +1,74 @@
+// The JSON API. Writes go to /api/v1 with the session's CSRF token, and
+// edits of a place carry its updated_at as If-Match, so saving over
+// someone else's newer edit fails instead of silently undoing it.
+
+import { notify } from './util.js';
+
+export const config = JSON.parse(document.getElementById('config').textContent);
+
+const csrfToken = (document.querySelector('meta[name="csrf-token"]') || {}).content || '';
+
+// ConflictError is thrown when a place changed since it was loaded
+export class ConflictError extends Error {
+    constructor(place) {
+        super(`${place.name} was changed elsewhere; showing the latest version`);
+        this.place = place;
+    }
+}
+
+export async function getJSON(path) {
+    const response = await fetch(path, { headers: { Accept: 'application/json' } });
+    const data = await response.json().catch(() => null);
+    if (!response.ok) {
+        throw new Error(data && data.error ? data.error.message : `Request failed (${response.status})`);
+    }
+    return data;
+}
+
+// write sends a change and returns the response body, or null after
+// telling the user why it failed. place, when given, is the version the
+// change is based on.
+export async function write(method, path, body, place) {
+    const headers = { Accept: 'application/json' };
+    if (body !== undefined) headers['Content-Type'] = 'application/json';
+    if (csrfToken) headers['X-CSRF-Token'] = csrfToken;
+    if (place) headers['If-Match'] = `"${place.updated_at}"`;
+
+    let response;
+    try {
+        response = await fetch(path, { method, headers, body: body === undefined ? undefined : JSON.stringify(body) });
+    } catch (error) {
+        notify('Failed to save: the server is unreachable');
+        return null;
+    }
+    if (response.status === 412 && place) {
+        throw new ConflictError(place);
+    }
+    if (response.status === 204) return {};
+    const data = await response.json().catch(() => null);
+    if (!response.ok) {
+        notify(data && data.error ? data.error.message : `Failed to save (${response.status})`);
+        return null;
+    }
+    return data;
+}
+
+export function placePath(id) {
+    return `/api/v1/places/${encodeURIComponent(id)}`;
+}
+
+export function getPlace(id) {
+    return getJSON(placePath(id));
+}
+
+// listAll fetches every page of a list endpoint, calling onPage with each
+// page's items as they come. It stops early when onPage returns false.
+export async function listAll(path, params, onPage) {
+    const query = new URLSearchParams(params);
+    query.set('limit', '500');
+    for (;;) {
+        const page = await getJSON(`${path}?${query}`);
+        if (onPage(page.data || [], page.total) === false || !page.next_cursor) return;
+        query.set('cursor', page.next_cursor);
+    }
+}
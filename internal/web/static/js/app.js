@@ -0,0 +1,143 @@
+// The web app: a header with the views, search and filters, and below it
+// the view the URL names. Each view is a module whose mount(el, params,
+// app) draws it and returns {title, keys, unmount}.
+
+import { config, getJSON } from './api.js';
+import { installKeys, showHelp } from './keys.js';
+import { match, navigate, route, start } from './router.js';
+import { applyChange, emptyFilters, filterQuery, filtersFromQuery, setFilters, store } from './store.js';
+import { toggleTheme } from './theme.js';
+import { escapeAttr, escapeHtml } from './util.js';
+import * as detail from './views/detail.js';
+import * as list from './views/list.js';
+import * as map from './views/map.js';
+import * as stats from './views/stats.js';
+
+const notFound = {
+    mount(el) {
+        el.innerHTML = '<div class="error">There is no such page. <a href="/">Back to your places</a></div>';
+        return { title: 'Not found', keys: [], unmount() {} };
+    },
+};
+
+route('/', list);
+route('/map', map);
+route('/places/:id', detail);
+route('/stats', stats);
+
+const viewEl = document.getElementById('view');
+let current = null;
+
+// app is what views may ask of the app
+const app = {
+    navigate,
+    setTitle(title) {
+        document.title = `${title} - Placeli`;
+    },
+    loadTagOptions,
+};
+
+function show(location) {
+    const found = match(location.pathname);
+    const view = found ? found.view : notFound;
+    // The list, map and details show the places matching the URL's filters
+    if (view === list || view === map || view === detail) {
+        setFilters(filtersFromQuery(location.search));
+    }
+
+    if (current) current.unmount();
+    viewEl.innerHTML = '';
+    current = view.mount(viewEl, found ? found.params : {}, app);
+    current.view = view;
+    app.setTitle(current.title);
+    updateHeader();
+}
+
+function updateHeader() {
+    const names = new Map([[list, 'list'], [detail, 'list'], [map, 'map'], [stats, 'stats']]);
+    document.querySelectorAll('nav.views a').forEach(link => {
+        link.classList.toggle('active', link.dataset.view === names.get(current.view));
+        if (link.dataset.view !== 'stats') {
+            link.setAttribute('href', `${link.dataset.view === 'map' ? '/map' : '/'}${filterQuery()}`);
+        }
+    });
+
+    const { search, tag, category, open } = store.filters;
+    const searchEl = document.getElementById('search');
+    if (document.activeElement !== searchEl) searchEl.value = search;
+    document.getElementById('open-now').checked = open;
+
+    // Tags and categories are filtered on from links, and shown as chips
+    const chips = [['tag', tag], ['category', category]].filter(([, value]) => value);
+    const filtersEl = document.getElementById('filters');
+    filtersEl.classList.toggle('hidden', chips.length === 0);
+    filtersEl.innerHTML = chips.map(([name, value]) => {
+        const without = filterQuery({ ...store.filters, [name]: '' });
+        return `<span class="${name === 'tag' ? 'tag' : 'category'}">${name}: ${escapeHtml(value)}
+            <a href="${escapeAttr(basePath() + without)}" title="Remove filter">×</a></span>`;
+    }).join('');
+}
+
+// basePath is where filtering from the current view goes: the map stays
+// on the map, and everything else goes to the list
+function basePath() {
+    return current && current.view === map ? '/map' : '/';
+}
+
+function applyFilters(changes) {
+    navigate(basePath() + filterQuery({ ...store.filters, ...changes }));
+}
+
+// Apply edits made elsewhere, in the TUI, CLI or another browser, as they
+// happen
+function watchChanges() {
+    if (!window.EventSource) return;
+    const events = new EventSource('/api/v1/events');
+    ['created', 'updated', 'deleted'].forEach(kind => {
+        events.addEventListener('place.' + kind, e => applyChange(kind, JSON.parse(e.data).id));
+    });
+}
+
+async function loadTagOptions() {
+    if (!config.can_write) return;
+    try {
+        const tags = (await getJSON('/api/v1/tags?limit=500')).data || [];
+        document.getElementById('tag-options').innerHTML =
+            tags.map(t => `<option value="${escapeAttr(t.tag)}">`).join('');
+    } catch (error) {
+        console.error('Failed to load tags:', error);
+    }
+}
+
+const globalKeys = [
+    { keys: ['1'], help: 'List', run: () => navigate('/' + filterQuery()) },
+    { keys: ['2'], help: 'Map', run: () => navigate('/map' + filterQuery()) },
+    { keys: ['3'], help: 'Stats', run: () => navigate('/stats') },
+    { keys: ['/'], help: 'Search', run: () => document.getElementById('search').select() },
+    { keys: ['o'], help: 'Only places open now', run: () => applyFilters({ open: !store.filters.open }) },
+    { keys: ['c'], help: 'Clear search and filters', run: () => applyFilters(emptyFilters()) },
+    { keys: ['D'], help: 'Dark mode', run: toggleTheme },
+    { keys: ['?'], help: 'Keyboard shortcuts', run: showHelp },
+];
+
+document.getElementById('search-form').addEventListener('submit', e => {
+    e.preventDefault();
+    const searchEl = document.getElementById('search');
+    searchEl.blur();
+    applyFilters({ search: searchEl.value.trim() });
+});
+document.getElementById('open-now').addEventListener('change', e => applyFilters({ open: e.target.checked }));
+document.getElementById('theme-toggle').addEventListener('click', toggleTheme);
+document.getElementById('help-toggle').addEventListener('click', showHelp);
+document.getElementById('help').addEventListener('click', e => {
+    // Clicks outside the dialog land on the dialog itself
+    if (e.target === e.currentTarget) e.currentTarget.close();
+});
+
+installKeys(() => [
+    { title: current.title, bindings: current.keys },
+    { title: 'Everywhere', bindings: globalKeys },
+]);
+start(show);
+watchChanges();
+loadTagOptions();
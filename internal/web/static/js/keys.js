@@ -0,0 +1,62 @@
+// Keyboard shortcuts, after the TUI's. Bindings are lists of
+// {keys, help, run}; the current view's bindings come before the global
+// ones, so a view can give a key its own meaning, as the TUI does.
+
+import { escapeHtml, isTyping } from './util.js';
+
+const keyNames = { ' ': 'space', Enter: 'enter', Escape: 'esc', Backspace: 'backspace', ArrowUp: '↑', ArrowDown: '↓', ArrowLeft: '←', ArrowRight: '→' };
+
+let sections = () => [];
+
+function find(bindings, key) {
+    return bindings.find(b => b.keys.includes(key));
+}
+
+// installKeys listens for shortcuts. sectionsFn returns the bindings in
+// effect as [{title, bindings}], most specific first.
+export function installKeys(sectionsFn) {
+    sections = sectionsFn;
+    document.addEventListener('keydown', e => {
+        if (e.ctrlKey || e.metaKey || e.altKey || e.isComposing) return;
+
+        const help = document.getElementById('help');
+        if (help.open) {
+            if (e.key === 'Escape' || e.key === '?') {
+                e.preventDefault();
+                help.close();
+            }
+            return;
+        }
+        // Typing goes to the field; esc leaves it
+        if (isTyping(e.target)) {
+            if (e.key === 'Escape') e.target.blur();
+            return;
+        }
+        if (e.target.closest && e.target.closest('button, a') && (e.key === 'Enter' || e.key === ' ')) return;
+
+        for (const section of sections()) {
+            const binding = find(section.bindings, e.key);
+            if (binding) {
+                e.preventDefault();
+                binding.run(e);
+                return;
+            }
+        }
+    });
+}
+
+export function showHelp() {
+    const help = document.getElementById('help');
+    help.innerHTML = '<h2>Keyboard shortcuts</h2>' + sections().map(section => `
+        <section>
+            <h3>${escapeHtml(section.title)}</h3>
+            <dl>
+                ${section.bindings.map(b => `
+                    <dt>${b.keys.map(k => `<kbd>${escapeHtml(keyNames[k] || k)}</kbd>`).join(' ')}</dt>
+                    <dd>${escapeHtml(b.help)}</dd>
+                `).join('')}
+            </dl>
+        </section>
+    `).join('') + '<p class="help-footer">Press <kbd>esc</kbd> to close</p>';
+    help.showModal();
+}
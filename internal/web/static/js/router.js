@@ -0,0 +1,53 @@
+// Client-side routing with the History API. The server answers every view's
+// path with the same page, so links and reloads work anywhere.
+
+const routes = [];
+let onRoute = () => {};
+
+// route adds a path pattern such as /places/:id
+export function route(pattern, view) {
+    const names = [];
+    const regexp = new RegExp('^' + pattern.replace(/:(\w+)/g, (_, name) => {
+        names.push(name);
+        return '([^/]+)';
+    }) + '$');
+    routes.push({ regexp, names, view });
+}
+
+export function match(pathname) {
+    for (const { regexp, names, view } of routes) {
+        const found = regexp.exec(pathname);
+        if (found) {
+            const params = {};
+            names.forEach((name, i) => { params[name] = decodeURIComponent(found[i + 1]); });
+            return { view, params };
+        }
+    }
+    return null;
+}
+
+export function navigate(url, { replace = false } = {}) {
+    const next = new URL(url, location.href);
+    if (next.href === location.href) return;
+    history[replace ? 'replaceState' : 'pushState'](null, '', next.pathname + next.search);
+    onRoute(location);
+}
+
+// start shows the view for the current URL, and again whenever it changes
+export function start(handler) {
+    onRoute = handler;
+    window.addEventListener('popstate', () => onRoute(location));
+
+    // Follow links to views without reloading the page
+    document.addEventListener('click', e => {
+        if (e.defaultPrevented || e.button !== 0 || e.metaKey || e.ctrlKey || e.shiftKey || e.altKey) return;
+        const link = e.target.closest('a[href]');
+        if (!link || link.target || link.hasAttribute('download')) return;
+        const url = new URL(link.href);
+        if (url.origin !== location.origin || !match(url.pathname)) return;
+        e.preventDefault();
+        navigate(url.href);
+    });
+
+    onRoute(location);
+}
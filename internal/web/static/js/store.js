@@ -0,0 +1,200 @@
+// The places the views show: every place matching the current filters,
+// sorted by name like the API returns them. Views subscribe to hear about
+// changes, whether made here or elsewhere.
+
+import { ConflictError, getPlace, listAll, placePath, write } from './api.js';
+import { notify } from './util.js';
+
+export const store = {
+    filters: emptyFilters(),
+    places: [],
+    total: 0,
+    loaded: false,
+    loading: false,
+    error: null,
+};
+
+const listeners = new Set();
+let generation = 0;
+
+export function emptyFilters() {
+    return { search: '', tag: '', category: '', open: false };
+}
+
+// Filters live in the URL's query, so links and reloads keep them
+
+export function filtersFromQuery(search) {
+    const query = new URLSearchParams(search);
+    return {
+        search: query.get('q') || '',
+        tag: query.get('tag') || '',
+        category: query.get('category') || '',
+        open: query.has('open'),
+    };
+}
+
+export function filterQuery(filters = store.filters) {
+    const query = new URLSearchParams();
+    if (filters.search) query.set('q', filters.search);
+    if (filters.tag) query.set('tag', filters.tag);
+    if (filters.category) query.set('category', filters.category);
+    if (filters.open) query.set('open', '1');
+    const text = query.toString();
+    return text ? `?${text}` : '';
+}
+
+export function isFiltered(filters = store.filters) {
+    return !!(filters.search || filters.tag || filters.category || filters.open);
+}
+
+// subscribe calls fn with each change: {kind: 'reset' | 'page' | 'loaded'}
+// while loading, or {kind: 'created' | 'updated' | 'deleted', id, place}
+export function subscribe(fn) {
+    listeners.add(fn);
+    return () => listeners.delete(fn);
+}
+
+function emit(change) {
+    listeners.forEach(fn => fn(change));
+}
+
+// setFilters loads the places matching filters, unless they already are
+export function setFilters(filters) {
+    if (store.loaded && filterQuery(filters) === filterQuery(store.filters)) return;
+    store.filters = filters;
+    reload();
+}
+
+export async function reload() {
+    const request = ++generation;
+    Object.assign(store, { places: [], total: 0, loaded: true, loading: true, error: null });
+    emit({ kind: 'reset' });
+
+    const { search, tag, category, open } = store.filters;
+    const params = {};
+    if (search) params.search = search;
+    if (tag) params.tag = tag;
+    if (category) params.category = category;
+    if (open) params.open_now = '1';
+
+    try {
+        await listAll('/api/v1/places', params, (page, total) => {
+            // Stop when overtaken by newer filters
+            if (request !== generation) return false;
+            store.places.push(...page);
+            store.total = total;
+            emit({ kind: 'page' });
+        });
+    } catch (error) {
+        if (request === generation) store.error = error.message;
+    }
+    if (request !== generation) return;
+    store.loading = false;
+    emit({ kind: 'loaded' });
+}
+
+export function indexOf(id) {
+    return store.places.findIndex(p => p.id === id);
+}
+
+export function findPlace(id) {
+    return store.places[indexOf(id)];
+}
+
+function placeKey(place) {
+    return `${place.name.toLowerCase()}\0${place.id}`;
+}
+
+// replace puts a newer version of a place in the list. New places only
+// join an unfiltered list, since they may not match the filters.
+export function replace(place, kind = 'updated') {
+    const index = indexOf(place.id);
+    if (index >= 0) {
+        store.places[index] = place;
+    } else if (!isFiltered()) {
+        const key = placeKey(place);
+        const at = store.places.findIndex(p => placeKey(p) > key);
+        store.places.splice(at < 0 ? store.places.length : at, 0, place);
+        store.total++;
+    }
+    emit({ kind, id: place.id, place });
+}
+
+export function remove(id) {
+    const index = indexOf(id);
+    if (index >= 0) {
+        store.places.splice(index, 1);
+        store.total--;
+    }
+    emit({ kind: 'deleted', id });
+}
+
+// applyChange applies an edit made elsewhere, in the TUI, CLI or another
+// browser, as told by the event stream
+export async function applyChange(kind, id) {
+    if (kind === 'deleted') {
+        remove(id);
+        return;
+    }
+    try {
+        replace(await getPlace(id), kind);
+    } catch (error) {
+        console.error('Failed to apply change:', error);
+    }
+}
+
+// edit sends a change based on a version of a place. If the place changed
+// since, the change isn't made and the latest version is shown instead.
+async function edit(place, send) {
+    try {
+        return await send();
+    } catch (error) {
+        if (!(error instanceof ConflictError)) throw error;
+        notify(error.message);
+        try {
+            replace(await getPlace(place.id));
+        } catch (reloadError) {
+            console.error('Failed to reload place:', reloadError);
+        }
+        return null;
+    }
+}
+
+// patch applies a merge patch to a place
+export async function patch(place, body) {
+    const updated = await edit(place, () => write('PATCH', placePath(place.id), body, place));
+    if (updated) replace(updated);
+    return updated;
+}
+
+export async function create(body) {
+    const place = await write('POST', '/api/v1/places', body);
+    if (place) replace(place, 'created');
+    return place;
+}
+
+export async function destroy(place) {
+    const done = await edit(place, () => write('DELETE', placePath(place.id), undefined, place));
+    if (done) remove(place.id);
+    return !!done;
+}
+
+// tagPlaces adds a tag to many places at once; the event stream brings
+// the tagged places
+export function tagPlaces(tag, ids) {
+    return write('POST', `/api/v1/tags/${encodeURIComponent(tag)}/places`, { ids });
+}
+
+// untagPlaces removes one of the user's tags from the places that have it
+// and returns how many did
+export async function untagPlaces(tag, places) {
+    let removed = 0;
+    for (const place of places.filter(p => (p.user_tags || []).includes(tag))) {
+        const path = `${placePath(place.id)}/tags/${encodeURIComponent(tag)}`;
+        if (await write('DELETE', path)) {
+            removed++;
+            replace(await getPlace(place.id));
+        }
+    }
+    return removed;
+}
@@ -0,0 +1,21 @@
+// Dark mode. Without a choice saved, the page follows the system setting;
+// toggling saves the opposite of what is showing. The page's head applies
+// the saved choice before anything draws.
+
+const storageKey = 'placeli-theme';
+
+export function currentTheme() {
+    return document.documentElement.dataset.theme ||
+        (window.matchMedia('(prefers-color-scheme: dark)').matches ? 'dark' : 'light');
+}
+
+export function toggleTheme() {
+    const next = currentTheme() === 'dark' ? 'light' : 'dark';
+    document.documentElement.dataset.theme = next;
+    try {
+        localStorage.setItem(storageKey, next);
+    } catch (error) {
+        // Without storage, such as in some private windows, the choice
+        // lasts until the page is reloaded
+    }
+}
@@ -0,0 +1,43 @@
+// Small helpers shared by the views
+
+export function escapeHtml(text) {
+    const div = document.createElement('div');
+    div.textContent = text == null ? '' : String(text);
+    return div.innerHTML;
+}
+
+export function escapeAttr(text) {
+    return escapeHtml(text).replace(/"/g, '&quot;');
+}
+
+export function stars(rating) {
+    return `${'★'.repeat(Math.floor(rating))} ${rating.toFixed(1)}`;
+}
+
+// hasCoordinates reports whether a place is on the map; imports without a
+// location leave 0,0
+export function hasCoordinates(place) {
+    return !!place.coordinates && !!(place.coordinates.lat || place.coordinates.lng);
+}
+
+// isWebURL keeps imported websites like javascript: out of links
+export function isWebURL(url) {
+    return /^https?:\/\//i.test(url || '');
+}
+
+let noticeTimer;
+
+// notify shows a message for a few seconds
+export function notify(message) {
+    const el = document.getElementById('notice');
+    el.textContent = message;
+    el.classList.remove('hidden');
+    clearTimeout(noticeTimer);
+    noticeTimer = setTimeout(() => el.classList.add('hidden'), 5000);
+}
+
+// isTyping reports whether the user is typing in a form field, where
+// keys and live updates should leave them alone
+export function isTyping(el = document.activeElement) {
+    return !!el && (el.isContentEditable || el.matches('input:not([type=checkbox]), textarea, select'));
+}
@@ -0,0 +1,341 @@
+// The detail view: everything about one place, and for users who may
+// write, editing its notes, tags and custom fields. h and l step through
+// the list like the TUI's review screen.
+
+import { config, getPlace } from '../api.js';
+import { destroy, filterQuery, indexOf, patch, store, subscribe } from '../store.js';
+import { escapeAttr, escapeHtml, hasCoordinates, isTyping, isWebURL, notify, stars } from '../util.js';
+
+export function mount(el, params, app) {
+    const id = params.id;
+    let place = null;
+    let miniMap = null;
+    let deleting = false;
+
+    el.innerHTML = '<div class="detail-view"><div class="no-places">Loading place...</div></div>';
+    const root = el.firstElementChild;
+
+    function placeURL(other) {
+        return `/places/${encodeURIComponent(other.id)}${filterQuery()}`;
+    }
+
+    function mapURL() {
+        const query = new URLSearchParams(filterQuery());
+        query.set('place', id);
+        return `/map?${query}`;
+    }
+
+    function neighbours() {
+        const index = indexOf(id);
+        if (index < 0) return {};
+        return { index, prev: store.places[index - 1], next: store.places[index + 1] };
+    }
+
+    function navHtml() {
+        const { index, prev, next } = neighbours();
+        return `
+            <nav class="detail-nav">
+                <a href="/${filterQuery()}" title="Back to the list (esc)">← List</a>
+                <span class="detail-position">
+                    ${prev ? `<a href="${escapeAttr(placeURL(prev))}" title="Previous (h)">‹ Prev</a>` : ''}
+                    ${index !== undefined ? `${index + 1} of ${store.places.length}` : ''}
+                    ${next ? `<a href="${escapeAttr(placeURL(next))}" title="Next (l)">Next ›</a>` : ''}
+                </span>
+            </nav>`;
+    }
+
+    function infoHtml() {
+        let content = '';
+        if (place.address) {
+            content += `<p><strong>Address:</strong> ${escapeHtml(place.address)}</p>`;
+        }
+        if (place.phone) {
+            content += `<p><strong>Phone:</strong> <a href="tel:${escapeAttr(place.phone.replace(/[^+\d]/g, ''))}">${escapeHtml(place.phone)}</a></p>`;
+        }
+        if (isWebURL(place.website)) {
+            content += `<p><strong>Website:</strong> <a href="${escapeAttr(place.website)}" target="_blank" rel="noopener noreferrer">${escapeHtml(place.website)}</a></p>`;
+        }
+        if (place.hours) {
+            content += `<p><strong>Hours:</strong> ${escapeHtml(place.hours)}</p>`;
+        }
+        if (place.rating > 0) {
+            content += `<p><strong>Rating:</strong> <span class="place-rating">${stars(place.rating)}</span>${place.user_ratings ? ` (${place.user_ratings} reviews)` : ''}</p>`;
+        }
+        if (place.categories && place.categories.length > 0) {
+            content += `<p><strong>Categories:</strong> ${place.categories.map(c =>
+                `<a class="category" href="/?category=${encodeURIComponent(c)}">${escapeHtml(c)}</a>`).join(' ')}</p>`;
+        }
+
+        const storedPhotos = (place.photos || []).filter(p => p.hash);
+        if (storedPhotos.length > 0) {
+            content += '<div class="photos">' + storedPhotos.map(p =>
+                `<a href="/photos/${p.hash}" target="_blank"><img src="/photos/${p.hash}?size=128" alt="" loading="lazy"></a>`
+            ).join('') + '</div>';
+        }
+        const attachments = place.attachments || [];
+        const attachedImages = attachments.filter(a => a.content_type.startsWith('image/'));
+        const attachedFiles = attachments.filter(a => !a.content_type.startsWith('image/'));
+        if (attachedImages.length > 0) {
+            content += '<p><strong>Your photos:</strong></p><div class="photos">' + attachedImages.map(a =>
+                `<a href="/photos/${a.hash}" target="_blank" title="${escapeAttr(a.name)}"><img src="/photos/${a.hash}?size=128" alt="${escapeAttr(a.name)}" loading="lazy"></a>`
+            ).join('') + '</div>';
+        }
+        if (attachedFiles.length > 0) {
+            content += '<p><strong>Files:</strong></p><ul class="attachments">' + attachedFiles.map(a =>
+                `<li><a href="/photos/${a.hash}" target="_blank">${escapeHtml(a.name)}</a></li>`
+            ).join('') + '</ul>';
+        }
+        return content;
+    }
+
+    function readOnlyHtml() {
+        let content = '';
+        if (place.user_notes) {
+            content += `<div class="user-notes"><strong>Notes:</strong><br>${escapeHtml(place.user_notes)}</div>`;
+        }
+        const tags = (place.user_tags || []).concat(place.shared_tags || []);
+        if (tags.length > 0) {
+            content += `<p><strong>Tags:</strong> ${tags.map(t => `<a class="tag" href="/?tag=${encodeURIComponent(t)}">${escapeHtml(t)}</a>`).join(' ')}</p>`;
+        }
+        const fields = Object.entries(place.custom_fields || {}).sort(([a], [b]) => a.localeCompare(b));
+        if (fields.length > 0) {
+            content += '<table class="fields">' + fields.map(([name, value]) =>
+                `<tr><td>${escapeHtml(name)}</td><td>${escapeHtml(typeof value === 'string' ? value : JSON.stringify(value))}</td></tr>`
+            ).join('') + '</table>';
+        }
+        return content;
+    }
+
+    function editorHtml() {
+        const tags = place.user_tags || [];
+        const fields = Object.entries(place.custom_fields || {}).sort(([a], [b]) => a.localeCompare(b));
+
+        let html = `
+            <div class="editor">
+                <label for="edit-notes"><strong>Notes:</strong></label>
+                <textarea id="edit-notes" rows="4" placeholder="Press n to write notes">${escapeHtml(place.user_notes || '')}</textarea>
+                <button type="button" data-action="save-notes">Save notes</button>
+            </div>
+            <div class="editor">
+                <strong>Tags:</strong>
+                <div class="tag-chips">
+                    ${tags.map(t => `<span class="tag">${escapeHtml(t)}<button type="button" title="Remove tag" data-action="remove-tag" data-tag="${escapeAttr(t)}">×</button></span>`).join('')}
+                    ${(place.shared_tags || []).map(t => `<span class="tag shared" title="Shared tag">${escapeHtml(t)}</span>`).join('')}
+                </div>
+                <input type="text" id="edit-tag" list="tag-options" placeholder="Add a tag and press Enter (t)" />
+            </div>`;
+
+        html += '<div class="editor"><strong>Fields:</strong><table class="fields">';
+        fields.forEach(([name, value]) => {
+            html += `<tr><td>${escapeHtml(name)}</td><td>${fieldInput(name, value)}</td>
+                <td><button type="button" title="Remove field" data-action="remove-field" data-field="${escapeAttr(name)}">×</button></td></tr>`;
+        });
+        html += `</table>
+            <div class="new-field">
+                <input type="text" id="new-field-name" placeholder="Field" />
+                <input type="text" id="new-field-value" placeholder="Value" />
+                <button type="button" data-action="add-field">Add</button>
+            </div>
+        </div>
+        <div class="editor danger">
+            <button type="button" data-action="delete" title="Delete (d)">Delete place</button>
+        </div>`;
+        return html;
+    }
+
+    function render() {
+        if (miniMap) {
+            miniMap.remove();
+            miniMap = null;
+        }
+        const showsMap = hasCoordinates(place) || place.geometry;
+        root.innerHTML = `
+            ${navHtml()}
+            <article class="detail">
+                <div class="detail-main">
+                    <h2 id="detail-name">${escapeHtml(place.name)}</h2>
+                    <div id="detail-content">
+                        ${infoHtml()}
+                        ${config.can_write ? editorHtml() : readOnlyHtml()}
+                    </div>
+                </div>
+                ${showsMap ? `
+                <aside class="detail-side">
+                    <div id="detail-map" class="mini-map"></div>
+                    <a href="${escapeAttr(mapURL())}" title="Show on the map (m)">Show on map</a>
+                </aside>` : ''}
+            </article>`;
+        if (showsMap) drawMiniMap();
+        app.setTitle(place.name);
+    }
+
+    function drawMiniMap() {
+        miniMap = L.map(root.querySelector('#detail-map'), { zoomControl: false, keyboard: false, attributionControl: false });
+        L.tileLayer(config.tile_url, { maxZoom: 19 }).addTo(miniMap);
+        if (place.geometry) {
+            const isArea = place.geometry.type.endsWith('Polygon');
+            const shape = L.geoJSON(place.geometry, {
+                style: { color: isArea ? '#2e7d32' : '#1565c0', weight: 3, fillOpacity: 0.15 }
+            }).addTo(miniMap);
+            miniMap.fitBounds(shape.getBounds(), { padding: [20, 20], maxZoom: 16 });
+        }
+        if (hasCoordinates(place)) {
+            L.marker([place.coordinates.lat, place.coordinates.lng]).addTo(miniMap);
+            if (!place.geometry) miniMap.setView([place.coordinates.lat, place.coordinates.lng], 15);
+        }
+    }
+
+    // Editing
+
+    async function saveNotes() {
+        if (await patch(place, { user_notes: root.querySelector('#edit-notes').value })) {
+            notify('Notes saved');
+        }
+    }
+
+    async function addTag(tag) {
+        tag = tag.trim();
+        const tags = place.user_tags || [];
+        if (!tag || tags.includes(tag)) return;
+        if (await patch(place, { user_tags: [...tags, tag] })) {
+            root.querySelector('#edit-tag').focus();
+            app.loadTagOptions();
+        }
+    }
+
+    function removeTag(tag) {
+        patch(place, { user_tags: (place.user_tags || []).filter(t => t !== tag) });
+    }
+
+    // saveField sets a custom field, or removes it when value is null
+    function saveField(name, value) {
+        patch(place, { custom_fields: { [name]: value } });
+    }
+
+    function addField() {
+        const name = root.querySelector('#new-field-name').value.trim();
+        const raw = root.querySelector('#new-field-value').value.trim();
+        if (!name || !raw) return;
+        let value = raw;
+        if (raw === 'true' || raw === 'false') value = raw === 'true';
+        else if (!isNaN(Number(raw))) value = Number(raw);
+        saveField(name, value);
+    }
+
+    async function deletePlace() {
+        if (!place || !confirm(`Delete ${place.name}?`)) return;
+        // Go on to the next place, as the TUI does
+        const { next, prev } = neighbours();
+        const then = next || prev;
+        deleting = true;
+        const deleted = await destroy(place);
+        deleting = false;
+        if (deleted) {
+            notify(`Deleted ${place.name}`);
+            app.navigate(then ? placeURL(then) : `/${filterQuery()}`, { replace: true });
+        }
+    }
+
+    root.addEventListener('click', e => {
+        const action = e.target.closest('[data-action]');
+        if (!action || !place) return;
+        switch (action.dataset.action) {
+        case 'save-notes': saveNotes(); break;
+        case 'remove-tag': removeTag(action.dataset.tag); break;
+        case 'remove-field': saveField(action.dataset.field, null); break;
+        case 'add-field': addField(); break;
+        case 'delete': deletePlace(); break;
+        }
+    });
+    root.addEventListener('change', e => {
+        if (e.target.dataset.field && place) saveField(e.target.dataset.field, fieldValue(e.target));
+    });
+    root.addEventListener('keydown', e => {
+        if (e.target.id === 'edit-tag' && e.key === 'Enter') addTag(e.target.value);
+        if (e.target.id === 'edit-notes' && e.key === 'Enter' && (e.ctrlKey || e.metaKey)) saveNotes();
+    });
+
+    // Show edits made elsewhere, unless the user is typing here
+    const unsubscribe = subscribe(change => {
+        if (change.id !== id || !place) {
+            if (place && ['reset', 'loaded', 'created', 'deleted'].includes(change.kind)) {
+                root.querySelector('.detail-nav').outerHTML = navHtml();
+            }
+            return;
+        }
+        if (change.kind === 'deleted') {
+            if (deleting) return;
+            notify(`${place.name} was deleted`);
+            app.navigate(`/${filterQuery()}`, { replace: true });
+            return;
+        }
+        if (change.place) {
+            place = change.place;
+            if (!(isTyping() && root.contains(document.activeElement))) render();
+        }
+    });
+
+    (async () => {
+        try {
+            place = await getPlace(id);
+            render();
+        } catch (error) {
+            root.innerHTML = `${navHtml()}<div class="error">${escapeHtml(error.message)}</div>`;
+        }
+    })();
+
+    const go = other => { if (other) app.navigate(placeURL(other), { replace: true }); };
+    const focus = selector => {
+        const input = root.querySelector(selector);
+        if (input) input.focus();
+    };
+    const keys = [
+        { keys: ['Escape', 'Backspace', 'q'], help: 'Back to the list', run: () => app.navigate(`/${filterQuery()}`) },
+        { keys: ['h', 'ArrowLeft'], help: 'Previous place', run: () => go(neighbours().prev) },
+        { keys: ['l', 'ArrowRight'], help: 'Next place', run: () => go(neighbours().next) },
+        { keys: ['m'], help: 'Show on the map', run: () => app.navigate(mapURL()) },
+        { keys: ['w'], help: 'Open website', run: () => { if (place && isWebURL(place.website)) window.open(place.website, '_blank', 'noopener'); } },
+    ];
+    if (config.can_write) {
+        keys.push(
+            { keys: ['n'], help: 'Edit notes', run: () => focus('#edit-notes') },
+            { keys: ['t'], help: 'Add a tag', run: () => focus('#edit-tag') },
+            { keys: ['d'], help: 'Delete place', run: deletePlace },
+        );
+    }
+
+    return {
+        title: 'Place',
+        keys,
+        unmount() {
+            unsubscribe();
+            if (miniMap) miniMap.remove();
+        },
+    };
+}
+
+// fieldInput picks an input for a custom field from its current value
+function fieldInput(name, value) {
+    const attrs = `data-field="${escapeAttr(name)}"`;
+    if (typeof value === 'boolean') {
+        return `<input type="checkbox" ${value ? 'checked' : ''} ${attrs} />`;
+    }
+    if (typeof value === 'number') {
+        return `<input type="number" step="any" value="${value}" ${attrs} />`;
+    }
+    if (Array.isArray(value)) {
+        return `<input type="text" data-kind="list" value="${escapeAttr(value.join(', '))}" ${attrs} />`;
+    }
+    if (typeof value === 'string' && /^\d{4}-\d{2}-\d{2}$/.test(value)) {
+        return `<input type="date" value="${value}" ${attrs} />`;
+    }
+    const text = typeof value === 'string' ? value : JSON.stringify(value);
+    return `<input type="text" value="${escapeAttr(text)}" ${attrs} />`;
+}
+
+function fieldValue(input) {
+    if (input.type === 'checkbox') return input.checked;
+    if (input.type === 'number') return input.value === '' ? null : Number(input.value);
+    if (input.dataset.kind === 'list') return input.value.split(',').map(v => v.trim()).filter(v => v);
+    return input.value;
+}
@@ -0,0 +1,186 @@
+// The list view: the places matching the filters, browsed like the TUI's
+// list with a cursor. Places can be marked to tag or untag them together.
+
+import { config } from '../api.js';
+import { filterQuery, reload, store, subscribe, tagPlaces, untagPlaces } from '../store.js';
+import { escapeAttr, escapeHtml, notify, stars } from '../util.js';
+
+// The cursor and marks outlast the view, so coming back from a place's
+// details returns to it
+let cursorId = null;
+const marked = new Set();
+
+export function mount(el, params, app) {
+    el.innerHTML = `
+        <div class="list-view">
+            <div class="list-header">
+                <span id="list-count" class="place-count"></span>
+                ${config.can_write ? `
+                <div id="mark-bar" class="mark-bar hidden">
+                    <span id="mark-count"></span>
+                    <button type="button" data-action="tag">Tag (t)</button>
+                    <button type="button" data-action="untag">Untag (T)</button>
+                    <button type="button" data-action="unmark">Clear (esc)</button>
+                </div>` : ''}
+            </div>
+            <div id="list" class="place-list" role="listbox" aria-label="Places"></div>
+        </div>`;
+    const listEl = el.querySelector('#list');
+
+    function cursorIndex() {
+        const index = store.places.findIndex(p => p.id === cursorId);
+        return index >= 0 ? index : 0;
+    }
+
+    function itemHtml(place, index) {
+        const classes = ['place-item'];
+        if (index === cursorIndex()) classes.push('selected');
+        if (marked.has(place.id)) classes.push('marked');
+        const tags = (place.user_tags || []).concat(place.shared_tags || []);
+        return `
+            <a class="${classes.join(' ')}" role="option" href="/places/${encodeURIComponent(place.id)}${filterQuery()}" data-id="${escapeAttr(place.id)}">
+                ${config.can_write ? '<span class="mark" title="Mark (x)"></span>' : ''}
+                <div class="place-summary">
+                    <div class="place-name">${escapeHtml(place.name)}</div>
+                    ${place.address ? `<div class="place-address">${escapeHtml(place.address)}</div>` : ''}
+                    ${place.rating > 0 ? `<div class="place-rating">${stars(place.rating)}</div>` : ''}
+                    ${(place.categories || []).length > 0 || tags.length > 0 ? `
+                        <div class="place-categories">
+                            ${(place.categories || []).map(c => `<span class="category">${escapeHtml(c)}</span>`).join('')}
+                            ${tags.map(t => `<span class="tag">${escapeHtml(t)}</span>`).join('')}
+                        </div>
+                    ` : ''}
+                </div>
+            </a>`;
+    }
+
+    function render() {
+        let count = `${store.places.length}${store.loading ? ` of ${store.total}` : ''} places`;
+        if (store.loading) count += ' (loading…)';
+        el.querySelector('#list-count').textContent = count;
+        renderMarks();
+
+        if (store.error) {
+            listEl.innerHTML = `<div class="error">Failed to load places: ${escapeHtml(store.error)}</div>`;
+            return;
+        }
+        if (store.places.length === 0) {
+            listEl.innerHTML = `<div class="no-places">${store.loading ? 'Loading places...' : 'No places found'}</div>`;
+            return;
+        }
+        listEl.innerHTML = store.places.map(itemHtml).join('');
+    }
+
+    function renderMarks() {
+        const bar = el.querySelector('#mark-bar');
+        if (!bar) return;
+        bar.classList.toggle('hidden', marked.size === 0);
+        el.querySelector('#mark-count').textContent = `${marked.size} marked`;
+    }
+
+    let pending = false;
+    const unsubscribe = subscribe(() => {
+        if (pending) return;
+        pending = true;
+        requestAnimationFrame(() => {
+            pending = false;
+            render();
+        });
+    });
+
+    function moveTo(index) {
+        if (store.places.length === 0) return;
+        index = Math.max(0, Math.min(store.places.length - 1, index));
+        cursorId = store.places[index].id;
+        listEl.querySelectorAll('.place-item.selected').forEach(item => item.classList.remove('selected'));
+        const item = listEl.children[index];
+        if (item) {
+            item.classList.add('selected');
+            item.scrollIntoView({ block: 'nearest' });
+        }
+    }
+
+    function current() {
+        return store.places[cursorIndex()];
+    }
+
+    function toggleMark(id) {
+        if (marked.has(id)) marked.delete(id); else marked.add(id);
+        const item = listEl.querySelector(`[data-id="${CSS.escape(id)}"]`);
+        if (item) item.classList.toggle('marked', marked.has(id));
+        renderMarks();
+    }
+
+    function clearMarks() {
+        marked.clear();
+        render();
+    }
+
+    // targets are the marked places, or the one under the cursor
+    function targets() {
+        if (marked.size > 0) return store.places.filter(p => marked.has(p.id));
+        return current() ? [current()] : [];
+    }
+
+    async function tag() {
+        const places = targets();
+        if (places.length === 0) return;
+        const tag = (prompt(`Tag ${places.length === 1 ? places[0].name : `${places.length} places`} with`) || '').trim();
+        if (!tag) return;
+        const result = await tagPlaces(tag, places.map(p => p.id));
+        if (!result) return;
+        notify(`Tagged ${result.tagged} places with ${tag}`);
+        if (marked.size > 0) clearMarks();
+        app.loadTagOptions();
+    }
+
+    async function untag() {
+        const places = targets();
+        if (places.length === 0) return;
+        const tag = (prompt(`Remove which tag from ${places.length === 1 ? places[0].name : `${places.length} places`}?`) || '').trim();
+        if (!tag) return;
+        const removed = await untagPlaces(tag, places);
+        notify(`Removed ${tag} from ${removed} places`);
+        if (marked.size > 0) clearMarks();
+        app.loadTagOptions();
+    }
+
+    listEl.addEventListener('click', e => {
+        const item = e.target.closest('.place-item');
+        if (!item) return;
+        cursorId = item.dataset.id;
+        if (e.target.matches('.mark')) {
+            // Marking doesn't open the place
+            e.preventDefault();
+            toggleMark(item.dataset.id);
+        }
+    });
+    el.addEventListener('click', e => {
+        const action = e.target.closest('[data-action]');
+        if (!action) return;
+        ({ tag, untag, unmark: clearMarks })[action.dataset.action]();
+    });
+
+    render();
+    moveTo(cursorIndex());
+
+    const open = () => { if (current()) app.navigate(`/places/${encodeURIComponent(current().id)}${filterQuery()}`); };
+    const keys = [
+        { keys: ['j', 'ArrowDown'], help: 'Next place', run: () => moveTo(cursorIndex() + 1) },
+        { keys: ['k', 'ArrowUp'], help: 'Previous place', run: () => moveTo(cursorIndex() - 1) },
+        { keys: ['g', 'Home'], help: 'First place', run: () => moveTo(0) },
+        { keys: ['G', 'End'], help: 'Last place', run: () => moveTo(store.places.length - 1) },
+        { keys: ['Enter', ' ', 'l'], help: 'Open place', run: open },
+        { keys: ['r'], help: 'Reload', run: reload },
+    ];
+    if (config.can_write) {
+        keys.push(
+            { keys: ['x'], help: 'Mark place', run: () => { if (current()) toggleMark(current().id); } },
+            { keys: ['t'], help: 'Tag marked or current place', run: tag },
+            { keys: ['T'], help: 'Untag marked or current place', run: untag },
+            { keys: ['Escape'], help: 'Clear marks', run: clearMarks },
+        );
+    }
+
+    return { title: 'Places', keys, unmount: unsubscribe };
+}
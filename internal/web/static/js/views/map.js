@@ -0,0 +1,373 @@
+// The map view. Without filters it asks the server for what is in view:
+// clusters when zoomed out, and summaries of the places themselves when
+// zoomed in. With filters it shows the matching places. Users who may write
+// can add places, drag them, and draw around places to tag them together.
+
+import { config, getJSON, getPlace } from '../api.js';
+import { create, filterQuery, findPlace, isFiltered, patch, store, subscribe, tagPlaces } from '../store.js';
+import { escapeHtml, hasCoordinates, notify, stars } from '../util.js';
+
+// Where the map was, so coming back doesn't lose the spot
+let lastView = null;
+let showLabels = false;
+
+const panStep = 100;
+
+export function mount(el, params, app) {
+    el.innerHTML = `
+        <div class="map-view">
+            <div id="map" tabindex="0" aria-label="Map"></div>
+            <div class="map-tools">
+                ${config.can_write ? `
+                <button type="button" id="add-mode" title="Click the map to add a place (a)">Add place</button>
+                <button type="button" id="select-mode" title="Draw around places to tag them together (s)">Select</button>
+                ` : ''}
+                <button type="button" id="fit" title="Show all places (f)">Fit</button>
+                <button type="button" id="labels" title="Show names (t)">Labels</button>
+            </div>
+            <div id="bulk-bar" class="bulk-bar hidden">
+                <span id="bulk-count"></span>
+                <input type="text" id="bulk-tag" list="tag-options" placeholder="Tag" />
+                <button type="button" id="bulk-apply">Tag selected</button>
+                <button type="button" id="bulk-cancel">Cancel</button>
+            </div>
+        </div>`;
+    const $ = selector => el.querySelector(selector);
+    // ?place= links to a place on the map
+    const focusId = new URLSearchParams(location.search).get('place');
+
+    const map = L.map($('#map'), { keyboard: false }).setView([0, 0], 2);
+    L.tileLayer(config.tile_url, {
+        maxZoom: 19,
+        attribution: '© OpenStreetMap contributors'
+    }).addTo(map);
+    const markersLayer = L.layerGroup().addTo(map);
+
+    let mode = null;
+    let selection = [];
+    let lasso = null;
+    let markers = new Map();
+    let mapPlaces = [];
+    let clusterView = null;
+    let viewRequest = 0;
+    let viewTimer;
+
+    const browsing = () => !isFiltered();
+
+    function popupFor(place) {
+        const popup = document.createElement('div');
+        popup.innerHTML = `
+            <strong>${escapeHtml(place.name)}</strong><br>
+            ${place.address ? escapeHtml(place.address) + '<br>' : ''}
+            ${place.rating > 0 ? stars(place.rating) + '<br>' : ''}
+            <a href="/places/${encodeURIComponent(place.id)}${filterQuery()}">Details</a>`;
+        // Leaflet keeps clicks in popups from reaching the router
+        popup.querySelector('a').addEventListener('click', e => {
+            e.preventDefault();
+            app.navigate(e.currentTarget.getAttribute('href'));
+        });
+        return popup;
+    }
+
+    function drawPlaces(places, fit) {
+        markersLayer.clearLayers();
+        markers = new Map();
+        mapPlaces = places;
+
+        const bounds = L.latLngBounds();
+        places.forEach(place => {
+            if (hasCoordinates(place)) {
+                const marker = L.marker([place.coordinates.lat, place.coordinates.lng], {
+                    draggable: config.can_write,
+                    title: place.name,
+                    keyboard: false,
+                })
+                    .bindPopup(() => popupFor(place))
+                    .on('dragend', () => movePlace(place.id, marker));
+                if (showLabels) {
+                    marker.bindTooltip(escapeHtml(place.name), { permanent: true, direction: 'right', className: 'marker-label' });
+                }
+                marker.addTo(markersLayer);
+                if (selection.includes(place.id)) {
+                    marker.getElement().classList.add('marker-selected');
+                }
+                markers.set(place.id, marker);
+                bounds.extend(marker.getLatLng());
+            }
+
+            // Outline areas and routes
+            if (place.geometry) {
+                const isArea = place.geometry.type.endsWith('Polygon');
+                const shape = L.geoJSON(place.geometry, {
+                    style: { color: isArea ? '#2e7d32' : '#1565c0', weight: 3, fillOpacity: 0.15 }
+                }).bindPopup(() => popupFor(place));
+                shape.addTo(markersLayer);
+                bounds.extend(shape.getBounds());
+            }
+        });
+
+        if (fit && bounds.isValid()) {
+            map.fitBounds(bounds, { padding: [50, 50], maxZoom: 16 });
+        }
+    }
+
+    function drawView(view) {
+        clusterView = view;
+        drawPlaces(view.places, false);
+        view.clusters.forEach(c => {
+            const size = c.count < 10 ? 'small' : c.count < 100 ? 'medium' : 'large';
+            L.marker([c.coordinates.lat, c.coordinates.lng], {
+                icon: L.divIcon({ html: `<span>${c.count}</span>`, className: `cluster cluster-${size}`, iconSize: [40, 40] }),
+                title: `${c.count} places`,
+                keyboard: false,
+            })
+                .on('click', () => map.fitBounds([[c.min.lat, c.min.lng], [c.max.lat, c.max.lng]], { padding: [40, 40], maxZoom: 18 }))
+                .addTo(markersLayer);
+        });
+    }
+
+    function bboxParam(b) {
+        const clamp = (v, lo, hi) => Math.min(hi, Math.max(lo, v));
+        return [
+            clamp(b.getWest(), -180, 180), clamp(b.getSouth(), -90, 90),
+            clamp(b.getEast(), -180, 180), clamp(b.getNorth(), -90, 90)
+        ].map(v => v.toFixed(6)).join(',');
+    }
+
+    async function loadView() {
+        const request = ++viewRequest;
+        try {
+            const view = await getJSON(`/api/places?bbox=${bboxParam(map.getBounds())}&zoom=${map.getZoom()}`);
+            // Drop responses overtaken by a later move or a search
+            if (request !== viewRequest || !browsing()) return;
+            drawView(view);
+        } catch (error) {
+            console.error('Failed to load map view:', error);
+        }
+    }
+
+    // fitAll zooms the map to show every place
+    async function fitAll() {
+        if (!browsing()) {
+            drawPlaces(store.places, true);
+            return;
+        }
+        try {
+            const view = await getJSON('/api/places?bbox=-180,-90,180,90&zoom=0');
+            const bounds = L.latLngBounds();
+            view.clusters.forEach(c => bounds.extend([c.min.lat, c.min.lng]).extend([c.max.lat, c.max.lng]));
+            view.places.forEach(p => bounds.extend([p.coordinates.lat, p.coordinates.lng]));
+            if (bounds.isValid()) {
+                map.fitBounds(bounds, { padding: [50, 50] });
+            }
+        } catch (error) {
+            console.error('Failed to load places:', error);
+        }
+        loadView();
+    }
+
+    // redraw draws the current places again, such as after the selection
+    // changes
+    function redraw() {
+        if (browsing()) {
+            if (clusterView) drawView(clusterView);
+        } else {
+            drawPlaces(store.places, false);
+        }
+    }
+
+    // focusPlace centers the map on a place and opens its popup
+    async function focusPlace(id) {
+        try {
+            const place = findPlace(id) || await getPlace(id);
+            if (!hasCoordinates(place)) {
+                notify(`${place.name} isn't on the map`);
+                return;
+            }
+            map.setView([place.coordinates.lat, place.coordinates.lng], Math.max(map.getZoom(), 16));
+            if (browsing()) await loadView();
+            const marker = markers.get(id);
+            if (marker) marker.openPopup();
+        } catch (error) {
+            notify(error.message);
+        }
+    }
+
+    map.on('moveend', () => { if (browsing()) loadView(); });
+
+    const unsubscribe = subscribe(change => {
+        if (browsing()) {
+            // Clusters are counted by the server, so ask again
+            if (['created', 'updated', 'deleted', 'reset'].includes(change.kind)) {
+                clearTimeout(viewTimer);
+                viewTimer = setTimeout(loadView, 300);
+            }
+            return;
+        }
+        if (change.kind === 'loaded') {
+            drawPlaces(store.places, !focusId);
+        } else if (change.kind !== 'reset' && change.kind !== 'page') {
+            redraw();
+        }
+    });
+
+    // Editing
+
+    async function movePlace(id, marker) {
+        const { lat, lng } = marker.getLatLng();
+        // The map may only have a summary of the place, without the
+        // updated_at edits are checked against
+        let place = findPlace(id);
+        if (!place) {
+            place = await getPlace(id).catch(() => null);
+        }
+        if (!place || !await patch(place, { coordinates: { lat, lng } })) {
+            redraw();
+        }
+    }
+
+    function setMode(next) {
+        if (!config.can_write) return;
+        mode = mode === next ? null : next;
+        $('#add-mode').classList.toggle('active', mode === 'add');
+        $('#select-mode').classList.toggle('active', mode === 'select');
+        $('#map').classList.toggle('crosshair', mode !== null);
+        if (mode === 'select') map.dragging.disable(); else map.dragging.enable();
+        if (mode !== 'select') clearSelection();
+    }
+
+    async function addPlaceAt(latlng) {
+        const name = prompt('Name of the new place');
+        if (!name || !name.trim()) return;
+        const place = await create({
+            name: name.trim(),
+            coordinates: { lat: latlng.lat, lng: latlng.lng }
+        });
+        if (!place) return;
+        setMode('add');
+        notify(`Added ${place.name}`);
+        if (browsing()) loadView();
+    }
+
+    // The lasso selects the places inside a shape drawn on the map
+
+    function startLasso(e) {
+        if (mode !== 'select') return;
+        lasso = L.polygon([e.latlng], { color: '#e67e22', weight: 2, fillOpacity: 0.1, interactive: false }).addTo(map);
+    }
+
+    function extendLasso(e) {
+        if (lasso) lasso.addLatLng(e.latlng);
+    }
+
+    function finishLasso() {
+        if (!lasso) return;
+        const shape = lasso.getLatLngs()[0];
+        map.removeLayer(lasso);
+        lasso = null;
+        if (shape.length < 3) return;
+
+        selection = mapPlaces
+            .filter(hasCoordinates)
+            .filter(p => insidePolygon(p.coordinates, shape))
+            .map(p => p.id);
+        redraw();
+        $('#bulk-count').textContent = `${selection.length} selected`;
+        $('#bulk-bar').classList.toggle('hidden', selection.length === 0);
+        if (selection.length > 0) $('#bulk-tag').focus();
+    }
+
+    async function tagSelection() {
+        const tag = $('#bulk-tag').value.trim();
+        if (!tag || selection.length === 0) return;
+        const result = await tagPlaces(tag, selection);
+        if (!result) return;
+        notify(`Tagged ${result.tagged} places with ${tag}`);
+        $('#bulk-tag').value = '';
+        clearSelection();
+        app.loadTagOptions();
+    }
+
+    function clearSelection() {
+        const had = selection.length > 0;
+        selection = [];
+        $('#bulk-bar').classList.add('hidden');
+        if (had) redraw();
+    }
+
+    if (config.can_write) {
+        map.on('click', e => { if (mode === 'add') addPlaceAt(e.latlng); });
+        map.on('mousedown', startLasso);
+        map.on('mousemove', extendLasso);
+        map.on('mouseup', finishLasso);
+        $('#add-mode').addEventListener('click', () => setMode('add'));
+        $('#select-mode').addEventListener('click', () => setMode('select'));
+    }
+    $('#bulk-apply').addEventListener('click', tagSelection);
+    $('#bulk-cancel').addEventListener('click', clearSelection);
+    $('#bulk-tag').addEventListener('keydown', e => { if (e.key === 'Enter') tagSelection(); });
+    $('#fit').addEventListener('click', fitAll);
+
+    function toggleLabels() {
+        showLabels = !showLabels;
+        $('#labels').classList.toggle('active', showLabels);
+        redraw();
+    }
+    $('#labels').addEventListener('click', toggleLabels);
+    $('#labels').classList.toggle('active', showLabels);
+
+    // Start where the map was left, at a linked place, or with every place
+    if (lastView) map.setView(lastView.center, lastView.zoom, { animate: false });
+    if (focusId) {
+        if (!browsing() && store.loaded && !store.loading) drawPlaces(store.places, false);
+        focusPlace(focusId);
+    } else if (!browsing()) {
+        if (store.loaded && !store.loading) drawPlaces(store.places, !lastView);
+    } else if (lastView) {
+        loadView();
+    } else {
+        fitAll();
+    }
+
+    const keys = [
+        { keys: ['h', 'ArrowLeft'], help: 'Pan left', run: () => map.panBy([-panStep, 0]) },
+        { keys: ['j', 'ArrowDown'], help: 'Pan down', run: () => map.panBy([0, panStep]) },
+        { keys: ['k', 'ArrowUp'], help: 'Pan up', run: () => map.panBy([0, -panStep]) },
+        { keys: ['l', 'ArrowRight'], help: 'Pan right', run: () => map.panBy([panStep, 0]) },
+        { keys: ['+', '='], help: 'Zoom in', run: () => map.zoomIn() },
+        { keys: ['-', '_'], help: 'Zoom out', run: () => map.zoomOut() },
+        { keys: ['f'], help: 'Fit all places', run: fitAll },
+        { keys: ['t'], help: 'Show or hide names', run: toggleLabels },
+        { keys: ['r'], help: 'Reload', run: () => (browsing() ? loadView() : drawPlaces(store.places, false)) },
+    ];
+    if (config.can_write) {
+        keys.push(
+            { keys: ['a'], help: 'Add a place', run: () => setMode('add') },
+            { keys: ['s'], help: 'Select places to tag', run: () => setMode('select') },
+            { keys: ['Escape'], help: 'Cancel', run: () => { if (mode) setMode(mode); else clearSelection(); } },
+        );
+    }
+
+    return {
+        title: 'Map',
+        keys,
+        unmount() {
+            unsubscribe();
+            clearTimeout(viewTimer);
+            lastView = { center: map.getCenter(), zoom: map.getZoom() };
+            map.remove();
+        },
+    };
+}
+
+function insidePolygon(point, shape) {
+    let inside = false;
+    for (let i = 0, j = shape.length - 1; i < shape.length; j = i++) {
+        const a = shape[i], b = shape[j];
+        if ((a.lat > point.lat) !== (b.lat > point.lat) &&
+            point.lng < (b.lng - a.lng) * (point.lat - a.lat) / (b.lat - a.lat) + a.lng) {
+            inside = !inside;
+        }
+    }
+    return inside;
+}
@@ -0,0 +1,98 @@
+// The stats view: counts over all the user's places, from /api/v1/stats.
+// Categories and tags link to the list of their places, and the counts
+// follow edits as they happen.
+
+import { getJSON } from '../api.js';
+import { subscribe } from '../store.js';
+import { escapeHtml } from '../util.js';
+
+export function mount(el) {
+    el.innerHTML = '<div class="stats-view"><div class="no-places">Loading stats...</div></div>';
+    const root = el.firstElementChild;
+    let request = 0;
+
+    function percent(count, total) {
+        return total > 0 ? Math.round(count / total * 100) : 0;
+    }
+
+    // bars draws a horizontal bar chart of [label, count, href] rows
+    function bars(rows) {
+        const most = Math.max(1, ...rows.map(([, count]) => count));
+        return `<table class="bars">${rows.map(([label, count, href]) => `
+            <tr>
+                <th>${href ? `<a href="${href}">${escapeHtml(label)}</a>` : escapeHtml(label)}</th>
+                <td><span class="bar" style="width: ${percent(count, most)}%"></span></td>
+                <td class="count">${count}</td>
+            </tr>`).join('')}</table>`;
+    }
+
+    function render(stats) {
+        const cards = [
+            ['Places', stats.places],
+            ['On the map', stats.mapped, stats.places],
+            ['Areas and routes', stats.areas, stats.places],
+            ['With notes', stats.with_notes, stats.places],
+            ['Tagged', stats.tagged, stats.places],
+            ['Visited', stats.visited, stats.places],
+            ['Visits', stats.visits],
+            ['Average rating', stats.rated > 0 ? stats.average_rating.toFixed(1) + ' ★' : '–'],
+        ];
+        root.innerHTML = `
+            <div class="stat-cards">
+                ${cards.map(([label, value, total]) => `
+                    <div class="stat-card">
+                        <div class="stat-value">${escapeHtml(String(value))}</div>
+                        <div class="stat-label">${escapeHtml(label)}${total ? ` · ${percent(value, total)}%` : ''}</div>
+                    </div>`).join('')}
+            </div>
+            <div class="stat-charts">
+                <section>
+                    <h3>Ratings</h3>
+                    ${stats.rated > 0
+                        ? bars(stats.ratings.map((count, i) => [`${'★'.repeat(i + 1)}`, count]).reverse())
+                        : '<p class="no-places">No rated places</p>'}
+                </section>
+                <section>
+                    <h3>Top categories</h3>
+                    ${stats.categories.length > 0
+                        ? bars(stats.categories.map(c => [c.category, c.count, `/?category=${encodeURIComponent(c.category)}`]))
+                        : '<p class="no-places">No categories</p>'}
+                </section>
+                <section>
+                    <h3>Top tags</h3>
+                    ${stats.tags.length > 0
+                        ? bars(stats.tags.map(t => [t.tag, t.count, `/?tag=${encodeURIComponent(t.tag)}`]))
+                        : '<p class="no-places">No tags</p>'}
+                </section>
+            </div>`;
+    }
+
+    async function load() {
+        const current = ++request;
+        try {
+            const stats = await getJSON('/api/v1/stats');
+            if (current === request) render(stats);
+        } catch (error) {
+            root.innerHTML = `<div class="error">Failed to load stats: ${escapeHtml(error.message)}</div>`;
+        }
+    }
+    load();
+
+    let timer;
+    const unsubscribe = subscribe(change => {
+        if (['created', 'updated', 'deleted'].includes(change.kind)) {
+            clearTimeout(timer);
+            timer = setTimeout(load, 1000);
+        }
+    });
+
+    return {
+        title: 'Stats',
+        keys: [{ keys: ['r'], help: 'Reload', run: load }],
+        unmount() {
+            request++;
+            clearTimeout(timer);
+            unsubscribe();
+        },
+    };
+}
@@ -0,0 +1,151 @@
+package web
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/user/placeli/internal/logger"
+	"github.com/user/placeli/internal/models"
+)
+
+// statsTopN is how many categories and tags the stats list
+const statsTopN = 10
+
+type categoryCount struct {
+	Category string `json:"category"`
+	Count    int    `json:"count"`
+}
+
+// placeStats summarizes the user's places for the stats view
+type placeStats struct {
+	Places    int `json:"places"`
+	Mapped    int `json:"mapped"`
+	Areas     int `json:"areas"`
+	WithNotes int `json:"with_notes"`
+	Tagged    int `json:"tagged"`
+	Visited   int `json:"visited"`
+	Visits    int `json:"visits"`
+
+	Rated         int     `json:"rated"`
+	AverageRating float64 `json:"average_rating"`
+	// Ratings counts rated places by whole stars, from 1 to 5
+	Ratings [5]int `json:"ratings"`
+
+	Categories []categoryCount `json:"categories"`
+	Tags       []tagCount      `json:"tags"`
+}
+
+// handleV1Stats counts the user's places by what they have: ratings,
+// notes, tags, visits, and the most used categories and tags
+func (s *Server) handleV1Stats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, r, http.MethodGet)
+		return
+	}
+
+	db := s.dbFor(r)
+	visits, err := db.GetVisitSummaries()
+	if err != nil {
+		logger.Error("Failed to fetch visits", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "failed to fetch visits")
+		return
+	}
+
+	summary := newStatsSummary(visits)
+	if err := db.ForEachPlace("", summary.add); err != nil {
+		logger.Error("Failed to fetch places", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal", "failed to fetch places")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, summary.result())
+}
+
+// statsSummary counts places one at a time, so the stats cover every place
+// without loading them all
+type statsSummary struct {
+	stats      placeStats
+	visits     map[string]models.VisitSummary
+	categories map[string]int
+	tags       map[string]int
+	ratingSum  float64
+}
+
+func newStatsSummary(visits map[string]models.VisitSummary) *statsSummary {
+	return &statsSummary{
+		visits:     visits,
+		categories: make(map[string]int),
+		tags:       make(map[string]int),
+	}
+}
+
+func (s *statsSummary) add(place *models.Place) error {
+	stats := &s.stats
+	stats.Places++
+	if place.Coordinates.Lat != 0 || place.Coordinates.Lng != 0 {
+		stats.Mapped++
+	}
+	if place.Geometry != nil {
+		stats.Areas++
+	}
+	if strings.TrimSpace(place.UserNotes) != "" {
+		stats.WithNotes++
+	}
+	if len(place.UserTags) > 0 || len(place.SharedTags) > 0 {
+		stats.Tagged++
+	}
+	if visit, ok := s.visits[place.ID]; ok {
+		stats.Visited++
+		stats.Visits += visit.Count
+	}
+	if place.Rating > 0 {
+		stats.Rated++
+		s.ratingSum += float64(place.Rating)
+		stars := min(max(int(place.Rating), 1), 5)
+		stats.Ratings[stars-1]++
+	}
+	for _, category := range place.Categories {
+		s.categories[category]++
+	}
+	for _, tag := range place.UserTags {
+		s.tags[tag]++
+	}
+	for _, tag := range place.SharedTags {
+		if !place.HasTag(tag) {
+			s.tags[tag]++
+		}
+	}
+	return nil
+}
+
+func (s *statsSummary) result() placeStats {
+	stats := s.stats
+	if stats.Rated > 0 {
+		// Ratings are float32, so round off the noise of widening them
+		stats.AverageRating = math.Round(s.ratingSum/float64(stats.Rated)*100) / 100
+	}
+
+	stats.Categories = make([]categoryCount, 0, len(s.categories))
+	for category, count := range s.categories {
+		stats.Categories = append(stats.Categories, categoryCount{Category: category, Count: count})
+	}
+	sort.Slice(stats.Categories, func(i, j int) bool {
+		a, b := stats.Categories[i], stats.Categories[j]
+		return a.Count > b.Count || a.Count == b.Count && a.Category < b.Category
+	})
+	stats.Categories = stats.Categories[:min(len(stats.Categories), statsTopN)]
+
+	stats.Tags = make([]tagCount, 0, len(s.tags))
+	for tag, count := range s.tags {
+		stats.Tags = append(stats.Tags, tagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(stats.Tags, func(i, j int) bool {
+		a, b := stats.Tags[i], stats.Tags[j]
+		return a.Count > b.Count || a.Count == b.Count && a.Tag < b.Tag
+	})
+	stats.Tags = stats.Tags[:min(len(stats.Tags), statsTopN)]
+
+	return stats
+}
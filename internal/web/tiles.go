@@ -0,0 +1,180 @@
+package web
+
+import (
+	"errors"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/user/placeli/internal/database"
+	"github.com/user/placeli/internal/logger"
+	"github.com/user/placeli/internal/models"
+	"github.com/user/placeli/internal/tiles"
+)
+
+// tileURL is the tile layer URL the web map uses when tiles are served
+// from the cache
+const tileURL = "/tiles/{z}/{x}/{y}.png"
+
+const (
+	// placeTileBuffer is how far past its edges, in tile grid units, a
+	// place tile takes places from, so markers on the edge aren't cut off
+	placeTileBuffer = 64
+
+	// placeTileLimit caps the places in one place tile
+	placeTileLimit = 5000
+)
+
+// SetTileCache serves map tiles under /tiles/ from an MBTiles cache. Tiles
+// missing from it are fetched from the upstream URL template and cached;
+// with no upstream, only cached tiles are shown.
+func (s *Server) SetTileCache(cache *tiles.Cache, upstream string) {
+	s.tiles = cache
+	s.tileUpstream = nil
+	if upstream != "" {
+		s.tileUpstream = tiles.NewFetcher(upstream)
+	}
+}
+
+// mapTileURL returns the tile layer URL for the web map
+func (s *Server) mapTileURL() string {
+	if s.tiles != nil {
+		return tileURL
+	}
+	return tiles.DefaultURL
+}
+
+// handleTile serves /tiles/{z}/{x}/{y}.png
+func (s *Server) handleTile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	tile, ok := parseTile(r)
+	if !ok || s.tiles == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	data, err := s.tiles.Get(tile)
+	if errors.Is(err, tiles.ErrNotFound) && s.tileUpstream != nil {
+		data, err = s.tileUpstream.Fetch(r.Context(), tile)
+		if err == nil {
+			if err := s.tiles.Put(tile, data); err != nil {
+				logger.Error("Failed to cache tile", "tile", tile.String(), "error", err)
+			}
+		}
+	}
+
+	switch {
+	case errors.Is(err, tiles.ErrNotFound):
+		http.NotFound(w, r)
+		return
+	case err != nil:
+		// Usually just offline; the map shows a blank tile
+		logger.Debug("Failed to get tile", "tile", tile.String(), "error", err)
+		http.Error(w, "Tile unavailable", http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", http.DetectContentType(data))
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.Write(data)
+}
+
+// handlePlaceTile serves /tiles/places/{z}/{x}/{y}.mvt: the places in a
+// tile as a Mapbox Vector Tile, for map clients such as MapLibre or QGIS.
+// Every place is a point in the "places" layer; places with an outline are
+// also drawn in the "shapes" layer. Both carry the place's id, name,
+// rating, and comma-separated categories and tags. Shapes are included by
+// the place's coordinates, so an outline reaching into a tile from a place
+// outside it is left out.
+func (s *Server) handlePlaceTile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	tile, ok := parseTile(r)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	b := tile.Bounds()
+	padLng := (b.MaxLng - b.MinLng) * placeTileBuffer / tiles.VectorExtent
+	padLat := (b.MaxLat - b.MinLat) * placeTileBuffer / tiles.VectorExtent
+	places, err := s.dbFor(r).PlaceSummariesIn(
+		models.Coordinates{Lat: b.MinLat - padLat, Lng: b.MinLng - padLng},
+		models.Coordinates{Lat: b.MaxLat + padLat, Lng: b.MaxLng + padLng},
+		placeTileLimit)
+	if err != nil {
+		logger.Error("Failed to fetch places for tile", "tile", tile.String(), "error", err)
+		http.Error(w, "Failed to fetch places", http.StatusInternalServerError)
+		return
+	}
+
+	points := tiles.VectorLayer{Name: "places"}
+	shapes := tiles.VectorLayer{Name: "shapes"}
+	for _, place := range places {
+		feature := tiles.VectorFeature{
+			ID:         placeFeatureID(place.ID),
+			Point:      place.Coordinates,
+			Properties: placeProperties(place),
+		}
+		points.Features = append(points.Features, feature)
+		if place.Geometry != nil {
+			feature.Geometry = place.Geometry
+			shapes.Features = append(shapes.Features, feature)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.mapbox-vector-tile")
+	// Places change as they're edited, so clients check back every time
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(tiles.EncodeVectorTile(tile, points, shapes))
+}
+
+// placeFeatureID derives a vector tile feature id from a place id, the
+// same in every tile and small enough for JavaScript numbers
+func placeFeatureID(id string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(id))
+	return h.Sum64() & (1<<53 - 1)
+}
+
+// placeProperties are the properties of a place's vector tile features
+func placeProperties(place database.PlaceSummary) map[string]interface{} {
+	props := map[string]interface{}{
+		"id":   place.ID,
+		"name": place.Name,
+	}
+	if place.Rating > 0 {
+		props["rating"] = place.Rating
+	}
+	if len(place.Categories) > 0 {
+		props["categories"] = strings.Join(place.Categories, ",")
+	}
+	if len(place.Tags) > 0 {
+		props["tags"] = strings.Join(place.Tags, ",")
+	}
+	return props
+}
+
+// parseTile reads a tile from the z, x and y path values, ignoring the
+// file extension on y
+func parseTile(r *http.Request) (tiles.Tile, bool) {
+	y, _, _ := strings.Cut(r.PathValue("y"), ".")
+	var values [3]int
+	for i, s := range []string{r.PathValue("z"), r.PathValue("x"), y} {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return tiles.Tile{}, false
+		}
+		values[i] = n
+	}
+	tile := tiles.Tile{Z: values[0], X: values[1], Y: values[2]}
+	return tile, tile.Valid()
+}